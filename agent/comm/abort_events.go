@@ -0,0 +1,116 @@
+package comm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/apimodels"
+	"github.com/mongodb/grip/slogger"
+)
+
+// ErrAbortEventsUnsupported is returned when the API server doesn't stream
+// abort events, in which case aborts are only noticed by heartbeats.
+var ErrAbortEventsUnsupported = errors.New("API server does not stream abort events")
+
+// AbortWatcher is implemented by communicators that can be told of aborts by the
+// API server as they happen, rather than on the next heartbeat.
+type AbortWatcher interface {
+	// WatchAbort blocks until the task is aborted, the server closes the stream,
+	// or the stop channel is closed. It returns true if the task was aborted.
+	WatchAbort(stop <-chan struct{}) (bool, error)
+}
+
+// WatchAbort subscribes to the API server's abort events for the task.
+func (h *HTTPCommunicator) WatchAbort(stop <-chan struct{}) (bool, error) {
+	req, err := h.newTaskRequest("abort_events", "GET", nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	resp, err := h.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		if ctx.Err() != nil {
+			return false, nil
+		}
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return false, ErrAbortEventsUnsupported
+	case http.StatusConflict:
+		return false, fmt.Errorf("unauthorized - wrong secret")
+	default:
+		return false, fmt.Errorf("unexpected status code watching for aborts: %v", resp.StatusCode)
+	}
+
+	abort, err := readAbortEvent(resp.Body)
+	if err != nil {
+		if ctx.Err() != nil {
+			return false, nil
+		}
+		return false, err
+	}
+	if abort == nil {
+		return false, nil
+	}
+	if abort.AbortReason != "" {
+		h.Logger.Logf(slogger.INFO, "Task was aborted: %v", abort.AbortReason)
+	}
+	atomic.StoreInt64(&h.abortDelay,
+		int64(time.Duration(abort.AbortDelayMillis)*time.Millisecond))
+	return true, nil
+}
+
+// readAbortEvent reads a server-sent events stream until it gets an "abort"
+// event, and returns its data. Returns nil if the stream ends without one.
+func readAbortEvent(stream io.Reader) (*apimodels.HeartbeatResponse, error) {
+	scanner := bufio.NewScanner(stream)
+	event := ""
+	data := []string{}
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			// a blank line ends the event
+			if event == "abort" {
+				resp := &apimodels.HeartbeatResponse{}
+				if err := json.Unmarshal([]byte(strings.Join(data, "\n")), resp); err != nil {
+					return nil, fmt.Errorf("error reading abort event: %v", err)
+				}
+				resp.Abort = true
+				return resp, nil
+			}
+			event = ""
+			data = data[:0]
+		case strings.HasPrefix(line, ":"):
+			// comments keep the connection alive
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	return nil, scanner.Err()
+}
@@ -0,0 +1,41 @@
+package comm
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestReadAbortEvent(t *testing.T) {
+	Convey("When reading an abort events stream", t, func() {
+		Convey("an abort event should be returned with its data", func() {
+			stream := ": keepalive\n\n" +
+				"event: abort\n" +
+				"data: {\"abort\":true,\"abort_reason\":\"no output\",\"abort_delay_millis\":250}\n\n"
+			resp, err := readAbortEvent(strings.NewReader(stream))
+			So(err, ShouldBeNil)
+			So(resp, ShouldNotBeNil)
+			So(resp.Abort, ShouldBeTrue)
+			So(resp.AbortReason, ShouldEqual, "no output")
+			So(resp.AbortDelayMillis, ShouldEqual, 250)
+		})
+		Convey("other events should be ignored", func() {
+			stream := "event: other\ndata: {}\n\n"
+			resp, err := readAbortEvent(strings.NewReader(stream))
+			So(err, ShouldBeNil)
+			So(resp, ShouldBeNil)
+		})
+		Convey("a stream that ends mid-event should not abort", func() {
+			stream := "event: abort\ndata: {}"
+			resp, err := readAbortEvent(strings.NewReader(stream))
+			So(err, ShouldBeNil)
+			So(resp, ShouldBeNil)
+		})
+		Convey("malformed abort data should be an error", func() {
+			stream := "event: abort\ndata: not json\n\n"
+			_, err := readAbortEvent(strings.NewReader(stream))
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
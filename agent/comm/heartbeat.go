@@ -1,6 +1,7 @@
 package comm
 
 import (
+	"sync"
 	"time"
 
 	"github.com/mongodb/grip/slogger"
@@ -23,6 +24,11 @@ type HeartbeatTicker struct {
 	// The current count of how many heartbeats have failed consecutively.
 	numFailed int
 
+	// aborted is closed once the task's abort has been signaled, whether it was
+	// noticed by a heartbeat or pushed by the API server.
+	aborted   chan struct{}
+	abortOnce sync.Once
+
 	// Interface which handles sending the actual heartbeat over the network
 	TaskCommunicator
 
@@ -37,6 +43,12 @@ func NewHeartbeatTicker(stopper <-chan struct{}) *HeartbeatTicker {
 
 func (hbt *HeartbeatTicker) StartHeartbeating() {
 	hbt.numFailed = 0
+	hbt.aborted = make(chan struct{})
+	hbt.abortOnce = sync.Once{}
+
+	if watcher, ok := hbt.TaskCommunicator.(AbortWatcher); ok {
+		go hbt.watchAborts(watcher)
+	}
 
 	go func() {
 		ticker := time.NewTicker(hbt.Interval)
@@ -57,9 +69,11 @@ func (hbt *HeartbeatTicker) StartHeartbeating() {
 					return
 				}
 				if abort {
-					hbt.SignalChan <- AbortedByUser
+					hbt.signalAbort()
 					return
 				}
+			case <-hbt.aborted:
+				return
 			case <-hbt.stop:
 				hbt.Logger.Logf(slogger.INFO, "Heartbeat ticker stopping.")
 				return
@@ -67,3 +81,40 @@ func (hbt *HeartbeatTicker) StartHeartbeating() {
 		}
 	}()
 }
+
+// watchAborts subscribes to the abort events of the task until it is aborted or
+// the ticker is stopped, reconnecting after each heartbeat interval if the stream
+// is closed. Heartbeats keep checking for aborts in the meantime, and are the only
+// check if the API server doesn't stream abort events.
+func (hbt *HeartbeatTicker) watchAborts(watcher AbortWatcher) {
+	for {
+		abort, err := watcher.WatchAbort(hbt.stop)
+		if abort {
+			hbt.signalAbort()
+			return
+		}
+		if err == ErrAbortEventsUnsupported {
+			hbt.Logger.Logf(slogger.INFO, "Not watching for aborts: %v", err)
+			return
+		}
+		if err != nil {
+			hbt.Logger.Logf(slogger.WARN, "Error watching for aborts: %v", err)
+		}
+
+		select {
+		case <-time.After(hbt.Interval):
+		case <-hbt.aborted:
+			return
+		case <-hbt.stop:
+			return
+		}
+	}
+}
+
+// signalAbort tells the agent that the task was aborted, if it hasn't been told yet.
+func (hbt *HeartbeatTicker) signalAbort() {
+	hbt.abortOnce.Do(func() {
+		close(hbt.aborted)
+		hbt.SignalChan <- AbortedByUser
+	})
+}
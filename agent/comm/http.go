@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/evergreen-ci/evergreen"
@@ -46,6 +47,9 @@ type HTTPCommunicator struct {
 	httpClient    *http.Client
 	// TODO only use one Client after global locking is removed
 	heartbeatClient *http.Client
+	// abortDelay is how long to wait before ending an aborted task, as suggested
+	// by the API server; it is accessed atomically.
+	abortDelay int64
 }
 
 // NewHTTPCommunicator returns an initialized HTTPCommunicator.
@@ -109,6 +113,12 @@ func (h *HTTPCommunicator) Start() error {
 
 // End marks the communicator's task as finished with the given status.
 func (h *HTTPCommunicator) End(detail *apimodels.TaskEndDetail) (*apimodels.TaskEndResponse, error) {
+	// spread out the end calls of tasks that were aborted together
+	if delay := time.Duration(atomic.LoadInt64(&h.abortDelay)); delay > 0 {
+		h.Logger.Logf(slogger.INFO, "Waiting %v before ending aborted task.", delay)
+		time.Sleep(delay)
+	}
+
 	taskEndResp := &apimodels.TaskEndResponse{}
 	resp, retryFail, err := h.postJSON("end", detail)
 	if resp != nil {
@@ -369,6 +379,13 @@ func (h *HTTPCommunicator) Heartbeat() (bool, error) {
 			"response: %v", err)
 		return false, err
 	}
+	if heartbeatResponse.Abort {
+		if heartbeatResponse.AbortReason != "" {
+			h.Logger.Logf(slogger.INFO, "Task was aborted: %v", heartbeatResponse.AbortReason)
+		}
+		atomic.StoreInt64(&h.abortDelay,
+			int64(time.Duration(heartbeatResponse.AbortDelayMillis)*time.Millisecond))
+	}
 	return heartbeatResponse.Abort, nil
 }
 
@@ -385,6 +402,16 @@ func (h *HTTPCommunicator) TryPostJSON(path string, data interface{}) (
 // requests to be done with multiple client configurations/timeouts.
 func (h *HTTPCommunicator) tryRequestWithClient(path string, method string, client *http.Client,
 	data *interface{}) (*http.Response, error) {
+	req, err := h.newTaskRequest(path, method, data)
+	if err != nil {
+		return nil, err
+	}
+	return client.Do(req)
+}
+
+// newTaskRequest returns a request to the given task endpoint, authenticated
+// with the task and host secrets.
+func (h *HTTPCommunicator) newTaskRequest(path string, method string, data *interface{}) (*http.Request, error) {
 	endpointUrl := fmt.Sprintf("%s/task/%s/%s", h.ServerURLRoot, h.TaskId,
 		path)
 	req, err := http.NewRequest(method, endpointUrl, nil)
@@ -403,7 +430,7 @@ func (h *HTTPCommunicator) tryRequestWithClient(path string, method string, clie
 	req.Header.Add(evergreen.HostHeader, h.HostId)
 	req.Header.Add(evergreen.HostSecretHeader, h.HostSecret)
 	req.Header.Add("Content-Type", "application/json")
-	return client.Do(req)
+	return req, nil
 }
 
 func (h *HTTPCommunicator) postJSON(path string, data interface{}) (
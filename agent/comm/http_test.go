@@ -187,7 +187,10 @@ func TestCommunicatorServerUp(t *testing.T) {
 				if heartbeatFail {
 					util.WriteJSON(&w, apimodels.HeartbeatResponse{}, http.StatusInternalServerError)
 				} else {
-					util.WriteJSON(&w, apimodels.HeartbeatResponse{heartbeatAbort}, http.StatusOK)
+					util.WriteJSON(&w, apimodels.HeartbeatResponse{
+						Abort:            heartbeatAbort,
+						AbortDelayMillis: 5,
+					}, http.StatusOK)
 				}
 			})
 			Convey("Failing calls should return err and successful calls should not", func() {
@@ -203,6 +206,7 @@ func TestCommunicatorServerUp(t *testing.T) {
 					abortflag, err := agentCommunicator.Heartbeat()
 					So(err, ShouldBeNil)
 					So(abortflag, ShouldBeTrue)
+					So(agentCommunicator.abortDelay, ShouldEqual, int64(5*time.Millisecond))
 				})
 			})
 		})
@@ -10,6 +10,11 @@ type TaskStartRequest struct {
 // the agent's heartbeat message.
 type HeartbeatResponse struct {
 	Abort bool `json:"abort,omitempty"`
+	// AbortDelayMillis is how long the agent should wait after aborting the task
+	// before ending it, so that tasks aborted together don't all end at once.
+	AbortDelayMillis int64 `json:"abort_delay_millis,omitempty"`
+	// AbortReason is the reason, if one was given, that the task was aborted.
+	AbortReason string `json:"abort_reason,omitempty"`
 }
 
 // TaskEndDetail contains data sent from the agent to the
@@ -39,11 +44,33 @@ type TaskEndResponse struct {
 // ExpansionVars is a map of expansion variables for a project.
 type ExpansionVars map[string]string
 
+// NextTaskRequest is the optional body of an agent's request for a next task.
+type NextTaskRequest struct {
+	// Prefetch is how many tasks, including the next one, the agent would like to
+	// be given to run one after another.
+	Prefetch int `json:"prefetch,omitempty"`
+}
+
 // NextTaskResponse represents the response sent back when an agent asks for a next task
 type NextTaskResponse struct {
 	TaskId     string `json:"task_id,omitempty"`
 	TaskSecret string `json:"task_secret,omitempty"`
 	ShouldExit bool   `json:"should_exit,omitempty"`
+
+	// Prefetched are the tasks reserved for the host to run, in order, after the
+	// next task. Each is handed to the agent when the task before it ends.
+	Prefetched []PrefetchedTask `json:"prefetched,omitempty"`
+
+	// PollIntervalSecs is set when no task is available: it's how long the agent
+	// should wait before asking again, which is longer the longer its distro's
+	// queue has been empty.
+	PollIntervalSecs int `json:"poll_interval_secs,omitempty"`
+}
+
+// PrefetchedTask is a task reserved for a host to run after its next task.
+type PrefetchedTask struct {
+	TaskId     string `json:"task_id"`
+	TaskSecret string `json:"task_secret"`
 }
 
 // EndTaskResponse is what is returned when the task ends
@@ -76,11 +76,20 @@ func getAPIClients(o *Options) (*APIClient, *APIClient, *model.CLISettings, erro
 // If body is not nil, also includes it as a request body as url-encoded data with the
 // appropriate header
 func (ac *APIClient) doReq(method, path string, body io.Reader) (*http.Response, error) {
+	return ac.doReqAccepting(method, path, "", body)
+}
+
+// doReqAccepting performs a request like doReq, asking for a response of the given
+// media type, if one is given.
+func (ac *APIClient) doReqAccepting(method, path, accept string, body io.Reader) (*http.Response, error) {
 	req, err := http.NewRequest(method, fmt.Sprintf("%s/%s", ac.APIRoot, path), body)
 	if err != nil {
 		return nil, err
 	}
 
+	if accept != "" {
+		req.Header.Add("Accept", accept)
+	}
 	req.Header.Add("Api-Key", ac.APIKey)
 	req.Header.Add("Api-User", ac.User)
 	resp, err := ac.httpClient.Do(req)
@@ -109,6 +118,49 @@ func (ac *APIClient) post(path string, body io.Reader) (*http.Response, error) {
 	return ac.doReq("POST", path, body)
 }
 
+// pagedMediaType is the media type of the API schema version in which list
+// endpoints respond with pages of their items.
+const pagedMediaType = "application/vnd.evergreen.v2+json"
+
+// pagedResponse is a page of the items a list endpoint returns.
+type pagedResponse struct {
+	Items   json.RawMessage `json:"items"`
+	HasMore bool            `json:"has_more"`
+}
+
+// getPages requests the pages of a list endpoint's items in turn, passing each
+// page's items to add, until there are no more pages or, if pageSize is set,
+// after the first page of that size.
+func (ac *APIClient) getPages(path string, pageSize int, add func(json.RawMessage) error) error {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	for page := 1; ; page++ {
+		pagePath := fmt.Sprintf("%v%vpage=%v", path, sep, page)
+		if pageSize > 0 {
+			pagePath = fmt.Sprintf("%v&page_size=%v", pagePath, pageSize)
+		}
+		resp, err := ac.doReqAccepting("GET", pagePath, pagedMediaType, nil)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return NewAPIError(resp)
+		}
+		paged := pagedResponse{}
+		if err = util.ReadJSONInto(resp.Body, &paged); err != nil {
+			return err
+		}
+		if err = add(paged.Items); err != nil {
+			return err
+		}
+		if !paged.HasMore || pageSize > 0 {
+			return nil
+		}
+	}
+}
+
 func (ac *APIClient) modifyExisting(patchId, action string) error {
 	data := struct {
 		PatchId string `json:"patch_id"`
@@ -133,9 +185,14 @@ func (ac *APIClient) modifyExisting(patchId, action string) error {
 	return nil
 }
 
-// ValidateLocalConfig validates the local project config with the server
-func (ac *APIClient) ValidateLocalConfig(data []byte) ([]validator.ValidationError, error) {
-	resp, err := ac.post("validate", bytes.NewBuffer(data))
+// ValidateLocalConfig validates the local project config with the server. If a
+// variant is given, only its semantics and those of its tasks are checked.
+func (ac *APIClient) ValidateLocalConfig(data []byte, variant string) ([]validator.ValidationError, error) {
+	path := "validate"
+	if variant != "" {
+		path += "?variant=" + url.QueryEscape(variant)
+	}
+	resp, err := ac.post(path, bytes.NewBuffer(data))
 	if err != nil {
 		return nil, err
 	}
@@ -160,17 +217,19 @@ func (ac *APIClient) FinalizePatch(patchId string) error {
 	return ac.modifyExisting(patchId, "finalize")
 }
 
-// GetPatches requests a list of the user's patches from the API and returns them as a list
+// GetPatches requests a list of the user's n most recent patches from the API, or all
+// of them if n is 0, and returns them as a list
 func (ac *APIClient) GetPatches(n int) ([]patch.Patch, error) {
-	resp, err := ac.get(fmt.Sprintf("patches/mine?n=%v", n), nil)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, NewAPIError(resp)
-	}
 	patches := []patch.Patch{}
-	if err := util.ReadJSONInto(resp.Body, &patches); err != nil {
+	err := ac.getPages("patches/mine", n, func(items json.RawMessage) error {
+		page := []patch.Patch{}
+		if err := json.Unmarshal(items, &page); err != nil {
+			return err
+		}
+		patches = append(patches, page...)
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 	return patches, nil
@@ -300,45 +359,49 @@ func (ac *APIClient) UpdatePatchModule(patchId, module, patch, base string) erro
 }
 
 func (ac *APIClient) ListProjects() ([]model.ProjectRef, error) {
-	resp, err := ac.get("projects", nil)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, NewAPIError(resp)
-	}
 	projs := []model.ProjectRef{}
-	if err := util.ReadJSONInto(resp.Body, &projs); err != nil {
+	err := ac.getPages("projects", 0, func(items json.RawMessage) error {
+		page := []model.ProjectRef{}
+		if err := json.Unmarshal(items, &page); err != nil {
+			return err
+		}
+		projs = append(projs, page...)
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 	return projs, nil
 }
 
 func (ac *APIClient) ListTasks(project string) ([]model.ProjectTask, error) {
-	resp, err := ac.get(fmt.Sprintf("tasks/%v", project), nil)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, NewAPIError(resp)
-	}
 	tasks := []model.ProjectTask{}
-	if err := util.ReadJSONInto(resp.Body, &tasks); err != nil {
+	// the CLI only lists tasks, so it has no use for their commands and dependencies
+	err := ac.getPages(fmt.Sprintf("tasks/%v?omit_definitions=true", project), 0, func(items json.RawMessage) error {
+		page := []model.ProjectTask{}
+		if err := json.Unmarshal(items, &page); err != nil {
+			return err
+		}
+		tasks = append(tasks, page...)
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 	return tasks, nil
 }
 
 func (ac *APIClient) ListVariants(project string) ([]model.BuildVariant, error) {
-	resp, err := ac.get(fmt.Sprintf("variants/%v", project), nil)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, NewAPIError(resp)
-	}
 	variants := []model.BuildVariant{}
-	if err := util.ReadJSONInto(resp.Body, &variants); err != nil {
+	err := ac.getPages(fmt.Sprintf("variants/%v", project), 0, func(items json.RawMessage) error {
+		page := []model.BuildVariant{}
+		if err := json.Unmarshal(items, &page); err != nil {
+			return err
+		}
+		variants = append(variants, page...)
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 	return variants, nil
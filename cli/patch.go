@@ -92,6 +92,7 @@ type ListCommand struct {
 // ValidateCommand is used to verify that a config file is valid.
 type ValidateCommand struct {
 	GlobalOpts *Options `no-flag:"true"`
+	Variant    string   `long:"variant" description:"only check the semantics of this build variant and its tasks"`
 	Positional struct {
 		FileName string `positional-arg-name:"filename" description:"path to an evergreen project file"`
 	} `positional-args:"1" required:"yes"`
@@ -227,7 +228,7 @@ func (vc *ValidateCommand) Execute(args []string) error {
 	if err != nil {
 		return err
 	}
-	projErrors, err := ac.ValidateLocalConfig(confFile)
+	projErrors, err := ac.ValidateLocalConfig(confFile, vc.Variant)
 	if err != nil {
 		return nil
 	}
@@ -1,6 +1,8 @@
 package cloud
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/evergreen-ci/evergreen"
@@ -9,6 +11,22 @@ import (
 	"github.com/evergreen-ci/evergreen/util"
 )
 
+// ErrRebootUnsupported is returned by CloudManagers whose providers can't reboot
+// instances.
+var ErrRebootUnsupported = errors.New("provider does not support rebooting hosts")
+
+// ErrConsoleOutputUnsupported is returned by CloudManagers whose providers can't
+// fetch the console output of instances.
+var ErrConsoleOutputUnsupported = errors.New("provider does not support fetching console output")
+
+// ErrCreateImageUnsupported is returned by CloudManagers whose providers can't
+// create images from instances.
+var ErrCreateImageUnsupported = errors.New("provider does not support creating images")
+
+// ErrLaunchTimeUnsupported is returned by CloudManagers whose providers can't
+// report when instances were launched.
+var ErrLaunchTimeUnsupported = errors.New("provider does not support fetching launch times")
+
 type CloudStatus int
 
 const (
@@ -53,6 +71,31 @@ func (stat CloudStatus) String() string {
 	}
 }
 
+// ParseCloudStatus returns the status whose string form is s, or StatusUnknown if
+// there is none.
+func ParseCloudStatus(s string) CloudStatus {
+	for _, stat := range []CloudStatus{StatusPending, StatusFailed, StatusInitializing,
+		StatusRunning, StatusStopped, StatusTerminated} {
+		if stat.String() == s {
+			return stat
+		}
+	}
+	return StatusUnknown
+}
+
+// EvergreenOwnedTag is the tag (or label) attached to instances Evergreen launches,
+// for providers that support tagging, so they can be told apart from other instances.
+const EvergreenOwnedTag = "evergreen-owned"
+
+// CloudInstance describes an instance that Evergreen launched, as the provider
+// reports it.
+type CloudInstance struct {
+	Id           string      `json:"id"`
+	Status       CloudStatus `json:"status"`
+	LaunchTime   time.Time   `json:"launch_time"`
+	InstanceType string      `json:"instance_type"`
+}
+
 // ProviderSettings exposes provider-specific configuration settings for a CloudManager.
 type ProviderSettings interface {
 	Validate() error
@@ -83,6 +126,27 @@ type CloudManager interface {
 	// TerminateInstances destroys the host in the underlying provider
 	TerminateInstance(*host.Host) error
 
+	// Reboot restarts the host's instance without terminating it. Providers
+	// that can't reboot instances return ErrRebootUnsupported.
+	Reboot(*host.Host) error
+
+	// GetConsoleOutput returns the console (or serial port) output of the host's
+	// instance, to help diagnose instances that fail to boot. Providers that can't
+	// fetch it return ErrConsoleOutputUnsupported.
+	GetConsoleOutput(*host.Host) (string, error)
+
+	// CreateImage creates an image with the given name from the host's instance,
+	// which distros can then start hosts from, and returns the provider's id for
+	// the image. The instance may be restarted, if the provider needs to, but is
+	// left running. Providers that can't create images return
+	// ErrCreateImageUnsupported.
+	CreateImage(h *host.Host, name string) (string, error)
+
+	// GetLaunchTime returns when the provider launched the host's instance, which
+	// is when billing for it starts. Providers that can't report it return
+	// ErrLaunchTimeUnsupported.
+	GetLaunchTime(*host.Host) (time.Time, error)
+
 	//IsUp returns true if the underlying provider has not destroyed the
 	//host (in other words, if the host "should" be reachable. This does not
 	//necessarily mean that the host actually *is* reachable via SSH
@@ -99,6 +163,14 @@ type CloudManager interface {
 	// GetDNSName returns the DNS name of a host.
 	GetDNSName(*host.Host) (string, error)
 
+	// GetInstanceID returns the provider's id for the instance backing a host,
+	// which is not necessarily the same as the host's Id.
+	GetInstanceID(*host.Host) (string, error)
+
+	// ListInstances returns every instance Evergreen has launched in the provider,
+	// whether or not it is still recorded in the hosts collection.
+	ListInstances() ([]CloudInstance, error)
+
 	// GetSSHOptions generates the command line args to be passed to ssh to
 	// allow connection to the machine
 	GetSSHOptions(host *host.Host, keyName string) ([]string, error)
@@ -114,6 +186,88 @@ type CloudCostCalculator interface {
 	CostForDuration(host *host.Host, start time.Time, end time.Time) (float64, error)
 }
 
+// CredentialsValidator is an interface for cloud managers that can check whether
+// the provider accepts their configured credentials.
+type CredentialsValidator interface {
+	ValidateCredentials() error
+}
+
+// HostTagger is an interface for cloud managers that can set tags on a host's
+// instance after it has started.
+type HostTagger interface {
+	// ValidateTags returns an error if the provider won't let the tags be set.
+	ValidateTags(tags map[string]string) error
+	// SetTags adds the tags to the host's instance, replacing the values of any
+	// tags it already has.
+	SetTags(h *host.Host, tags map[string]string) error
+}
+
+// StaticAddressAssociator is an interface for cloud managers that can pin a static
+// public address to a host's instance, so that it keeps its address when it's
+// stopped and started.
+type StaticAddressAssociator interface {
+	// AssociateStaticAddress allocates a static address, associates it with the
+	// host's instance and records it on the host, then returns it. Hosts that
+	// already have one keep it. The address is released when the host is
+	// terminated.
+	AssociateStaticAddress(h *host.Host) (string, error)
+
+	// ReleaseStaticAddress releases the static address of a host whose instance
+	// was terminated outside of Evergreen, if it has one.
+	ReleaseStaticAddress(h *host.Host) error
+}
+
+// Fallbacker is an interface for cloud managers whose hosts can be replaced by hosts
+// of another provider if they don't come up, such as spot requests that aren't
+// fulfilled.
+type Fallbacker interface {
+	// FallbackProvider returns the provider of the hosts that replace this
+	// provider's hosts.
+	FallbackProvider() string
+
+	// NeedsFallback returns true if the host should be replaced.
+	NeedsFallback(h *host.Host) (bool, error)
+
+	// FallBack replaces the host with one of the fallback provider, started with
+	// the same options, and returns it. It returns nil if the host came up after
+	// all, and is kept.
+	FallBack(h *host.Host) (*host.Host, error)
+}
+
+// BatchSpawner is an interface for cloud managers that can start several identical
+// hosts with one request to the provider.
+type BatchSpawner interface {
+	// BatchSpawnInstance starts up to count hosts of the distro and returns the
+	// ones that were started.
+	BatchSpawnInstance(d *distro.Distro, hostOpts HostOptions, count int) ([]host.Host, error)
+}
+
+// Quota is a provider's limit on a resource, and how much of it is in use.
+type Quota struct {
+	Resource string `json:"resource"`
+	// the provider account the limit applies to, if the provider has several
+	Account string `json:"account,omitempty"`
+	// the provider region the limit applies to, if limits are per region
+	Region string `json:"region,omitempty"`
+	Used   int    `json:"used"`
+	Limit  int    `json:"limit"`
+}
+
+// QuotaReporter is an interface for cloud managers that can report how close their
+// accounts are to the provider's limits.
+type QuotaReporter interface {
+	GetQuotas() ([]Quota, error)
+}
+
+// InstanceEventParser is an interface for cloud managers whose providers can
+// notify Evergreen when the state of an instance changes, so that hosts can be
+// provisioned as their instances come up without polling them.
+type InstanceEventParser interface {
+	// ParseInstanceEvent returns the provider's id for the instance a state change
+	// notification is about, and the status the instance changed to.
+	ParseInstanceEvent(body []byte) (string, CloudStatus, error)
+}
+
 // HostOptions is a struct of options that are commonly passed around when creating a
 // new cloud host.
 type HostOptions struct {
@@ -122,6 +276,114 @@ type HostOptions struct {
 	UserName           string
 	UserData           string
 	UserHost           bool
+
+	// Tenancy overrides the tenancy set in the distro's settings, for providers
+	// that support it.
+	Tenancy string
+
+	// RootVolumeSize overrides the size, in GB, of the host's root volume set in the
+	// distro's settings, for providers that support it. Zero means no override.
+	RootVolumeSize int
+
+	// ShutdownBehavior is whether shutting the host down from inside it stops or
+	// terminates its instance, from the distro's ShutdownBehavior, for providers
+	// that support it.
+	ShutdownBehavior string
+
+	// RequestKey is the key the client sent with the request for the host, if any.
+	RequestKey string
+}
+
+// MaxUserDataSize is the largest user data, in bytes, that a host can be started with.
+const MaxUserDataSize = 16 * 1024
+
+// MaxSetupScriptSize is the largest setup script, in bytes, that a host's owner can
+// have run on it after it is provisioned.
+const MaxSetupScriptSize = 16 * 1024
+
+// MinRootVolumeSize and MaxRootVolumeSize bound the size, in GB, of the root volume
+// a host can be started with.
+const (
+	MinRootVolumeSize = 8
+	MaxRootVolumeSize = 1024
+)
+
+// ValidateRootVolumeSize returns an error if a root volume can't be the given size,
+// in GB. Zero, meaning the default size, is valid.
+func ValidateRootVolumeSize(size int) error {
+	if size != 0 && (size < MinRootVolumeSize || size > MaxRootVolumeSize) {
+		return fmt.Errorf("root volume size must be between %v and %v GB, not %v",
+			MinRootVolumeSize, MaxRootVolumeSize, size)
+	}
+	return nil
+}
+
+// ValidateShutdownBehavior checks that a host shutdown behavior, if set, is one
+// of the supported ones.
+func ValidateShutdownBehavior(behavior string) error {
+	switch behavior {
+	case "", evergreen.HostShutdownStop, evergreen.HostShutdownTerminate:
+		return nil
+	}
+	return fmt.Errorf("shutdown behavior '%v' must be '%v' or '%v'", behavior,
+		evergreen.HostShutdownStop, evergreen.HostShutdownTerminate)
+}
+
+// Validate checks that the options can be used to start a host, so that an
+// intent host isn't created for a host that can never start.
+func (opts HostOptions) Validate() error {
+	if opts.ExpirationDuration != nil && *opts.ExpirationDuration <= 0 {
+		return fmt.Errorf("expiration duration must be positive, not %v", *opts.ExpirationDuration)
+	}
+	if len(opts.UserData) > MaxUserDataSize {
+		return fmt.Errorf("user data is %v bytes, which is more than the maximum of %v bytes",
+			len(opts.UserData), MaxUserDataSize)
+	}
+	switch opts.Tenancy {
+	case "", evergreen.HostTenancyDefault, evergreen.HostTenancyDedicated, evergreen.HostTenancyHost:
+	default:
+		return fmt.Errorf("tenancy '%v' must be one of '%v', '%v', or '%v'", opts.Tenancy,
+			evergreen.HostTenancyDefault, evergreen.HostTenancyDedicated, evergreen.HostTenancyHost)
+	}
+	if err := ValidateRootVolumeSize(opts.RootVolumeSize); err != nil {
+		return err
+	}
+	if err := ValidateShutdownBehavior(opts.ShutdownBehavior); err != nil {
+		return err
+	}
+	if opts.ProvisionOptions != nil && opts.ProvisionOptions.TaskId != "" && opts.ProvisionOptions.OwnerId == "" {
+		return fmt.Errorf("hosts provisioned with task %v must have an owner", opts.ProvisionOptions.TaskId)
+	}
+	if opts.ProvisionOptions != nil && len(opts.ProvisionOptions.SetupScript) > MaxSetupScriptSize {
+		return fmt.Errorf("setup script is %v bytes, which is more than the maximum of %v bytes",
+			len(opts.ProvisionOptions.SetupScript), MaxSetupScriptSize)
+	}
+	return nil
+}
+
+// DistroRoom returns how many of n new hosts of the distro may be started without
+// exceeding the distro's MaxHosts.
+func DistroRoom(d *distro.Distro, n int) (int, error) {
+	if d.MaxHosts <= 0 {
+		return n, nil
+	}
+	count, err := host.Count(host.UpByDistroId(d.Id))
+	if err != nil {
+		return 0, fmt.Errorf("error counting hosts of distro %v: %v", d.Id, err)
+	}
+	return roomUnder(d.MaxHosts, count, n), nil
+}
+
+// roomUnder returns how many of n more may be added to count without exceeding max.
+func roomUnder(max, count, n int) int {
+	room := max - count
+	if room < 0 {
+		return 0
+	}
+	if room < n {
+		return room
+	}
+	return n
 }
 
 // NewIntent creates an IntentHost using the given host settings. An IntentHost is a host that
@@ -147,6 +409,8 @@ func NewIntent(d distro.Distro, instanceName, provider string, options HostOptio
 		Provider:         provider,
 		StartedBy:        options.UserName,
 		UserHost:         options.UserHost,
+		RequestKey:       options.RequestKey,
+		SSHKey:           d.SSHKey,
 	}
 
 	if options.ExpirationDuration != nil {
@@ -173,6 +437,9 @@ type CloudHost struct {
 }
 
 func (cloudHost *CloudHost) IsSSHReachable() (bool, error) {
+	if err := cloudHost.checkKey(); err != nil {
+		return false, err
+	}
 	return cloudHost.CloudMgr.IsSSHReachable(cloudHost.Host, cloudHost.KeyPath)
 }
 
@@ -184,6 +451,22 @@ func (cloudHost *CloudHost) TerminateInstance() error {
 	return cloudHost.CloudMgr.TerminateInstance(cloudHost.Host)
 }
 
+func (cloudHost *CloudHost) Reboot() error {
+	return cloudHost.CloudMgr.Reboot(cloudHost.Host)
+}
+
+func (cloudHost *CloudHost) GetConsoleOutput() (string, error) {
+	return cloudHost.CloudMgr.GetConsoleOutput(cloudHost.Host)
+}
+
+func (cloudHost *CloudHost) CreateImage(name string) (string, error) {
+	return cloudHost.CloudMgr.CreateImage(cloudHost.Host, name)
+}
+
+func (cloudHost *CloudHost) GetLaunchTime() (time.Time, error) {
+	return cloudHost.CloudMgr.GetLaunchTime(cloudHost.Host)
+}
+
 func (cloudHost *CloudHost) GetInstanceStatus() (CloudStatus, error) {
 	return cloudHost.CloudMgr.GetInstanceStatus(cloudHost.Host)
 }
@@ -192,6 +475,22 @@ func (cloudHost *CloudHost) GetDNSName() (string, error) {
 	return cloudHost.CloudMgr.GetDNSName(cloudHost.Host)
 }
 
+func (cloudHost *CloudHost) GetInstanceID() (string, error) {
+	return cloudHost.CloudMgr.GetInstanceID(cloudHost.Host)
+}
+
 func (cloudHost *CloudHost) GetSSHOptions() ([]string, error) {
+	if err := cloudHost.checkKey(); err != nil {
+		return nil, err
+	}
 	return cloudHost.CloudMgr.GetSSHOptions(cloudHost.Host, cloudHost.KeyPath)
 }
+
+// checkKey returns an error if the host was provisioned with a key that's no
+// longer in the settings, so it can't be connected to.
+func (cloudHost *CloudHost) checkKey() error {
+	if keyName := cloudHost.Host.SSHKeyName(); keyName != "" && cloudHost.KeyPath == "" {
+		return fmt.Errorf("ssh key '%v' of host %v is not in the settings", keyName, cloudHost.Host.Id)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package cloud
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model/host"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestHostOptionsValidate(t *testing.T) {
+	Convey("With host options", t, func() {
+		expiration := time.Hour
+		opts := HostOptions{
+			ProvisionOptions:   &host.ProvisionOptions{TaskId: "t1", OwnerId: "me"},
+			ExpirationDuration: &expiration,
+			UserName:           "me",
+			UserData:           "foo=bar",
+		}
+
+		Convey("valid options should pass", func() {
+			So(opts.Validate(), ShouldBeNil)
+			opts.ExpirationDuration = nil
+			So(opts.Validate(), ShouldBeNil)
+		})
+		Convey("a zero or negative expiration should fail", func() {
+			expiration = 0
+			So(opts.Validate(), ShouldNotBeNil)
+			expiration = -time.Hour
+			So(opts.Validate(), ShouldNotBeNil)
+		})
+		Convey("oversized user data should fail", func() {
+			opts.UserData = strings.Repeat("a", MaxUserDataSize)
+			So(opts.Validate(), ShouldBeNil)
+			opts.UserData += "a"
+			So(opts.Validate(), ShouldNotBeNil)
+		})
+		Convey("an unknown tenancy should fail", func() {
+			opts.Tenancy = evergreen.HostTenancyDedicated
+			So(opts.Validate(), ShouldBeNil)
+			opts.Tenancy = "shared"
+			So(opts.Validate(), ShouldNotBeNil)
+		})
+		Convey("a root volume size outside the allowed range should fail", func() {
+			opts.RootVolumeSize = MinRootVolumeSize
+			So(opts.Validate(), ShouldBeNil)
+			opts.RootVolumeSize = MaxRootVolumeSize
+			So(opts.Validate(), ShouldBeNil)
+			opts.RootVolumeSize = MinRootVolumeSize - 1
+			So(opts.Validate(), ShouldNotBeNil)
+			opts.RootVolumeSize = MaxRootVolumeSize + 1
+			So(opts.Validate(), ShouldNotBeNil)
+		})
+		Convey("an oversized setup script should fail", func() {
+			opts.ProvisionOptions.SetupScript = strings.Repeat("a", MaxSetupScriptSize)
+			So(opts.Validate(), ShouldBeNil)
+			opts.ProvisionOptions.SetupScript += "a"
+			So(opts.Validate(), ShouldNotBeNil)
+		})
+		Convey("provisioning with a task but no owner should fail", func() {
+			opts.ProvisionOptions.OwnerId = ""
+			So(opts.Validate(), ShouldNotBeNil)
+		})
+	})
+}
+
+func TestRoomUnder(t *testing.T) {
+	Convey("When adding hosts under a maximum", t, func() {
+		Convey("all of them should fit if there's room", func() {
+			So(roomUnder(10, 5, 3), ShouldEqual, 3)
+			So(roomUnder(10, 5, 5), ShouldEqual, 5)
+		})
+		Convey("only as many as there's room for should fit", func() {
+			So(roomUnder(10, 8, 3), ShouldEqual, 2)
+		})
+		Convey("none should fit at or over the maximum", func() {
+			So(roomUnder(10, 10, 3), ShouldEqual, 0)
+			So(roomUnder(10, 12, 3), ShouldEqual, 0)
+		})
+	})
+}
@@ -0,0 +1,32 @@
+package cloud
+
+import (
+	"fmt"
+
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/evergreen-ci/evergreen/util"
+)
+
+// RecordLaunchTime looks up when the host's instance was launched and records it
+// on the host, so that billing can start from it. Managers call it once the host
+// is up. Hosts whose providers can't report launch times are left as they are,
+// and are billed from their creation time.
+func RecordLaunchTime(mgr CloudManager, h *host.Host) error {
+	if !util.IsZeroTime(h.LaunchTime) {
+		return nil
+	}
+	launchTime, err := mgr.GetLaunchTime(h)
+	if err == ErrLaunchTimeUnsupported {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error getting the launch time of host %v: %v", h.Id, err)
+	}
+	if util.IsZeroTime(launchTime) {
+		return nil
+	}
+	if err = h.SetLaunchTime(launchTime); err != nil {
+		return fmt.Errorf("error recording the launch time of host %v: %v", h.Id, err)
+	}
+	return nil
+}
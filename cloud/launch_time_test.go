@@ -0,0 +1,59 @@
+package cloud
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/evergreen-ci/evergreen/testutil"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// launchTimeManager is a CloudManager that reports a fixed launch time or error,
+// counting how often it's asked.
+type launchTimeManager struct {
+	CloudManager
+	launchTime time.Time
+	err        error
+	lookups    int
+}
+
+func (m *launchTimeManager) GetLaunchTime(*host.Host) (time.Time, error) {
+	m.lookups++
+	return m.launchTime, m.err
+}
+
+func TestRecordLaunchTime(t *testing.T) {
+	db.SetGlobalSessionProvider(db.SessionFactoryFromConfig(testutil.TestConfig()))
+
+	Convey("When recording the launch time of a host", t, func() {
+		testutil.HandleTestingErr(db.Clear(host.Collection), t, "error clearing hosts")
+		created := time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)
+		launched := created.Add(3 * time.Minute)
+		h := &host.Host{Id: "h", CreationTime: created}
+		So(h.Insert(), ShouldBeNil)
+
+		Convey("the provider's launch time should be stored and billed from", func() {
+			So(RecordLaunchTime(&launchTimeManager{launchTime: launched}, h), ShouldBeNil)
+			So(h.BillingStart(), ShouldResemble, launched)
+			stored, err := host.FindOne(host.ById(h.Id))
+			So(err, ShouldBeNil)
+			So(stored.LaunchTime.Equal(launched), ShouldBeTrue)
+			So(stored.BillingStart().Equal(launched), ShouldBeTrue)
+		})
+		Convey("a recorded launch time should not be looked up again", func() {
+			h.LaunchTime = launched
+			mgr := &launchTimeManager{err: errors.New("not called")}
+			So(RecordLaunchTime(mgr, h), ShouldBeNil)
+			So(mgr.lookups, ShouldEqual, 0)
+		})
+		Convey("hosts should be billed from their creation time if the provider can't report it", func() {
+			So(RecordLaunchTime(&launchTimeManager{err: ErrLaunchTimeUnsupported}, h), ShouldBeNil)
+			So(RecordLaunchTime(&launchTimeManager{}, h), ShouldBeNil)
+			So(RecordLaunchTime(&launchTimeManager{err: errors.New("throttled")}, h), ShouldNotBeNil)
+			So(h.BillingStart(), ShouldResemble, created)
+		})
+	})
+}
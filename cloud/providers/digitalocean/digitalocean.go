@@ -5,6 +5,7 @@ import (
 	"math"
 	"math/rand"
 	"strconv"
+	"strings"
 	"time"
 
 	digo "github.com/dynport/gocloud/digitalocean"
@@ -25,6 +26,10 @@ const (
 	DigitalOceanStatusArchive = "archive"
 
 	ProviderName = "digitalocean"
+
+	// droplets don't support tags, so the ones Evergreen launches are
+	// recognized by their names
+	dropletNamePrefix = "droplet-"
 )
 
 type DigitalOceanManager struct {
@@ -73,6 +78,7 @@ func (_ *DigitalOceanManager) GetSettings() cloud.ProviderSettings {
 
 //SpawnInstance creates a new droplet for the given distro.
 func (digoMgr *DigitalOceanManager) SpawnInstance(d *distro.Distro, hostOpts cloud.HostOptions) (*host.Host, error) {
+	defer cloud.RecordCallTime(ProviderName, "SpawnInstance", time.Now())
 	if d.Provider != ProviderName {
 		return nil, fmt.Errorf("Can't spawn instance of %v for distro %v: provider is %v", ProviderName, d.Id, d.Provider)
 	}
@@ -86,7 +92,7 @@ func (digoMgr *DigitalOceanManager) SpawnInstance(d *distro.Distro, hostOpts clo
 		return nil, fmt.Errorf("Invalid DigitalOcean settings in distro %v: %v", d.Id, err)
 	}
 
-	instanceName := "droplet-" +
+	instanceName := dropletNamePrefix +
 		fmt.Sprintf("%v", rand.New(rand.NewSource(time.Now().UnixNano())).Int())
 
 	intentHost := cloud.NewIntent(*d, instanceName, ProviderName, hostOpts)
@@ -140,6 +146,7 @@ func (digoMgr *DigitalOceanManager) SpawnInstance(d *distro.Distro, hostOpts clo
 	}
 
 	host.Id = fmt.Sprintf("%v", newDroplet.Id)
+	host.InstanceId = host.Id
 	host.Host = newDroplet.IpAddress
 
 	if err = host.Insert(); err != nil {
@@ -174,6 +181,7 @@ func (digoMgr *DigitalOceanManager) getDropletInfo(dropletId int) (*digo.Droplet
 //GetInstanceStatus returns a universal status code representing the state
 //of a droplet.
 func (digoMgr *DigitalOceanManager) GetInstanceStatus(host *host.Host) (cloud.CloudStatus, error) {
+	defer cloud.RecordCallTime(ProviderName, "GetInstanceStatus", time.Now())
 	hostIdAsInt, err := strconv.Atoi(host.Id)
 	if err != nil {
 		err = fmt.Errorf("Can't get status of '%v': DigitalOcean host id's "+
@@ -187,18 +195,44 @@ func (digoMgr *DigitalOceanManager) GetInstanceStatus(host *host.Host) (cloud.Cl
 		return cloud.StatusUnknown, fmt.Errorf("Failed to get droplet info: %v", err)
 	}
 
-	switch droplet.Status {
+	return dropletStatus(droplet.Status), nil
+}
+
+// dropletStatus converts a DigitalOcean droplet status into a universal status code.
+func dropletStatus(status string) cloud.CloudStatus {
+	switch status {
 	case DigitalOceanStatusNew:
-		return cloud.StatusInitializing, nil
+		return cloud.StatusInitializing
 	case DigitalOceanStatusActive:
-		return cloud.StatusRunning, nil
+		return cloud.StatusRunning
 	case DigitalOceanStatusArchive:
-		return cloud.StatusStopped, nil
+		return cloud.StatusStopped
 	case DigitalOceanStatusOff:
-		return cloud.StatusTerminated, nil
+		return cloud.StatusTerminated
 	default:
-		return cloud.StatusUnknown, nil
+		return cloud.StatusUnknown
+	}
+}
+
+// ListInstances returns the droplets Evergreen has launched.
+func (digoMgr *DigitalOceanManager) ListInstances() ([]cloud.CloudInstance, error) {
+	droplets, err := digoMgr.account.Droplets()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list droplets: %v", err)
 	}
+	result := []cloud.CloudInstance{}
+	for _, droplet := range droplets {
+		if !strings.HasPrefix(droplet.Name, dropletNamePrefix) {
+			continue
+		}
+		result = append(result, cloud.CloudInstance{
+			Id:           strconv.Itoa(droplet.Id),
+			Status:       dropletStatus(droplet.Status),
+			LaunchTime:   droplet.CreatedAt,
+			InstanceType: strconv.Itoa(droplet.SizeId),
+		})
+	}
+	return result, nil
 }
 
 //GetDNSName gets the DNS hostname of a droplet by reading it directly from
@@ -220,6 +254,11 @@ func (digoMgr *DigitalOceanManager) GetDNSName(host *host.Host) (string, error)
 
 }
 
+//GetInstanceID returns the id of the host's droplet, which is also the host's Id.
+func (digoMgr *DigitalOceanManager) GetInstanceID(host *host.Host) (string, error) {
+	return host.Id, nil
+}
+
 //CanSpawn returns if a given cloud provider supports spawning a new host
 //dynamically. Always returns true for DigitalOcean.
 func (digoMgr *DigitalOceanManager) CanSpawn() (bool, error) {
@@ -228,6 +267,7 @@ func (digoMgr *DigitalOceanManager) CanSpawn() (bool, error) {
 
 //TerminateInstance destroys a droplet.
 func (digoMgr *DigitalOceanManager) TerminateInstance(host *host.Host) error {
+	defer cloud.RecordCallTime(ProviderName, "TerminateInstance", time.Now())
 	hostIdAsInt, err := strconv.Atoi(host.Id)
 	if err != nil {
 		err = fmt.Errorf("Can't terminate '%v': DigitalOcean host id's must be integers", host.Id)
@@ -251,6 +291,36 @@ func (digoMgr *DigitalOceanManager) TerminateInstance(host *host.Host) error {
 	return host.Terminate()
 }
 
+// Reboot is not supported for DigitalOcean droplets.
+func (digoMgr *DigitalOceanManager) Reboot(host *host.Host) error {
+	return cloud.ErrRebootUnsupported
+}
+
+// GetConsoleOutput is not supported for DigitalOcean droplets.
+func (digoMgr *DigitalOceanManager) GetConsoleOutput(host *host.Host) (string, error) {
+	return "", cloud.ErrConsoleOutputUnsupported
+}
+
+// CreateImage is not supported for DigitalOcean droplets.
+func (digoMgr *DigitalOceanManager) CreateImage(host *host.Host, name string) (string, error) {
+	return "", cloud.ErrCreateImageUnsupported
+}
+
+// GetLaunchTime returns when the host's droplet was created.
+func (digoMgr *DigitalOceanManager) GetLaunchTime(host *host.Host) (time.Time, error) {
+	defer cloud.RecordCallTime(ProviderName, "GetLaunchTime", time.Now())
+	hostIdAsInt, err := strconv.Atoi(host.Id)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Can't get launch time of '%v': DigitalOcean host id's "+
+			"must be integers", host.Id)
+	}
+	droplet, err := digoMgr.getDropletInfo(hostIdAsInt)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Failed to get droplet info: %v", err)
+	}
+	return droplet.CreatedAt, nil
+}
+
 //Configure populates a DigitalOceanManager by reading relevant settings from the
 //config object.
 func (digoMgr *DigitalOceanManager) Configure(settings *evergreen.Settings) error {
@@ -282,9 +352,9 @@ func (digoMgr *DigitalOceanManager) IsUp(host *host.Host) (bool, error) {
 	return false, nil
 }
 
+// OnUp records when the droplet was launched. DigitalOcean doesn't support tags.
 func (digoMgr *DigitalOceanManager) OnUp(host *host.Host) error {
-	//Currently a no-op as DigitalOcean doesn't support tags.
-	return nil
+	return cloud.RecordLaunchTime(digoMgr, host)
 }
 
 //GetSSHOptions returns an array of default SSH options for connecting to a
@@ -305,16 +375,17 @@ func (digoMgr *DigitalOceanManager) GetSSHOptions(host *host.Host, keyPath strin
 func (digoMgr *DigitalOceanManager) TimeTilNextPayment(host *host.Host) time.Duration {
 
 	now := time.Now()
+	launchTime := host.BillingStart()
 
-	// the time since the host was created
-	timeSinceCreation := now.Sub(host.CreationTime)
+	// the time since the host was launched
+	timeSinceCreation := now.Sub(launchTime)
 
-	// the hours since the host was created, rounded up
+	// the hours since the host was launched, rounded up
 	hoursRoundedUp := time.Duration(math.Ceil(timeSinceCreation.Hours()))
 
 	// the next round number of hours the host will have been up - the time
 	// that the next payment will be due
-	nextPaymentTime := host.CreationTime.Add(hoursRoundedUp)
+	nextPaymentTime := launchTime.Add(hoursRoundedUp)
 
 	return nextPaymentTime.Sub(now)
 }
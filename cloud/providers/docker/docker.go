@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"strings"
 	"time"
 
 	"github.com/evergreen-ci/evergreen"
@@ -213,6 +214,7 @@ func (_ *DockerManager) GetSettings() cloud.ProviderSettings {
 
 // SpawnInstance creates and starts a new Docker container
 func (dockerMgr *DockerManager) SpawnInstance(d *distro.Distro, hostOpts cloud.HostOptions) (*host.Host, error) {
+	defer cloud.RecordCallTime(ProviderName, "SpawnInstance", time.Now())
 	var err error
 
 	if d.Provider != ProviderName {
@@ -243,7 +245,8 @@ func (dockerMgr *DockerManager) SpawnInstance(d *distro.Distro, hostOpts cloud.H
 				ExposedPorts: map[docker.Port]struct{}{
 					SSHDPort: {},
 				},
-				Image: settings.ImageId,
+				Image:  settings.ImageId,
+				Labels: map[string]string{cloud.EvergreenOwnedTag: "true"},
 			},
 			HostConfig: hostConfig,
 		},
@@ -290,6 +293,7 @@ func (dockerMgr *DockerManager) SpawnInstance(d *distro.Distro, hostOpts cloud.H
 
 	intentHost := cloud.NewIntent(*d, instanceName, ProviderName, hostOpts)
 	intentHost.Host = hostStr
+	intentHost.InstanceId = newContainer.ID
 
 	err = intentHost.Insert()
 	if err != nil {
@@ -322,12 +326,13 @@ func getStatus(s *docker.State) int {
 // GetInstanceStatus returns a universal status code representing the state
 // of a container.
 func (dockerMgr *DockerManager) GetInstanceStatus(host *host.Host) (cloud.CloudStatus, error) {
+	defer cloud.RecordCallTime(ProviderName, "GetInstanceStatus", time.Now())
 	dockerClient, _, err := generateClient(&host.Distro)
 	if err != nil {
 		return cloud.StatusUnknown, err
 	}
 
-	container, err := dockerClient.InspectContainer(host.Id)
+	container, err := dockerClient.InspectContainer(containerId(host))
 	if err != nil {
 		return cloud.StatusUnknown, fmt.Errorf("Failed to get container information for host '%v': %v", host.Id, err)
 	}
@@ -346,12 +351,83 @@ func (dockerMgr *DockerManager) GetInstanceStatus(host *host.Host) (cloud.CloudS
 	}
 }
 
+// ListInstances returns the containers Evergreen has launched on the Docker hosts
+// used by any Docker distro.
+func (dockerMgr *DockerManager) ListInstances() ([]cloud.CloudInstance, error) {
+	distros, err := distro.Find(distro.ByProvider(ProviderName))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find Docker distros: %v", err)
+	}
+
+	result := []cloud.CloudInstance{}
+	seen := map[string]bool{}
+	for _, d := range distros {
+		dockerClient, settings, err := generateClient(&d)
+		if err != nil {
+			return nil, err
+		}
+		endpoint := fmt.Sprintf("%s:%v", settings.HostIp, settings.ClientPort)
+		if seen[endpoint] {
+			continue
+		}
+		seen[endpoint] = true
+
+		containers, err := dockerClient.ListContainers(docker.ListContainersOptions{
+			All:     true,
+			Filters: map[string][]string{"label": {cloud.EvergreenOwnedTag + "=true"}},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Failed to list containers on Docker host '%s': %v", endpoint, err)
+		}
+		for _, container := range containers {
+			result = append(result, cloud.CloudInstance{
+				Id:           container.ID,
+				Status:       containerStatus(container.Status),
+				LaunchTime:   time.Unix(container.Created, 0),
+				InstanceType: container.Image,
+			})
+		}
+	}
+	return result, nil
+}
+
+// containerStatus converts the status summary Docker gives when listing containers
+// (e.g. "Up 2 hours", "Exited (0) 5 minutes ago") into a universal status code.
+func containerStatus(status string) cloud.CloudStatus {
+	switch {
+	case strings.HasPrefix(status, "Up") && strings.Contains(status, "(Paused)"):
+		return cloud.StatusStopped
+	case strings.HasPrefix(status, "Up"):
+		return cloud.StatusRunning
+	case strings.HasPrefix(status, "Created"), strings.HasPrefix(status, "Restarting"):
+		return cloud.StatusInitializing
+	case strings.HasPrefix(status, "Exited"), strings.HasPrefix(status, "Dead"):
+		return cloud.StatusTerminated
+	default:
+		return cloud.StatusUnknown
+	}
+}
+
 //GetDNSName gets the DNS hostname of a container by reading it directly from
 //the Docker API
 func (dockerMgr *DockerManager) GetDNSName(host *host.Host) (string, error) {
 	return host.Host, nil
 }
 
+//GetInstanceID returns the id of the host's container.
+func (dockerMgr *DockerManager) GetInstanceID(host *host.Host) (string, error) {
+	return containerId(host), nil
+}
+
+//containerId returns the id of the host's container, falling back to the host's Id
+//for hosts created before container ids were recorded.
+func containerId(h *host.Host) string {
+	if h.InstanceId != "" {
+		return h.InstanceId
+	}
+	return h.Id
+}
+
 //CanSpawn returns if a given cloud provider supports spawning a new host
 //dynamically. Always returns true for Docker.
 func (dockerMgr *DockerManager) CanSpawn() (bool, error) {
@@ -360,12 +436,13 @@ func (dockerMgr *DockerManager) CanSpawn() (bool, error) {
 
 //TerminateInstance destroys a container.
 func (dockerMgr *DockerManager) TerminateInstance(host *host.Host) error {
+	defer cloud.RecordCallTime(ProviderName, "TerminateInstance", time.Now())
 	dockerClient, _, err := generateClient(&host.Distro)
 	if err != nil {
 		return err
 	}
 
-	err = dockerClient.StopContainer(host.Id, TimeoutSeconds)
+	err = dockerClient.StopContainer(containerId(host), TimeoutSeconds)
 	if err != nil {
 		err = fmt.Errorf("Failed to stop container '%v': %+v", host.Id, err)
 		grip.Error(err)
@@ -374,7 +451,7 @@ func (dockerMgr *DockerManager) TerminateInstance(host *host.Host) error {
 
 	err = dockerClient.RemoveContainer(
 		docker.RemoveContainerOptions{
-			ID: host.Id,
+			ID: containerId(host),
 		},
 	)
 	if err != nil {
@@ -386,6 +463,35 @@ func (dockerMgr *DockerManager) TerminateInstance(host *host.Host) error {
 	return host.Terminate()
 }
 
+// Reboot is not supported for docker containers.
+func (dockerMgr *DockerManager) Reboot(host *host.Host) error {
+	return cloud.ErrRebootUnsupported
+}
+
+// GetConsoleOutput is not supported for docker containers.
+func (dockerMgr *DockerManager) GetConsoleOutput(host *host.Host) (string, error) {
+	return "", cloud.ErrConsoleOutputUnsupported
+}
+
+// CreateImage is not supported for docker containers.
+func (dockerMgr *DockerManager) CreateImage(host *host.Host, name string) (string, error) {
+	return "", cloud.ErrCreateImageUnsupported
+}
+
+// GetLaunchTime returns when the host's container was created.
+func (dockerMgr *DockerManager) GetLaunchTime(host *host.Host) (time.Time, error) {
+	defer cloud.RecordCallTime(ProviderName, "GetLaunchTime", time.Now())
+	dockerClient, _, err := generateClient(&host.Distro)
+	if err != nil {
+		return time.Time{}, err
+	}
+	container, err := dockerClient.InspectContainer(containerId(host))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Failed to get container information for host '%v': %v", host.Id, err)
+	}
+	return container.Created, nil
+}
+
 //Configure populates a DockerManager by reading relevant settings from the
 //config object.
 func (dockerMgr *DockerManager) Configure(settings *evergreen.Settings) error {
@@ -415,8 +521,9 @@ func (dockerMgr *DockerManager) IsUp(host *host.Host) (bool, error) {
 	return false, nil
 }
 
+// OnUp records when the host's container was created.
 func (dockerMgr *DockerManager) OnUp(host *host.Host) error {
-	return nil
+	return cloud.RecordLaunchTime(dockerMgr, host)
 }
 
 //GetSSHOptions returns an array of default SSH options for connecting to a
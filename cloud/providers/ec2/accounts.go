@@ -0,0 +1,156 @@
+package ec2
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/goamz/goamz/aws"
+	"github.com/goamz/goamz/ec2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// accountSettingKey is the key of the distro provider setting that names the AWS
+// account its hosts are started in.
+const accountSettingKey = "account"
+
+// awsAccounts holds the credentials of the AWS accounts hosts can be started in:
+// the deployment's default account, and the named accounts distros can choose.
+type awsAccounts struct {
+	defaultAuth *aws.Auth
+	named       map[string]*aws.Auth
+}
+
+// loadAWSAccounts reads the credentials of the default and named AWS accounts
+// from the settings.
+func loadAWSAccounts(config *evergreen.AWSConfig) (awsAccounts, error) {
+	if config.Id == "" || config.Secret == "" {
+		return awsAccounts{}, fmt.Errorf("AWS ID/Secret must not be blank")
+	}
+	accounts := awsAccounts{
+		defaultAuth: &aws.Auth{AccessKey: config.Id, SecretKey: config.Secret},
+		named:       map[string]*aws.Auth{},
+	}
+	for _, account := range config.Accounts {
+		if account.Id == "" || account.Secret == "" {
+			return awsAccounts{}, fmt.Errorf("AWS ID/Secret of account '%v' must not be blank", account.Name)
+		}
+		accounts.named[account.Name] = &aws.Auth{AccessKey: account.Id, SecretKey: account.Secret}
+	}
+	return accounts, nil
+}
+
+// has returns true if the account is configured. A blank name is the default account.
+func (a awsAccounts) has(name string) bool {
+	if name == "" {
+		return true
+	}
+	_, ok := a.named[name]
+	return ok
+}
+
+// get returns the credentials of the named account. A blank name is the default account.
+func (a awsAccounts) get(name string) (*aws.Auth, error) {
+	if name == "" {
+		if a.defaultAuth == nil {
+			return nil, fmt.Errorf("AWS credentials are not configured")
+		}
+		return a.defaultAuth, nil
+	}
+	auth, ok := a.named[name]
+	if !ok {
+		return nil, fmt.Errorf("AWS account '%v' is not configured", name)
+	}
+	return auth, nil
+}
+
+// credentials returns the credentials of the account the distro's hosts are started in.
+func (a awsAccounts) credentials(d *distro.Distro) (*aws.Auth, error) {
+	auth, err := a.get(distroAccount(d))
+	if err != nil {
+		return nil, fmt.Errorf("error loading credentials for distro %v: %v", d.Id, err)
+	}
+	return auth, nil
+}
+
+// handle returns an EC2 handle for the account the distro's hosts are started in.
+func (a awsAccounts) handle(d *distro.Distro) (*ec2.EC2, error) {
+	auth, err := a.credentials(d)
+	if err != nil {
+		return nil, err
+	}
+	return getUSEast(*auth), nil
+}
+
+// hostHandle returns an EC2 handle for the account and region the host's instance
+// runs in. Hosts whose zone isn't recorded yet are looked up at US east, where
+// spot requests are made.
+func (a awsAccounts) hostHandle(h *host.Host) (*ec2.EC2, error) {
+	auth, err := a.credentials(&h.Distro)
+	if err != nil {
+		return nil, err
+	}
+	ec2Handle, err := getRegionHandle(*auth, azToRegion(h.Zone))
+	if err != nil {
+		return nil, fmt.Errorf("error getting EC2 handle for host %v: %v", h.Id, err)
+	}
+	return ec2Handle, nil
+}
+
+// all returns the credentials of every configured account, starting with the default.
+func (a awsAccounts) all() []*aws.Auth {
+	auths := []*aws.Auth{}
+	for _, name := range a.names() {
+		auth, _ := a.get(name)
+		auths = append(auths, auth)
+	}
+	return auths
+}
+
+// names returns the names of every configured account in order, starting with
+// the default account's blank name.
+func (a awsAccounts) names() []string {
+	names := []string{}
+	if a.defaultAuth != nil {
+		names = append(names, "")
+	}
+	named := []string{}
+	for name := range a.named {
+		named = append(named, name)
+	}
+	sort.Strings(named)
+	return append(names, named...)
+}
+
+// distroAccount returns the name of the AWS account the distro's hosts are started
+// in, or a blank name for the default account.
+func distroAccount(d *distro.Distro) string {
+	if d == nil || d.ProviderSettings == nil {
+		return ""
+	}
+	account, _ := (*d.ProviderSettings)[accountSettingKey].(string)
+	return account
+}
+
+// CheckDistroAccounts returns an error if any EC2 distro names an AWS account that
+// isn't in the settings, so that a misconfigured deployment fails at startup instead
+// of when it next spawns hosts.
+func CheckDistroAccounts(settings *evergreen.Settings) error {
+	distros, err := distro.Find(db.Query(bson.M{
+		distro.ProviderKey: bson.M{"$in": []string{OnDemandProviderName, SpotProviderName}},
+	}))
+	if err != nil {
+		return fmt.Errorf("error finding EC2 distros: %v", err)
+	}
+	for i := range distros {
+		name := distroAccount(&distros[i])
+		if name != "" && settings.Providers.AWS.FindAccount(name) == nil {
+			return fmt.Errorf("distro %v uses AWS account '%v', which is not configured",
+				distros[i].Id, name)
+		}
+	}
+	return nil
+}
@@ -11,7 +11,6 @@ import (
 	"github.com/evergreen-ci/evergreen/model/distro"
 	"github.com/evergreen-ci/evergreen/model/host"
 	"github.com/evergreen-ci/evergreen/util"
-	"github.com/goamz/goamz/aws"
 	"github.com/goamz/goamz/ec2"
 	"github.com/mitchellh/mapstructure"
 	"github.com/mongodb/grip"
@@ -19,7 +18,8 @@ import (
 
 // EC2Manager implements the CloudManager interface for Amazon EC2
 type EC2Manager struct {
-	awsCredentials *aws.Auth
+	accounts              awsAccounts
+	defaultSecurityGroups []string
 }
 
 //Valid values for EC2 instance states:
@@ -41,10 +41,31 @@ type EC2ProviderSettings struct {
 
 	// this is the security group name in EC2 classic and the security group ID in VPC (eg. sg-xxxx)
 	SecurityGroup string `mapstructure:"security_group" json:"security_group,omitempty" bson:"security_group,omitempty"`
+	// additional security groups, in the same form as SecurityGroup. If neither is set,
+	// the deployment-wide security groups are used.
+	SecurityGroups []string `mapstructure:"security_groups" json:"security_groups,omitempty" bson:"security_groups,omitempty"`
 	// only set in VPC (eg. subnet-xxxx)
 	SubnetId string `mapstructure:"subnet_id" json:"subnet_id,omitempty" bson:"subnet_id,omitempty"`
 	// this is set to true if the security group is part of a vpc
 	IsVpc bool `mapstructure:"is_vpc" json:"is_vpc,omitempty" bson:"is_vpc,omitempty"`
+	// one of "default", "dedicated", or "host"; blank means "default"
+	Tenancy string `mapstructure:"tenancy" json:"tenancy,omitempty" bson:"tenancy,omitempty"`
+	// the size of the root volume in GB; zero means the image's size
+	RootVolumeSize int `mapstructure:"root_volume_size" json:"root_volume_size,omitempty" bson:"root_volume_size,omitempty"`
+
+	// the name of the AWS account to start instances in; blank means the default account
+	Account string `mapstructure:"account" json:"account,omitempty" bson:"account,omitempty"`
+
+	// the deployment-wide security groups, used if the distro doesn't specify any
+	defaultSecurityGroups []string
+	// the accounts configured in the settings
+	accounts awsAccounts
+}
+
+// getSecurityGroups returns the security groups that instances of the distro
+// should be started in.
+func (self *EC2ProviderSettings) getSecurityGroups() []string {
+	return securityGroups(self.SecurityGroup, self.SecurityGroups, self.defaultSecurityGroups)
 }
 
 func (self *EC2ProviderSettings) Validate() error {
@@ -56,32 +77,52 @@ func (self *EC2ProviderSettings) Validate() error {
 		return fmt.Errorf("Instance size must not be blank")
 	}
 
-	if self.SecurityGroup == "" {
-		return fmt.Errorf("Security group must not be blank")
+	if err := validateSecurityGroups(self.getSecurityGroups(), self.IsVpc); err != nil {
+		return err
 	}
 
 	if self.KeyName == "" {
 		return fmt.Errorf("Key name must not be blank")
 	}
 
+	if err := validateTenancy(self.Tenancy); err != nil {
+		return err
+	}
+
+	if err := cloud.ValidateRootVolumeSize(self.RootVolumeSize); err != nil {
+		return err
+	}
+
 	_, err := makeBlockDeviceMappings(self.MountPoints)
 	if err != nil {
 		return err
 	}
 
+	if !self.accounts.has(self.Account) {
+		return fmt.Errorf("AWS account '%v' is not configured", self.Account)
+	}
+
 	return nil
 }
 
 //Configure loads necessary credentials or other settings from the global config
 //object.
 func (cloudManager *EC2Manager) Configure(settings *evergreen.Settings) error {
-	if settings.Providers.AWS.Id == "" || settings.Providers.AWS.Secret == "" {
-		return fmt.Errorf("AWS ID/Secret must not be blank")
+	accounts, err := loadAWSAccounts(&settings.Providers.AWS)
+	if err != nil {
+		return err
 	}
+	cloudManager.accounts = accounts
+	cloudManager.defaultSecurityGroups = settings.Providers.AWS.SecurityGroups
+	return nil
+}
 
-	cloudManager.awsCredentials = &aws.Auth{
-		AccessKey: settings.Providers.AWS.Id,
-		SecretKey: settings.Providers.AWS.Secret,
+// ValidateCredentials checks that EC2 accepts the credentials of each configured account.
+func (cloudManager *EC2Manager) ValidateCredentials() error {
+	for _, auth := range cloudManager.accounts.all() {
+		if err := validateCredentials(getUSEast(*auth)); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -99,30 +140,129 @@ func (cloudManager *EC2Manager) IsSSHReachable(host *host.Host, keyPath string)
 }
 
 func (cloudManager *EC2Manager) GetInstanceStatus(host *host.Host) (cloud.CloudStatus, error) {
-	ec2Handle := getUSEast(*cloudManager.awsCredentials)
-	instanceInfo, err := getInstanceInfo(ec2Handle, host.Id)
+	defer cloud.RecordCallTime(OnDemandProviderName, "GetInstanceStatus", time.Now())
+	ec2Handle, err := cloudManager.accounts.handle(&host.Distro)
+	if err != nil {
+		return cloud.StatusUnknown, err
+	}
+	instanceInfo, err := getInstanceInfo(ec2Handle, instanceId(host))
 	if err != nil {
 		return cloud.StatusUnknown, err
 	}
 	return ec2StatusToEvergreenStatus(instanceInfo.State.Name), nil
 }
 
+// ParseInstanceEvent parses an EC2 instance state change event delivered by
+// EventBridge.
+func (cloudManager *EC2Manager) ParseInstanceEvent(body []byte) (string, cloud.CloudStatus, error) {
+	return parseStateChangeEvent(body)
+}
+
 func (cloudManager *EC2Manager) CanSpawn() (bool, error) {
 	return true, nil
 }
 
-func (*EC2Manager) GetSettings() cloud.ProviderSettings {
-	return &EC2ProviderSettings{}
+func (cloudManager *EC2Manager) GetSettings() cloud.ProviderSettings {
+	return &EC2ProviderSettings{
+		defaultSecurityGroups: cloudManager.defaultSecurityGroups,
+		accounts:              cloudManager.accounts,
+	}
 }
 
 func (cloudManager *EC2Manager) SpawnInstance(d *distro.Distro, hostOpts cloud.HostOptions) (*host.Host, error) {
+	defer cloud.RecordCallTime(OnDemandProviderName, "SpawnInstance", time.Now())
+	ec2Settings, err := cloudManager.decodeOnDemandSettings(d)
+	if err != nil {
+		return nil, err
+	}
+	return cloudManager.spawnOnDemandInstance(d, ec2Settings, hostOpts)
+}
+
+// BatchSpawnInstance starts up to count on-demand instances of the distro with a
+// single RunInstances call.
+func (cloudManager *EC2Manager) BatchSpawnInstance(d *distro.Distro, hostOpts cloud.HostOptions, count int) ([]host.Host, error) {
+	defer cloud.RecordCallTime(OnDemandProviderName, "BatchSpawnInstance", time.Now())
+	if count < 1 {
+		return nil, fmt.Errorf("Can't spawn %v instances of distro %v", count, d.Id)
+	}
+	ec2Settings, err := cloudManager.decodeOnDemandSettings(d)
+	if err != nil {
+		return nil, err
+	}
+
+	ec2Handle, options, err := cloudManager.onDemandRunOptions(d, ec2Settings, hostOpts)
+	if err != nil {
+		return nil, err
+	}
+	options.MinCount = 1
+	options.MaxCount = count
+
+	intentHosts := make([]*host.Host, 0, count)
+	removeIntents := func(intents []*host.Host) {
+		for _, intentHost := range intents {
+			if err := intentHost.Remove(); err != nil {
+				grip.Errorf("Could not remove intent host '%s': %+v", intentHost.Id, err)
+			}
+		}
+	}
+	for i := 0; i < count; i++ {
+		intentHost := newOnDemandIntent(d, ec2Settings, hostOpts, options)
+		if err = intentHost.Insert(); err != nil {
+			removeIntents(intentHosts)
+			err = fmt.Errorf("Could not insert intent host '%s': %+v", intentHost.Id, err)
+			grip.Error(err)
+			return nil, err
+		}
+		intentHosts = append(intentHosts, intentHost)
+	}
+	grip.Debugf("Inserted %v intent hosts for distro '%v' to signal instance spawn intent",
+		count, d.Id)
+
+	resp, err := ec2Handle.RunInstances(options)
+	if err != nil {
+		removeIntents(intentHosts)
+		err = fmt.Errorf("EC2 RunInstances API call returned error: %v", err)
+		grip.Error(err)
+		return nil, err
+	}
+	grip.Debugf("Spawned %d of %d instances for distro '%v'", len(resp.Instances), count, d.Id)
+	if len(resp.Instances) < count {
+		removeIntents(intentHosts[len(resp.Instances):])
+	}
+
+	newHosts := make([]host.Host, 0, len(resp.Instances))
+	for i, instance := range resp.Instances {
+		newHost, err := recordInstance(intentHosts[i], instance.InstanceId, instance.AvailabilityZone)
+		if err != nil {
+			grip.Error(err)
+			// don't leave an instance running that no host record tracks
+			if _, _, err = terminateInstance(ec2Handle, instance.InstanceId); err != nil {
+				grip.Errorf("Could not terminate unrecorded instance %s: %+v", instance.InstanceId, err)
+			}
+			continue
+		}
+		if err = attachTags(ec2Handle, makeTags(intentHosts[i]), instance.InstanceId); err != nil {
+			grip.Errorf("Unable to attach tags for %s: %+v", instance.InstanceId, err)
+		}
+		newHosts = append(newHosts, *newHost)
+	}
+	if len(newHosts) == 0 {
+		return nil, fmt.Errorf("Could not record any of the instances started for distro '%v'", d.Id)
+	}
+	return newHosts, nil
+}
+
+// decodeOnDemandSettings decodes and validates the distro's settings for on-demand instances.
+func (cloudManager *EC2Manager) decodeOnDemandSettings(d *distro.Distro) (*EC2ProviderSettings, error) {
 	if d.Provider != OnDemandProviderName {
 		return nil, fmt.Errorf("Can't spawn instance of %v for distro %v: provider is %v", OnDemandProviderName, d.Id, d.Provider)
 	}
-	ec2Handle := getUSEast(*cloudManager.awsCredentials)
 
 	//Decode and validate the ProviderSettings into the ec2-specific ones.
-	ec2Settings := &EC2ProviderSettings{}
+	ec2Settings := &EC2ProviderSettings{
+		defaultSecurityGroups: cloudManager.defaultSecurityGroups,
+		accounts:              cloudManager.accounts,
+	}
 	if err := mapstructure.Decode(d.ProviderSettings, ec2Settings); err != nil {
 		return nil, fmt.Errorf("Error decoding params for distro %v: %v", d.Id, err)
 	}
@@ -130,20 +270,93 @@ func (cloudManager *EC2Manager) SpawnInstance(d *distro.Distro, hostOpts cloud.H
 	if err := ec2Settings.Validate(); err != nil {
 		return nil, fmt.Errorf("Invalid EC2 settings in distro %#v: %v and %#v", d, err, ec2Settings)
 	}
+	return ec2Settings, nil
+}
+
+// onDemandRunOptions returns the handle of the distro's account and the options to
+// start a single on-demand instance of the distro with.
+func (cloudManager *EC2Manager) onDemandRunOptions(d *distro.Distro, ec2Settings *EC2ProviderSettings,
+	hostOpts cloud.HostOptions) (*ec2.EC2, *ec2.RunInstancesOptions, error) {
+	ec2Handle, err := cloudManager.accounts.handle(d)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tenancy := ec2Settings.Tenancy
+	if hostOpts.Tenancy != "" {
+		if err = validateTenancy(hostOpts.Tenancy); err != nil {
+			return nil, nil, fmt.Errorf("Invalid host options for distro %v: %v", d.Id, err)
+		}
+		tenancy = hostOpts.Tenancy
+	}
+	if tenancy == evergreen.HostTenancyDefault {
+		tenancy = ""
+	}
 
 	blockDevices, err := makeBlockDeviceMappings(ec2Settings.MountPoints)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	instanceName := generateName(d.Id)
+	rootVolumeSize := getRootVolumeSize(ec2Settings.RootVolumeSize, hostOpts)
+	if rootVolumeSize != 0 {
+		var rootDevice ec2.BlockDeviceMapping
+		rootDevice, err = makeRootDeviceMapping(ec2Handle, ec2Settings.AMI, rootVolumeSize, blockDevices)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Can't resize root volume of distro %v: %v", d.Id, err)
+		}
+		blockDevices = append(blockDevices, rootDevice)
+	}
+
+	options := &ec2.RunInstancesOptions{
+		MinCount:         1,
+		MaxCount:         1,
+		ImageId:          ec2Settings.AMI,
+		KeyName:          ec2Settings.KeyName,
+		InstanceType:     ec2Settings.InstanceType,
+		SecurityGroups:   ec2.SecurityGroupNames(ec2Settings.getSecurityGroups()...),
+		BlockDevices:     blockDevices,
+		Tenancy:          tenancy,
+		ShutdownBehavior: hostOpts.ShutdownBehavior,
+	}
+
+	// if it's a Vpc override the options to be the correct VPC settings.
+	if ec2Settings.IsVpc {
+		options.SecurityGroups = ec2.SecurityGroupIds(ec2Settings.getSecurityGroups()...)
+		options.AssociatePublicIpAddress = true
+		options.SubnetId = ec2Settings.SubnetId
+	}
+	return ec2Handle, options, nil
+}
+
+// newOnDemandIntent returns an intent host, not yet inserted, for an instance
+// started with the given options.
+func newOnDemandIntent(d *distro.Distro, ec2Settings *EC2ProviderSettings, hostOpts cloud.HostOptions,
+	options *ec2.RunInstancesOptions) *host.Host {
+	intentHost := cloud.NewIntent(*d, generateName(d.Id), OnDemandProviderName, hostOpts)
+	intentHost.InstanceType = ec2Settings.InstanceType
+	intentHost.Tenancy = options.Tenancy
+	intentHost.ShutdownBehavior = options.ShutdownBehavior
+	intentHost.RootVolumeSize = getRootVolumeSize(ec2Settings.RootVolumeSize, hostOpts)
+	return intentHost
+}
+
+// spawnOnDemandInstance starts an on-demand instance of the distro using the given,
+// already validated, settings. The distro's own provider is not checked, so this
+// can also be used to start an on-demand instance in place of a spot instance.
+func (cloudManager *EC2Manager) spawnOnDemandInstance(d *distro.Distro, ec2Settings *EC2ProviderSettings,
+	hostOpts cloud.HostOptions) (*host.Host, error) {
+	ec2Handle, options, err := cloudManager.onDemandRunOptions(d, ec2Settings, hostOpts)
+	if err != nil {
+		return nil, err
+	}
 
 	// proactively write all possible information pertaining
 	// to the host we want to create. this way, if we are unable
 	// to start it or record its instance id, we have a way of knowing
 	// something went wrong - and what
-	intentHost := cloud.NewIntent(*d, instanceName, OnDemandProviderName, hostOpts)
-	intentHost.InstanceType = ec2Settings.InstanceType
+	intentHost := newOnDemandIntent(d, ec2Settings, hostOpts, options)
+	instanceName := intentHost.Id
 
 	// record this 'intent host'
 	if err := intentHost.Insert(); err != nil {
@@ -155,27 +368,10 @@ func (cloudManager *EC2Manager) SpawnInstance(d *distro.Distro, hostOpts cloud.H
 	grip.Debugf("Inserted intent host '%v' for distro '%v' to signal instance spawn intent",
 		instanceName, d.Id)
 
-	options := ec2.RunInstancesOptions{
-		MinCount:       1,
-		MaxCount:       1,
-		ImageId:        ec2Settings.AMI,
-		KeyName:        ec2Settings.KeyName,
-		InstanceType:   ec2Settings.InstanceType,
-		SecurityGroups: ec2.SecurityGroupNames(ec2Settings.SecurityGroup),
-		BlockDevices:   blockDevices,
-	}
-
-	// if it's a Vpc override the options to be the correct VPC settings.
-	if ec2Settings.IsVpc {
-		options.SecurityGroups = ec2.SecurityGroupIds(ec2Settings.SecurityGroup)
-		options.AssociatePublicIpAddress = true
-		options.SubnetId = ec2Settings.SubnetId
-	}
-
 	// start the instance - starting an instance does not mean you can connect
 	// to it immediately you have to use GetInstanceStatus to ensure that
 	// it's actually running
-	newHost, resp, err := startEC2Instance(ec2Handle, &options, intentHost)
+	newHost, resp, err := startEC2Instance(ec2Handle, options, intentHost)
 	grip.Debugf("id=%s, intentHost=%s, starResp=%+v, newHost=%+v",
 		instanceName, intentHost.Id, resp, newHost)
 
@@ -204,8 +400,11 @@ func (cloudManager *EC2Manager) SpawnInstance(d *distro.Distro, hostOpts cloud.H
 }
 
 func (cloudManager *EC2Manager) IsUp(host *host.Host) (bool, error) {
-	ec2Handle := getUSEast(*cloudManager.awsCredentials)
-	instanceInfo, err := getInstanceInfo(ec2Handle, host.Id)
+	ec2Handle, err := cloudManager.accounts.handle(&host.Distro)
+	if err != nil {
+		return false, err
+	}
+	instanceInfo, err := getInstanceInfo(ec2Handle, instanceId(host))
 	if err != nil {
 		return false, err
 	}
@@ -215,21 +414,51 @@ func (cloudManager *EC2Manager) IsUp(host *host.Host) (bool, error) {
 	return false, nil
 }
 
+// OnUp records when the host's instance was launched. Tags don't need to be set
+// here since they're set when the instance is spawned.
 func (cloudManager *EC2Manager) OnUp(host *host.Host) error {
-	//Not currently needed since we can set the tags immediately
-	return nil
+	return cloud.RecordLaunchTime(cloudManager, host)
 }
 
 func (cloudManager *EC2Manager) GetDNSName(host *host.Host) (string, error) {
-	ec2Handle := getUSEast(*cloudManager.awsCredentials)
-	instanceInfo, err := getInstanceInfo(ec2Handle, host.Id)
+	ec2Handle, err := cloudManager.accounts.handle(&host.Distro)
+	if err != nil {
+		return "", err
+	}
+	instanceInfo, err := getInstanceInfo(ec2Handle, instanceId(host))
 	if err != nil {
 		return "", err
 	}
 	return instanceInfo.DNSName, nil
 }
 
+// GetInstanceID returns the EC2 instance id of the host.
+func (cloudManager *EC2Manager) GetInstanceID(host *host.Host) (string, error) {
+	return instanceId(host), nil
+}
+
+// ListInstances returns the on-demand instances Evergreen has launched in EC2,
+// in every configured account.
+func (cloudManager *EC2Manager) ListInstances() ([]cloud.CloudInstance, error) {
+	result := []cloud.CloudInstance{}
+	for _, auth := range cloudManager.accounts.all() {
+		instances, err := describeOwnedInstances(getUSEast(*auth))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to list EC2 instances: %v", err)
+		}
+		for _, instance := range instances {
+			// spot instances are listed by the spot manager
+			if instance.InstanceLifecycle == "spot" {
+				continue
+			}
+			result = append(result, makeCloudInstance(instance))
+		}
+	}
+	return result, nil
+}
+
 func (cloudManager *EC2Manager) TerminateInstance(host *host.Host) error {
+	defer cloud.RecordCallTime(OnDemandProviderName, "TerminateInstance", time.Now())
 	// terminate the instance
 	if host.Status == evergreen.HostTerminated {
 		err := fmt.Errorf("Can not terminate %v - already marked as "+
@@ -238,24 +467,133 @@ func (cloudManager *EC2Manager) TerminateInstance(host *host.Host) error {
 		return err
 	}
 
-	ec2Handle := getUSEast(*cloudManager.awsCredentials)
-	resp, err := ec2Handle.TerminateInstances([]string{host.Id})
-
+	ec2Handle, err := cloudManager.accounts.handle(&host.Distro)
 	if err != nil {
 		return err
 	}
+	resp, exhausted, err := terminateInstance(ec2Handle, instanceId(host))
+	if err != nil {
+		if exhausted {
+			// stop the monitor from trying to terminate the host again
+			grip.Error(host.SetTerminationFailed(err.Error()))
+		}
+		return err
+	}
 
 	for _, stateChange := range resp.StateChanges {
 		grip.Infoln("Terminated", stateChange.InstanceId)
 	}
+	grip.Error(releaseHostStaticAddress(ec2Handle, host))
 
 	// set the host status as terminated and update its termination time
 	return host.Terminate()
 }
 
+// Reboot reboots the host's EC2 instance.
+func (cloudManager *EC2Manager) Reboot(host *host.Host) error {
+	defer cloud.RecordCallTime(OnDemandProviderName, "Reboot", time.Now())
+	ec2Handle, err := cloudManager.accounts.handle(&host.Distro)
+	if err != nil {
+		return err
+	}
+	return rebootInstance(ec2Handle, host.Id, instanceId(host))
+}
+
+// GetConsoleOutput returns the console output of the host's EC2 instance.
+func (cloudManager *EC2Manager) GetConsoleOutput(host *host.Host) (string, error) {
+	defer cloud.RecordCallTime(OnDemandProviderName, "GetConsoleOutput", time.Now())
+	auth, err := cloudManager.accounts.credentials(&host.Distro)
+	if err != nil {
+		return "", err
+	}
+	region, err := instanceRegion(getUSEast(*auth), host, instanceId(host))
+	if err != nil {
+		return "", fmt.Errorf("Failed to get console output of host %v: %v", host.Id, err)
+	}
+	output, err := getConsoleOutput(getSDK(*auth, region), instanceId(host))
+	if err != nil {
+		return "", fmt.Errorf("Failed to get console output of host %v: %v", host.Id, err)
+	}
+	return output, nil
+}
+
+// CreateImage creates an AMI from the host's EC2 instance. EC2 reboots the
+// instance while imaging it, so that its file systems are consistent.
+func (cloudManager *EC2Manager) CreateImage(host *host.Host, name string) (string, error) {
+	defer cloud.RecordCallTime(OnDemandProviderName, "CreateImage", time.Now())
+	auth, err := cloudManager.accounts.credentials(&host.Distro)
+	if err != nil {
+		return "", err
+	}
+	region, err := instanceRegion(getUSEast(*auth), host, instanceId(host))
+	if err != nil {
+		return "", fmt.Errorf("Failed to create image of host %v: %v", host.Id, err)
+	}
+	imageId, err := createImage(getSDK(*auth, region), instanceId(host), name)
+	if err != nil {
+		return "", fmt.Errorf("Failed to create image of host %v: %v", host.Id, err)
+	}
+	return imageId, nil
+}
+
+// GetLaunchTime returns when the host's EC2 instance was launched.
+func (cloudManager *EC2Manager) GetLaunchTime(host *host.Host) (time.Time, error) {
+	defer cloud.RecordCallTime(OnDemandProviderName, "GetLaunchTime", time.Now())
+	ec2Handle, err := cloudManager.accounts.hostHandle(host)
+	if err != nil {
+		return time.Time{}, err
+	}
+	launchTime, err := getLaunchTime(ec2Handle, instanceId(host))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Failed to get launch time of host %v: %v", host.Id, err)
+	}
+	return launchTime, nil
+}
+
+// ValidateTags returns an error if users can't set the tags on instances.
+func (cloudManager *EC2Manager) ValidateTags(tags map[string]string) error {
+	return validateUserTags(tags)
+}
+
+// SetTags adds the tags to the host's instance.
+func (cloudManager *EC2Manager) SetTags(h *host.Host, tags map[string]string) error {
+	if err := validateUserTags(tags); err != nil {
+		return err
+	}
+	ec2Handle, err := cloudManager.accounts.handle(&h.Distro)
+	if err != nil {
+		return err
+	}
+	if err = attachTags(ec2Handle, tags, instanceId(h)); err != nil {
+		return fmt.Errorf("Failed to tag host %v: %v", h.Id, err)
+	}
+	return nil
+}
+
+// AssociateStaticAddress pins an Elastic IP to the host's EC2 instance.
+func (cloudManager *EC2Manager) AssociateStaticAddress(h *host.Host) (string, error) {
+	defer cloud.RecordCallTime(OnDemandProviderName, "AssociateStaticAddress", time.Now())
+	ec2Handle, err := cloudManager.accounts.handle(&h.Distro)
+	if err != nil {
+		return "", err
+	}
+	return associateStaticAddress(ec2Handle, h, instanceId(h))
+}
+
+// ReleaseStaticAddress releases the Elastic IP of a host whose EC2 instance was
+// terminated.
+func (cloudManager *EC2Manager) ReleaseStaticAddress(h *host.Host) error {
+	defer cloud.RecordCallTime(OnDemandProviderName, "ReleaseStaticAddress", time.Now())
+	ec2Handle, err := cloudManager.accounts.handle(&h.Distro)
+	if err != nil {
+		return err
+	}
+	return releaseHostStaticAddress(ec2Handle, h)
+}
+
 // determine how long until a payment is due for the host
 func (cloudManager *EC2Manager) TimeTilNextPayment(host *host.Host) time.Duration {
-	return timeTilNextEC2Payment(host)
+	return timeTilNextEC2Payment(host.BillingStart())
 }
 
 func startEC2Instance(ec2Handle *ec2.EC2, options *ec2.RunInstancesOptions,
@@ -282,36 +620,8 @@ func startEC2Instance(ec2Handle *ec2.EC2, options *ec2.RunInstancesOptions,
 	grip.Debugln("Started", instance.InstanceId)
 	grip.Debugln("Key name:", options.KeyName)
 
-	// find old intent host
-	host, err := host.FindOne(host.ById(intentHost.Id))
-	if host == nil {
-		err = fmt.Errorf("Can't locate record inserted for intended host '%s'",
-			intentHost.Id)
-		grip.Error(err)
-		return nil, nil, err
-	}
+	host, err := recordInstance(intentHost, instance.InstanceId, instance.AvailabilityZone)
 	if err != nil {
-		err = fmt.Errorf("Can't locate record inserted for intended host '%v' "+
-			"due to error: %+v", intentHost.Id, err)
-		grip.Error(err)
-		return nil, nil, err
-	}
-
-	// we found the old document now we can insert the new one
-	host.Id = instance.InstanceId
-	err = host.Insert()
-	if err != nil {
-		err = fmt.Errorf("Could not insert updated host information for '%v' with '%v': %+v",
-			intentHost.Id, host.Id, err)
-		grip.Error(err)
-		return nil, nil, err
-	}
-
-	// remove the intent host document
-	err = intentHost.Remove()
-	if err != nil {
-		err = fmt.Errorf("Could not remove insert host '%v' (replaced by '%v'): %+v",
-			intentHost.Id, host.Id, err)
 		grip.Error(err)
 		return nil, nil, err
 	}
@@ -350,15 +660,49 @@ func startEC2Instance(ec2Handle *ec2.EC2, options *ec2.RunInstancesOptions,
 	return host, resp, nil
 }
 
+// recordInstance replaces the intent host's record with one for the instance
+// started for it in the given availability zone, and returns the new record.
+func recordInstance(intentHost *host.Host, instanceId, zone string) (*host.Host, error) {
+	// find old intent host
+	h, err := host.FindOne(host.ById(intentHost.Id))
+	if err != nil {
+		return nil, fmt.Errorf("Can't locate record inserted for intended host '%v' "+
+			"due to error: %+v", intentHost.Id, err)
+	}
+	if h == nil {
+		return nil, fmt.Errorf("Can't locate record inserted for intended host '%s'",
+			intentHost.Id)
+	}
+
+	// we found the old document now we can insert the new one
+	h.Id = instanceId
+	h.InstanceId = instanceId
+	h.Zone = zone
+	if err = h.Insert(); err != nil {
+		return nil, fmt.Errorf("Could not insert updated host information for '%v' with '%v': %+v",
+			intentHost.Id, h.Id, err)
+	}
+
+	// remove the intent host document
+	if err = intentHost.Remove(); err != nil {
+		return nil, fmt.Errorf("Could not remove insert host '%v' (replaced by '%v'): %+v",
+			intentHost.Id, h.Id, err)
+	}
+	return h, nil
+}
+
 // CostForDuration returns the cost of running a host between the given start and end times
 func (cloudManager *EC2Manager) CostForDuration(h *host.Host, start, end time.Time) (float64, error) {
 	// sanity check
 	if end.Before(start) || util.IsZeroTime(start) || util.IsZeroTime(end) {
 		return 0, fmt.Errorf("task timing data is malformed")
 	}
-	// grab instance details from EC2
-	ec2Handle := getUSEast(*cloudManager.awsCredentials)
-	instance, err := getInstanceInfo(ec2Handle, h.Id)
+	// grab instance details from EC2, in the host's account and region
+	ec2Handle, err := cloudManager.accounts.hostHandle(h)
+	if err != nil {
+		return 0, err
+	}
+	instance, err := getInstanceInfo(ec2Handle, instanceId(h))
 	if err != nil {
 		return 0, err
 	}
@@ -367,14 +711,14 @@ func (cloudManager *EC2Manager) CostForDuration(h *host.Host, start, end time.Ti
 		os = osWindows
 	}
 	dur := end.Sub(start)
-	region := azToRegion(instance.AvailabilityZone)
+	region := hostRegion(h, instance)
 	iType := instance.InstanceType
 
 	ebsCost, err := blockDeviceCosts(ec2Handle, instance.BlockDevices, dur)
 	if err != nil {
 		return 0, fmt.Errorf("calculating block device costs: %v", err)
 	}
-	hostCost, err := onDemandCost(&pkgOnDemandPriceFetcher, os, iType, region, dur)
+	hostCost, err := onDemandCost(&pkgOnDemandPriceFetcher, os, iType, region, h.Tenancy, dur)
 	if err != nil {
 		return 0, err
 	}
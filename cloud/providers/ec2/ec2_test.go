@@ -1,11 +1,27 @@
 package ec2
 
 import (
+	"encoding/base64"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	ec2sdk "github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/cloud"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/host"
 	"github.com/evergreen-ci/evergreen/testutil"
+	"github.com/goamz/goamz/aws"
+	"github.com/goamz/goamz/ec2"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -82,7 +98,7 @@ func TestSpotPriceHistory(t *testing.T) {
 		m := &EC2SpotManager{}
 		m.Configure(testConfig)
 		Convey("loading 2 hours of price history should succeed", func() {
-			ps, err := m.describeHourlySpotPriceHistory("m3.large", "us-east-1a", osLinux,
+			ps, err := m.describeHourlySpotPriceHistory(m.accounts.defaultAuth, "m3.large", "us-east-1a", osLinux,
 				time.Now().Add(-2*time.Hour), time.Now())
 			So(err, ShouldBeNil)
 			So(len(ps), ShouldBeGreaterThan, 2)
@@ -94,7 +110,7 @@ func TestSpotPriceHistory(t *testing.T) {
 			})
 		})
 		Convey("loading 10 days of price history should succeed", func() {
-			ps, err := m.describeHourlySpotPriceHistory("m3.large", "us-east-1a", osLinux,
+			ps, err := m.describeHourlySpotPriceHistory(m.accounts.defaultAuth, "m3.large", "us-east-1a", osLinux,
 				time.Now().Add(-240*time.Hour), time.Now())
 			So(err, ShouldBeNil)
 			So(len(ps), ShouldBeGreaterThan, 240)
@@ -222,12 +238,26 @@ func TestOnDemandPriceAPITranslation(t *testing.T) {
 			r, err = regionFullname("us-west-2")
 			So(err, ShouldBeNil)
 			So(r, ShouldEqual, "US West (Oregon)")
+			r, err = regionFullname("eu-west-1")
+			So(err, ShouldBeNil)
+			So(r, ShouldEqual, "EU (Ireland)")
+			r, err = regionFullname("ap-southeast-2")
+			So(err, ShouldBeNil)
+			So(r, ShouldEqual, "Asia Pacific (Sydney)")
 			Convey("but an unknown region will return an error", func() {
 				r, err = regionFullname("amazing")
 				So(err, ShouldNotBeNil)
 			})
 		})
 	})
+
+	Convey("With a set of availability zones", t, func() {
+		Convey("the region should be the zone without its final letter", func() {
+			So(azToRegion("us-east-1a"), ShouldEqual, "us-east-1")
+			So(azToRegion("eu-central-1b"), ShouldEqual, "eu-central-1")
+			So(azToRegion(""), ShouldEqual, "")
+		})
+	})
 }
 
 type mockODPriceFetcher struct {
@@ -235,7 +265,7 @@ type mockODPriceFetcher struct {
 	err   error
 }
 
-func (mpf *mockODPriceFetcher) FetchPrice(_ osType, _, _ string) (float64, error) {
+func (mpf *mockODPriceFetcher) FetchPrice(_ osType, _, _, _ string) (float64, error) {
 	if mpf.err != nil {
 		return 0, mpf.err
 	}
@@ -246,17 +276,17 @@ func TestOnDemandPriceCalculation(t *testing.T) {
 	Convey("With prices of $1.00/hr", t, func() {
 		pf := &mockODPriceFetcher{1.0, nil}
 		Convey("a half-hour task should cost 50¢", func() {
-			cost, err := onDemandCost(pf, osLinux, "m3.4xlarge", "us-east-1", time.Minute*30)
+			cost, err := onDemandCost(pf, osLinux, "m3.4xlarge", "us-east-1", "", time.Minute*30)
 			So(err, ShouldBeNil)
 			So(cost, ShouldEqual, .50)
 		})
 		Convey("an hour task should cost $1", func() {
-			cost, err := onDemandCost(pf, osLinux, "m3.4xlarge", "us-east-1", time.Hour)
+			cost, err := onDemandCost(pf, osLinux, "m3.4xlarge", "us-east-1", "", time.Hour)
 			So(err, ShouldBeNil)
 			So(cost, ShouldEqual, 1)
 		})
 		Convey("a two-hour task should cost $2", func() {
-			cost, err := onDemandCost(pf, osLinux, "m3.4xlarge", "us-east-1", time.Hour*2)
+			cost, err := onDemandCost(pf, osLinux, "m3.4xlarge", "us-east-1", "", time.Hour*2)
 			So(err, ShouldBeNil)
 			So(cost, ShouldEqual, 2)
 		})
@@ -264,7 +294,7 @@ func TestOnDemandPriceCalculation(t *testing.T) {
 	Convey("With prices of $0.00/hr", t, func() {
 		pf := &mockODPriceFetcher{0, nil}
 		Convey("onDemandPrice should return a 'not found' error", func() {
-			cost, err := onDemandCost(pf, osLinux, "m3.4xlarge", "us-east-1", time.Hour)
+			cost, err := onDemandCost(pf, osLinux, "m3.4xlarge", "us-east-1", "", time.Hour)
 			So(err, ShouldNotBeNil)
 			So(err.Error(), ShouldContainSubstring, "not found")
 			So(cost, ShouldEqual, 0)
@@ -273,7 +303,7 @@ func TestOnDemandPriceCalculation(t *testing.T) {
 	Convey("With an erroring PriceFetcher", t, func() {
 		pf := &mockODPriceFetcher{1, fmt.Errorf("bad thing")}
 		Convey("errors should be bubbled up", func() {
-			cost, err := onDemandCost(pf, osLinux, "m3.4xlarge", "us-east-1", time.Hour*2)
+			cost, err := onDemandCost(pf, osLinux, "m3.4xlarge", "us-east-1", "", time.Hour*2)
 			So(err, ShouldNotBeNil)
 			So(err.Error(), ShouldContainSubstring, "bad thing")
 			So(cost, ShouldEqual, 0)
@@ -287,14 +317,14 @@ func TestFetchOnDemandPricing(t *testing.T) {
 		pf := cachedOnDemandPriceFetcher{}
 		So(pf.prices, ShouldBeNil)
 		Convey("various prices in us-east-1 should be sane", func() {
-			c34x, err := pf.FetchPrice(osLinux, "c3.4xlarge", "us-east-1")
+			c34x, err := pf.FetchPrice(osLinux, "c3.4xlarge", "us-east-1", "")
 			So(err, ShouldBeNil)
 			So(c34x, ShouldBeGreaterThan, .80)
-			c3x, err := pf.FetchPrice(osLinux, "c3.xlarge", "us-east-1")
+			c3x, err := pf.FetchPrice(osLinux, "c3.xlarge", "us-east-1", "")
 			So(err, ShouldBeNil)
 			So(c3x, ShouldBeGreaterThan, .20)
 			So(c34x, ShouldBeGreaterThan, c3x)
-			wc3x, err := pf.FetchPrice(osWindows, "c3.xlarge", "us-east-1")
+			wc3x, err := pf.FetchPrice(osWindows, "c3.xlarge", "us-east-1", "")
 			So(err, ShouldBeNil)
 			So(wc3x, ShouldBeGreaterThan, .20)
 			So(wc3x, ShouldBeGreaterThan, c3x)
@@ -335,3 +365,707 @@ func TestCostForDuration(t *testing.T) {
 	}
 	fmt.Println("PRICE AGAIN", cost)
 }*/
+
+func TestInstanceId(t *testing.T) {
+	Convey("With an EC2 manager", t, func() {
+		m := &EC2Manager{}
+
+		Convey("hosts with a recorded instance id should use it", func() {
+			id, err := m.GetInstanceID(&host.Host{Id: "evg-ubuntu-1", InstanceId: "i-12345"})
+			So(err, ShouldBeNil)
+			So(id, ShouldEqual, "i-12345")
+		})
+
+		Convey("hosts without a recorded instance id should fall back to their id", func() {
+			id, err := m.GetInstanceID(&host.Host{Id: "i-12345"})
+			So(err, ShouldBeNil)
+			So(id, ShouldEqual, "i-12345")
+		})
+	})
+}
+
+func TestSecurityGroups(t *testing.T) {
+	Convey("With EC2 provider settings", t, func() {
+		settings := &EC2ProviderSettings{
+			AMI:                   "ami-12345",
+			InstanceType:          "m3.large",
+			KeyName:               "mci",
+			defaultSecurityGroups: []string{"default"},
+		}
+
+		Convey("distros without security groups should use the defaults", func() {
+			So(settings.getSecurityGroups(), ShouldResemble, []string{"default"})
+			So(settings.Validate(), ShouldBeNil)
+		})
+
+		Convey("distros with security groups should use their own", func() {
+			settings.SecurityGroup = "builds"
+			settings.SecurityGroups = []string{"builds", "inbound"}
+			So(settings.getSecurityGroups(), ShouldResemble, []string{"builds", "inbound"})
+			So(settings.Validate(), ShouldBeNil)
+		})
+
+		Convey("distros without security groups or defaults should be invalid", func() {
+			settings.defaultSecurityGroups = nil
+			So(settings.Validate(), ShouldNotBeNil)
+		})
+
+		Convey("VPC distros should require security group ids", func() {
+			settings.IsVpc = true
+			settings.SecurityGroups = []string{"sg-12345", "inbound"}
+			So(settings.Validate(), ShouldNotBeNil)
+			settings.SecurityGroups = []string{"sg-12345"}
+			So(settings.Validate(), ShouldBeNil)
+		})
+	})
+}
+
+func TestTenancy(t *testing.T) {
+	Convey("With EC2 provider settings", t, func() {
+		settings := &EC2ProviderSettings{
+			AMI:           "ami-12345",
+			InstanceType:  "m3.large",
+			KeyName:       "mci",
+			SecurityGroup: "default",
+		}
+
+		Convey("supported tenancies should be valid", func() {
+			for _, tenancy := range []string{"", "default", "dedicated", "host"} {
+				settings.Tenancy = tenancy
+				So(settings.Validate(), ShouldBeNil)
+			}
+		})
+
+		Convey("unsupported tenancies should be invalid", func() {
+			settings.Tenancy = "shared"
+			So(settings.Validate(), ShouldNotBeNil)
+		})
+	})
+
+	Convey("Tenancies should map to their names in the EC2 price listings", t, func() {
+		So(tenancyBillingName(""), ShouldEqual, "Shared")
+		So(tenancyBillingName("default"), ShouldEqual, "Shared")
+		So(tenancyBillingName("dedicated"), ShouldEqual, "Dedicated")
+		So(tenancyBillingName("host"), ShouldEqual, "Host")
+	})
+}
+
+func TestShutdownBehavior(t *testing.T) {
+	Convey("With an EC2 manager and a distro", t, func() {
+		accounts, err := loadAWSAccounts(&evergreen.AWSConfig{Id: "id", Secret: "secret"})
+		So(err, ShouldBeNil)
+		m := &EC2Manager{accounts: accounts}
+		d := &distro.Distro{Id: "ubuntu"}
+		settings := &EC2ProviderSettings{
+			AMI:           "ami-12345",
+			InstanceType:  "m3.large",
+			KeyName:       "mci",
+			SecurityGroup: "default",
+		}
+
+		Convey("instances should be started with the host options' shutdown behavior", func() {
+			_, options, err := m.onDemandRunOptions(d, settings, cloud.HostOptions{ShutdownBehavior: "stop"})
+			So(err, ShouldBeNil)
+			So(options.ShutdownBehavior, ShouldEqual, "stop")
+			So(newOnDemandIntent(d, settings, cloud.HostOptions{}, options).ShutdownBehavior, ShouldEqual, "stop")
+		})
+
+		Convey("a blank shutdown behavior should be left to EC2", func() {
+			_, options, err := m.onDemandRunOptions(d, settings, cloud.HostOptions{})
+			So(err, ShouldBeNil)
+			So(options.ShutdownBehavior, ShouldEqual, "")
+		})
+	})
+}
+
+func TestRootVolumeSize(t *testing.T) {
+	Convey("With EC2 provider settings and a root volume size", t, func() {
+		settings := &EC2ProviderSettings{
+			AMI:            "ami-12345",
+			InstanceType:   "m3.large",
+			KeyName:        "mci",
+			SecurityGroup:  "default",
+			RootVolumeSize: 50,
+		}
+
+		Convey("sizes in the allowed range should be valid", func() {
+			So(settings.Validate(), ShouldBeNil)
+			settings.RootVolumeSize = cloud.MaxRootVolumeSize + 1
+			So(settings.Validate(), ShouldNotBeNil)
+		})
+
+		Convey("a size in the host options should override the distro's", func() {
+			So(getRootVolumeSize(settings.RootVolumeSize, cloud.HostOptions{}), ShouldEqual, 50)
+			So(getRootVolumeSize(settings.RootVolumeSize, cloud.HostOptions{RootVolumeSize: 200}), ShouldEqual, 200)
+			So(getRootVolumeSize(0, cloud.HostOptions{}), ShouldEqual, 0)
+		})
+	})
+}
+
+func TestMakeRootDeviceMapping(t *testing.T) {
+	Convey("With an image whose root volume is created from a 50 GB snapshot", t, func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `<DescribeImagesResponse><imagesSet><item><imageId>ami-12345</imageId>`+
+				`<rootDeviceType>ebs</rootDeviceType><rootDeviceName>/dev/sda1</rootDeviceName>`+
+				`<blockDeviceMapping><item><deviceName>/dev/sda1</deviceName>`+
+				`<ebs><snapshotId>snap-1</snapshotId><volumeSize>50</volumeSize></ebs></item></blockDeviceMapping>`+
+				`</item></imagesSet></DescribeImagesResponse>`)
+		}))
+		defer server.Close()
+		ec2Handle := ec2.NewWithClient(aws.Auth{AccessKey: "key", SecretKey: "secret"},
+			aws.Region{Name: "test", EC2Endpoint: server.URL}, http.DefaultClient)
+
+		Convey("a larger root volume should resize the image's root device", func() {
+			mapping, err := makeRootDeviceMapping(ec2Handle, "ami-12345", 100, nil)
+			So(err, ShouldBeNil)
+			So(mapping.DeviceName, ShouldEqual, "/dev/sda1")
+			So(mapping.VolumeSize, ShouldEqual, 100)
+			So(mapping.DeleteOnTermination, ShouldBeTrue)
+		})
+
+		Convey("a root volume smaller than the snapshot should be rejected", func() {
+			_, err := makeRootDeviceMapping(ec2Handle, "ami-12345", 20, nil)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "50 GB snapshot")
+		})
+
+		Convey("a mount point on the root device should be rejected", func() {
+			_, err := makeRootDeviceMapping(ec2Handle, "ami-12345", 100,
+				[]ec2.BlockDeviceMapping{{DeviceName: "/dev/sda1"}})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestAWSAccounts(t *testing.T) {
+	Convey("With a default AWS account and a named one", t, func() {
+		config := &evergreen.AWSConfig{
+			Id:     "default-id",
+			Secret: "default-secret",
+			Accounts: []evergreen.AWSAccount{
+				{Name: "staging", Id: "staging-id", Secret: "staging-secret"},
+			},
+		}
+		accounts, err := loadAWSAccounts(config)
+		So(err, ShouldBeNil)
+		So(len(accounts.all()), ShouldEqual, 2)
+		So(accounts.names(), ShouldResemble, []string{"", "staging"})
+
+		Convey("distros without an account should use the default one", func() {
+			auth, err := accounts.credentials(&distro.Distro{Id: "d"})
+			So(err, ShouldBeNil)
+			So(auth.AccessKey, ShouldEqual, "default-id")
+		})
+
+		Convey("distros with an account should use its credentials", func() {
+			d := &distro.Distro{Id: "d", ProviderSettings: &map[string]interface{}{"account": "staging"}}
+			auth, err := accounts.credentials(d)
+			So(err, ShouldBeNil)
+			So(auth.AccessKey, ShouldEqual, "staging-id")
+			So(auth.SecretKey, ShouldEqual, "staging-secret")
+		})
+
+		Convey("distros with an unknown account should fail", func() {
+			d := &distro.Distro{Id: "d", ProviderSettings: &map[string]interface{}{"account": "prod"}}
+			_, err := accounts.credentials(d)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("provider settings should only accept configured accounts", func() {
+			settings := &EC2ProviderSettings{
+				AMI:           "ami-12345",
+				InstanceType:  "m3.large",
+				KeyName:       "mci",
+				SecurityGroup: "default",
+				accounts:      accounts,
+			}
+			So(settings.Validate(), ShouldBeNil)
+			settings.Account = "staging"
+			So(settings.Validate(), ShouldBeNil)
+			settings.Account = "prod"
+			So(settings.Validate(), ShouldNotBeNil)
+		})
+	})
+
+	Convey("Accounts without credentials should fail to load", t, func() {
+		_, err := loadAWSAccounts(&evergreen.AWSConfig{})
+		So(err, ShouldNotBeNil)
+		_, err = loadAWSAccounts(&evergreen.AWSConfig{
+			Id:       "default-id",
+			Secret:   "default-secret",
+			Accounts: []evergreen.AWSAccount{{Name: "staging"}},
+		})
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestHostHandle(t *testing.T) {
+	Convey("With a default AWS account and a named one", t, func() {
+		accounts, err := loadAWSAccounts(&evergreen.AWSConfig{
+			Id:       "default-id",
+			Secret:   "default-secret",
+			Accounts: []evergreen.AWSAccount{{Name: "staging", Id: "staging-id", Secret: "staging-secret"}},
+		})
+		So(err, ShouldBeNil)
+		staging := distro.Distro{Id: "d", ProviderSettings: &map[string]interface{}{"account": "staging"}}
+
+		Convey("hosts should be looked up in their own account and region", func() {
+			ec2Handle, err := accounts.hostHandle(&host.Host{Id: "h1", Zone: "us-west-2b", Distro: staging})
+			So(err, ShouldBeNil)
+			So(ec2Handle.Region.Name, ShouldEqual, "us-west-2")
+			So(ec2Handle.Auth.AccessKey, ShouldEqual, "staging-id")
+		})
+
+		Convey("hosts without a recorded zone should be looked up at US east", func() {
+			ec2Handle, err := accounts.hostHandle(&host.Host{Id: "h1", Distro: distro.Distro{Id: "d"}})
+			So(err, ShouldBeNil)
+			So(ec2Handle.Region.Name, ShouldEqual, aws.USEast.Name)
+			So(ec2Handle.Auth.AccessKey, ShouldEqual, "default-id")
+		})
+
+		Convey("hosts in an unknown region should fail", func() {
+			_, err := accounts.hostHandle(&host.Host{Id: "h1", Zone: "mars-north-1a", Distro: staging})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("a host's instance should be described by its region's endpoint", func() {
+			fake := &fakeSpotEC2{zone: "zz-test-1a"}
+			server := httptest.NewServer(fake)
+			defer server.Close()
+			aws.Regions["zz-test-1"] = aws.Region{Name: "zz-test-1", EC2Endpoint: server.URL}
+			defer delete(aws.Regions, "zz-test-1")
+
+			ec2Handle, err := accounts.hostHandle(&host.Host{Id: "h1", Zone: "zz-test-1a", Distro: staging})
+			So(err, ShouldBeNil)
+			instance, err := getInstanceInfo(ec2Handle, "i-1")
+			So(err, ShouldBeNil)
+			So(instance.InstanceId, ShouldEqual, "i-1")
+			So(fake.actions, ShouldResemble, []string{"DescribeInstances"})
+		})
+	})
+}
+
+func TestGetLaunchTime(t *testing.T) {
+	Convey("With a host in a region other than US east", t, func() {
+		fake := &fakeSpotEC2{zone: "zz-test-1a"}
+		server := httptest.NewServer(fake)
+		defer server.Close()
+		aws.Regions["zz-test-1"] = aws.Region{Name: "zz-test-1", EC2Endpoint: server.URL}
+		defer delete(aws.Regions, "zz-test-1")
+
+		accounts, err := loadAWSAccounts(&evergreen.AWSConfig{Id: "default-id", Secret: "default-secret"})
+		So(err, ShouldBeNil)
+		cloudManager := &EC2Manager{accounts: accounts}
+		h := &host.Host{Id: "h1", InstanceId: "i-1", Zone: "zz-test-1a", Distro: distro.Distro{Id: "d"}}
+
+		Convey("its launch time should be looked up in its region", func() {
+			launchTime, err := cloudManager.GetLaunchTime(h)
+			So(err, ShouldBeNil)
+			expected, err := time.Parse(time.RFC3339, fakeLaunchTime)
+			So(err, ShouldBeNil)
+			So(launchTime, ShouldResemble, expected)
+			So(fake.actions, ShouldResemble, []string{"DescribeInstances"})
+		})
+	})
+}
+
+func TestCountStandardVCPUs(t *testing.T) {
+	Convey("With running on-demand and spot instances", t, func() {
+		instances := []ec2.Instance{
+			{InstanceType: "m5.2xlarge"},
+			{InstanceType: "t3.micro"},
+			{InstanceType: "m3.medium"},
+			{InstanceType: "p3.8xlarge"},
+			{InstanceType: "inf1.xlarge"},
+			{InstanceType: "m5.metal"},
+			{InstanceType: "c5.large", InstanceLifecycle: "spot"},
+			{InstanceType: "r4.xlarge", InstanceLifecycle: "spot"},
+		}
+
+		Convey("only the vCPUs of standard on-demand instances of known sizes should count"+
+			" towards the on-demand quota", func() {
+			So(countStandardVCPUs(instances, false), ShouldEqual, 8+2+1)
+		})
+
+		Convey("only the vCPUs of standard spot instances should count towards the spot quota", func() {
+			So(countStandardVCPUs(instances, true), ShouldEqual, 2+4)
+		})
+	})
+}
+
+func TestServiceQuotas(t *testing.T) {
+	Convey("With a Service Quotas endpoint", t, func() {
+		var target, body string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			target = r.Header.Get("X-Amz-Target")
+			b, _ := ioutil.ReadAll(r.Body)
+			body = string(b)
+			if strings.Contains(body, "L-missing") {
+				w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"__type": "NoSuchResourceException", "message": "quota not found"}`)
+				return
+			}
+			fmt.Fprint(w, `{"Quota": {"QuotaCode": "L-1216C47A", "Value": 1152.0}}`)
+		}))
+		defer server.Close()
+		quotas := newServiceQuotasWithConfig(&awssdk.Config{
+			Region:      awssdk.String("us-east-1"),
+			Endpoint:    awssdk.String(server.URL),
+			Credentials: credentials.NewStaticCredentials("key", "secret", ""),
+		})
+
+		Convey("a quota's value should be looked up by service and quota code", func() {
+			value, err := quotas.getQuotaValue(ec2ServiceCode, onDemandVCPUQuotaCode)
+			So(err, ShouldBeNil)
+			So(value, ShouldEqual, 1152)
+			So(target, ShouldEqual, "ServiceQuotasV20190624.GetServiceQuota")
+			So(body, ShouldContainSubstring, `"ServiceCode":"ec2"`)
+			So(body, ShouldContainSubstring, `"QuotaCode":"L-1216C47A"`)
+		})
+
+		Convey("errors looking up a quota should be returned", func() {
+			_, err := quotas.getQuotaValue(ec2ServiceCode, "L-missing")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "NoSuchResourceException")
+		})
+	})
+}
+
+func TestInstanceRegion(t *testing.T) {
+	Convey("With an EC2 instance in us-west-2", t, func() {
+		fake := &fakeSpotEC2{zone: "us-west-2a"}
+		server := httptest.NewServer(fake)
+		defer server.Close()
+		ec2Handle := ec2.NewWithClient(aws.Auth{AccessKey: "key", SecretKey: "secret"},
+			aws.Region{Name: "test", EC2Endpoint: server.URL}, http.DefaultClient)
+
+		Convey("the region of the zone recorded on the host should be used", func() {
+			region, err := instanceRegion(ec2Handle, &host.Host{Id: "h1", Zone: "eu-west-1b"}, "i-1")
+			So(err, ShouldBeNil)
+			So(region, ShouldEqual, "eu-west-1")
+			So(fake.actions, ShouldBeEmpty)
+		})
+
+		Convey("the instance's zone should be used for hosts without one recorded", func() {
+			h := &host.Host{Id: "h1"}
+			region, err := instanceRegion(ec2Handle, h, "i-1")
+			So(err, ShouldBeNil)
+			So(region, ShouldEqual, "us-west-2")
+			So(h.Zone, ShouldEqual, "us-west-2a")
+			So(fake.actions, ShouldResemble, []string{"DescribeInstances"})
+		})
+	})
+
+	Convey("SDK clients should be for the given region, or US east by default", t, func() {
+		auth := aws.Auth{AccessKey: "key", SecretKey: "secret"}
+		So(*getSDK(auth, "us-west-2").Config.Region, ShouldEqual, "us-west-2")
+		So(*getSDK(auth, "").Config.Region, ShouldEqual, aws.USEast.Name)
+	})
+}
+
+func TestGetConsoleOutput(t *testing.T) {
+	Convey("With an EC2 endpoint", t, func() {
+		var instanceId string
+		output := base64.StdEncoding.EncodeToString([]byte("Kernel panic - not syncing\n"))
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			instanceId = r.FormValue("InstanceId")
+			switch instanceId {
+			case "i-missing":
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `<Response><Errors><Error><Code>InvalidInstanceID.NotFound</Code>`+
+					`<Message>The instance ID 'i-missing' does not exist</Message></Error></Errors></Response>`)
+			case "i-booting":
+				fmt.Fprint(w, `<GetConsoleOutputResponse><instanceId>i-booting</instanceId></GetConsoleOutputResponse>`)
+			default:
+				fmt.Fprintf(w, `<GetConsoleOutputResponse><instanceId>%v</instanceId><output>%v</output>`+
+					`</GetConsoleOutputResponse>`, instanceId, output)
+			}
+		}))
+		defer server.Close()
+		svc := ec2sdk.New(session.New(), &awssdk.Config{
+			Region:      awssdk.String("us-west-2"),
+			Endpoint:    awssdk.String(server.URL),
+			Credentials: credentials.NewStaticCredentials("key", "secret", ""),
+		})
+
+		Convey("the instance's output should be decoded", func() {
+			out, err := getConsoleOutput(svc, "i-1")
+			So(err, ShouldBeNil)
+			So(out, ShouldEqual, "Kernel panic - not syncing\n")
+			So(instanceId, ShouldEqual, "i-1")
+		})
+
+		Convey("instances without output yet should have empty output", func() {
+			out, err := getConsoleOutput(svc, "i-booting")
+			So(err, ShouldBeNil)
+			So(out, ShouldEqual, "")
+		})
+
+		Convey("errors from EC2 should be returned", func() {
+			_, err := getConsoleOutput(svc, "i-missing")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "InvalidInstanceID.NotFound")
+		})
+	})
+}
+
+func TestCreateImage(t *testing.T) {
+	Convey("With an EC2 endpoint", t, func() {
+		var form url.Values
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.ParseForm()
+			form = r.PostForm
+			switch r.FormValue("InstanceId") {
+			case "i-missing":
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `<Response><Errors><Error><Code>InvalidInstanceID.NotFound</Code>`+
+					`<Message>The instance ID 'i-missing' does not exist</Message></Error></Errors></Response>`)
+			case "i-noimage":
+				fmt.Fprint(w, `<CreateImageResponse></CreateImageResponse>`)
+			default:
+				fmt.Fprint(w, `<CreateImageResponse><imageId>ami-1234</imageId></CreateImageResponse>`)
+			}
+		}))
+		defer server.Close()
+		svc := ec2sdk.New(session.New(), &awssdk.Config{
+			Region:      awssdk.String("us-west-2"),
+			Endpoint:    awssdk.String(server.URL),
+			Credentials: credentials.NewStaticCredentials("key", "secret", ""),
+		})
+
+		Convey("an image of the instance should be created with the name", func() {
+			imageId, err := createImage(svc, "i-1", "my-image")
+			So(err, ShouldBeNil)
+			So(imageId, ShouldEqual, "ami-1234")
+			So(form.Get("Action"), ShouldEqual, "CreateImage")
+			So(form.Get("InstanceId"), ShouldEqual, "i-1")
+			So(form.Get("Name"), ShouldEqual, "my-image")
+		})
+
+		Convey("a response without an image id should be an error", func() {
+			_, err := createImage(svc, "i-noimage", "my-image")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("errors from EC2 should be returned", func() {
+			_, err := createImage(svc, "i-missing", "my-image")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "InvalidInstanceID.NotFound")
+		})
+	})
+}
+
+func TestQuotaRegions(t *testing.T) {
+	Convey("With hosts in several accounts and regions", t, func() {
+		staging := distro.Distro{Id: "d", ProviderSettings: &map[string]interface{}{"account": "staging"}}
+		hosts := []host.Host{
+			{Id: "h1", Zone: "us-west-2a"},
+			{Id: "h2", Zone: "us-west-2b"},
+			{Id: "h3", Zone: "eu-west-1a", Distro: staging},
+			{Id: "h4"},
+			{Id: "h5", Zone: "ap-south-1a", Distro: distro.Distro{
+				Id: "d", ProviderSettings: &map[string]interface{}{"account": "removed"}}},
+		}
+
+		Convey("each account's quotas should be reported in US east and its hosts' regions", func() {
+			regions := quotaRegions([]string{"", "staging"}, hosts)
+			So(regions, ShouldResemble, map[string][]string{
+				"":        {"us-east-1", "us-west-2"},
+				"staging": {"eu-west-1", "us-east-1"},
+			})
+		})
+	})
+}
+
+func TestGetVCPUQuota(t *testing.T) {
+	Convey("With Service Quotas and EC2 endpoints for a region", t, func() {
+		var quotaRequest string
+		quotaServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, _ := ioutil.ReadAll(r.Body)
+			quotaRequest = string(b)
+			fmt.Fprint(w, `{"Quota": {"Value": 64.0}}`)
+		}))
+		defer quotaServer.Close()
+		quotas := newServiceQuotasWithConfig(&awssdk.Config{
+			Region:      awssdk.String("us-west-2"),
+			Endpoint:    awssdk.String(quotaServer.URL),
+			Credentials: credentials.NewStaticCredentials("key", "secret", ""),
+		})
+
+		var state string
+		ec2Server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			state = r.FormValue("Filter.1.Name")
+			fmt.Fprint(w, `<DescribeInstancesResponse><reservationSet><item><instancesSet>`+
+				`<item><instanceId>i-1</instanceId><instanceType>m5.xlarge</instanceType></item>`+
+				`<item><instanceId>i-2</instanceId><instanceType>c5.large</instanceType>`+
+				`<instanceLifecycle>spot</instanceLifecycle></item>`+
+				`</instancesSet></item></reservationSet></DescribeInstancesResponse>`)
+		}))
+		defer ec2Server.Close()
+		ec2Handle := ec2.NewWithClient(aws.Auth{AccessKey: "key", SecretKey: "secret"},
+			aws.Region{Name: "us-west-2", EC2Endpoint: ec2Server.URL}, http.DefaultClient)
+
+		Convey("the on-demand quota should count the region's on-demand instances", func() {
+			quota, err := getVCPUQuota(quotas, ec2Handle, false)
+			So(err, ShouldBeNil)
+			So(quota.Resource, ShouldEqual, OnDemandVCPUQuota)
+			So(quota.Limit, ShouldEqual, 64)
+			So(quota.Used, ShouldEqual, 4)
+			So(quotaRequest, ShouldContainSubstring, onDemandVCPUQuotaCode)
+			So(state, ShouldEqual, "instance-state-name")
+		})
+
+		Convey("the spot quota should count the region's spot instances", func() {
+			quota, err := getVCPUQuota(quotas, ec2Handle, true)
+			So(err, ShouldBeNil)
+			So(quota.Resource, ShouldEqual, SpotVCPUQuota)
+			So(quota.Used, ShouldEqual, 2)
+			So(quotaRequest, ShouldContainSubstring, spotVCPUQuotaCode)
+		})
+	})
+}
+
+func TestValidateUserTags(t *testing.T) {
+	Convey("When validating tags users want to set", t, func() {
+		Convey("ordinary tags should be valid", func() {
+			So(validateUserTags(map[string]string{"owner": "jane", "cost-center": ""}), ShouldBeNil)
+		})
+
+		Convey("tags set by Evergreen or AWS should be invalid", func() {
+			So(validateUserTags(map[string]string{"expire-on": "2099-01-01"}), ShouldNotBeNil)
+			So(validateUserTags(map[string]string{cloud.EvergreenOwnedTag: "false"}), ShouldNotBeNil)
+			So(validateUserTags(map[string]string{"aws:cloudformation": "x"}), ShouldNotBeNil)
+		})
+
+		Convey("blank, oversized, or missing tags should be invalid", func() {
+			So(validateUserTags(map[string]string{"": "x"}), ShouldNotBeNil)
+			So(validateUserTags(map[string]string{strings.Repeat("k", maxTagKeyLength+1): "x"}), ShouldNotBeNil)
+			So(validateUserTags(map[string]string{"k": strings.Repeat("v", maxTagValueLength+1)}), ShouldNotBeNil)
+			So(validateUserTags(map[string]string{}), ShouldNotBeNil)
+		})
+	})
+}
+
+func TestSpotFallbackSettings(t *testing.T) {
+	Convey("With EC2 spot settings that fall back to on-demand", t, func() {
+		settings := &EC2SpotSettings{
+			BidPrice:           0.5,
+			AMI:                "ami-12345",
+			InstanceType:       "m3.large",
+			KeyName:            "mci",
+			SecurityGroup:      "sg-1",
+			SubnetId:           "subnet-1",
+			IsVpc:              true,
+			FallbackToOnDemand: true,
+		}
+
+		Convey("the default timeout should be used if none is set", func() {
+			So(settings.Validate(), ShouldBeNil)
+			So(settings.getFallbackTimeout(), ShouldEqual, DefaultSpotFallbackTimeout)
+			settings.FallbackTimeoutSecs = 30
+			So(settings.getFallbackTimeout(), ShouldEqual, 30*time.Second)
+		})
+
+		Convey("a negative timeout should be invalid", func() {
+			settings.FallbackTimeoutSecs = -1
+			So(settings.Validate(), ShouldNotBeNil)
+		})
+
+		Convey("the on-demand settings should match the spot settings", func() {
+			onDemand := settings.onDemandSettings()
+			So(onDemand.Validate(), ShouldBeNil)
+			So(onDemand.AMI, ShouldEqual, settings.AMI)
+			So(onDemand.InstanceType, ShouldEqual, settings.InstanceType)
+			So(onDemand.KeyName, ShouldEqual, settings.KeyName)
+			So(onDemand.getSecurityGroups(), ShouldResemble, settings.getSecurityGroups())
+			So(onDemand.SubnetId, ShouldEqual, settings.SubnetId)
+			So(onDemand.IsVpc, ShouldBeTrue)
+		})
+	})
+}
+
+func TestFallbackHostOptions(t *testing.T) {
+	Convey("With the host of an unfulfilled spot request", t, func() {
+		h := &host.Host{
+			Id:               "sir-1",
+			StartedBy:        "user1",
+			UserHost:         true,
+			UserData:         "#!/bin/bash",
+			RootVolumeSize:   100,
+			RequestKey:       "key",
+			ProvisionOptions: &host.ProvisionOptions{OwnerId: "user1"},
+			ExpirationTime:   time.Now().Add(time.Hour),
+		}
+
+		Convey("its replacement should be started with the same options", func() {
+			hostOpts := fallbackHostOptions(h)
+			So(hostOpts.UserName, ShouldEqual, "user1")
+			So(hostOpts.UserHost, ShouldBeTrue)
+			So(hostOpts.UserData, ShouldEqual, "#!/bin/bash")
+			So(hostOpts.RootVolumeSize, ShouldEqual, 100)
+			So(hostOpts.RequestKey, ShouldEqual, "key")
+			So(hostOpts.ProvisionOptions, ShouldEqual, h.ProvisionOptions)
+			So(*hostOpts.ExpirationDuration, ShouldBeBetween, 59*time.Minute, time.Hour)
+		})
+
+		Convey("hosts that don't expire should have replacements that don't expire", func() {
+			h.ExpirationTime = time.Time{}
+			So(fallbackHostOptions(h).ExpirationDuration, ShouldBeNil)
+		})
+	})
+}
+
+func TestMakeCloudInstance(t *testing.T) {
+	Convey("An EC2 instance description should convert to a cloud instance", t, func() {
+		instance := makeCloudInstance(ec2.Instance{
+			InstanceId:   "i-12345",
+			InstanceType: "m3.large",
+			State:        ec2.InstanceState{Name: EC2StatusRunning},
+			LaunchTime:   "2017-03-01T12:30:00.000Z",
+		})
+		So(instance.Id, ShouldEqual, "i-12345")
+		So(instance.InstanceType, ShouldEqual, "m3.large")
+		So(instance.Status, ShouldEqual, cloud.StatusRunning)
+		So(instance.LaunchTime.Equal(time.Date(2017, 3, 1, 12, 30, 0, 0, time.UTC)), ShouldBeTrue)
+	})
+}
+
+func TestParseStateChangeEvent(t *testing.T) {
+	Convey("When parsing EC2 instance state change events", t, func() {
+		Convey("a state change should give the instance and its new status", func() {
+			id, status, err := parseStateChangeEvent([]byte(`{
+				"detail-type": "EC2 Instance State-change Notification",
+				"source": "aws.ec2",
+				"detail": {"instance-id": "i-12345", "state": "running"}
+			}`))
+			So(err, ShouldBeNil)
+			So(id, ShouldEqual, "i-12345")
+			So(status, ShouldEqual, cloud.StatusRunning)
+		})
+		Convey("other events should be rejected", func() {
+			_, _, err := parseStateChangeEvent([]byte(`{
+				"detail-type": "EC2 Spot Instance Interruption Warning",
+				"detail": {"instance-id": "i-12345"}
+			}`))
+			So(err, ShouldNotBeNil)
+			_, _, err = parseStateChangeEvent([]byte(`{
+				"detail-type": "EC2 Instance State-change Notification",
+				"detail": {"state": "running"}
+			}`))
+			So(err, ShouldNotBeNil)
+			_, _, err = parseStateChangeEvent([]byte(`not json`))
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestIsRetryableEC2Error(t *testing.T) {
+	Convey("Only transient EC2 errors should be retried", t, func() {
+		So(isRetryableEC2Error(&ec2.Error{StatusCode: 503, Code: "RequestLimitExceeded"}), ShouldBeTrue)
+		So(isRetryableEC2Error(&ec2.Error{StatusCode: 400, Code: "RequestLimitExceeded"}), ShouldBeTrue)
+		So(isRetryableEC2Error(&ec2.Error{StatusCode: 500, Code: "SomethingElse"}), ShouldBeTrue)
+		So(isRetryableEC2Error(&ec2.Error{StatusCode: 400, Code: "InvalidInstanceID.NotFound"}), ShouldBeFalse)
+		So(isRetryableEC2Error(fmt.Errorf("not an EC2 error")), ShouldBeFalse)
+	})
+}
@@ -1,6 +1,7 @@
 package ec2
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -16,13 +17,20 @@ import (
 	"sync"
 	"time"
 
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	ec2sdk "github.com/aws/aws-sdk-go/service/ec2"
 	gcec2 "github.com/dynport/gocloud/aws/ec2"
+	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/cloud"
 	"github.com/evergreen-ci/evergreen/db/bsonutil"
 	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/evergreen-ci/evergreen/util"
 	"github.com/goamz/goamz/aws"
 	"github.com/goamz/goamz/ec2"
 	"github.com/mongodb/grip"
+	"gopkg.in/mgo.v2"
 )
 
 const (
@@ -78,25 +86,62 @@ func generateName(distroId string) string {
 // regionFullname takes the API ID of amazon region and returns the
 // full region name. For instance, "us-west-1" becomes "US West (N. California)".
 // This is necessary as the On Demand pricing endpoint uses the full name, unlike
-// the rest of the API. THIS FUNCTION ONLY HANDLES U.S. REGIONS.
+// the rest of the API.
 func regionFullname(region string) (string, error) {
 	switch region {
 	case "us-east-1":
 		return "US East (N. Virginia)", nil
+	case "us-east-2":
+		return "US East (Ohio)", nil
 	case "us-west-1":
 		return "US West (N. California)", nil
 	case "us-west-2":
 		return "US West (Oregon)", nil
+	case "ca-central-1":
+		return "Canada (Central)", nil
+	case "eu-west-1":
+		return "EU (Ireland)", nil
+	case "eu-west-2":
+		return "EU (London)", nil
+	case "eu-central-1":
+		return "EU (Frankfurt)", nil
+	case "ap-northeast-1":
+		return "Asia Pacific (Tokyo)", nil
+	case "ap-northeast-2":
+		return "Asia Pacific (Seoul)", nil
+	case "ap-southeast-1":
+		return "Asia Pacific (Singapore)", nil
+	case "ap-southeast-2":
+		return "Asia Pacific (Sydney)", nil
+	case "ap-south-1":
+		return "Asia Pacific (Mumbai)", nil
+	case "sa-east-1":
+		return "South America (Sao Paulo)", nil
 	}
 	return "", fmt.Errorf("region %v not supported for On Demand cost calculation", region)
 }
 
 // azToRegion takes an availability zone and returns the region id.
 func azToRegion(az string) string {
+	if az == "" {
+		return ""
+	}
 	// an amazon region is just the availability zone minus the final letter
 	return az[:len(az)-1]
 }
 
+// hostRegion returns the region the host's instance runs in, from the zone recorded
+// on the host. Hosts started before zones were recorded have the instance's zone
+// recorded now, so that later lookups don't depend on the instance.
+func hostRegion(h *host.Host, instance *ec2.Instance) string {
+	if h.Zone == "" && instance.AvailabilityZone != "" {
+		if err := h.SetZone(instance.AvailabilityZone); err != nil {
+			grip.Warningf("Error recording the zone of host %s: %+v", h.Id, err)
+		}
+	}
+	return azToRegion(h.Zone)
+}
+
 // returns the format of os name expected by EC2 On Demand billing data,
 // bucking the normal AWS API naming scheme.
 func osBillingName(os osType) string {
@@ -136,8 +181,73 @@ func makeBlockDeviceMappings(mounts []MountPoint) ([]ec2.BlockDeviceMapping, err
 	return mappings, nil
 }
 
+// getRootVolumeSize returns the size, in GB, of the root volume to start a host
+// with: the host options' size if set, otherwise the distro's. Zero means the
+// image's size.
+func getRootVolumeSize(settingsSize int, hostOpts cloud.HostOptions) int {
+	if hostOpts.RootVolumeSize != 0 {
+		return hostOpts.RootVolumeSize
+	}
+	return settingsSize
+}
+
+// makeRootDeviceMapping returns a block device mapping that resizes the root volume
+// of instances of the image to the given size, in GB. The image's root volume must
+// be an EBS volume that isn't also one of the other mappings, and the size can't be
+// smaller than the snapshot the volume is created from.
+func makeRootDeviceMapping(ec2Handle *ec2.EC2, ami string, size int,
+	mappings []ec2.BlockDeviceMapping) (ec2.BlockDeviceMapping, error) {
+	resp, err := ec2Handle.Images([]string{ami}, nil)
+	if err != nil {
+		return ec2.BlockDeviceMapping{}, fmt.Errorf("error describing image %v: %v", ami, err)
+	}
+	if len(resp.Images) != 1 {
+		return ec2.BlockDeviceMapping{}, fmt.Errorf("image %v not found", ami)
+	}
+	image := resp.Images[0]
+	if image.RootDeviceType != "ebs" || image.RootDeviceName == "" {
+		return ec2.BlockDeviceMapping{}, fmt.Errorf("image %v doesn't have an EBS root volume", ami)
+	}
+	for _, mapping := range mappings {
+		if mapping.DeviceName == image.RootDeviceName {
+			return ec2.BlockDeviceMapping{}, fmt.Errorf("mount point %v is the root device of image %v",
+				mapping.DeviceName, ami)
+		}
+	}
+	// the root volume is created from the image's snapshot, so it can't be smaller
+	for _, device := range image.BlockDevices {
+		if device.DeviceName == image.RootDeviceName && int64(size) < device.VolumeSize {
+			return ec2.BlockDeviceMapping{}, fmt.Errorf("root volume size %v GB is smaller than "+
+				"the %v GB snapshot of image %v", size, device.VolumeSize, ami)
+		}
+	}
+	return ec2.BlockDeviceMapping{
+		DeviceName:          image.RootDeviceName,
+		VolumeSize:          int64(size),
+		DeleteOnTermination: true,
+	}, nil
+}
+
 //helper function for getting an EC2 handle at US east
 func getUSEast(creds aws.Auth) *ec2.EC2 {
+	return getEC2(creds, aws.USEast)
+}
+
+// getRegionHandle returns an EC2 handle for the named region, or at US east if no
+// region is given.
+func getRegionHandle(creds aws.Auth, region string) (*ec2.EC2, error) {
+	if region == "" {
+		return getUSEast(creds), nil
+	}
+	r, ok := aws.Regions[region]
+	if !ok {
+		return nil, fmt.Errorf("unknown EC2 region '%v'", region)
+	}
+	return getEC2(creds, r), nil
+}
+
+// getEC2 returns an EC2 handle for the region.
+func getEC2(creds aws.Auth, region aws.Region) *ec2.EC2 {
 	client := &http.Client{
 		// This is the same configuration as the default in
 		// net/http with the disable keep alives option specified.
@@ -152,7 +262,124 @@ func getUSEast(creds aws.Auth) *ec2.EC2 {
 		},
 	}
 
-	return ec2.NewWithClient(creds, aws.USEast, client)
+	return ec2.NewWithClient(creds, region, client)
+}
+
+// getUSEastSDK returns a client for EC2 at US east from the AWS SDK, for calls that
+// goamz doesn't support.
+func getUSEastSDK(creds aws.Auth) *ec2sdk.EC2 {
+	return getSDK(creds, aws.USEast.Name)
+}
+
+// getSDK returns a client for EC2 in the given region from the AWS SDK, or at US
+// east if no region is given.
+func getSDK(creds aws.Auth, region string) *ec2sdk.EC2 {
+	if region == "" {
+		region = aws.USEast.Name
+	}
+	return ec2sdk.New(session.New(), &awssdk.Config{
+		Region: awssdk.String(region),
+		Credentials: credentials.NewCredentials(&credentials.StaticProvider{
+			Value: credentials.Value{
+				AccessKeyID:     creds.AccessKey,
+				SecretAccessKey: creds.SecretKey,
+			},
+		}),
+	})
+}
+
+// instanceRegion returns the region of the host's instance, from the zone recorded
+// on the host, or else from the instance's zone as EC2 describes it.
+func instanceRegion(ec2Handle *ec2.EC2, h *host.Host, instanceId string) (string, error) {
+	if h.Zone != "" {
+		return azToRegion(h.Zone), nil
+	}
+	instance, err := getInstanceInfo(ec2Handle, instanceId)
+	if err != nil {
+		return "", err
+	}
+	return hostRegion(h, instance), nil
+}
+
+// getConsoleOutput returns the most recent console output of an EC2 instance, which
+// EC2 returns base64-encoded. The client must be for the instance's region.
+func getConsoleOutput(svc *ec2sdk.EC2, instanceId string) (string, error) {
+	resp, err := svc.GetConsoleOutput(&ec2sdk.GetConsoleOutputInput{
+		InstanceId: awssdk.String(instanceId),
+	})
+	if err != nil {
+		return "", err
+	}
+	if resp.Output == nil {
+		return "", nil
+	}
+	output, err := base64.StdEncoding.DecodeString(*resp.Output)
+	if err != nil {
+		return "", fmt.Errorf("error decoding console output: %v", err)
+	}
+	return string(output), nil
+}
+
+// createImage starts creating an AMI with the given name from an EC2 instance and
+// returns its id. The AMI is usable once EC2 finishes creating it. The client must
+// be for the instance's region, which is where the AMI is created.
+func createImage(svc *ec2sdk.EC2, instanceId, name string) (string, error) {
+	resp, err := svc.CreateImage(&ec2sdk.CreateImageInput{
+		InstanceId: awssdk.String(instanceId),
+		Name:       awssdk.String(name),
+	})
+	if err != nil {
+		return "", err
+	}
+	if resp.ImageId == nil {
+		return "", fmt.Errorf("EC2 returned no image id")
+	}
+	return *resp.ImageId, nil
+}
+
+// the attempts made to terminate an instance, and the sleep before the first retry;
+// the sleep grows by the same amount before each later retry
+var (
+	terminateMaxAttempts = 5
+	terminateRetrySleep  = 2 * time.Second
+)
+
+// retryableEC2ErrorCodes are the codes of EC2 errors that are transient, so the
+// request that failed with them may succeed if made again.
+var retryableEC2ErrorCodes = []string{
+	"RequestLimitExceeded",
+	"InternalError",
+	"ServiceUnavailable",
+	"Unavailable",
+}
+
+// isRetryableEC2Error returns whether a request to EC2 that failed with err may
+// succeed if made again, such as when it was throttled or EC2 had an internal error.
+func isRetryableEC2Error(err error) bool {
+	switch e := err.(type) {
+	case *ec2.Error:
+		return util.SliceContains(retryableEC2ErrorCodes, e.Code) || e.StatusCode >= 500
+	case net.Error:
+		return e.Temporary() || e.Timeout()
+	}
+	return false
+}
+
+// terminateInstance terminates an EC2 instance, retrying with backoff if it fails
+// with a retryable error. It returns whether the retries were exhausted, in which
+// case the instance can't be expected to be terminated by trying again soon.
+func terminateInstance(ec2Handle *ec2.EC2, instanceId string) (*ec2.TerminateInstancesResp, bool, error) {
+	var resp *ec2.TerminateInstancesResp
+	exhausted, err := util.RetryArithmeticBackoff(func() error {
+		var err error
+		resp, err = ec2Handle.TerminateInstances([]string{instanceId})
+		if err != nil && isRetryableEC2Error(err) {
+			grip.Warningf("Error terminating instance %s, retrying: %v", instanceId, err)
+			return util.RetriableError{Failure: err}
+		}
+		return err
+	}, terminateMaxAttempts, terminateRetrySleep)
+	return resp, exhausted, err
 }
 
 func getEC2KeyOptions(h *host.Host, keyPath string) ([]string, error) {
@@ -166,6 +393,138 @@ func getEC2KeyOptions(h *host.Host, keyPath string) ([]string, error) {
 	return opts, nil
 }
 
+// securityGroups returns the security groups a distro's instances should use: the
+// ones set in the distro's settings, or the deployment-wide defaults if it has none.
+func securityGroups(group string, groups, defaults []string) []string {
+	out := []string{}
+	if group != "" {
+		out = append(out, group)
+	}
+	for _, g := range groups {
+		if !util.SliceContains(out, g) {
+			out = append(out, g)
+		}
+	}
+	if len(out) == 0 {
+		return defaults
+	}
+	return out
+}
+
+// validateSecurityGroups checks that at least one security group is set, and that
+// groups are given by id (eg. sg-xxxx) for VPC distros.
+func validateSecurityGroups(groups []string, isVpc bool) error {
+	if len(groups) == 0 {
+		return fmt.Errorf("Security group must not be blank")
+	}
+	for _, g := range groups {
+		if strings.TrimSpace(g) == "" {
+			return fmt.Errorf("Security group must not be blank")
+		}
+		if isVpc && !strings.HasPrefix(g, "sg-") {
+			return fmt.Errorf("Security group '%v' must be a security group id (sg-xxxx) for a VPC", g)
+		}
+	}
+	return nil
+}
+
+// validateCredentials makes an inexpensive EC2 API call to check that the
+// provider accepts the handle's credentials.
+func validateCredentials(ec2Handle *ec2.EC2) error {
+	filter := ec2.NewFilter()
+	filter.Add("instance-state-name", EC2StatusPending)
+	if _, err := ec2Handle.DescribeInstances(nil, filter); err != nil {
+		return fmt.Errorf("EC2 rejected the configured credentials: %v", err)
+	}
+	return nil
+}
+
+// describeOwnedInstances returns the EC2 instances tagged as launched by Evergreen.
+func describeOwnedInstances(ec2Handle *ec2.EC2) ([]ec2.Instance, error) {
+	filter := ec2.NewFilter()
+	filter.Add("tag:"+cloud.EvergreenOwnedTag, "true")
+	resp, err := ec2Handle.DescribeInstances(nil, filter)
+	if err != nil {
+		return nil, err
+	}
+	instances := []ec2.Instance{}
+	for _, reservation := range resp.Reservations {
+		instances = append(instances, reservation.Instances...)
+	}
+	return instances, nil
+}
+
+// makeCloudInstance converts an EC2 instance description into a cloud.CloudInstance.
+func makeCloudInstance(instance ec2.Instance) cloud.CloudInstance {
+	launchTime, err := time.Parse(time.RFC3339, instance.LaunchTime)
+	if err != nil {
+		grip.Warningf("Could not parse launch time '%v' of instance %v: %v",
+			instance.LaunchTime, instance.InstanceId, err)
+	}
+	return cloud.CloudInstance{
+		Id:           instance.InstanceId,
+		Status:       ec2StatusToEvergreenStatus(instance.State.Name),
+		LaunchTime:   launchTime,
+		InstanceType: instance.InstanceType,
+	}
+}
+
+// getLaunchTime returns when an EC2 instance was launched.
+func getLaunchTime(ec2Handle *ec2.EC2, instanceId string) (time.Time, error) {
+	instance, err := getInstanceInfo(ec2Handle, instanceId)
+	if err != nil {
+		return time.Time{}, err
+	}
+	launchTime, err := time.Parse(time.RFC3339, instance.LaunchTime)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not parse launch time '%v' of instance %v: %v",
+			instance.LaunchTime, instanceId, err)
+	}
+	return launchTime, nil
+}
+
+// validateTenancy checks that the tenancy, if set, is one that EC2 supports.
+func validateTenancy(tenancy string) error {
+	switch tenancy {
+	case "", evergreen.HostTenancyDefault, evergreen.HostTenancyDedicated, evergreen.HostTenancyHost:
+		return nil
+	default:
+		return fmt.Errorf("Tenancy '%v' must be one of '%v', '%v', or '%v'", tenancy,
+			evergreen.HostTenancyDefault, evergreen.HostTenancyDedicated, evergreen.HostTenancyHost)
+	}
+}
+
+// tenancyBillingName returns the name the EC2 price listings use for a tenancy.
+func tenancyBillingName(tenancy string) string {
+	switch tenancy {
+	case evergreen.HostTenancyDedicated:
+		return "Dedicated"
+	case evergreen.HostTenancyHost:
+		return "Host"
+	default:
+		return "Shared"
+	}
+}
+
+// instanceId returns the EC2 instance id for an on-demand host. Hosts created
+// before instance ids were recorded separately use the instance id as their Id.
+func instanceId(h *host.Host) string {
+	if h.InstanceId != "" {
+		return h.InstanceId
+	}
+	return h.Id
+}
+
+// rebootInstance reboots the EC2 instance with the given id, which was started for
+// the host.
+func rebootInstance(ec2Handle *ec2.EC2, hostId, instanceId string) error {
+	if _, err := ec2Handle.RebootInstances(instanceId); err != nil {
+		return fmt.Errorf("Failed to reboot host %v: %v", hostId, err)
+	}
+	grip.Infof("Rebooted instance %s for host %s", instanceId, hostId)
+	return nil
+}
+
 //getInstanceInfo returns the full ec2 instance info for the given instance ID.
 //Note that this is the *instance* id, not the spot request ID, which is different.
 func getInstanceInfo(ec2Handle *ec2.EC2, instanceId string) (*ec2.Instance, error) {
@@ -192,6 +551,122 @@ func getInstanceInfo(ec2Handle *ec2.EC2, instanceId string) (*ec2.Instance, erro
 	return &instances[0], nil
 }
 
+// associateStaticAddress allocates an Elastic IP, claims the host for it, then
+// associates it with the host's instance. Instances in a VPC need a VPC address. If
+// another request claimed the host first, the new address is released and the
+// other request's is returned.
+func associateStaticAddress(ec2Handle *ec2.EC2, h *host.Host, instanceId string) (string, error) {
+	if h.StaticAddress != nil {
+		return h.StaticAddress.PublicIp, nil
+	}
+	instance, err := getInstanceInfo(ec2Handle, instanceId)
+	if err != nil {
+		return "", err
+	}
+	domain := "standard"
+	if instance.VpcId != "" {
+		domain = "vpc"
+	}
+
+	allocated, err := ec2Handle.AllocateAddress(&ec2.AllocateAddressOptions{Domain: domain})
+	if err != nil {
+		return "", fmt.Errorf("Failed to allocate an Elastic IP for host %v: %v", h.Id, err)
+	}
+	address := &host.StaticAddress{PublicIp: allocated.PublicIp, AllocationId: allocated.AllocationId}
+	if err = h.SetStaticAddress(address); err != nil {
+		grip.Error(releaseAddress(ec2Handle, address))
+		if err != mgo.ErrNotFound {
+			return "", err
+		}
+		claimed, err := host.FindOne(host.ById(h.Id))
+		if err != nil {
+			return "", err
+		}
+		if claimed == nil || claimed.StaticAddress == nil {
+			return "", fmt.Errorf("Failed to record Elastic IP %v for host %v", address.PublicIp, h.Id)
+		}
+		h.StaticAddress = claimed.StaticAddress
+		return h.StaticAddress.PublicIp, nil
+	}
+
+	options := &ec2.AssociateAddressOptions{InstanceId: instanceId}
+	if address.AllocationId != "" {
+		options.AllocationId = address.AllocationId
+	} else {
+		options.PublicIp = address.PublicIp
+	}
+	associated, err := ec2Handle.AssociateAddress(options)
+	if err != nil {
+		// don't keep paying for an address no instance uses
+		grip.Error(releaseAddress(ec2Handle, address))
+		grip.Error(h.ClearStaticAddress())
+		return "", fmt.Errorf("Failed to associate Elastic IP %v with host %v: %v",
+			address.PublicIp, h.Id, err)
+	}
+	address.AssociationId = associated.AssociationId
+
+	if err = h.SetStaticAddress(address); err != nil {
+		grip.Error(releaseStaticAddress(ec2Handle, address))
+		grip.Error(h.ClearStaticAddress())
+		return "", err
+	}
+
+	// the instance's public DNS name changes along with its address
+	if instance, err = getInstanceInfo(ec2Handle, instanceId); err != nil {
+		grip.Warningf("Failed to look up the new DNS name of host %s: %+v", h.Id, err)
+	} else if instance.DNSName != "" {
+		grip.Error(h.UpdateDNSName(instance.DNSName))
+	}
+	return address.PublicIp, nil
+}
+
+// releaseStaticAddress disassociates an Elastic IP from its instance and releases it.
+// Addresses are disassociated from instances that are terminated, so failing to
+// disassociate one doesn't stop it from being released.
+func releaseStaticAddress(ec2Handle *ec2.EC2, address *host.StaticAddress) error {
+	var err error
+	if address.AllocationId != "" {
+		if address.AssociationId != "" {
+			_, err = ec2Handle.DisassociateAddress("", address.AssociationId)
+		}
+	} else {
+		_, err = ec2Handle.DisassociateAddress(address.PublicIp, "")
+	}
+	if err != nil {
+		grip.Warningf("Failed to disassociate Elastic IP %s: %+v", address.PublicIp, err)
+	}
+	return releaseAddress(ec2Handle, address)
+}
+
+// releaseAddress releases an Elastic IP that isn't associated with an instance.
+// Addresses in a VPC are released by their allocation id, and others by their IP.
+func releaseAddress(ec2Handle *ec2.EC2, address *host.StaticAddress) error {
+	var err error
+	if address.AllocationId != "" {
+		_, err = ec2Handle.ReleaseAddress("", address.AllocationId)
+	} else {
+		_, err = ec2Handle.ReleaseAddress(address.PublicIp, "")
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to release Elastic IP %v: %v", address.PublicIp, err)
+	}
+	return nil
+}
+
+// releaseHostStaticAddress releases the static address of a host whose instance was
+// terminated, if it has one. An address that can't be released is left recorded on
+// the host, so that it can be found and released by hand.
+func releaseHostStaticAddress(ec2Handle *ec2.EC2, h *host.Host) error {
+	if h.StaticAddress == nil {
+		return nil
+	}
+	if err := releaseStaticAddress(ec2Handle, h.StaticAddress); err != nil {
+		return fmt.Errorf("Error releasing the static address of terminated host %v: %v", h.Id, err)
+	}
+	grip.Infof("Released Elastic IP %s of host %s", h.StaticAddress.PublicIp, h.Id)
+	return h.ClearStaticAddress()
+}
+
 //ec2StatusToEvergreenStatus returns a "universal" status code based on EC2's
 //provider-specific status codes.
 func ec2StatusToEvergreenStatus(ec2Status string) cloud.CloudStatus {
@@ -211,6 +686,37 @@ func ec2StatusToEvergreenStatus(ec2Status string) cloud.CloudStatus {
 	}
 }
 
+// the detail type of the EventBridge events that EC2 sends when an instance
+// changes state
+const stateChangeEventType = "EC2 Instance State-change Notification"
+
+// stateChangeEvent is the part of an EC2 instance state change event, as
+// EventBridge delivers it, that Evergreen uses.
+type stateChangeEvent struct {
+	DetailType string `json:"detail-type"`
+	Detail     struct {
+		InstanceId string `json:"instance-id"`
+		State      string `json:"state"`
+	} `json:"detail"`
+}
+
+// parseStateChangeEvent returns the id of the instance an EC2 instance state change
+// event is about, and its new status.
+func parseStateChangeEvent(body []byte) (string, cloud.CloudStatus, error) {
+	event := stateChangeEvent{}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return "", cloud.StatusUnknown, fmt.Errorf("error parsing instance event: %v", err)
+	}
+	if event.DetailType != stateChangeEventType {
+		return "", cloud.StatusUnknown, fmt.Errorf("event of type '%v' is not an instance state change",
+			event.DetailType)
+	}
+	if event.Detail.InstanceId == "" {
+		return "", cloud.StatusUnknown, fmt.Errorf("instance state change event has no instance id")
+	}
+	return event.Detail.InstanceId, ec2StatusToEvergreenStatus(event.Detail.State), nil
+}
+
 // expireInDays creates an expire-on string in the format YYYY-MM-DD for numDays days
 // in the future.
 func expireInDays(numDays int) string {
@@ -255,6 +761,8 @@ func makeTags(intentHost *host.Host) map[string]string {
 		"mode":       "production",
 		"start-time": intentHost.CreationTime.Format(NameTimeFormat),
 		"expire-on":  expireOn,
+
+		cloud.EvergreenOwnedTag: "true",
 	}
 
 	if intentHost.UserHost {
@@ -276,22 +784,54 @@ func attachTags(ec2Handle *ec2.EC2,
 	return err
 }
 
-// determine how long until a payment is due for the specified host. since ec2
-// bills per full hour the host has been up this number is just how long until,
-// the host has been up the next round number of hours
-func timeTilNextEC2Payment(host *host.Host) time.Duration {
+const (
+	// maxTagKeyLength and maxTagValueLength are EC2's limits on the length of tags
+	maxTagKeyLength   = 127
+	maxTagValueLength = 255
+)
+
+// reservedTags are the tags Evergreen sets on instances that users may not change.
+var reservedTags = []string{"Name", "distro", "expire-on", "start-time", cloud.EvergreenOwnedTag}
+
+// validateUserTags returns an error if users can't set the tags on an instance.
+func validateUserTags(tags map[string]string) error {
+	if len(tags) == 0 {
+		return fmt.Errorf("no tags given")
+	}
+	for key, value := range tags {
+		if key == "" {
+			return fmt.Errorf("tag keys must not be blank")
+		}
+		if len(key) > maxTagKeyLength || len(value) > maxTagValueLength {
+			return fmt.Errorf("tag '%v' is too long: keys may be at most %v characters and values %v",
+				key, maxTagKeyLength, maxTagValueLength)
+		}
+		if strings.HasPrefix(strings.ToLower(key), "aws:") {
+			return fmt.Errorf("tag '%v' uses the reserved 'aws:' prefix", key)
+		}
+		if util.SliceContains(reservedTags, key) {
+			return fmt.Errorf("tag '%v' is set by Evergreen and can't be changed", key)
+		}
+	}
+	return nil
+}
+
+// determine how long until a payment is due for a host launched at launchTime.
+// since ec2 bills per full hour the host has been up this number is just how long
+// until, the host has been up the next round number of hours
+func timeTilNextEC2Payment(launchTime time.Time) time.Duration {
 
 	now := time.Now()
 
-	// the time since the host was created
-	timeSinceCreation := now.Sub(host.CreationTime)
+	// the time since the host was launched
+	timeSinceCreation := now.Sub(launchTime)
 
-	// the hours since the host was created, rounded up
+	// the hours since the host was launched, rounded up
 	hoursRoundedUp := time.Duration(math.Ceil(timeSinceCreation.Hours()))
 
 	// the next round number of hours the host will have been up - the time
 	// that the next payment will be due
-	nextPaymentTime := host.CreationTime.Add(hoursRoundedUp * time.Hour)
+	nextPaymentTime := launchTime.Add(hoursRoundedUp * time.Hour)
 
 	return nextPaymentTime.Sub(now)
 
@@ -446,9 +986,9 @@ func ebsCost(pf ebsPriceFetcher, region string, size int, duration time.Duration
 }
 
 // onDemandPriceFetcher is an interface for fetching the hourly price of a given
-// os/instance/region combination.
+// os/instance/region/tenancy combination.
 type onDemandPriceFetcher interface {
-	FetchPrice(os osType, instance, region string) (float64, error)
+	FetchPrice(os osType, instance, region, tenancy string) (float64, error)
 }
 
 // odInfo is an internal type for keying hosts by the attributes that affect billing.
@@ -456,13 +996,17 @@ type odInfo struct {
 	os       string
 	instance string
 	region   string
+	tenancy  string
 }
 
 // cachedOnDemandPriceFetcher is a thread-safe onDemandPriceFetcher that caches the results from
 // Amazon, allowing on long load on first access followed by virtually instant response time.
+// Each region's price table is loaded the first time a price in that region is fetched.
 type cachedOnDemandPriceFetcher struct {
 	prices map[odInfo]float64
-	m      sync.Mutex
+	// the regions whose price tables have been loaded into prices
+	regions map[string]bool
+	m       sync.Mutex
 }
 
 // pkgOnDemandPriceFetcher is a package-level cached price fetcher.
@@ -495,30 +1039,35 @@ func (t Terms) skuPrice(sku string) float64 {
 	return 0
 }
 
-// FetchPrice returns the hourly price of a host based on its attributes. A pricing table
-// is cached after the first communication with Amazon to avoid expensive API calls.
-func (cpf *cachedOnDemandPriceFetcher) FetchPrice(os osType, instance, region string) (float64, error) {
+// FetchPrice returns the hourly price of a host based on its attributes. The pricing table
+// of the host's region is cached after the first communication with Amazon to avoid
+// expensive API calls.
+func (cpf *cachedOnDemandPriceFetcher) FetchPrice(os osType, instance, region, tenancy string) (float64, error) {
 	cpf.m.Lock()
 	defer cpf.m.Unlock()
-	if cpf.prices == nil {
-		if err := cpf.cachePrices(); err != nil {
-			return 0, fmt.Errorf("loading On Demand price data: %v", err)
-		}
-	}
-	region, err := regionFullname(region)
+	fullname, err := regionFullname(region)
 	if err != nil {
 		return 0, err
 	}
+	if !cpf.regions[region] {
+		if err = cpf.cachePrices(region); err != nil {
+			return 0, fmt.Errorf("loading On Demand price data for %v: %v", region, err)
+		}
+	}
 	return cpf.prices[odInfo{
-		os: osBillingName(os), instance: instance, region: region,
+		os: osBillingName(os), instance: instance, region: fullname, tenancy: tenancyBillingName(tenancy),
 	}], nil
 }
 
-// cachePrices updates the internal cache with Amazon data.
-func (cpf *cachedOnDemandPriceFetcher) cachePrices() error {
-	cpf.prices = map[odInfo]float64{}
+// cachePrices updates the internal cache with Amazon data for a region.
+func (cpf *cachedOnDemandPriceFetcher) cachePrices(region string) error {
+	if cpf.prices == nil {
+		cpf.prices = map[odInfo]float64{}
+		cpf.regions = map[string]bool{}
+	}
 	// the On Demand pricing API is not part of the normal EC2 API
-	endpoint := "https://pricing.us-east-1.amazonaws.com/offers/v1.0/aws/AmazonEC2/current/index.json"
+	endpoint := fmt.Sprintf(
+		"https://pricing.us-east-1.amazonaws.com/offers/v1.0/aws/AmazonEC2/current/%v/index.json", region)
 	grip.Debugln("Loading On Demand pricing from", endpoint)
 	resp, err := http.Get(endpoint)
 	if resp != nil {
@@ -550,7 +1099,6 @@ func (cpf *cachedOnDemandPriceFetcher) cachePrices() error {
 	for _, p := range details.Products {
 		if p.ProductFamily == "Compute Instance" &&
 			p.Attributes.PreInstalledSW == "NA" &&
-			p.Attributes.Tenancy == "Shared" &&
 			p.Attributes.LicenseModel != "Bring your own license" {
 			// the product description does not include pricing information,
 			// so we must look up the SKU in the "Terms" section.
@@ -559,15 +1107,17 @@ func (cpf *cachedOnDemandPriceFetcher) cachePrices() error {
 				os:       p.Attributes.OperatingSystem,
 				instance: p.Attributes.InstanceType,
 				region:   p.Attributes.Location,
+				tenancy:  p.Attributes.Tenancy,
 			}] = price
 		}
 	}
+	cpf.regions[region] = true
 	return nil
 }
 
 // onDemandCost is a helper for calculating the price of an On Demand instance using the given price fetcher.
-func onDemandCost(pf onDemandPriceFetcher, os osType, instance, region string, dur time.Duration) (float64, error) {
-	price, err := pf.FetchPrice(os, instance, region)
+func onDemandCost(pf onDemandPriceFetcher, os osType, instance, region, tenancy string, dur time.Duration) (float64, error) {
+	price, err := pf.FetchPrice(os, instance, region, tenancy)
 	if err != nil {
 		return 0, err
 	}
@@ -6,9 +6,6 @@ import (
 	"strings"
 	"time"
 
-	awssdk "github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/session"
 	ec2sdk "github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/cloud"
@@ -32,9 +29,16 @@ const (
 	EC2ErrorSpotRequestNotFound = "InvalidSpotInstanceRequestID.NotFound"
 )
 
+const (
+	// how long to wait for a spot request to be fulfilled before falling back to an
+	// on-demand instance, if the distro doesn't specify a timeout
+	DefaultSpotFallbackTimeout = 2 * time.Minute
+)
+
 // EC2SpotManager implements the CloudManager interface for Amazon EC2 Spot
 type EC2SpotManager struct {
-	awsCredentials *aws.Auth
+	accounts              awsAccounts
+	defaultSecurityGroups []string
 }
 
 type EC2SpotSettings struct {
@@ -47,10 +51,62 @@ type EC2SpotSettings struct {
 
 	// this is the security group name in EC2 classic and the security group ID in VPC (eg. sg-xxxx)
 	SecurityGroup string `mapstructure:"security_group" json:"security_group,omitempty" bson:"security_group,omitempty"`
+	// additional security groups, in the same form as SecurityGroup. If neither is set,
+	// the deployment-wide security groups are used.
+	SecurityGroups []string `mapstructure:"security_groups" json:"security_groups,omitempty" bson:"security_groups,omitempty"`
 	// only set in VPC (eg. subnet-xxxx)
 	SubnetId string `mapstructure:"subnet_id" json:"subnet_id,omitempty" bson:"subnet_id,omitempty"`
 	// this is set to true if the security group is part of a vpc
 	IsVpc bool `mapstructure:"is_vpc" json:"is_vpc,omitempty" bson:"is_vpc,omitempty"`
+	// the size of the root volume in GB; zero means the image's size
+	RootVolumeSize int `mapstructure:"root_volume_size" json:"root_volume_size,omitempty" bson:"root_volume_size,omitempty"`
+
+	// if set, the monitor starts an on-demand instance in place of the spot instance
+	// if the spot request isn't fulfilled within FallbackTimeoutSecs
+	FallbackToOnDemand  bool `mapstructure:"fallback_to_on_demand" json:"fallback_to_on_demand,omitempty" bson:"fallback_to_on_demand,omitempty"`
+	FallbackTimeoutSecs int  `mapstructure:"fallback_timeout_secs" json:"fallback_timeout_secs,omitempty" bson:"fallback_timeout_secs,omitempty"`
+
+	// the name of the AWS account to start instances in; blank means the default account
+	Account string `mapstructure:"account" json:"account,omitempty" bson:"account,omitempty"`
+
+	// the deployment-wide security groups, used if the distro doesn't specify any
+	defaultSecurityGroups []string
+	// the accounts configured in the settings
+	accounts awsAccounts
+}
+
+// getSecurityGroups returns the security groups that instances of the distro
+// should be started in.
+func (self *EC2SpotSettings) getSecurityGroups() []string {
+	return securityGroups(self.SecurityGroup, self.SecurityGroups, self.defaultSecurityGroups)
+}
+
+// getFallbackTimeout returns how long to wait for a spot request to be fulfilled
+// before falling back to an on-demand instance.
+func (self *EC2SpotSettings) getFallbackTimeout() time.Duration {
+	if self.FallbackTimeoutSecs == 0 {
+		return DefaultSpotFallbackTimeout
+	}
+	return time.Duration(self.FallbackTimeoutSecs) * time.Second
+}
+
+// onDemandSettings returns the settings used to start an on-demand instance in
+// place of a spot instance.
+func (self *EC2SpotSettings) onDemandSettings() *EC2ProviderSettings {
+	return &EC2ProviderSettings{
+		AMI:                   self.AMI,
+		InstanceType:          self.InstanceType,
+		KeyName:               self.KeyName,
+		MountPoints:           self.MountPoints,
+		SecurityGroup:         self.SecurityGroup,
+		SecurityGroups:        self.SecurityGroups,
+		SubnetId:              self.SubnetId,
+		IsVpc:                 self.IsVpc,
+		RootVolumeSize:        self.RootVolumeSize,
+		Account:               self.Account,
+		defaultSecurityGroups: self.defaultSecurityGroups,
+		accounts:              self.accounts,
+	}
 }
 
 func (self *EC2SpotSettings) Validate() error {
@@ -66,41 +122,65 @@ func (self *EC2SpotSettings) Validate() error {
 		return fmt.Errorf("Instance size must not be blank")
 	}
 
-	if self.SecurityGroup == "" {
-		return fmt.Errorf("Security group must not be blank")
+	if err := validateSecurityGroups(self.getSecurityGroups(), self.IsVpc); err != nil {
+		return err
 	}
 	if self.KeyName == "" {
 		return fmt.Errorf("Key name must not be blank")
 	}
 
+	if self.FallbackTimeoutSecs < 0 {
+		return fmt.Errorf("Fallback timeout must not be negative")
+	}
+
+	if err := cloud.ValidateRootVolumeSize(self.RootVolumeSize); err != nil {
+		return err
+	}
+
 	_, err := makeBlockDeviceMappings(self.MountPoints)
 	if err != nil {
 		return err
 	}
 
+	if !self.accounts.has(self.Account) {
+		return fmt.Errorf("AWS account '%v' is not configured", self.Account)
+	}
+
 	return nil
 }
 
 //Configure loads necessary credentials or other settings from the global config
 //object.
 func (cloudManager *EC2SpotManager) Configure(settings *evergreen.Settings) error {
-	if settings.Providers.AWS.Id == "" || settings.Providers.AWS.Secret == "" {
-		return fmt.Errorf("AWS ID/Secret must not be blank")
+	accounts, err := loadAWSAccounts(&settings.Providers.AWS)
+	if err != nil {
+		return err
 	}
-	cloudManager.awsCredentials = &aws.Auth{
-		AccessKey: settings.Providers.AWS.Id,
-		SecretKey: settings.Providers.AWS.Secret,
+	cloudManager.accounts = accounts
+	cloudManager.defaultSecurityGroups = settings.Providers.AWS.SecurityGroups
+	return nil
+}
+
+// ValidateCredentials checks that EC2 accepts the credentials of each configured account.
+func (cloudManager *EC2SpotManager) ValidateCredentials() error {
+	for _, auth := range cloudManager.accounts.all() {
+		if err := validateCredentials(getUSEast(*auth)); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-func (*EC2SpotManager) GetSettings() cloud.ProviderSettings {
-	return &EC2SpotSettings{}
+func (cloudManager *EC2SpotManager) GetSettings() cloud.ProviderSettings {
+	return &EC2SpotSettings{
+		defaultSecurityGroups: cloudManager.defaultSecurityGroups,
+		accounts:              cloudManager.accounts,
+	}
 }
 
 // determine how long until a payment is due for the host
 func (cloudManager *EC2SpotManager) TimeTilNextPayment(host *host.Host) time.Duration {
-	return timeTilNextEC2Payment(host)
+	return timeTilNextEC2Payment(host.BillingStart())
 }
 
 func (cloudManager *EC2SpotManager) GetSSHOptions(h *host.Host, keyPath string) ([]string, error) {
@@ -126,7 +206,11 @@ func (cloudManager *EC2SpotManager) IsUp(host *host.Host) (bool, error) {
 func (cloudManager *EC2SpotManager) OnUp(host *host.Host) error {
 	tags := makeTags(host)
 	tags["spot"] = "true" // mark this as a spot instance
-	spotReq, err := cloudManager.describeSpotRequest(host.Id)
+	ec2Handle, err := cloudManager.accounts.handle(&host.Distro)
+	if err != nil {
+		return err
+	}
+	spotReq, err := describeSpotRequest(ec2Handle, host.Id)
 	if err != nil {
 		return err
 	}
@@ -138,7 +222,33 @@ func (cloudManager *EC2SpotManager) OnUp(host *host.Host) error {
 		grip.Error(err)
 		return err
 	}
-	return attachTags(getUSEast(*cloudManager.awsCredentials), tags, spotReq.InstanceId)
+	if err = host.SetInstanceId(spotReq.InstanceId); err != nil {
+		return fmt.Errorf("Could not record instance id for host '%v': %v", host.Id, err)
+	}
+	if err = cloud.RecordLaunchTime(cloudManager, host); err != nil {
+		grip.Warning(err)
+	}
+	return attachTags(ec2Handle, tags, spotReq.InstanceId)
+}
+
+// GetInstanceID returns the id of the EC2 instance that fulfilled the host's spot
+// request. Returns an error if the request has not been fulfilled yet.
+func (cloudManager *EC2SpotManager) GetInstanceID(host *host.Host) (string, error) {
+	if host.InstanceId != "" {
+		return host.InstanceId, nil
+	}
+	ec2Handle, err := cloudManager.accounts.handle(&host.Distro)
+	if err != nil {
+		return "", err
+	}
+	spotReq, err := describeSpotRequest(ec2Handle, host.Id)
+	if err != nil {
+		return "", err
+	}
+	if spotReq.InstanceId == "" {
+		return "", fmt.Errorf("spot request %v has not been fulfilled", host.Id)
+	}
+	return spotReq.InstanceId, nil
 }
 
 func (cloudManager *EC2SpotManager) IsSSHReachable(host *host.Host, keyPath string) (bool, error) {
@@ -163,7 +273,12 @@ func (cloudManager *EC2SpotManager) IsSSHReachable(host *host.Host, keyPath stri
 // the status returned will be the status of the instance that fulfilled it,
 // matching the behavior used in cloud/providers/ec2/ec2.go
 func (cloudManager *EC2SpotManager) GetInstanceStatus(host *host.Host) (cloud.CloudStatus, error) {
-	spotDetails, err := cloudManager.describeSpotRequest(host.Id)
+	defer cloud.RecordCallTime(SpotProviderName, "GetInstanceStatus", time.Now())
+	ec2Handle, err := cloudManager.accounts.handle(&host.Distro)
+	if err != nil {
+		return cloud.StatusUnknown, err
+	}
+	spotDetails, err := describeSpotRequest(ec2Handle, host.Id)
 	if err != nil {
 		err = fmt.Errorf("failed to get spot request info for %v: %v", host.Id, err)
 		grip.Error(err)
@@ -172,7 +287,6 @@ func (cloudManager *EC2SpotManager) GetInstanceStatus(host *host.Host) (cloud.Cl
 
 	//Spot request has been fulfilled, so get status of the instance itself
 	if spotDetails.InstanceId != "" {
-		ec2Handle := getUSEast(*cloudManager.awsCredentials)
 		instanceInfo, err := getInstanceInfo(ec2Handle, spotDetails.InstanceId)
 		if err != nil {
 			grip.Errorf("Got an error checking spot details %+v", err)
@@ -200,12 +314,24 @@ func (cloudManager *EC2SpotManager) GetInstanceStatus(host *host.Host) (cloud.Cl
 	}
 }
 
+// ParseInstanceEvent parses an EC2 instance state change event delivered by
+// EventBridge. The event is about the instance that fulfilled a spot request, so
+// it only matches a host once the host's instance id is recorded, which happens
+// at spawn for requests fulfilled right away and otherwise in hostinit.
+func (cloudManager *EC2SpotManager) ParseInstanceEvent(body []byte) (string, cloud.CloudStatus, error) {
+	return parseStateChangeEvent(body)
+}
+
 func (cloudManager *EC2SpotManager) CanSpawn() (bool, error) {
 	return true, nil
 }
 
 func (cloudManager *EC2SpotManager) GetDNSName(host *host.Host) (string, error) {
-	spotDetails, err := cloudManager.describeSpotRequest(host.Id)
+	ec2Handle, err := cloudManager.accounts.handle(&host.Distro)
+	if err != nil {
+		return "", err
+	}
+	spotDetails, err := describeSpotRequest(ec2Handle, host.Id)
 	if err != nil {
 		err = fmt.Errorf("failed to get spot request info for %v: %+v", host.Id, err)
 		grip.Error(err)
@@ -218,7 +344,6 @@ func (cloudManager *EC2SpotManager) GetDNSName(host *host.Host) (string, error)
 	}
 
 	//Spot request is fulfilled, find the instance info and get DNS info
-	ec2Handle := getUSEast(*cloudManager.awsCredentials)
 	instanceInfo, err := getInstanceInfo(ec2Handle, spotDetails.InstanceId)
 	if err != nil {
 		return "", err
@@ -227,13 +352,16 @@ func (cloudManager *EC2SpotManager) GetDNSName(host *host.Host) (string, error)
 }
 
 func (cloudManager *EC2SpotManager) SpawnInstance(d *distro.Distro, hostOpts cloud.HostOptions) (*host.Host, error) {
+	defer cloud.RecordCallTime(SpotProviderName, "SpawnInstance", time.Now())
 	if d.Provider != SpotProviderName {
 		return nil, fmt.Errorf("Can't spawn instance of %v for distro %v: provider is %v", SpotProviderName, d.Id, d.Provider)
 	}
-	ec2Handle := getUSEast(*cloudManager.awsCredentials)
 
 	//Decode and validate the ProviderSettings into the ec2-specific ones.
-	ec2Settings := &EC2SpotSettings{}
+	ec2Settings := &EC2SpotSettings{
+		defaultSecurityGroups: cloudManager.defaultSecurityGroups,
+		accounts:              cloudManager.accounts,
+	}
 	if err := mapstructure.Decode(d.ProviderSettings, ec2Settings); err != nil {
 		return nil, fmt.Errorf("Error decoding params for distro %v: %v", d.Id, err)
 	}
@@ -242,14 +370,39 @@ func (cloudManager *EC2SpotManager) SpawnInstance(d *distro.Distro, hostOpts clo
 		return nil, fmt.Errorf("Invalid EC2 spot settings in distro %v: %v", d.Id, err)
 	}
 
+	ec2Handle, err := cloudManager.accounts.handle(d)
+	if err != nil {
+		return nil, err
+	}
+
+	if hostOpts.Tenancy != "" && hostOpts.Tenancy != evergreen.HostTenancyDefault {
+		return nil, fmt.Errorf("Can't spawn instance of distro %v with %v tenancy: "+
+			"spot instances only support default tenancy", d.Id, hostOpts.Tenancy)
+	}
+	if hostOpts.ShutdownBehavior == evergreen.HostShutdownStop {
+		return nil, fmt.Errorf("Can't spawn instance of distro %v that stops on shutdown: "+
+			"spot instances are always terminated", d.Id)
+	}
+
 	blockDevices, err := makeBlockDeviceMappings(ec2Settings.MountPoints)
 	if err != nil {
 		return nil, err
 	}
 
+	rootVolumeSize := getRootVolumeSize(ec2Settings.RootVolumeSize, hostOpts)
+	if rootVolumeSize != 0 {
+		var rootDevice ec2.BlockDeviceMapping
+		rootDevice, err = makeRootDeviceMapping(ec2Handle, ec2Settings.AMI, rootVolumeSize, blockDevices)
+		if err != nil {
+			return nil, fmt.Errorf("Can't resize root volume of distro %v: %v", d.Id, err)
+		}
+		blockDevices = append(blockDevices, rootDevice)
+	}
+
 	instanceName := generateName(d.Id)
 	intentHost := cloud.NewIntent(*d, instanceName, SpotProviderName, hostOpts)
 	intentHost.InstanceType = ec2Settings.InstanceType
+	intentHost.RootVolumeSize = rootVolumeSize
 
 	// record this 'intent host'
 	if err := intentHost.Insert(); err != nil {
@@ -267,13 +420,13 @@ func (cloudManager *EC2SpotManager) SpawnInstance(d *distro.Distro, hostOpts clo
 		ImageId:        ec2Settings.AMI,
 		KeyName:        ec2Settings.KeyName,
 		InstanceType:   ec2Settings.InstanceType,
-		SecurityGroups: ec2.SecurityGroupNames(ec2Settings.SecurityGroup),
+		SecurityGroups: ec2.SecurityGroupNames(ec2Settings.getSecurityGroups()...),
 		BlockDevices:   blockDevices,
 	}
 
 	// if the spot instance is a vpc then set the appropriate fields
 	if ec2Settings.IsVpc {
-		spotRequest.SecurityGroups = ec2.SecurityGroupIds(ec2Settings.SecurityGroup)
+		spotRequest.SecurityGroups = ec2.SecurityGroupIds(ec2Settings.getSecurityGroups()...)
 		spotRequest.AssociatePublicIpAddress = true
 		spotRequest.SubnetId = ec2Settings.SubnetId
 	}
@@ -295,15 +448,24 @@ func (cloudManager *EC2SpotManager) SpawnInstance(d *distro.Distro, hostOpts clo
 		err = fmt.Errorf("Spot request %v was found in  state %v on intent host %v",
 			spotReqRes.SpotRequestId, spotReqRes.State, intentHost.Id)
 		grip.Error(err)
+		abandonSpotRequest(ec2Handle, spotReqRes.SpotRequestId)
+		if err := intentHost.Remove(); err != nil {
+			grip.Errorf("Failed to remove intent host %s: %+v", intentHost.Id, err)
+		}
 		return nil, err
 	}
 
 	intentHost.Id = spotReqRes.SpotRequestId
+	// requests fulfilled right away already have an instance, which instance
+	// state change notifications are about
+	intentHost.InstanceId = spotReqRes.InstanceId
 	err = intentHost.Insert()
 	if err != nil {
 		err = fmt.Errorf("Could not insert updated host info with id %v  for intent host "+
 			"%v: %+v", intentHost.Id, instanceName, err)
 		grip.Error(err)
+		// without a host for it, nothing would terminate the request's instance
+		abandonSpotRequest(ec2Handle, spotReqRes.SpotRequestId)
 		return nil, err
 	}
 
@@ -347,7 +509,181 @@ func (cloudManager *EC2SpotManager) SpawnInstance(d *distro.Distro, hostOpts clo
 	return intentHost, nil
 }
 
+// FallbackProvider returns the provider of the on-demand instances that replace
+// unfulfilled spot requests.
+func (cloudManager *EC2SpotManager) FallbackProvider() string {
+	return OnDemandProviderName
+}
+
+// NeedsFallback returns true if the host's distro falls back to on-demand instances
+// and the host's spot request failed outright, or wasn't fulfilled within the
+// distro's fallback timeout.
+func (cloudManager *EC2SpotManager) NeedsFallback(h *host.Host) (bool, error) {
+	ec2Settings, err := cloudManager.spotSettings(&h.Distro)
+	if err != nil {
+		return false, err
+	}
+	if !ec2Settings.FallbackToOnDemand {
+		return false, nil
+	}
+
+	status, err := cloudManager.GetInstanceStatus(h)
+	if err != nil {
+		return false, err
+	}
+	switch status {
+	case cloud.StatusFailed, cloud.StatusTerminated:
+		return true, nil
+	case cloud.StatusPending, cloud.StatusUnknown:
+		return time.Since(h.CreationTime) > ec2Settings.getFallbackTimeout(), nil
+	default:
+		// the request has been fulfilled
+		return false, nil
+	}
+}
+
+// FallBack cancels the host's spot request and starts an on-demand instance in its
+// place, with the same options. The request may have been fulfilled by the time
+// it's canceled, in which case the spot instance is kept and nil is returned.
+func (cloudManager *EC2SpotManager) FallBack(spotHost *host.Host) (*host.Host, error) {
+	defer cloud.RecordCallTime(SpotProviderName, "FallBack", time.Now())
+	d := &spotHost.Distro
+	ec2Settings, err := cloudManager.spotSettings(d)
+	if err != nil {
+		return nil, err
+	}
+	ec2Handle, err := cloudManager.accounts.handle(d)
+	if err != nil {
+		return nil, err
+	}
+
+	instanceId, err := cancelSpotRequest(ec2Handle, spotHost.Id)
+	if err != nil {
+		return nil, err
+	}
+	if instanceId != "" {
+		grip.Infof("Spot request %s was fulfilled before it was canceled; not falling back",
+			spotHost.Id)
+		return nil, nil
+	}
+
+	if err = spotHost.Remove(); err != nil {
+		return nil, fmt.Errorf("Could not remove host for canceled spot request %s: %+v", spotHost.Id, err)
+	}
+
+	onDemandManager := &EC2Manager{
+		accounts:              cloudManager.accounts,
+		defaultSecurityGroups: cloudManager.defaultSecurityGroups,
+	}
+	newHost, err := onDemandManager.spawnOnDemandInstance(d, ec2Settings.onDemandSettings(),
+		fallbackHostOptions(spotHost))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to fall back to an on-demand instance for distro %s: %v", d.Id, err)
+	}
+	grip.Infof("Started on-demand host %s in place of spot request %s", newHost.Id, spotHost.Id)
+	return newHost, nil
+}
+
+// spotSettings decodes and validates the spot settings of the distro.
+func (cloudManager *EC2SpotManager) spotSettings(d *distro.Distro) (*EC2SpotSettings, error) {
+	ec2Settings := &EC2SpotSettings{
+		defaultSecurityGroups: cloudManager.defaultSecurityGroups,
+		accounts:              cloudManager.accounts,
+	}
+	if err := mapstructure.Decode(d.ProviderSettings, ec2Settings); err != nil {
+		return nil, fmt.Errorf("Error decoding params for distro %v: %v", d.Id, err)
+	}
+	if err := ec2Settings.Validate(); err != nil {
+		return nil, fmt.Errorf("Invalid EC2 spot settings in distro %v: %v", d.Id, err)
+	}
+	return ec2Settings, nil
+}
+
+// fallbackHostOptions returns the options that the host of an unfulfilled spot
+// request was started with, to start an on-demand instance in its place. A spawn
+// host keeps the expiration time it was given.
+func fallbackHostOptions(h *host.Host) cloud.HostOptions {
+	hostOpts := cloud.HostOptions{
+		ProvisionOptions: h.ProvisionOptions,
+		UserName:         h.StartedBy,
+		UserData:         h.UserData,
+		UserHost:         h.UserHost,
+		RootVolumeSize:   h.RootVolumeSize,
+		RequestKey:       h.RequestKey,
+	}
+	if !util.IsZeroTime(h.ExpirationTime) {
+		remaining := h.ExpirationTime.Sub(time.Now())
+		hostOpts.ExpirationDuration = &remaining
+	}
+	return hostOpts
+}
+
+// ListInstances returns the spot instances Evergreen has launched in EC2, in every
+// configured account. Spot requests that have not been fulfilled yet are listed by
+// their request id.
+func (cloudManager *EC2SpotManager) ListInstances() ([]cloud.CloudInstance, error) {
+	result := []cloud.CloudInstance{}
+	for _, auth := range cloudManager.accounts.all() {
+		instances, err := listSpotInstances(getUSEast(*auth))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, instances...)
+	}
+	return result, nil
+}
+
+// listSpotInstances returns the spot instances and unfulfilled spot requests
+// Evergreen has launched in one account.
+func listSpotInstances(ec2Handle *ec2.EC2) ([]cloud.CloudInstance, error) {
+	filter := ec2.NewFilter()
+	filter.Add("tag:"+cloud.EvergreenOwnedTag, "true")
+	resp, err := ec2Handle.DescribeSpotRequests(nil, filter)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list EC2 spot requests: %v", err)
+	}
+
+	result := []cloud.CloudInstance{}
+	instanceIds := []string{}
+	for _, spotReq := range resp.SpotRequestResults {
+		if spotReq.InstanceId != "" {
+			instanceIds = append(instanceIds, spotReq.InstanceId)
+			continue
+		}
+		if spotReq.State != SpotStatusOpen && spotReq.State != SpotStatusActive {
+			continue
+		}
+		createTime, err := time.Parse(time.RFC3339, spotReq.CreateTime)
+		if err != nil {
+			grip.Warningf("Could not parse creation time '%v' of spot request %v: %v",
+				spotReq.CreateTime, spotReq.SpotRequestId, err)
+		}
+		result = append(result, cloud.CloudInstance{
+			Id:           spotReq.SpotRequestId,
+			Status:       cloud.StatusPending,
+			LaunchTime:   createTime,
+			InstanceType: spotReq.SpotLaunchSpec.InstanceType,
+		})
+	}
+	if len(instanceIds) == 0 {
+		return result, nil
+	}
+
+	// instances are only tagged once they're up, so describe them by id instead
+	instancesResp, err := ec2Handle.DescribeInstances(instanceIds, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to describe EC2 spot instances: %v", err)
+	}
+	for _, reservation := range instancesResp.Reservations {
+		for _, instance := range reservation.Instances {
+			result = append(result, makeCloudInstance(instance))
+		}
+	}
+	return result, nil
+}
+
 func (cloudManager *EC2SpotManager) TerminateInstance(host *host.Host) error {
+	defer cloud.RecordCallTime(SpotProviderName, "TerminateInstance", time.Now())
 	// terminate the instance
 	if host.Status == evergreen.HostTerminated {
 		errMsg := fmt.Errorf("Can not terminate %v - already marked as "+
@@ -356,7 +692,11 @@ func (cloudManager *EC2SpotManager) TerminateInstance(host *host.Host) error {
 		return errMsg
 	}
 
-	spotDetails, err := cloudManager.describeSpotRequest(host.Id)
+	ec2Handle, err := cloudManager.accounts.handle(&host.Distro)
+	if err != nil {
+		return err
+	}
+	spotDetails, err := describeSpotRequest(ec2Handle, host.Id)
 	if err != nil {
 		ec2err, ok := err.(*ec2.Error)
 		if ok && ec2err.Code == EC2ErrorSpotRequestNotFound {
@@ -373,26 +713,30 @@ func (cloudManager *EC2SpotManager) TerminateInstance(host *host.Host) error {
 	}
 
 	grip.Infoln("Canceling spot request", host.Id)
-	//First cancel the spot request
-	ec2Handle := getUSEast(*cloudManager.awsCredentials)
-
-	resp, err := ec2Handle.CancelSpotRequests([]string{host.Id})
-	grip.Debugf("host=%s, cancelResp=%+v", host.Id, resp)
+	//First cancel the spot request. A pending request may be fulfilled before it's
+	// canceled, so the instance it was fulfilled with is checked for afterward.
+	instanceId, err := cancelSpotRequest(ec2Handle, host.Id)
 	if err != nil {
-		err = fmt.Errorf("Failed to cancel spot request for host %v: %+v", host.Id, err)
 		grip.Error(err)
 		return err
 	}
+	if instanceId == "" {
+		instanceId = spotDetails.InstanceId
+	}
 
 	//Canceling the spot request doesn't terminate the instance that fulfilled it,
 	// if it was fulfilled. We need to terminate the instance explicitly
-	if spotDetails.InstanceId != "" {
+	if instanceId != "" {
 		grip.Infof("Spot request %s canceled, now terminating instance %s",
-			spotDetails.InstanceId, host.Id)
-		resp, err := ec2Handle.TerminateInstances([]string{spotDetails.InstanceId})
+			host.Id, instanceId)
+		resp, exhausted, err := terminateInstance(ec2Handle, instanceId)
 		if err != nil {
 			err = fmt.Errorf("Failed to terminate host %v: %v", host.Id, err)
 			grip.Error(err)
+			if exhausted {
+				// stop the monitor from trying to terminate the host again
+				grip.Error(host.SetTerminationFailed(err.Error()))
+			}
 			return err
 		}
 
@@ -400,6 +744,7 @@ func (cloudManager *EC2SpotManager) TerminateInstance(host *host.Host) error {
 			grip.Debugf("change=%d, host=%s, state=[%+v]", idx, host.Id, stateChange)
 			grip.Infof("Terminated %s", stateChange.InstanceId)
 		}
+		grip.Error(releaseHostStaticAddress(ec2Handle, host))
 	} else {
 		grip.Infof("Spot request %s canceled (no instances have fulfilled it)", host.Id)
 	}
@@ -408,11 +753,174 @@ func (cloudManager *EC2SpotManager) TerminateInstance(host *host.Host) error {
 	return host.Terminate()
 }
 
+// Reboot reboots the EC2 instance that fulfilled the host's spot request.
+func (cloudManager *EC2SpotManager) Reboot(host *host.Host) error {
+	defer cloud.RecordCallTime(SpotProviderName, "Reboot", time.Now())
+	instanceId, err := cloudManager.GetInstanceID(host)
+	if err != nil {
+		return fmt.Errorf("Failed to reboot host %v: %v", host.Id, err)
+	}
+	ec2Handle, err := cloudManager.accounts.handle(&host.Distro)
+	if err != nil {
+		return err
+	}
+	return rebootInstance(ec2Handle, host.Id, instanceId)
+}
+
+// GetConsoleOutput returns the console output of the EC2 instance that fulfilled
+// the host's spot request.
+func (cloudManager *EC2SpotManager) GetConsoleOutput(host *host.Host) (string, error) {
+	defer cloud.RecordCallTime(SpotProviderName, "GetConsoleOutput", time.Now())
+	instanceId, err := cloudManager.GetInstanceID(host)
+	if err != nil {
+		return "", fmt.Errorf("Failed to get console output of host %v: %v", host.Id, err)
+	}
+	auth, err := cloudManager.accounts.credentials(&host.Distro)
+	if err != nil {
+		return "", err
+	}
+	region, err := instanceRegion(getUSEast(*auth), host, instanceId)
+	if err != nil {
+		return "", fmt.Errorf("Failed to get console output of host %v: %v", host.Id, err)
+	}
+	output, err := getConsoleOutput(getSDK(*auth, region), instanceId)
+	if err != nil {
+		return "", fmt.Errorf("Failed to get console output of host %v: %v", host.Id, err)
+	}
+	return output, nil
+}
+
+// CreateImage creates an AMI from the EC2 instance that fulfilled the host's spot
+// request. EC2 reboots the instance while imaging it, so that its file systems are
+// consistent.
+func (cloudManager *EC2SpotManager) CreateImage(host *host.Host, name string) (string, error) {
+	defer cloud.RecordCallTime(SpotProviderName, "CreateImage", time.Now())
+	instanceId, err := cloudManager.GetInstanceID(host)
+	if err != nil {
+		return "", fmt.Errorf("Failed to create image of host %v: %v", host.Id, err)
+	}
+	auth, err := cloudManager.accounts.credentials(&host.Distro)
+	if err != nil {
+		return "", err
+	}
+	region, err := instanceRegion(getUSEast(*auth), host, instanceId)
+	if err != nil {
+		return "", fmt.Errorf("Failed to create image of host %v: %v", host.Id, err)
+	}
+	imageId, err := createImage(getSDK(*auth, region), instanceId, name)
+	if err != nil {
+		return "", fmt.Errorf("Failed to create image of host %v: %v", host.Id, err)
+	}
+	return imageId, nil
+}
+
+// GetLaunchTime returns when the EC2 instance that fulfilled the host's spot
+// request was launched.
+func (cloudManager *EC2SpotManager) GetLaunchTime(host *host.Host) (time.Time, error) {
+	defer cloud.RecordCallTime(SpotProviderName, "GetLaunchTime", time.Now())
+	instanceId, err := cloudManager.GetInstanceID(host)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Failed to get launch time of host %v: %v", host.Id, err)
+	}
+	ec2Handle, err := cloudManager.accounts.hostHandle(host)
+	if err != nil {
+		return time.Time{}, err
+	}
+	launchTime, err := getLaunchTime(ec2Handle, instanceId)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Failed to get launch time of host %v: %v", host.Id, err)
+	}
+	return launchTime, nil
+}
+
+// ValidateTags returns an error if users can't set the tags on instances.
+func (cloudManager *EC2SpotManager) ValidateTags(tags map[string]string) error {
+	return validateUserTags(tags)
+}
+
+// SetTags adds the tags to the instance that fulfilled the host's spot request.
+func (cloudManager *EC2SpotManager) SetTags(h *host.Host, tags map[string]string) error {
+	if err := validateUserTags(tags); err != nil {
+		return err
+	}
+	instanceId, err := cloudManager.GetInstanceID(h)
+	if err != nil {
+		return fmt.Errorf("Failed to tag host %v: %v", h.Id, err)
+	}
+	ec2Handle, err := cloudManager.accounts.handle(&h.Distro)
+	if err != nil {
+		return err
+	}
+	if err = attachTags(ec2Handle, tags, instanceId); err != nil {
+		return fmt.Errorf("Failed to tag host %v: %v", h.Id, err)
+	}
+	return nil
+}
+
+// AssociateStaticAddress pins an Elastic IP to the instance that fulfilled the host's
+// spot request.
+func (cloudManager *EC2SpotManager) AssociateStaticAddress(h *host.Host) (string, error) {
+	defer cloud.RecordCallTime(SpotProviderName, "AssociateStaticAddress", time.Now())
+	instanceId, err := cloudManager.GetInstanceID(h)
+	if err != nil {
+		return "", err
+	}
+	ec2Handle, err := cloudManager.accounts.handle(&h.Distro)
+	if err != nil {
+		return "", err
+	}
+	return associateStaticAddress(ec2Handle, h, instanceId)
+}
+
+// ReleaseStaticAddress releases the Elastic IP of a host whose spot instance was
+// terminated.
+func (cloudManager *EC2SpotManager) ReleaseStaticAddress(h *host.Host) error {
+	defer cloud.RecordCallTime(SpotProviderName, "ReleaseStaticAddress", time.Now())
+	ec2Handle, err := cloudManager.accounts.handle(&h.Distro)
+	if err != nil {
+		return err
+	}
+	return releaseHostStaticAddress(ec2Handle, h)
+}
+
+// cancelSpotRequest cancels the spot request and returns the id of the instance that
+// fulfilled it, if any. The request is described after it's canceled, since it may
+// have been fulfilled before the cancellation took effect.
+func cancelSpotRequest(ec2Handle *ec2.EC2, spotReqId string) (string, error) {
+	resp, err := ec2Handle.CancelSpotRequests([]string{spotReqId})
+	grip.Debugf("spotRequest=%s, cancelResp=%+v", spotReqId, resp)
+	if err != nil {
+		return "", fmt.Errorf("Failed to cancel spot request %v: %+v", spotReqId, err)
+	}
+	spotDetails, err := describeSpotRequest(ec2Handle, spotReqId)
+	if err != nil {
+		return "", fmt.Errorf("Failed to get spot request info for %v after canceling it: %+v",
+			spotReqId, err)
+	}
+	return spotDetails.InstanceId, nil
+}
+
+// abandonSpotRequest cancels a spot request that no host is recorded for, and
+// terminates the instance that fulfilled it, if any. Errors are logged.
+func abandonSpotRequest(ec2Handle *ec2.EC2, spotReqId string) {
+	instanceId, err := cancelSpotRequest(ec2Handle, spotReqId)
+	if err != nil {
+		grip.Errorf("Error abandoning spot request %s: %+v", spotReqId, err)
+		return
+	}
+	if instanceId == "" {
+		return
+	}
+	if _, _, err = terminateInstance(ec2Handle, instanceId); err != nil {
+		grip.Errorf("Error terminating instance %s of abandoned spot request %s: %+v",
+			instanceId, spotReqId, err)
+	}
+}
+
 // describeSpotRequest gets infomration about a spot request
 // Note that if the SpotRequestResult object returned has a non-blank InstanceId
 // field, this indicates that the spot request has been fulfilled.
-func (cloudManager *EC2SpotManager) describeSpotRequest(spotReqId string) (*ec2.SpotRequestResult, error) {
-	ec2Handle := getUSEast(*cloudManager.awsCredentials)
+func describeSpotRequest(ec2Handle *ec2.EC2, spotReqId string) (*ec2.SpotRequestResult, error) {
 	resp, err := ec2Handle.DescribeSpotRequests([]string{spotReqId}, nil)
 	if err != nil {
 		return nil, err
@@ -454,11 +962,19 @@ func (cloudManager *EC2SpotManager) CostForDuration(h *host.Host, start, end tim
 	}
 
 	// grab instance details from EC2
-	spotDetails, err := cloudManager.describeSpotRequest(h.Id)
+	auth, err := cloudManager.accounts.credentials(&h.Distro)
+	if err != nil {
+		return 0, err
+	}
+	spotDetails, err := describeSpotRequest(getUSEast(*auth), h.Id)
+	if err != nil {
+		return 0, err
+	}
+	// the instance is in the host's region, which is recorded once it's known
+	ec2Handle, err := cloudManager.accounts.hostHandle(h)
 	if err != nil {
 		return 0, err
 	}
-	ec2Handle := getUSEast(*cloudManager.awsCredentials)
 	instance, err := getInstanceInfo(ec2Handle, spotDetails.InstanceId)
 	if err != nil {
 		return 0, err
@@ -471,7 +987,9 @@ func (cloudManager *EC2SpotManager) CostForDuration(h *host.Host, start, end tim
 	if err != nil {
 		return 0, fmt.Errorf("calculating block device costs: %v", err)
 	}
-	spotCost, err := cloudManager.calculateSpotCost(instance, os, start, end)
+	// record the zone for the host's later cost calculations
+	hostRegion(h, instance)
+	spotCost, err := cloudManager.calculateSpotCost(auth, instance, os, start, end)
 	if err != nil {
 		return 0, err
 	}
@@ -480,13 +998,13 @@ func (cloudManager *EC2SpotManager) CostForDuration(h *host.Host, start, end tim
 
 // calculateSpotCost is a helper for fetching spot price history and computing the
 // cost of a task across a host's billing cycles.
-func (cloudManager *EC2SpotManager) calculateSpotCost(
+func (cloudManager *EC2SpotManager) calculateSpotCost(auth *aws.Auth,
 	i *ec2.Instance, os osType, start, end time.Time) (float64, error) {
 	launchTime, err := time.Parse(time.RFC3339, i.LaunchTime)
 	if err != nil {
 		return 0, fmt.Errorf("reading instance launch time: %v", err)
 	}
-	rates, err := cloudManager.describeHourlySpotPriceHistory(
+	rates, err := cloudManager.describeHourlySpotPriceHistory(auth,
 		i.InstanceType, i.AvailabilityZone, os, launchTime, end)
 	if err != nil {
 		return 0, err
@@ -535,17 +1053,9 @@ func spotCostForRange(start, end time.Time, rates []spotRate) float64 {
 // describeHourlySpotPriceHistory talks to Amazon to get spot price history, then
 // simplifies that history into hourly billing rates starting from the supplied
 // start time. Returns a slice of hour-separated spot prices or any errors that occur.
-func (cloudManager *EC2SpotManager) describeHourlySpotPriceHistory(
+func (cloudManager *EC2SpotManager) describeHourlySpotPriceHistory(auth *aws.Auth,
 	iType string, zone string, os osType, start, end time.Time) ([]spotRate, error) {
-	svc := ec2sdk.New(session.New(), &awssdk.Config{
-		Region: awssdk.String(aws.USEast.Name),
-		Credentials: credentials.NewCredentials(&credentials.StaticProvider{
-			credentials.Value{
-				AccessKeyID:     cloudManager.awsCredentials.AccessKey,
-				SecretAccessKey: cloudManager.awsCredentials.SecretKey,
-			},
-		}),
-	})
+	svc := getSDK(*auth, azToRegion(zone))
 	// expand times to contain the full runtime of the host
 	startFilter, endFilter := start.Add(-5*time.Hour), end.Add(time.Hour)
 	osStr := string(os)
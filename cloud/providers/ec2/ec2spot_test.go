@@ -1,7 +1,12 @@
 package ec2
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/cloud"
@@ -9,6 +14,8 @@ import (
 	"github.com/evergreen-ci/evergreen/model/distro"
 	"github.com/evergreen-ci/evergreen/model/host"
 	"github.com/evergreen-ci/evergreen/testutil"
+	"github.com/goamz/goamz/aws"
+	"github.com/goamz/goamz/ec2"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -79,3 +86,130 @@ func fetchTestDistro() *distro.Distro {
 		SSHKey:      "",
 	}
 }
+
+// fakeSpotEC2 serves the EC2 actions used to cancel spot requests, reporting the
+// request as fulfilled by instanceId once it's canceled, to reboot instances,
+// failing reboots of instances other than instanceId, and to describe instances,
+// all of which are in zone and were launched at fakeLaunchTime. It fails the
+// first terminateFailures terminations as throttled. It records the actions it's
+// sent.
+type fakeSpotEC2 struct {
+	instanceId        string
+	zone              string
+	terminateFailures int
+
+	mu         sync.Mutex
+	actions    []string
+	terminated []string
+	rebooted   []string
+}
+
+// fakeLaunchTime is when fakeSpotEC2 reports instances were launched.
+const fakeLaunchTime = "2017-03-01T12:00:00.000Z"
+
+func (f *fakeSpotEC2) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	action := r.FormValue("Action")
+	f.actions = append(f.actions, action)
+	switch action {
+	case "CancelSpotInstanceRequests":
+		fmt.Fprintf(w, `<CancelSpotInstanceRequestsResponse><spotInstanceRequestSet><item>`+
+			`<spotInstanceRequestId>%v</spotInstanceRequestId><state>cancelled</state>`+
+			`</item></spotInstanceRequestSet></CancelSpotInstanceRequestsResponse>`,
+			r.FormValue("SpotInstanceRequestId.1"))
+	case "DescribeSpotInstanceRequests":
+		fmt.Fprintf(w, `<DescribeSpotInstanceRequestsResponse><spotInstanceRequestSet><item>`+
+			`<spotInstanceRequestId>%v</spotInstanceRequestId><state>cancelled</state>`+
+			`<instanceId>%v</instanceId></item></spotInstanceRequestSet></DescribeSpotInstanceRequestsResponse>`,
+			r.FormValue("SpotInstanceRequestId.1"), f.instanceId)
+	case "TerminateInstances":
+		if f.terminateFailures > 0 {
+			f.terminateFailures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `<Response><Errors><Error><Code>RequestLimitExceeded</Code>`+
+				`<Message>Request limit exceeded.</Message></Error></Errors></Response>`)
+			return
+		}
+		f.terminated = append(f.terminated, r.FormValue("InstanceId.1"))
+		fmt.Fprint(w, `<TerminateInstancesResponse></TerminateInstancesResponse>`)
+	case "RebootInstances":
+		if id := r.FormValue("InstanceId.1"); id != f.instanceId {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, `<Response><Errors><Error><Code>InvalidInstanceID.NotFound</Code>`+
+				`<Message>The instance ID '%v' does not exist</Message></Error></Errors></Response>`, id)
+			return
+		}
+		f.rebooted = append(f.rebooted, r.FormValue("InstanceId.1"))
+		fmt.Fprint(w, `<RebootInstancesResponse><return>true</return></RebootInstancesResponse>`)
+	case "DescribeInstances":
+		fmt.Fprintf(w, `<DescribeInstancesResponse><reservationSet><item><reservationId>r-1</reservationId>`+
+			`<instancesSet><item><instanceId>%v</instanceId><launchTime>%v</launchTime>`+
+			`<placement><availabilityZone>%v</availabilityZone></placement></item></instancesSet>`+
+			`</item></reservationSet></DescribeInstancesResponse>`,
+			r.FormValue("InstanceId.1"), fakeLaunchTime, f.zone)
+	default:
+		http.Error(w, "unexpected action "+action, http.StatusBadRequest)
+	}
+}
+
+func TestRebootInstance(t *testing.T) {
+	Convey("With an EC2 instance", t, func() {
+		fake := &fakeSpotEC2{instanceId: "i-running"}
+		server := httptest.NewServer(fake)
+		defer server.Close()
+		ec2Handle := ec2.NewWithClient(aws.Auth{AccessKey: "key", SecretKey: "secret"},
+			aws.Region{Name: "test", EC2Endpoint: server.URL}, http.DefaultClient)
+
+		Convey("rebooting it should reboot that instance", func() {
+			So(rebootInstance(ec2Handle, "h1", "i-running"), ShouldBeNil)
+			So(fake.rebooted, ShouldResemble, []string{"i-running"})
+		})
+
+		Convey("rebooting an instance EC2 can't find should fail naming the host", func() {
+			err := rebootInstance(ec2Handle, "h2", "i-missing")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "h2")
+			So(err.Error(), ShouldContainSubstring, "InvalidInstanceID.NotFound")
+			So(fake.rebooted, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestCancelSpotRequest(t *testing.T) {
+	Convey("With a spot request that was fulfilled before it was canceled", t, func() {
+		fake := &fakeSpotEC2{instanceId: "i-fulfilled"}
+		server := httptest.NewServer(fake)
+		defer server.Close()
+		ec2Handle := ec2.NewWithClient(aws.Auth{AccessKey: "key", SecretKey: "secret"},
+			aws.Region{Name: "test", EC2Endpoint: server.URL}, http.DefaultClient)
+
+		Convey("canceling it should report the instance that fulfilled it", func() {
+			instanceId, err := cancelSpotRequest(ec2Handle, "sir-1")
+			So(err, ShouldBeNil)
+			So(instanceId, ShouldEqual, "i-fulfilled")
+			So(fake.actions, ShouldResemble, []string{"CancelSpotInstanceRequests", "DescribeSpotInstanceRequests"})
+		})
+
+		Convey("abandoning it should terminate that instance", func() {
+			abandonSpotRequest(ec2Handle, "sir-1")
+			So(fake.terminated, ShouldResemble, []string{"i-fulfilled"})
+		})
+
+		Convey("abandoning it should retry a throttled termination", func() {
+			defer func(sleep time.Duration) { terminateRetrySleep = sleep }(terminateRetrySleep)
+			terminateRetrySleep = time.Millisecond
+			fake.terminateFailures = 1
+			abandonSpotRequest(ec2Handle, "sir-1")
+			So(fake.terminated, ShouldResemble, []string{"i-fulfilled"})
+			So(fake.actions, ShouldResemble, []string{"CancelSpotInstanceRequests",
+				"DescribeSpotInstanceRequests", "TerminateInstances", "TerminateInstances"})
+		})
+
+		Convey("abandoning it should terminate nothing if it wasn't fulfilled", func() {
+			fake.instanceId = ""
+			abandonSpotRequest(ec2Handle, "sir-1")
+			So(fake.terminated, ShouldBeEmpty)
+		})
+	})
+}
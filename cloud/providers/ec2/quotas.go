@@ -0,0 +1,197 @@
+package ec2
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/evergreen-ci/evergreen/cloud"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/goamz/goamz/aws"
+	"github.com/goamz/goamz/ec2"
+)
+
+const (
+	// OnDemandVCPUQuota is the name of the limit on how many vCPUs of standard (A, C,
+	// D, H, I, M, R, T and Z) on-demand instances an account can run at once.
+	OnDemandVCPUQuota = "on_demand_standard_vcpus"
+
+	// SpotVCPUQuota is the name of the limit on how many vCPUs of standard spot
+	// instances an account can run at once.
+	SpotVCPUQuota = "spot_standard_vcpus"
+
+	// the Service Quotas codes of EC2 and of its vCPU limits
+	ec2ServiceCode        = "ec2"
+	onDemandVCPUQuotaCode = "L-1216C47A"
+	spotVCPUQuotaCode     = "L-34B43A08"
+)
+
+// GetQuotas returns, for each configured account and each region it has hosts in,
+// how many vCPUs of standard on-demand instances are running and how many EC2
+// allows to run at once.
+func (cloudManager *EC2Manager) GetQuotas() ([]cloud.Quota, error) {
+	return getVCPUQuotas(cloudManager.accounts, OnDemandProviderName, false)
+}
+
+// GetQuotas returns, for each configured account and each region it has hosts in,
+// how many vCPUs of standard spot instances are running and how many EC2 allows to
+// run at once.
+func (cloudManager *EC2SpotManager) GetQuotas() ([]cloud.Quota, error) {
+	return getVCPUQuotas(cloudManager.accounts, SpotProviderName, true)
+}
+
+// getVCPUQuotas returns the vCPU quotas of each of the accounts, for either spot or
+// on-demand instances, in the regions the provider's hosts are up in. vCPU limits
+// apply to each region separately.
+func getVCPUQuotas(accounts awsAccounts, provider string, spot bool) ([]cloud.Quota, error) {
+	hosts, err := host.Find(host.UpByProvider(provider))
+	if err != nil {
+		return nil, fmt.Errorf("error finding %v hosts: %v", provider, err)
+	}
+	regions := quotaRegions(accounts.names(), hosts)
+
+	quotas := []cloud.Quota{}
+	for _, name := range accounts.names() {
+		auth, err := accounts.get(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, region := range regions[name] {
+			ec2Handle, err := getRegionHandle(*auth, region)
+			if err != nil {
+				return nil, err
+			}
+			quota, err := getVCPUQuota(newServiceQuotas(*auth, region), ec2Handle, spot)
+			if err != nil {
+				return nil, fmt.Errorf("error getting EC2 vCPU quota of account '%v' in %v: %v",
+					name, region, err)
+			}
+			quota.Account = name
+			quota.Region = region
+			quotas = append(quotas, quota)
+		}
+	}
+	return quotas, nil
+}
+
+// quotaRegions returns the regions to report each of the named accounts' quotas in:
+// US east, where hosts are started by default, and the regions of the zones the
+// accounts' hosts were started in.
+func quotaRegions(accountNames []string, hosts []host.Host) map[string][]string {
+	regions := map[string][]string{}
+	for _, name := range accountNames {
+		regions[name] = []string{aws.USEast.Name}
+	}
+	for i := range hosts {
+		name := distroAccount(&hosts[i].Distro)
+		region := azToRegion(hosts[i].Zone)
+		if _, ok := regions[name]; !ok || region == "" || util.SliceContains(regions[name], region) {
+			continue
+		}
+		regions[name] = append(regions[name], region)
+	}
+	for _, accountRegions := range regions {
+		sort.Strings(accountRegions)
+	}
+	return regions
+}
+
+// getVCPUQuota returns an account's vCPU limit for standard spot or on-demand
+// instances in a region, from its Service Quotas API, and how many vCPUs of such
+// instances are pending or running there, from its EC2 API.
+func getVCPUQuota(quotas *serviceQuotas, ec2Handle *ec2.EC2, spot bool) (cloud.Quota, error) {
+	quota := cloud.Quota{Resource: OnDemandVCPUQuota}
+	quotaCode := onDemandVCPUQuotaCode
+	if spot {
+		quota.Resource = SpotVCPUQuota
+		quotaCode = spotVCPUQuotaCode
+	}
+
+	limit, err := quotas.getQuotaValue(ec2ServiceCode, quotaCode)
+	if err != nil {
+		return quota, err
+	}
+	quota.Limit = int(limit)
+
+	filter := ec2.NewFilter()
+	filter.Add("instance-state-name", EC2StatusPending, EC2StatusRunning)
+	resp, err := ec2Handle.DescribeInstances(nil, filter)
+	if err != nil {
+		return quota, err
+	}
+	instances := []ec2.Instance{}
+	for _, reservation := range resp.Reservations {
+		instances = append(instances, reservation.Instances...)
+	}
+	quota.Used = countStandardVCPUs(instances, spot)
+	return quota, nil
+}
+
+// countStandardVCPUs returns how many vCPUs the standard spot or on-demand
+// instances among the given ones have. Instances of sizes whose vCPUs aren't known,
+// such as bare metal, aren't counted.
+func countStandardVCPUs(instances []ec2.Instance, spot bool) int {
+	vcpus := 0
+	for _, instance := range instances {
+		if (instance.InstanceLifecycle == "spot") != spot {
+			continue
+		}
+		family, size := splitInstanceType(instance.InstanceType)
+		if !isStandardFamily(family) {
+			continue
+		}
+		if n, ok := instanceVCPUs(family, size); ok {
+			vcpus += n
+		}
+	}
+	return vcpus
+}
+
+// splitInstanceType splits an instance type, e.g. "m5.2xlarge", into its family
+// and size.
+func splitInstanceType(instanceType string) (string, string) {
+	parts := strings.SplitN(instanceType, ".", 2)
+	if len(parts) != 2 {
+		return instanceType, ""
+	}
+	return parts[0], parts[1]
+}
+
+// isStandardFamily returns whether instances of the family count towards the
+// standard vCPU limits, which cover the A, C, D, H, I, M, R, T and Z families.
+func isStandardFamily(family string) bool {
+	if family == "" || strings.HasPrefix(family, "inf") {
+		return false
+	}
+	return strings.ContainsRune("acdhimrtz", rune(family[0]))
+}
+
+// instanceVCPUs returns the number of vCPUs of an instance of the given family and
+// size, which follows from the size for all but the smallest burstable instances.
+func instanceVCPUs(family, size string) (int, bool) {
+	burstable := family == "t3" || family == "t3a" || family == "t4g"
+	switch size {
+	case "nano", "micro", "small":
+		if burstable {
+			return 2, true
+		}
+		return 1, true
+	case "medium":
+		if burstable || family == "t2" {
+			return 2, true
+		}
+		return 1, true
+	case "large":
+		return 2, true
+	case "xlarge":
+		return 4, true
+	}
+	if strings.HasSuffix(size, "xlarge") {
+		if n, err := strconv.Atoi(strings.TrimSuffix(size, "xlarge")); err == nil && n > 0 {
+			return 4 * n, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,100 @@
+package ec2
+
+import (
+	"fmt"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/aws/aws-sdk-go/private/protocol/jsonrpc"
+	"github.com/goamz/goamz/aws"
+)
+
+// serviceQuotas is a client for the AWS Service Quotas API, which the vendored
+// SDK predates. It only supports looking up the value of a quota.
+type serviceQuotas struct {
+	*client.Client
+}
+
+// newServiceQuotas returns a Service Quotas client for the account in the region,
+// whose quotas it reports.
+func newServiceQuotas(creds aws.Auth, region string) *serviceQuotas {
+	return newServiceQuotasWithConfig(&awssdk.Config{
+		Region: awssdk.String(region),
+		Credentials: credentials.NewCredentials(&credentials.StaticProvider{
+			Value: credentials.Value{
+				AccessKeyID:     creds.AccessKey,
+				SecretAccessKey: creds.SecretKey,
+			},
+		}),
+	})
+}
+
+func newServiceQuotasWithConfig(config *awssdk.Config) *serviceQuotas {
+	c := session.New().ClientConfig("servicequotas", config)
+	svc := &serviceQuotas{
+		Client: client.New(
+			*c.Config,
+			metadata.ClientInfo{
+				ServiceName:   "servicequotas",
+				SigningRegion: c.SigningRegion,
+				Endpoint:      c.Endpoint,
+				APIVersion:    "2019-06-24",
+				JSONVersion:   "1.1",
+				TargetPrefix:  "ServiceQuotasV20190624",
+			},
+			c.Handlers,
+		),
+	}
+	svc.Handlers.Sign.PushBackNamed(v4.SignRequestHandler)
+	svc.Handlers.Build.PushBackNamed(jsonrpc.BuildHandler)
+	svc.Handlers.Unmarshal.PushBackNamed(jsonrpc.UnmarshalHandler)
+	svc.Handlers.UnmarshalMeta.PushBackNamed(jsonrpc.UnmarshalMetaHandler)
+	svc.Handlers.UnmarshalError.PushBackNamed(jsonrpc.UnmarshalErrorHandler)
+	return svc
+}
+
+type getServiceQuotaInput struct {
+	_ struct{} `type:"structure"`
+
+	ServiceCode *string `type:"string" required:"true"`
+	QuotaCode   *string `type:"string" required:"true"`
+}
+
+type getServiceQuotaOutput struct {
+	_ struct{} `type:"structure"`
+
+	Quota *serviceQuota `type:"structure"`
+}
+
+type serviceQuota struct {
+	_ struct{} `type:"structure"`
+
+	Value *float64 `type:"double"`
+}
+
+// getQuotaValue returns the value of the account's quota with the given code for
+// the service, e.g. the number of vCPUs it may run.
+func (c *serviceQuotas) getQuotaValue(serviceCode, quotaCode string) (float64, error) {
+	op := &request.Operation{
+		Name:       "GetServiceQuota",
+		HTTPMethod: "POST",
+		HTTPPath:   "/",
+	}
+	output := &getServiceQuotaOutput{}
+	req := c.NewRequest(op, &getServiceQuotaInput{
+		ServiceCode: awssdk.String(serviceCode),
+		QuotaCode:   awssdk.String(quotaCode),
+	}, output)
+	if err := req.Send(); err != nil {
+		return 0, err
+	}
+	if output.Quota == nil || output.Quota.Value == nil {
+		return 0, fmt.Errorf("quota %v of service %v has no value", quotaCode, serviceCode)
+	}
+	return *output.Quota.Value, nil
+}
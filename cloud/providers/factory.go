@@ -44,6 +44,9 @@ func GetCloudManager(providerName string, settings *evergreen.Settings) (cloud.C
 
 // GetCloudHost returns an instance of CloudHost wrapping the given model.Host,
 // giving access to the provider-specific methods to manipulate on the host.
+// The host is connected to with the key it was provisioned with, so rotating its
+// distro's key doesn't affect it. If that key is no longer in the settings, the
+// CloudHost can still manage the instance, but can't connect to it over SSH.
 func GetCloudHost(host *host.Host, settings *evergreen.Settings) (*cloud.CloudHost, error) {
 	mgr, err := GetCloudManager(host.Provider, settings)
 	if err != nil {
@@ -51,8 +54,18 @@ func GetCloudHost(host *host.Host, settings *evergreen.Settings) (*cloud.CloudHo
 	}
 
 	keyPath := ""
-	if host.Distro.SSHKey != "" {
-		keyPath = settings.Keys[host.Distro.SSHKey]
+	if keyName := host.SSHKeyName(); keyName != "" {
+		keyPath = settings.Keys[keyName]
 	}
 	return &cloud.CloudHost{host, keyPath, mgr}, nil
 }
+
+// CheckSpawnAllowed returns an error if the settings don't allow spawning hosts with
+// the given provider. Hosts that already exist can still be managed with GetCloudManager.
+func CheckSpawnAllowed(providerName string, settings *evergreen.Settings) error {
+	if !settings.Providers.IsAllowed(providerName) {
+		return fmt.Errorf("spawning hosts with provider '%v' is not allowed; allowed providers are %v",
+			providerName, settings.Providers.Allowed)
+	}
+	return nil
+}
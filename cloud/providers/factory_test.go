@@ -8,6 +8,7 @@ import (
 	"github.com/evergreen-ci/evergreen/cloud/providers/ec2"
 	"github.com/evergreen-ci/evergreen/cloud/providers/mock"
 	"github.com/evergreen-ci/evergreen/cloud/providers/static"
+	"github.com/evergreen-ci/evergreen/model/distro"
 	"github.com/evergreen-ci/evergreen/model/host"
 	"github.com/evergreen-ci/evergreen/testutil"
 	. "github.com/smartystreets/goconvey/convey"
@@ -93,3 +94,65 @@ func TestIsHostReachable(t *testing.T) {
 	})
 
 }
+
+func TestCheckSpawnAllowed(t *testing.T) {
+	Convey("With settings that don't restrict providers", t, func() {
+		settings := &evergreen.Settings{}
+
+		Convey("spawning with any provider should be allowed", func() {
+			So(CheckSpawnAllowed(ec2.OnDemandProviderName, settings), ShouldBeNil)
+			So(CheckSpawnAllowed(static.ProviderName, settings), ShouldBeNil)
+		})
+
+		Convey("once providers are restricted, only the allowed ones should be", func() {
+			settings.Providers.Allowed = []string{static.ProviderName, mock.ProviderName}
+			So(CheckSpawnAllowed(static.ProviderName, settings), ShouldBeNil)
+			So(CheckSpawnAllowed(mock.ProviderName, settings), ShouldBeNil)
+			So(CheckSpawnAllowed(ec2.OnDemandProviderName, settings), ShouldNotBeNil)
+			So(CheckSpawnAllowed(digitalocean.ProviderName, settings), ShouldNotBeNil)
+		})
+	})
+}
+
+func TestGetCloudHostKey(t *testing.T) {
+	Convey("With a host provisioned with a key its distro no longer uses", t, func() {
+		settings := &evergreen.Settings{Keys: map[string]string{"old": "/keys/old", "new": "/keys/new"}}
+		h := &host.Host{Id: "h1", Provider: mock.ProviderName, SSHKey: "old", Distro: distro.Distro{SSHKey: "new"}}
+
+		Convey("the key the host was provisioned with should be used", func() {
+			cloudHost, err := GetCloudHost(h, settings)
+			So(err, ShouldBeNil)
+			So(cloudHost.KeyPath, ShouldEqual, "/keys/old")
+		})
+
+		Convey("hosts without a recorded key should use their distro's key", func() {
+			h.SSHKey = ""
+			cloudHost, err := GetCloudHost(h, settings)
+			So(err, ShouldBeNil)
+			So(cloudHost.KeyPath, ShouldEqual, "/keys/new")
+		})
+
+		Convey("once the key is removed from the settings", func() {
+			delete(settings.Keys, "old")
+			cloudHost, err := GetCloudHost(h, settings)
+			So(err, ShouldBeNil)
+			So(cloudHost.KeyPath, ShouldEqual, "")
+
+			Convey("the host should not be connected to over ssh", func() {
+				_, err = cloudHost.GetSSHOptions()
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "'old'")
+				_, err = cloudHost.IsSSHReachable()
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("hosts without a key should not need one in the settings", func() {
+			h.SSHKey = ""
+			h.Distro.SSHKey = ""
+			cloudHost, err := GetCloudHost(h, settings)
+			So(err, ShouldBeNil)
+			So(cloudHost.KeyPath, ShouldEqual, "")
+		})
+	})
+}
@@ -1,6 +1,7 @@
 package mock
 
 import (
+	"encoding/json"
 	"fmt"
 	"sync"
 	"time"
@@ -25,6 +26,7 @@ type MockInstance struct {
 	TimeTilNextPayment time.Duration
 	DNSName            string
 	OnUpRan            bool
+	LaunchTime         time.Time
 }
 
 var MockInstances map[string]MockInstance = map[string]MockInstance{}
@@ -68,10 +70,24 @@ func (mockMgr *MockCloudManager) SpawnInstance(distro *distro.Distro, hostOpts c
 		SSHOptions:         []string{},
 		TimeTilNextPayment: time.Duration(0),
 		DNSName:            "",
+		LaunchTime:         time.Now(),
 	}
 	return intentHost, nil
 }
 
+// BatchSpawnInstance spawns count mock hosts of the distro.
+func (mockMgr *MockCloudManager) BatchSpawnInstance(distro *distro.Distro, hostOpts cloud.HostOptions, count int) ([]host.Host, error) {
+	hosts := make([]host.Host, 0, count)
+	for i := 0; i < count; i++ {
+		h, err := mockMgr.SpawnInstance(distro, hostOpts)
+		if err != nil {
+			return nil, err
+		}
+		hosts = append(hosts, *h)
+	}
+	return hosts, nil
+}
+
 // get the status of an instance
 func (mockMgr *MockCloudManager) GetInstanceStatus(host *host.Host) (cloud.CloudStatus, error) {
 	l := mockMgr.mutex
@@ -105,6 +121,42 @@ func (_ *MockCloudManager) Validate() error {
 	return nil
 }
 
+// MockInstanceEvent is the body of the instance state change notifications the
+// mock provider parses.
+type MockInstanceEvent struct {
+	InstanceId string `json:"instance_id"`
+	Status     string `json:"status"`
+}
+
+// ParseInstanceEvent parses a JSON-encoded MockInstanceEvent.
+func (mockMgr *MockCloudManager) ParseInstanceEvent(body []byte) (string, cloud.CloudStatus, error) {
+	event := MockInstanceEvent{}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return "", cloud.StatusUnknown, err
+	}
+	if event.InstanceId == "" {
+		return "", cloud.StatusUnknown, fmt.Errorf("mock instance event has no instance id")
+	}
+	return event.InstanceId, cloud.ParseCloudStatus(event.Status), nil
+}
+
+// get instance id
+func (mockMgr *MockCloudManager) GetInstanceID(host *host.Host) (string, error) {
+	return host.Id, nil
+}
+
+// list all mock instances
+func (mockMgr *MockCloudManager) ListInstances() ([]cloud.CloudInstance, error) {
+	l := mockMgr.mutex
+	l.RLock()
+	defer l.RUnlock()
+	instances := []cloud.CloudInstance{}
+	for id, instance := range mockMgr.Instances {
+		instances = append(instances, cloud.CloudInstance{Id: id, Status: instance.Status})
+	}
+	return instances, nil
+}
+
 func (mockMgr *MockCloudManager) CanSpawn() (bool, error) {
 	return true, nil
 }
@@ -129,6 +181,56 @@ func (mockMgr *MockCloudManager) TerminateInstance(host *host.Host) error {
 	return host.Terminate()
 }
 
+// Reboot checks that the instance exists and hasn't been terminated.
+func (mockMgr *MockCloudManager) Reboot(host *host.Host) error {
+	l := mockMgr.mutex
+	l.RLock()
+	defer l.RUnlock()
+	instance, ok := mockMgr.Instances[host.Id]
+	if !ok {
+		return fmt.Errorf("unable to fetch host: %v", host.Id)
+	}
+	if instance.Status == cloud.StatusTerminated {
+		return fmt.Errorf("Cannot reboot %v - already terminated", host.Id)
+	}
+	return nil
+}
+
+// GetConsoleOutput returns a line describing the instance's status.
+func (mockMgr *MockCloudManager) GetConsoleOutput(host *host.Host) (string, error) {
+	l := mockMgr.mutex
+	l.RLock()
+	defer l.RUnlock()
+	instance, ok := mockMgr.Instances[host.Id]
+	if !ok {
+		return "", fmt.Errorf("unable to fetch host: %v", host.Id)
+	}
+	return fmt.Sprintf("mock instance %v is %v\n", host.Id, instance.Status), nil
+}
+
+// CreateImage returns an image id made from the name.
+func (mockMgr *MockCloudManager) CreateImage(host *host.Host, name string) (string, error) {
+	l := mockMgr.mutex
+	l.RLock()
+	defer l.RUnlock()
+	if _, ok := mockMgr.Instances[host.Id]; !ok {
+		return "", fmt.Errorf("unable to fetch host: %v", host.Id)
+	}
+	return fmt.Sprintf("mock_image_%v", name), nil
+}
+
+// GetLaunchTime returns the launch time of the mock instance.
+func (mockMgr *MockCloudManager) GetLaunchTime(host *host.Host) (time.Time, error) {
+	l := mockMgr.mutex
+	l.RLock()
+	defer l.RUnlock()
+	instance, ok := mockMgr.Instances[host.Id]
+	if !ok {
+		return time.Time{}, fmt.Errorf("unable to fetch host: %v", host.Id)
+	}
+	return instance.LaunchTime, nil
+}
+
 func (mockMgr *MockCloudManager) Configure(settings *evergreen.Settings) error {
 	//no-op. maybe will need to load something from settings in the future.
 	return nil
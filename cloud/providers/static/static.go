@@ -57,6 +57,16 @@ func (staticMgr *StaticManager) GetDNSName(host *host.Host) (string, error) {
 	return host.Id, nil
 }
 
+// static hosts are identified by their name
+func (staticMgr *StaticManager) GetInstanceID(host *host.Host) (string, error) {
+	return host.Id, nil
+}
+
+// static hosts are never launched by Evergreen, so there are none to list
+func (staticMgr *StaticManager) ListInstances() ([]cloud.CloudInstance, error) {
+	return []cloud.CloudInstance{}, nil
+}
+
 func (staticMgr *StaticManager) CanSpawn() (bool, error) {
 	return false, nil
 }
@@ -75,6 +85,26 @@ func (staticMgr *StaticManager) TerminateInstance(host *host.Host) error {
 	return nil
 }
 
+// Reboot is not supported for static hosts, which Evergreen doesn't manage.
+func (staticMgr *StaticManager) Reboot(host *host.Host) error {
+	return cloud.ErrRebootUnsupported
+}
+
+// GetConsoleOutput is not supported for static hosts, which Evergreen doesn't manage.
+func (staticMgr *StaticManager) GetConsoleOutput(host *host.Host) (string, error) {
+	return "", cloud.ErrConsoleOutputUnsupported
+}
+
+// CreateImage is not supported for static hosts, which Evergreen doesn't manage.
+func (staticMgr *StaticManager) CreateImage(host *host.Host, name string) (string, error) {
+	return "", cloud.ErrCreateImageUnsupported
+}
+
+// GetLaunchTime is not supported for static hosts.
+func (staticMgr *StaticManager) GetLaunchTime(host *host.Host) (time.Time, error) {
+	return time.Time{}, cloud.ErrLaunchTimeUnsupported
+}
+
 func (_ *StaticManager) GetSettings() cloud.ProviderSettings {
 	return &Settings{}
 }
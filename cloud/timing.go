@@ -0,0 +1,105 @@
+package cloud
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// timingBuckets are the upper bounds of the buckets that call durations are counted
+// in. Durations longer than the last bound are only counted in the total.
+var timingBuckets = []time.Duration{
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+	time.Minute,
+	2 * time.Minute,
+}
+
+// CallTiming is a histogram of how long calls to one method of a provider's
+// CloudManager have taken.
+type CallTiming struct {
+	Provider string `json:"provider"`
+	Method   string `json:"method"`
+	Count    int64  `json:"count"`
+	// total time spent in the calls, in seconds
+	TotalSecs float64 `json:"total_secs"`
+	// the number of calls that took at most each bucket's duration, keyed by the
+	// duration in seconds
+	Buckets map[string]int64 `json:"buckets"`
+}
+
+type timingKey struct {
+	provider string
+	method   string
+}
+
+type timingHistogram struct {
+	count  int64
+	total  time.Duration
+	counts []int64
+}
+
+// timings holds the histograms recorded by this process. They aren't persisted or
+// shared between processes, and are lost on restart.
+var (
+	timingsMutex sync.Mutex
+	timings      = map[timingKey]*timingHistogram{}
+)
+
+// RecordCallTime records how long a call to the given method of a provider's
+// CloudManager took, measured from start until now. It is meant to be deferred at
+// the top of the method, with time.Now() as the start.
+func RecordCallTime(provider, method string, start time.Time) {
+	elapsed := time.Since(start)
+
+	timingsMutex.Lock()
+	defer timingsMutex.Unlock()
+	key := timingKey{provider, method}
+	hist, ok := timings[key]
+	if !ok {
+		hist = &timingHistogram{counts: make([]int64, len(timingBuckets))}
+		timings[key] = hist
+	}
+	hist.count++
+	hist.total += elapsed
+	for i, bound := range timingBuckets {
+		if elapsed <= bound {
+			hist.counts[i]++
+		}
+	}
+}
+
+// CallTimings returns the histograms recorded by this process for every provider and
+// method that it has called, sorted by provider and then method.
+func CallTimings() []CallTiming {
+	timingsMutex.Lock()
+	defer timingsMutex.Unlock()
+	result := make([]CallTiming, 0, len(timings))
+	for key, hist := range timings {
+		timing := CallTiming{
+			Provider:  key.provider,
+			Method:    key.method,
+			Count:     hist.count,
+			TotalSecs: hist.total.Seconds(),
+			Buckets:   map[string]int64{},
+		}
+		for i, bound := range timingBuckets {
+			timing.Buckets[strconv.FormatFloat(bound.Seconds(), 'f', -1, 64)] = hist.counts[i]
+		}
+		result = append(result, timing)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Provider != result[j].Provider {
+			return result[i].Provider < result[j].Provider
+		}
+		return result[i].Method < result[j].Method
+	})
+	return result
+}
@@ -0,0 +1,34 @@
+package cloud
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRecordCallTime(t *testing.T) {
+	Convey("With call times recorded for a provider", t, func() {
+		timings = map[timingKey]*timingHistogram{}
+		RecordCallTime("test", "SpawnInstance", time.Now())
+		RecordCallTime("test", "SpawnInstance", time.Now().Add(-3*time.Second))
+		RecordCallTime("test", "GetInstanceStatus", time.Now())
+
+		Convey("each method should have its own histogram", func() {
+			result := CallTimings()
+			So(len(result), ShouldEqual, 2)
+			So(result[0].Method, ShouldEqual, "GetInstanceStatus")
+			So(result[0].Count, ShouldEqual, 1)
+
+			spawn := result[1]
+			So(spawn.Provider, ShouldEqual, "test")
+			So(spawn.Method, ShouldEqual, "SpawnInstance")
+			So(spawn.Count, ShouldEqual, 2)
+			So(spawn.TotalSecs, ShouldBeGreaterThanOrEqualTo, 3)
+			So(spawn.Buckets["0.1"], ShouldEqual, 1)
+			So(spawn.Buckets["2.5"], ShouldEqual, 1)
+			So(spawn.Buckets["5"], ShouldEqual, 2)
+			So(spawn.Buckets["120"], ShouldEqual, 2)
+		})
+	})
+}
@@ -0,0 +1,72 @@
+package cloud
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/mongodb/grip"
+)
+
+// ErrWaitTimeout is returned when an instance doesn't reach the status waited for
+// before the timeout elapses.
+var ErrWaitTimeout = errors.New("timed out waiting for instance status")
+
+// the bounds of the interval between checks of an instance's status while waiting
+// on it; the interval doubles after each check
+var (
+	waitMinInterval = 2 * time.Second
+	waitMaxInterval = 30 * time.Second
+)
+
+// WaitForStatus polls the status of the host's instance until it reaches target,
+// backing off between checks, and returns the last status seen. If the instance
+// fails or is terminated first, an error is returned straight away; if the timeout
+// elapses first, ErrWaitTimeout is.
+func WaitForStatus(mgr CloudManager, h *host.Host, target CloudStatus, timeout time.Duration) (CloudStatus, error) {
+	status, err := WaitForStatusFunc(mgr, h, timeout, func(status CloudStatus) bool {
+		return status == target || status == StatusFailed || status == StatusTerminated
+	})
+	if err != nil {
+		return status, err
+	}
+	if status != target {
+		return status, fmt.Errorf("instance of host %v is %v, not %v", h.Id, status, target)
+	}
+	return status, nil
+}
+
+// WaitForStatusFunc polls the status of the host's instance, backing off between
+// checks, until done returns true for it or the timeout elapses, in which case
+// ErrWaitTimeout is returned. It returns the last status seen. Errors checking the
+// status are logged and the check retried.
+func WaitForStatusFunc(mgr CloudManager, h *host.Host, timeout time.Duration,
+	done func(CloudStatus) bool) (CloudStatus, error) {
+	deadline := time.Now().Add(timeout)
+	interval := waitMinInterval
+	status := StatusUnknown
+	for {
+		current, err := mgr.GetInstanceStatus(h)
+		if err != nil {
+			grip.Warningf("Error checking the instance status of host %s: %+v", h.Id, err)
+		} else {
+			status = current
+			if done(status) {
+				return status, nil
+			}
+		}
+
+		remaining := deadline.Sub(time.Now())
+		if remaining <= 0 {
+			return status, ErrWaitTimeout
+		}
+		if interval > remaining {
+			interval = remaining
+		}
+		time.Sleep(interval)
+		if interval *= 2; interval > waitMaxInterval {
+			interval = waitMaxInterval
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package cloud
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/model/host"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// statusSequenceManager is a CloudManager whose instances go through a fixed
+// sequence of statuses, one per check, staying in the last one.
+type statusSequenceManager struct {
+	CloudManager
+	statuses []CloudStatus
+	errs     []error
+	checks   int
+}
+
+func (m *statusSequenceManager) GetInstanceStatus(*host.Host) (CloudStatus, error) {
+	i := m.checks
+	if i >= len(m.statuses) {
+		i = len(m.statuses) - 1
+	}
+	m.checks++
+	if i < len(m.errs) && m.errs[i] != nil {
+		return StatusUnknown, m.errs[i]
+	}
+	return m.statuses[i], nil
+}
+
+func TestWaitForStatus(t *testing.T) {
+	Convey("With short intervals between status checks", t, func() {
+		oldMin, oldMax := waitMinInterval, waitMaxInterval
+		waitMinInterval, waitMaxInterval = time.Millisecond, 2*time.Millisecond
+		defer func() { waitMinInterval, waitMaxInterval = oldMin, oldMax }()
+		h := &host.Host{Id: "h1"}
+
+		Convey("waiting should return once the instance reaches the status", func() {
+			mgr := &statusSequenceManager{
+				statuses: []CloudStatus{StatusPending, StatusUnknown, StatusInitializing, StatusRunning},
+				errs:     []error{nil, errors.New("api error")},
+			}
+			status, err := WaitForStatus(mgr, h, StatusRunning, time.Minute)
+			So(err, ShouldBeNil)
+			So(status, ShouldEqual, StatusRunning)
+			So(mgr.checks, ShouldEqual, 4)
+		})
+		Convey("waiting should fail once the instance fails", func() {
+			mgr := &statusSequenceManager{statuses: []CloudStatus{StatusPending, StatusFailed}}
+			status, err := WaitForStatus(mgr, h, StatusRunning, time.Minute)
+			So(err, ShouldNotBeNil)
+			So(err, ShouldNotEqual, ErrWaitTimeout)
+			So(status, ShouldEqual, StatusFailed)
+		})
+		Convey("waiting should time out if the instance never reaches the status", func() {
+			mgr := &statusSequenceManager{statuses: []CloudStatus{StatusPending}}
+			status, err := WaitForStatus(mgr, h, StatusRunning, 10*time.Millisecond)
+			So(err, ShouldEqual, ErrWaitTimeout)
+			So(status, ShouldEqual, StatusPending)
+		})
+	})
+}
@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"regexp"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
@@ -84,6 +87,183 @@ type APIConfig struct {
 	HttpsListenAddr string
 	HttpsKey        string
 	HttpsCert       string
+
+	// DisableHTTP2 turns off HTTP/2 negotiation on the TLS listener, so that all
+	// connections use HTTP/1.1. Intended for debugging.
+	DisableHTTP2 bool `yaml:"disable_http2"`
+
+	// MaxConnections caps the number of concurrent connections accepted by each of
+	// the API server's listeners. Zero or less means no limit is enforced.
+	MaxConnections int `yaml:"max_connections"`
+
+	// PluginRequestLimits bounds requests to the routes installed by API plugins.
+	// Limits for a specific plugin may be set in PluginRequestLimitsByName, keyed
+	// by plugin name, and take precedence over these.
+	PluginRequestLimits       RequestLimits            `yaml:"plugin_request_limits"`
+	PluginRequestLimitsByName map[string]RequestLimits `yaml:"plugin_request_limits_by_name"`
+
+	// ProjectRateLimits bounds how often requests about each project may be made to
+	// the REST routes. Limits for a specific project may be set in
+	// ProjectRateLimitsByProject, keyed by project identifier, and take precedence
+	// over these.
+	ProjectRateLimits          RateLimit            `yaml:"project_rate_limits"`
+	ProjectRateLimitsByProject map[string]RateLimit `yaml:"project_rate_limits_by_project"`
+
+	// HostRateLimits bounds how often each host's agent may make requests to the
+	// agent routes.
+	HostRateLimits RateLimit `yaml:"host_rate_limits"`
+
+	// MaxTaskPriorityByUser and MaxTaskPriorityByProject override the highest task
+	// priority, MaxTaskPriority by default, that users may set through the API. A
+	// user's limit takes precedence over the limit for the task's project.
+	MaxTaskPriorityByUser    map[string]int64 `yaml:"max_task_priority_by_user"`
+	MaxTaskPriorityByProject map[string]int64 `yaml:"max_task_priority_by_project"`
+
+	// MaxProcessInfoEntries caps the number of processes accepted in one report
+	// of a task's process info; any beyond it are dropped. Zero or less means
+	// DefaultMaxProcessInfoEntries.
+	MaxProcessInfoEntries int `yaml:"max_process_info_entries"`
+
+	// AbortDelayWindowSecs is the window over which the agents of aborted tasks are
+	// told to randomly delay ending their tasks, so that tasks aborted together
+	// don't all end at once. Zero means DefaultAbortDelayWindowSecs; less than
+	// zero means agents end aborted tasks immediately.
+	AbortDelayWindowSecs int `yaml:"abort_delay_window_secs"`
+
+	// NoOutputTimeoutSecs is how long a running task may go without sending any log
+	// output before it is aborted at its next heartbeat. Zero means tasks are never
+	// aborted for a lack of output.
+	NoOutputTimeoutSecs int `yaml:"no_output_timeout_secs"`
+
+	// MaxTaskPrefetch is the most tasks an agent may be given by one request for its
+	// next task, to run one after another. The tasks after the first are reserved for
+	// the agent's host. Zero or one means agents are given one task at a time.
+	MaxTaskPrefetch int `yaml:"max_task_prefetch"`
+
+	// TaskPollMinSecs and TaskPollMaxSecs bound the interval that agents are told to
+	// wait before asking again for a task when none is available. The interval grows
+	// from the minimum toward the maximum the longer the distro's queue has been
+	// empty. Zero means DefaultTaskPollMinSecs and DefaultTaskPollMaxSecs.
+	TaskPollMinSecs int `yaml:"task_poll_min_secs"`
+	TaskPollMaxSecs int `yaml:"task_poll_max_secs"`
+
+	// TestLogOverflow is what is done with test logs larger than the API server
+	// accepts: one of TestLogOverflowModes. Empty means TestLogOverflowReject.
+	TestLogOverflow string `yaml:"test_log_overflow"`
+
+	// ArtifactSigning configures signing links to tasks' artifacts in S3. Links
+	// aren't signed unless it's set.
+	ArtifactSigning ArtifactSigningConfig `yaml:"artifact_signing"`
+}
+
+// ArtifactSigningConfig holds the credentials that links to task artifacts in S3
+// are signed with, and the buckets that links may be signed for. The credentials
+// should only be able to read from those buckets.
+type ArtifactSigningConfig struct {
+	Key     string   `yaml:"key"`
+	Secret  string   `yaml:"secret"`
+	Buckets []string `yaml:"buckets"`
+}
+
+const (
+	// TestLogOverflowReject rejects test logs that are too large.
+	TestLogOverflowReject = "reject"
+	// TestLogOverflowTruncate stores as many of the lines of test logs that are too
+	// large as fit, followed by a line noting that the log was truncated.
+	TestLogOverflowTruncate = "truncate"
+)
+
+// TestLogOverflowModes are the valid values of APIConfig.TestLogOverflow.
+var TestLogOverflowModes = []string{TestLogOverflowReject, TestLogOverflowTruncate}
+
+// RequestLimits bounds the size and duration of requests handled by the API server.
+// A zero value for either field means no limit is enforced.
+type RequestLimits struct {
+	MaxBodyBytes   int64 `yaml:"max_body_bytes"`
+	TimeoutSeconds int   `yaml:"timeout_seconds"`
+}
+
+// PluginLimits returns the request limits that apply to the API plugin with the given name.
+func (c *APIConfig) PluginLimits(name string) RequestLimits {
+	if limits, ok := c.PluginRequestLimitsByName[name]; ok {
+		return limits
+	}
+	return c.PluginRequestLimits
+}
+
+// RateLimit bounds how many requests a client may make in each period. A zero
+// value for either field means no limit is enforced.
+type RateLimit struct {
+	MaxRequests   int `yaml:"max_requests"`
+	PeriodSeconds int `yaml:"period_seconds"`
+}
+
+// ProjectRateLimit returns the rate limit that applies to REST requests about the
+// project with the given identifier.
+func (c *APIConfig) ProjectRateLimit(identifier string) RateLimit {
+	if limit, ok := c.ProjectRateLimitsByProject[identifier]; ok {
+		return limit
+	}
+	return c.ProjectRateLimits
+}
+
+// TaskPriorityLimit returns the highest priority the given user may set on tasks
+// in the given project.
+func (c *APIConfig) TaskPriorityLimit(userId, projectId string) int64 {
+	if limit, ok := c.MaxTaskPriorityByUser[userId]; ok {
+		return limit
+	}
+	if limit, ok := c.MaxTaskPriorityByProject[projectId]; ok {
+		return limit
+	}
+	return MaxTaskPriority
+}
+
+// ProcessInfoLimit returns the maximum number of processes accepted in one report
+// of a task's process info.
+func (c *APIConfig) ProcessInfoLimit() int {
+	if c.MaxProcessInfoEntries > 0 {
+		return c.MaxProcessInfoEntries
+	}
+	return DefaultMaxProcessInfoEntries
+}
+
+// AbortDelayWindow returns the window over which aborted tasks' agents are told to
+// delay ending their tasks.
+func (c *APIConfig) AbortDelayWindow() time.Duration {
+	switch {
+	case c.AbortDelayWindowSecs < 0:
+		return 0
+	case c.AbortDelayWindowSecs == 0:
+		return DefaultAbortDelayWindowSecs * time.Second
+	default:
+		return time.Duration(c.AbortDelayWindowSecs) * time.Second
+	}
+}
+
+// NoOutputTimeout returns how long a running task may go without log output
+// before it is aborted, or zero if tasks are never aborted for it.
+func (c *APIConfig) NoOutputTimeout() time.Duration {
+	if c.NoOutputTimeoutSecs <= 0 {
+		return 0
+	}
+	return time.Duration(c.NoOutputTimeoutSecs) * time.Second
+}
+
+// TaskPollIntervals returns the bounds of the interval agents are told to wait
+// before asking again for a task when none is available.
+func (c *APIConfig) TaskPollIntervals() (time.Duration, time.Duration) {
+	min, max := c.TaskPollMinSecs, c.TaskPollMaxSecs
+	if min <= 0 {
+		min = DefaultTaskPollMinSecs
+	}
+	if max <= 0 {
+		max = DefaultTaskPollMaxSecs
+	}
+	if max < min {
+		max = min
+	}
+	return time.Duration(min) * time.Second, time.Duration(max) * time.Second
 }
 
 // UIConfig holds relevant settings for the UI server.
@@ -108,9 +288,18 @@ type UIConfig struct {
 	SecureCookies bool
 }
 
-// MonitorConfig holds logging settings for the monitor process.
+// MonitorConfig holds logging and host termination settings for the monitor process.
 type MonitorConfig struct {
 	LogFile string
+	// MaxTimeTilNextPaymentSecs is how close an idle host must be to the end of
+	// its paid interval before it is terminated.
+	MaxTimeTilNextPaymentSecs int64
+	// QueueWaitSLASecs is how long a task may wait in a distro's queue before an
+	// event is logged for it. Zero disables the check.
+	QueueWaitSLASecs int64
+	// MaxIdleExemptionHours is the longest a spawn host's owner may exempt it from
+	// being terminated for being idle.
+	MaxIdleExemptionHours int64
 }
 
 // RunnerConfig holds logging and timing settings for the runner process.
@@ -123,6 +312,22 @@ type RunnerConfig struct {
 type HostInitConfig struct {
 	LogFile           string
 	SSHTimeoutSeconds int64
+
+	// EventDrivenProviders lists the providers that notify Evergreen of instance
+	// state changes, so hostinit waits for those notifications instead of polling
+	// the status of their instances.
+	EventDrivenProviders []string `yaml:"event_driven_providers"`
+}
+
+// IsEventDriven returns true if hostinit should wait to be notified of the
+// state changes of the provider's instances rather than poll them.
+func (c HostInitConfig) IsEventDriven(provider string) bool {
+	for _, p := range c.EventDrivenProviders {
+		if p == provider {
+			return true
+		}
+	}
+	return false
 }
 
 // NotifyConfig hold logging and email settings for the notify package.
@@ -157,12 +362,54 @@ type TaskRunnerConfig struct {
 type CloudProviders struct {
 	AWS          AWSConfig          `yaml:"aws"`
 	DigitalOcean DigitalOceanConfig `yaml:"digitalocean"`
+
+	// Allowed lists the providers that hosts may be spawned with, regardless of
+	// the distros' settings. If empty, all providers are allowed.
+	Allowed []string `yaml:"allowed"`
+}
+
+// IsAllowed returns true if hosts may be spawned with the given provider.
+func (c *CloudProviders) IsAllowed(providerName string) bool {
+	if len(c.Allowed) == 0 {
+		return true
+	}
+	for _, allowed := range c.Allowed {
+		if allowed == providerName {
+			return true
+		}
+	}
+	return false
 }
 
 // AWSConfig stores auth info for Amazon Web Services.
 type AWSConfig struct {
 	Secret string `yaml:"aws_secret"`
 	Id     string `yaml:"aws_id"`
+
+	// SecurityGroups are applied to EC2 instances of distros that don't specify
+	// their own security groups.
+	SecurityGroups []string `yaml:"security_groups"`
+
+	// Accounts are additional AWS accounts that distros can start their hosts in
+	// by name, instead of the account above.
+	Accounts []AWSAccount `yaml:"accounts"`
+}
+
+// AWSAccount stores auth info for a named AWS account.
+type AWSAccount struct {
+	Name   string `yaml:"name"`
+	Secret string `yaml:"aws_secret"`
+	Id     string `yaml:"aws_id"`
+}
+
+// FindAccount returns the named AWS account, or nil if it is not configured.
+func (c *AWSConfig) FindAccount(name string) *AWSAccount {
+	for i := range c.Accounts {
+		if c.Accounts[i].Name == name {
+			return &c.Accounts[i]
+		}
+	}
+	return nil
 }
 
 // DigitalOceanConfig stores auth info for Digital Ocean.
@@ -199,8 +446,17 @@ type DBSettings struct {
 	SSL                  bool         `yaml:"ssl"`
 	DB                   string       `yaml:"db"`
 	WriteConcernSettings WriteConcern `yaml:"write_concern"`
+
+	// ReadPreference is the consistency mode of queries that can tolerate slightly
+	// stale data, so that they may be served by a secondary: "monotonic" or
+	// "eventual" (see mgo's Session.SetMode). If empty, all queries read from
+	// the primary.
+	ReadPreference string `yaml:"read_preference"`
 }
 
+// ReadPreferences are the valid values of DBSettings.ReadPreference.
+var ReadPreferences = []string{"monotonic", "eventual"}
+
 // Settings contains all configuration settings for running Evergreen.
 type Settings struct {
 	Database            DBSettings        `yaml:"database"`
@@ -228,6 +484,55 @@ type Settings struct {
 	Expansions          map[string]string `yaml:"expansions"`
 	Plugins             PluginConfig      `yaml:"plugins"`
 	IsProd              bool              `yaml:"isprod"`
+
+	// HostDNSFormat, if set, is the name hosts are referred to by in links and
+	// messages, in place of their provider's DNS name; e.g.
+	// "${host_id}.hosts.example.com". See HostDNSVariables.
+	HostDNSFormat string `yaml:"host_dns_format"`
+
+	// TaskExpansions lists the keys of Expansions that tasks are given. The rest of
+	// the deployment's expansions, which may be secrets, are kept from tasks.
+	TaskExpansions []string `yaml:"task_expansions"`
+
+	// LoggedHeaders lists the request headers the API and UI servers include in
+	// their access logs. The values of headers carrying credentials, such as task
+	// and host secrets, are always redacted.
+	LoggedHeaders []string `yaml:"logged_headers"`
+
+	// loadedAt is when the settings were read from their file, if they were
+	loadedAt time.Time
+}
+
+// LoadedAt returns when the settings were read from their file, or the zero time
+// if they weren't.
+func (settings *Settings) LoadedAt() time.Time {
+	return settings.loadedAt
+}
+
+// HostDNSVariables are the variables that may be used in HostDNSFormat: the host's
+// id, its distro's id, its DNS name from its provider, and the first label of
+// that DNS name.
+var HostDNSVariables = []string{"host_id", "distro_id", "dns_name", "dns_prefix"}
+
+// hostDNSVariable matches a variable in HostDNSFormat.
+var hostDNSVariable = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// HostDNSName returns the name a host is referred to by in links and messages,
+// which is its provider's DNS name unless HostDNSFormat is set. Hosts without a
+// DNS name yet don't have one.
+func (settings *Settings) HostDNSName(hostId, distroId, dnsName string) string {
+	if settings.HostDNSFormat == "" || dnsName == "" {
+		return dnsName
+	}
+	values := map[string]string{
+		"host_id":    hostId,
+		"distro_id":  distroId,
+		"dns_name":   dnsName,
+		"dns_prefix": strings.SplitN(dnsName, ".", 2)[0],
+	}
+	return hostDNSVariable.ReplaceAllStringFunc(settings.HostDNSFormat, func(variable string) string {
+		return values[hostDNSVariable.FindStringSubmatch(variable)[1]]
+	})
 }
 
 // NewSettings builds an in-memory representation of the given settings file.
@@ -241,6 +546,7 @@ func NewSettings(filename string) (*Settings, error) {
 	if err != nil {
 		return nil, err
 	}
+	settings.loadedAt = time.Now()
 	return settings, nil
 }
 
@@ -296,6 +602,49 @@ var ConfigValidationRules = []ConfigValidator{
 		return nil
 	},
 
+	func(settings *Settings) error {
+		if settings.Database.ReadPreference == "" {
+			return nil
+		}
+		for _, pref := range ReadPreferences {
+			if settings.Database.ReadPreference == pref {
+				return nil
+			}
+		}
+		return fmt.Errorf("Invalid read preference '%v'; must be one of %v",
+			settings.Database.ReadPreference, ReadPreferences)
+	},
+
+	func(settings *Settings) error {
+		if settings.Api.TestLogOverflow == "" {
+			return nil
+		}
+		for _, mode := range TestLogOverflowModes {
+			if settings.Api.TestLogOverflow == mode {
+				return nil
+			}
+		}
+		return fmt.Errorf("Invalid test log overflow mode '%v'; must be one of %v",
+			settings.Api.TestLogOverflow, TestLogOverflowModes)
+	},
+
+	func(settings *Settings) error {
+		used := map[string]bool{}
+		for _, account := range settings.Providers.AWS.Accounts {
+			if account.Name == "" {
+				return fmt.Errorf("AWS account names must not be blank")
+			}
+			if used[account.Name] {
+				return fmt.Errorf("Duplicate AWS account '%v'", account.Name)
+			}
+			used[account.Name] = true
+			if account.Id == "" || account.Secret == "" {
+				return fmt.Errorf("AWS ID/Secret of account '%v' must not be blank", account.Name)
+			}
+		}
+		return nil
+	},
+
 	func(settings *Settings) error {
 		if settings.ApiUrl == "" {
 			return fmt.Errorf("API hostname must not be empty")
@@ -353,6 +702,20 @@ var ConfigValidationRules = []ConfigValidator{
 		return nil
 	},
 
+	func(settings *Settings) error {
+		known := map[string]bool{}
+		for _, variable := range HostDNSVariables {
+			known[variable] = true
+		}
+		for _, match := range hostDNSVariable.FindAllStringSubmatch(settings.HostDNSFormat, -1) {
+			if !known[match[1]] {
+				return fmt.Errorf("host_dns_format uses unknown variable '%v'; variables are %v",
+					match[1], HostDNSVariables)
+			}
+		}
+		return nil
+	},
+
 	func(settings *Settings) error {
 		if settings.AuthConfig.Crowd == nil && settings.AuthConfig.Naive == nil && settings.AuthConfig.Github == nil {
 			return fmt.Errorf("You must specify one form of authentication")
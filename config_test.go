@@ -11,9 +11,10 @@ import (
 //and returns a settings object.
 func TestInitSettings(t *testing.T) {
 	Convey("Parsing a valid settings file should succeed", t, func() {
-		_, err := NewSettings(filepath.Join(FindEvergreenHome(),
+		settings, err := NewSettings(filepath.Join(FindEvergreenHome(),
 			"testdata", "mci_settings.yml"))
 		So(err, ShouldBeNil)
+		So(settings.LoadedAt().IsZero(), ShouldBeFalse)
 	})
 }
 
@@ -25,3 +26,21 @@ func TestBadInit(t *testing.T) {
 		So(err, ShouldNotBeNil)
 	})
 }
+
+func TestHostDNSName(t *testing.T) {
+	Convey("With settings that alias host DNS names", t, func() {
+		settings := &Settings{HostDNSFormat: "${dns_prefix}-${distro_id}.hosts.example.com"}
+
+		Convey("the alias should be built from the host's names", func() {
+			So(settings.HostDNSName("h1", "ubuntu", "ec2-1-2-3-4.compute-1.amazonaws.com"),
+				ShouldEqual, "ec2-1-2-3-4-ubuntu.hosts.example.com")
+		})
+		Convey("hosts without a DNS name should not get an alias", func() {
+			So(settings.HostDNSName("h1", "ubuntu", ""), ShouldEqual, "")
+		})
+		Convey("without a format, the provider's DNS name should be used", func() {
+			settings.HostDNSFormat = ""
+			So(settings.HostDNSName("h1", "ubuntu", "ec2.amazonaws.com"), ShouldEqual, "ec2.amazonaws.com")
+		})
+	})
+}
@@ -28,6 +28,31 @@ func Insert(collection string, item interface{}) error {
 	return db.C(collection).Insert(item)
 }
 
+// InsertMany inserts all of the specified items into the specified collection
+// in a single batch.
+func InsertMany(collection string, items ...interface{}) error {
+	if len(items) == 0 {
+		return nil
+	}
+	session, db, err := GetGlobalSessionFactory().GetSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	return db.C(collection).Insert(items...)
+}
+
+// Ping verifies that the database can be reached with the global session provider.
+func Ping() error {
+	session, _, err := GetGlobalSessionFactory().GetSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+	return session.Ping()
+}
+
 // Clear removes all documents from a specified collection.
 func Clear(collection string) error {
 	session, db, err := GetGlobalSessionFactory().GetSession()
@@ -103,8 +128,13 @@ func RemoveAll(collection string, query interface{}) error {
 // provided interface, which must be a pointer.
 func FindOne(collection string, query interface{},
 	projection interface{}, sort []string, out interface{}) error {
+	return findOne(false, collection, query, projection, sort, out)
+}
 
-	session, db, err := GetGlobalSessionFactory().GetSession()
+func findOne(secondaryOk bool, collection string, query interface{},
+	projection interface{}, sort []string, out interface{}) error {
+
+	session, db, err := getReadSession(secondaryOk)
 	if err != nil {
 		grip.Errorf("error establishing db connection: %+v", err)
 		return err
@@ -123,8 +153,14 @@ func FindOne(collection string, query interface{},
 func FindAll(collection string, query interface{},
 	projection interface{}, sort []string, skip int, limit int,
 	out interface{}) error {
+	return findAll(false, collection, query, projection, sort, skip, limit, out)
+}
+
+func findAll(secondaryOk bool, collection string, query interface{},
+	projection interface{}, sort []string, skip int, limit int,
+	out interface{}) error {
 
-	session, db, err := GetGlobalSessionFactory().GetSession()
+	session, db, err := getReadSession(secondaryOk)
 	if err != nil {
 		grip.Errorf("error establishing db connection: %+v", err)
 
@@ -200,8 +236,12 @@ func Upsert(collection string, query interface{},
 
 // Count run a count command with the specified query against the collection.
 func Count(collection string, query interface{}) (int, error) {
+	return count(false, collection, query)
+}
 
-	session, db, err := GetGlobalSessionFactory().GetSession()
+func count(secondaryOk bool, collection string, query interface{}) (int, error) {
+
+	session, db, err := getReadSession(secondaryOk)
 	if err != nil {
 		grip.Errorf("error establishing db connection: %+v", err)
 
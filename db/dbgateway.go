@@ -25,8 +25,11 @@ type SessionFactory struct {
 	dialTimeout   time.Duration
 	socketTimeout time.Duration
 	safety        mgo.Safe
-	dialLock      sync.Mutex
-	masterSession *mgo.Session
+	// readPreference is the consistency mode of sessions for queries that may
+	// read from a secondary; if empty, they read from the primary like any other.
+	readPreference string
+	dialLock       sync.Mutex
+	masterSession  *mgo.Session
 }
 
 // SessionProvider returns mgo Sessions for database interaction.
@@ -34,6 +37,13 @@ type SessionProvider interface {
 	GetSession() (*mgo.Session, *mgo.Database, error)
 }
 
+// ReadSessionProvider is a SessionProvider that can also return sessions for queries
+// that may be served by a secondary.
+type ReadSessionProvider interface {
+	SessionProvider
+	GetReadSession() (*mgo.Session, *mgo.Database, error)
+}
+
 // SessionFactoryFromConfig creates a usable SessionFactory from
 // the Evergreen settings.
 func SessionFactoryFromConfig(settings *evergreen.Settings) *SessionFactory {
@@ -43,7 +53,9 @@ func SessionFactoryFromConfig(settings *evergreen.Settings) *SessionFactory {
 	safety.WTimeout = settings.Database.WriteConcernSettings.WTimeout
 	safety.FSync = settings.Database.WriteConcernSettings.FSync
 	safety.J = settings.Database.WriteConcernSettings.J
-	return NewSessionFactory(settings.Database.Url, settings.Database.DB, settings.Database.SSL, safety, defaultDialTimeout)
+	sf := NewSessionFactory(settings.Database.Url, settings.Database.DB, settings.Database.SSL, safety, defaultDialTimeout)
+	sf.readPreference = settings.Database.ReadPreference
+	return sf
 }
 
 // NewSessionFactory returns a new session factory pointed at the given URL/DB combo,
@@ -96,6 +108,33 @@ func (sf *SessionFactory) GetSession() (*mgo.Session, *mgo.Database, error) {
 	return sessionCopy, sessionCopy.DB(sf.db), nil
 }
 
+// GetReadSession returns a session for queries that may read from a secondary,
+// using the factory's read preference. If it has none, the session reads from the
+// primary, just like one from GetSession.
+func (sf *SessionFactory) GetReadSession() (*mgo.Session, *mgo.Database, error) {
+	session, db, err := sf.GetSession()
+	if err != nil {
+		return nil, nil, err
+	}
+	switch sf.readPreference {
+	case "monotonic":
+		session.SetMode(mgo.Monotonic, true)
+	case "eventual":
+		session.SetMode(mgo.Eventual, true)
+	}
+	return session, db, nil
+}
+
+// getReadSession returns a session from the global session provider for a query
+// that may read from a secondary if secondaryOk is set and the provider supports it.
+func getReadSession(secondaryOk bool) (*mgo.Session, *mgo.Database, error) {
+	provider := GetGlobalSessionFactory()
+	if rsp, ok := provider.(ReadSessionProvider); ok && secondaryOk {
+		return rsp.GetReadSession()
+	}
+	return provider.GetSession()
+}
+
 // SetGlobalSessionProvider sets the global session provider.
 func SetGlobalSessionProvider(sessionProvider SessionProvider) {
 	globalSessionProvider = sessionProvider
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"math/rand"
 	"time"
 
 	"gopkg.in/mgo.v2"
@@ -19,6 +20,10 @@ type Lock struct {
 	Locked   bool      `bson:"locked"`
 	LockedBy string    `bson:"locked_by"`
 	LockedAt time.Time `bson:"locked_at"`
+
+	// a description of what the lock is held for, such as the task and operation,
+	// for diagnosing locks that are held too long
+	Holder string `bson:"holder,omitempty"`
 }
 
 // InitializeGlobalLock should be called once, at program initialization.
@@ -71,8 +76,51 @@ func WaitTillAcquireGlobalLock(id string, timeoutMS time.Duration) (bool, error)
 	}
 }
 
+// WaitTillAcquireGlobalLockWithBackoff tries to acquire the given database lock
+// until timeout has passed, like WaitTillAcquireGlobalLock, but sleeps for an
+// exponentially growing and randomly jittered interval between attempts, so that
+// contenders that fail at the same time don't all retry at the same time. The
+// holder, describing what the lock is for, is recorded with the lock. Returns
+// whether or not the lock was acquired.
+func WaitTillAcquireGlobalLockWithBackoff(id, holder string, timeout, minSleep, maxSleep time.Duration) (bool, error) {
+	deadline := time.Now().Add(timeout)
+	for attempt := 0; ; attempt++ {
+		acquired, err := acquireGlobalLock(id, holder)
+		if err != nil {
+			return false, err
+		}
+		if acquired {
+			return true, nil
+		}
+
+		sleep := lockBackoff(attempt, minSleep, maxSleep)
+		if time.Now().Add(sleep).After(deadline) {
+			return false, nil
+		}
+		time.Sleep(sleep)
+	}
+}
+
+// lockBackoff returns how long to sleep after the given (zero-based) failed attempt
+// to acquire a lock: a random duration between half and all of minSleep doubled
+// once per attempt, capped at maxSleep.
+func lockBackoff(attempt int, minSleep, maxSleep time.Duration) time.Duration {
+	ceiling := minSleep
+	for i := 0; i < attempt && ceiling < maxSleep; i++ {
+		ceiling *= 2
+	}
+	if ceiling > maxSleep {
+		ceiling = maxSleep
+	}
+	half := ceiling / 2
+	if half <= 0 {
+		return ceiling
+	}
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
 // attempt to acquire the global lock of no one has it
-func setDocumentLocked(id string, upsert bool) (bool, error) {
+func setDocumentLocked(id, holder string, upsert bool) (bool, error) {
 	session, db, err := GetGlobalSessionFactory().GetSession()
 	if err != nil {
 		return false, err
@@ -98,6 +146,7 @@ func setDocumentLocked(id string, upsert bool) (bool, error) {
 			"locked":    true,
 			"locked_by": id,
 			"locked_at": time.Now(),
+			"holder":    holder,
 		}},
 		Upsert:    upsert,
 		ReturnNew: true,
@@ -118,14 +167,18 @@ func setDocumentLocked(id string, upsert bool) (bool, error) {
 // no one has it or it's timed out. Returns a boolean indicating
 // whether the lock was acquired.
 func AcquireGlobalLock(id string) (bool, error) {
-	acquired, err := setDocumentLocked(id, false)
+	return acquireGlobalLock(id, "")
+}
+
+func acquireGlobalLock(id, holder string) (bool, error) {
+	acquired, err := setDocumentLocked(id, holder, false)
 
 	if err == mgo.ErrNotFound {
 		// in the case where no lock document exists
 		// this will return a duplicate key error if
 		// another lock contender grabs the lock before
 		// we are able to
-		acquired, err = setDocumentLocked(id, true)
+		acquired, err = setDocumentLocked(id, holder, true)
 
 		// since we're upserting now, don't
 		// return any duplicate key errors
@@ -148,6 +201,25 @@ func ReleaseGlobalLock(id string) error {
 	// will return mgo.ErrNotFound if the lock expired
 	return db.C(LockCollection).Update(
 		bson.M{"_id": GlobalLockId, "locked_by": id},
-		bson.M{"$set": bson.M{"locked": false}},
+		bson.M{"$set": bson.M{"locked": false}, "$unset": bson.M{"holder": 1}},
 	)
 }
+
+// FindGlobalLock returns the global lock, or nil if it hasn't been created.
+func FindGlobalLock() (*Lock, error) {
+	session, db, err := GetGlobalSessionFactory().GetSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	lock := &Lock{}
+	err = db.C(LockCollection).Find(bson.M{"_id": GlobalLockId}).One(lock)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
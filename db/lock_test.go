@@ -0,0 +1,34 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLockBackoff(t *testing.T) {
+	Convey("With lock backoff between 100ms and 1s", t, func() {
+		minSleep, maxSleep := 100*time.Millisecond, time.Second
+
+		Convey("the sleep should grow with each attempt, with jitter", func() {
+			for attempt, ceiling := range []time.Duration{
+				100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond,
+			} {
+				for i := 0; i < 20; i++ {
+					sleep := lockBackoff(attempt, minSleep, maxSleep)
+					So(sleep, ShouldBeGreaterThanOrEqualTo, ceiling/2)
+					So(sleep, ShouldBeLessThanOrEqualTo, ceiling)
+				}
+			}
+		})
+
+		Convey("the sleep should never exceed the maximum", func() {
+			for _, attempt := range []int{4, 10, 100} {
+				sleep := lockBackoff(attempt, minSleep, maxSleep)
+				So(sleep, ShouldBeGreaterThanOrEqualTo, maxSleep/2)
+				So(sleep, ShouldBeLessThanOrEqualTo, maxSleep)
+			}
+		})
+	})
+}
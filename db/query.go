@@ -7,6 +7,8 @@ type Q struct {
 	sort       []string
 	skip       int
 	limit      int
+	// secondaryOk is set if the query may read from a secondary
+	secondaryOk bool
 }
 
 // Query creates a db.Q for the given MongoDB query. The filter
@@ -58,10 +60,20 @@ func (q Q) Limit(limit int) Q {
 	return q
 }
 
+// SecondaryOk marks the query as able to tolerate slightly stale data, so that it
+// may be served by a secondary if the database settings have a read preference.
+// Queries that need to see the results of recent writes, such as those made
+// while handling agent requests, must not be marked.
+func (q Q) SecondaryOk() Q {
+	q.secondaryOk = true
+	return q
+}
+
 // FindOneQ runs a Q query against the given collection, applying the results to "out."
 // Only reads one document from the DB.
 func FindOneQ(collection string, q Q, out interface{}) error {
-	return FindOne(
+	return findOne(
+		q.secondaryOk,
 		collection,
 		q.filter,
 		q.projection,
@@ -72,7 +84,8 @@ func FindOneQ(collection string, q Q, out interface{}) error {
 
 // FindAllQ runs a Q query against the given collection, applying the results to "out."
 func FindAllQ(collection string, q Q, out interface{}) error {
-	return FindAll(
+	return findAll(
+		q.secondaryOk,
 		collection,
 		q.filter,
 		q.projection,
@@ -85,7 +98,7 @@ func FindAllQ(collection string, q Q, out interface{}) error {
 
 // CountQ runs a Q count query against the given collection.
 func CountQ(collection string, q Q) (int, error) {
-	return Count(collection, q.filter)
+	return count(q.secondaryOk, collection, q.filter)
 }
 
 //RemoveAllQ removes all docs that satisfy the query
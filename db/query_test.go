@@ -85,6 +85,17 @@ func TestQueryExecution(t *testing.T) {
 				So(err, ShouldBeNil)
 				So(out[0]["three"], ShouldEqual, "COOL")
 			})
+
+			Convey("a query allowed to read from a secondary should return the same documents", func() {
+				out := []insertableStruct{}
+				err := FindAllQ(collection, BelowFiveSorted.SecondaryOk(), &out)
+				So(err, ShouldBeNil)
+				So(len(out), ShouldEqual, 4)
+				So(out[0].FieldTwo, ShouldEqual, 4)
+				count, err := CountQ(collection, BelowFive.SecondaryOk())
+				So(err, ShouldBeNil)
+				So(count, ShouldEqual, 4)
+			})
 		})
 	})
 }
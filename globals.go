@@ -12,14 +12,15 @@ import (
 const (
 	User = "mci"
 
-	HostRunning         = "running"
-	HostTerminated      = "terminated"
-	HostUninitialized   = "starting"
-	HostInitializing    = "provisioning"
-	HostProvisionFailed = "provision failed"
-	HostUnreachable     = "unreachable"
-	HostQuarantined     = "quarantined"
-	HostDecommissioned  = "decommissioned"
+	HostRunning           = "running"
+	HostTerminated        = "terminated"
+	HostUninitialized     = "starting"
+	HostInitializing      = "provisioning"
+	HostProvisionFailed   = "provision failed"
+	HostUnreachable       = "unreachable"
+	HostQuarantined       = "quarantined"
+	HostDecommissioned    = "decommissioned"
+	HostTerminationFailed = "termination failed"
 
 	HostStatusSuccess = "success"
 	HostStatusFailed  = "failed"
@@ -55,6 +56,16 @@ const (
 
 	HostTypeStatic = "static"
 
+	// host tenancy: whether a host shares hardware with other customers' hosts
+	HostTenancyDefault   = "default"
+	HostTenancyDedicated = "dedicated"
+	HostTenancyHost      = "host"
+
+	// host shutdown behavior: what happens to a host's instance when it is shut
+	// down from inside the host
+	HostShutdownStop      = "stop"
+	HostShutdownTerminate = "terminate"
+
 	CompileStage = "compile"
 	PushStage    = "push"
 
@@ -64,6 +75,19 @@ const (
 	// maximum task priority
 	MaxTaskPriority = 100
 
+	// default maximum number of processes accepted in one report of a task's
+	// process info
+	DefaultMaxProcessInfoEntries = 1000
+
+	// default window, in seconds, over which agents of aborted tasks spread out
+	// their calls to end the task
+	DefaultAbortDelayWindowSecs = 30
+
+	// default bounds, in seconds, of the interval agents are told to wait before
+	// asking again for a task when none is available
+	DefaultTaskPollMinSecs = 5
+	DefaultTaskPollMaxSecs = 60
+
 	// LogMessage struct versions
 	LogmessageFormatTimestamp = 1
 	LogmessageCurrentVersion  = LogmessageFormatTimestamp
@@ -85,6 +109,7 @@ const (
 const (
 	AuthTokenCookie  = "mci-token"
 	TaskSecretHeader = "Task-Secret"
+	TaskTokenHeader  = "Task-Token"
 	HostHeader       = "Host-Id"
 	HostSecretHeader = "Host-Secret"
 )
@@ -34,6 +34,22 @@ const (
 	SCPTimeout         = time.Minute
 	setupScriptName    = "setup.sh"
 	teardownScriptName = "teardown.sh"
+
+	userSetupScriptName = "user_setup.sh"
+)
+
+// UserSetupScriptTimeout is the longest a host owner's setup script may run.
+const UserSetupScriptTimeout = 15 * time.Minute
+
+// instanceEventGracePeriod is how long hostinit waits to be notified of the state
+// of a new instance, for providers that send notifications, before polling it.
+const instanceEventGracePeriod = 15 * time.Minute
+
+// for providers that send notifications, how many times and how often setup
+// checks whether a host whose instance is running can be reached over SSH yet
+const (
+	sshReadyAttempts   = 10
+	sshReadyRetrySleep = 15 * time.Second
 )
 
 // Error indicating another hostinit got to the setup first.
@@ -100,8 +116,8 @@ func (init *HostInit) setupReadyHosts() error {
 				subject := fmt.Sprintf("%v Evergreen provisioning failure on %v",
 					notify.ProvisionFailurePreface, h.Distro.Id)
 				hostLink := fmt.Sprintf("%v/host/%v", init.Settings.Ui.Url, h.Id)
-				message := fmt.Sprintf("Provisioning failed on %v host -- %v: see %v",
-					h.Distro.Id, h.Id, hostLink)
+				message := fmt.Sprintf("Provisioning failed on %v host -- %v (%v): see %v",
+					h.Distro.Id, h.Id, h.DNSAlias(init.Settings), hostLink)
 				if err := notify.NotifyAdmins(subject, message, init.Settings); err != nil {
 					grip.Errorf("Error sending email: %+v", err)
 				}
@@ -124,14 +140,14 @@ func (init *HostInit) setupReadyHosts() error {
 func (init *HostInit) IsHostReady(host *host.Host) (bool, error) {
 
 	// fetch the appropriate cloud provider for the host
-	cloudMgr, err := providers.GetCloudManager(host.Distro.Provider, init.Settings)
+	cloudMgr, err := providers.GetCloudManager(host.Provider, init.Settings)
 	if err != nil {
 		return false,
-			fmt.Errorf("failed to get cloud manager for provider %v: %v", host.Distro.Provider, err)
+			fmt.Errorf("failed to get cloud manager for provider %v: %v", host.Provider, err)
 	}
 
 	// ask for the instance's status
-	hostStatus, err := cloudMgr.GetInstanceStatus(host)
+	hostStatus, err := init.instanceStatus(cloudMgr, host)
 	if err != nil {
 		return false, fmt.Errorf("error checking instance status of host %v: %v", host.Id, err)
 	}
@@ -175,6 +191,12 @@ func (init *HostInit) IsHostReady(host *host.Host) (bool, error) {
 
 	}
 
+	// hosts whose providers notify Evergreen of their instances' state aren't
+	// polled for SSH reachability on each pass; setupHost waits for it instead
+	if init.Settings.HostInit.IsEventDriven(host.Provider) {
+		return true, nil
+	}
+
 	// check if the host is reachable via SSH
 	cloudHost, err := providers.GetCloudHost(host, init.Settings)
 	if err != nil {
@@ -189,6 +211,34 @@ func (init *HostInit) IsHostReady(host *host.Host) (bool, error) {
 	return reachable, nil
 }
 
+// instanceStatus returns the status of the host's instance. If the host's provider
+// notifies Evergreen of instance state changes, this is the status last notified,
+// and the provider is only asked for it once instanceEventGracePeriod has passed
+// without a notification, in case one was lost.
+func (init *HostInit) instanceStatus(cloudMgr cloud.CloudManager, h *host.Host) (cloud.CloudStatus, error) {
+	if init.Settings.HostInit.IsEventDriven(h.Provider) {
+		// notifications name the instance, so it must be recorded for them to
+		// match the host, as for spot requests that weren't fulfilled at spawn
+		if h.InstanceId == "" {
+			instanceId, err := cloudMgr.GetInstanceID(h)
+			if err != nil {
+				grip.Debugf("Instance of host %s is not known yet: %v", h.Id, err)
+			} else if err = h.SetInstanceId(instanceId); err != nil {
+				return cloud.StatusUnknown, fmt.Errorf("error recording instance id of host %v: %v", h.Id, err)
+			}
+		}
+		if h.NotifiedStatus != "" {
+			return cloud.ParseCloudStatus(h.NotifiedStatus), nil
+		}
+		if time.Since(h.CreationTime) < instanceEventGracePeriod {
+			return cloud.StatusPending, nil
+		}
+		grip.Warningf("No instance state change notification for host %s after %v; "+
+			"checking its status", h.Id, instanceEventGracePeriod)
+	}
+	return cloudMgr.GetInstanceStatus(h)
+}
+
 // setupHost runs the specified setup script for an individual host. Returns
 // the output from running the script remotely, as well as any error that
 // occurs. If the script exits with a non-zero exit code, the error will be non-nil.
@@ -229,6 +279,11 @@ func (init *HostInit) setupHost(targetHost *host.Host) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("error getting ssh options for host %v: %v", targetHost.Id, err)
 	}
+	if init.Settings.HostInit.IsEventDriven(targetHost.Provider) {
+		if err = waitForSSH(cloudHost); err != nil {
+			return "", err
+		}
+	}
 
 	if targetHost.Distro.Teardown != "" {
 		err = init.copyScript(targetHost, teardownScriptName, targetHost.Distro.Teardown)
@@ -253,10 +308,38 @@ func (init *HostInit) setupHost(targetHost *host.Host) (string, error) {
 	return "", nil
 }
 
+// waitForSSH waits for a host whose instance was reported running to become
+// reachable over SSH, which may take a little longer than the instance takes to
+// start.
+func waitForSSH(cloudHost *cloud.CloudHost) error {
+	_, err := util.Retry(func() error {
+		reachable, err := cloudHost.IsSSHReachable()
+		if err != nil {
+			return fmt.Errorf("error checking if host %v is reachable: %v", cloudHost.Host.Id, err)
+		}
+		if !reachable {
+			return util.RetriableError{Failure: fmt.Errorf("host %v is not reachable over ssh",
+				cloudHost.Host.Id)}
+		}
+		return nil
+	}, sshReadyAttempts, sshReadyRetrySleep)
+	return err
+}
+
 // copyScript writes a given script as file "name" to the target host. This works
 // by creating a local copy of the script on the runner's machine, scping it over
 // then removing the local copy.
 func (init *HostInit) copyScript(target *host.Host, name, script string) error {
+	expanded, err := init.expandScript(script)
+	if err != nil {
+		return fmt.Errorf("error expanding script for host %v: %v", target.Id, err)
+	}
+	return init.uploadScript(target, name, expanded)
+}
+
+// uploadScript writes a script as file "name" to the target host as is, without
+// expanding it.
+func (init *HostInit) uploadScript(target *host.Host, name, script string) error {
 	// parse the hostname into the user, host and port
 	hostInfo, err := util.ParseSSHInfo(target.Host)
 	if err != nil {
@@ -277,11 +360,7 @@ func (init *HostInit) copyScript(target *host.Host, name, script string) error {
 		os.Remove(file.Name())
 	}()
 
-	expanded, err := init.expandScript(script)
-	if err != nil {
-		return fmt.Errorf("error expanding script for host %v: %v", target.Id, err)
-	}
-	if _, err := io.WriteString(file, expanded); err != nil {
+	if _, err := io.WriteString(file, script); err != nil {
 		return fmt.Errorf("error writing local script: %v", err)
 	}
 
@@ -345,10 +424,11 @@ func (init *HostInit) ProvisionHost(h *host.Host) error {
 		}
 
 		alerts.RunHostProvisionFailTriggers(h)
-		event.LogProvisionFailed(h.Id, output)
+		event.LogProvisionFailed(h.Id, output, event.ExitCodeFromError(err))
 
 		// setup script failed, mark the host's provisioning as failed
-		if err := h.SetUnprovisioned(); err != nil {
+		reason := host.ClassifyProvisionFailure(output + "\n" + err.Error())
+		if err := h.SetUnprovisioned(reason); err != nil {
 			grip.Errorf("unprovisioning host %s failed: %+v", h.Id, err)
 		}
 
@@ -379,9 +459,45 @@ func (init *HostInit) ProvisionHost(h *host.Host) error {
 
 	grip.Infof("Host %s successfully provisioned", h.Id)
 
+	// the owner's script may run for a while, so don't hold up this pass of
+	// hostinit waiting for it
+	if h.ProvisionOptions != nil && h.ProvisionOptions.SetupScript != "" {
+		go init.runUserSetupScript(h)
+	}
+
 	return nil
 }
 
+// runUserSetupScript runs the setup script the host's owner asked for on it, and
+// records the output as a host event. The script runs as the distro's user, after
+// the host is provisioned, so failures don't affect the host's status.
+func (init *HostInit) runUserSetupScript(h *host.Host) {
+	grip.Infof("Running user setup script on host %s", h.Id)
+	start := time.Now()
+	output, err := init.userSetupScript(h)
+	grip.ErrorWhenf(err != nil, "Error running user setup script on host %s: %+v", h.Id, err)
+	if err != nil {
+		output = strings.TrimRight(output, "\n") + "\n" + err.Error()
+	}
+	event.LogHostUserSetupScript(h.Id, output, err == nil, time.Since(start))
+}
+
+func (init *HostInit) userSetupScript(h *host.Host) (string, error) {
+	cloudHost, err := providers.GetCloudHost(h, init.Settings)
+	if err != nil {
+		return "", fmt.Errorf("failed to get cloud host for %v: %v", h.Id, err)
+	}
+	sshOptions, err := cloudHost.GetSSHOptions()
+	if err != nil {
+		return "", fmt.Errorf("error getting ssh options for host %v: %v", h.Id, err)
+	}
+	if err = init.uploadScript(h, userSetupScriptName, h.ProvisionOptions.SetupScript); err != nil {
+		return "", fmt.Errorf("error copying script %v to host %v: %v",
+			userSetupScriptName, h.Id, err)
+	}
+	return hostutil.RunRemoteUserScript(h, userSetupScriptName, sshOptions, UserSetupScriptTimeout)
+}
+
 // LocateCLIBinary returns the (absolute) path to the CLI binary for the given architecture, based
 // on the system settings. Returns an error if the file does not exist.
 func LocateCLIBinary(settings *evergreen.Settings, architecture string) (string, error) {
@@ -3,6 +3,7 @@ package hostinit
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/cloud"
@@ -202,6 +203,58 @@ func TestHostIsReady(t *testing.T) {
 
 }
 
+func TestInstanceStatus(t *testing.T) {
+	Convey("With the mock provider treated as event-driven", t, func() {
+		mock.Clear()
+		settings := &evergreen.Settings{}
+		settings.HostInit.EventDrivenProviders = []string{mock.ProviderName}
+		hostInit := &HostInit{settings}
+		cloudManager, err := providers.GetCloudManager(mock.ProviderName, settings)
+		So(err, ShouldBeNil)
+		mock.MockInstances["h1"] = mock.MockInstance{Status: cloud.StatusRunning}
+
+		Convey("a new host that hasn't been notified about should be pending", func() {
+			h := &host.Host{Id: "h1", InstanceId: "h1", Provider: mock.ProviderName, CreationTime: time.Now()}
+			status, err := hostInit.instanceStatus(cloudManager, h)
+			So(err, ShouldBeNil)
+			So(status, ShouldEqual, cloud.StatusPending)
+		})
+		Convey("a host that has been notified about should have the notified status", func() {
+			h := &host.Host{Id: "h1", InstanceId: "h1", Provider: mock.ProviderName, CreationTime: time.Now(),
+				NotifiedStatus: cloud.StatusFailed.String()}
+			status, err := hostInit.instanceStatus(cloudManager, h)
+			So(err, ShouldBeNil)
+			So(status, ShouldEqual, cloud.StatusFailed)
+		})
+		Convey("an old host that hasn't been notified about should be polled", func() {
+			h := &host.Host{Id: "h1", InstanceId: "h1", Provider: mock.ProviderName,
+				CreationTime: time.Now().Add(-instanceEventGracePeriod - time.Minute)}
+			status, err := hostInit.instanceStatus(cloudManager, h)
+			So(err, ShouldBeNil)
+			So(status, ShouldEqual, cloud.StatusRunning)
+		})
+		Convey("the instance id of a host should be recorded so notifications match it", func() {
+			testutil.HandleTestingErr(db.Clear(host.Collection), t, "error clearing hosts")
+			h := &host.Host{Id: "h1", Provider: mock.ProviderName, CreationTime: time.Now()}
+			So(h.Insert(), ShouldBeNil)
+			status, err := hostInit.instanceStatus(cloudManager, h)
+			So(err, ShouldBeNil)
+			So(status, ShouldEqual, cloud.StatusPending)
+			So(h.InstanceId, ShouldEqual, "h1")
+			found, err := host.FindOne(host.ByInstanceId("h1"))
+			So(err, ShouldBeNil)
+			So(found.InstanceId, ShouldEqual, "h1")
+		})
+		Convey("hosts of other providers should be polled", func() {
+			settings.HostInit.EventDrivenProviders = nil
+			h := &host.Host{Id: "h1", Provider: mock.ProviderName, CreationTime: time.Now()}
+			status, err := hostInit.instanceStatus(cloudManager, h)
+			So(err, ShouldBeNil)
+			So(status, ShouldEqual, cloud.StatusRunning)
+		})
+	})
+}
+
 func spawnMockHost() (*host.Host, error) {
 	mockDistro := distro.Distro{
 		Id:       "mock_distro",
@@ -2,11 +2,13 @@ package hostutil
 
 import (
 	"bytes"
+	"context"
 	"time"
 
 	"github.com/evergreen-ci/evergreen/command"
 	"github.com/evergreen-ci/evergreen/model/host"
 	"github.com/evergreen-ci/evergreen/util"
+	"github.com/mongodb/grip"
 )
 
 const SSHTimeout = time.Minute * 10
@@ -14,6 +16,16 @@ const SSHTimeout = time.Minute * 10
 // RunRemoteScript executes a shell script that already exists on the remote host,
 // returning logs and any errors that occur. Logs may still be returned for some errors.
 func RunRemoteScript(h *host.Host, script string, sshOptions []string) (string, error) {
+	return runRemoteScript(h, script, sshOptions, h.Distro.SetupAsSudo, SSHTimeout)
+}
+
+// RunRemoteUserScript executes a shell script that already exists on the remote host
+// as the distro's user, never with sudo, and kills its ssh session after the timeout.
+func RunRemoteUserScript(h *host.Host, script string, sshOptions []string, timeout time.Duration) (string, error) {
+	return runRemoteScript(h, script, sshOptions, false, timeout)
+}
+
+func runRemoteScript(h *host.Host, script string, sshOptions []string, asSudo bool, timeout time.Duration) (string, error) {
 	// parse the hostname into the user, host and port
 	hostInfo, err := util.ParseSSHInfo(h.Host)
 	if err != nil {
@@ -26,7 +38,7 @@ func RunRemoteScript(h *host.Host, script string, sshOptions []string) (string,
 
 	// run the remote script as sudo, if appropriate
 	sudoStr := ""
-	if h.Distro.SetupAsSudo {
+	if asSudo {
 		sudoStr = "sudo "
 	}
 	// run command to ssh into remote machine and execute script
@@ -44,15 +56,35 @@ func RunRemoteScript(h *host.Host, script string, sshOptions []string) (string,
 		Background:     false,
 	}
 	// force creation of a tty if sudo
-	if h.Distro.SetupAsSudo {
+	if asSudo {
 		cmd.Options = []string{"-t", "-t", "-p", hostInfo.Port}
 	}
 	cmd.Options = append(cmd.Options, sshOptions...)
 
 	// run the ssh command with given timeout
-	err = util.RunFunctionWithTimeout(
-		cmd.Run,
-		SSHTimeout,
-	)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	err = runRemoteCommand(ctx, cmd)
 	return sshCmdStd.String(), err
 }
+
+// runRemoteCommand runs the command until it exits or the context is done, in
+// which case its ssh process is killed and util.ErrTimedOut is returned.
+func runRemoteCommand(ctx context.Context, cmd *command.RemoteCommand) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		grip.Error(cmd.Stop())
+		<-done
+		return util.ErrTimedOut
+	}
+}
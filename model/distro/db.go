@@ -11,6 +11,7 @@ var (
 	IdKey               = bsonutil.MustHaveTag(Distro{}, "Id")
 	ArchKey             = bsonutil.MustHaveTag(Distro{}, "Arch")
 	PoolSizeKey         = bsonutil.MustHaveTag(Distro{}, "PoolSize")
+	MaxHostsKey         = bsonutil.MustHaveTag(Distro{}, "MaxHosts")
 	ProviderKey         = bsonutil.MustHaveTag(Distro{}, "Provider")
 	ProviderSettingsKey = bsonutil.MustHaveTag(Distro{}, "ProviderSettings")
 	SetupAsSudoKey      = bsonutil.MustHaveTag(Distro{}, "SetupAsSudo")
@@ -24,6 +25,7 @@ var (
 
 	SpawnAllowedKey = bsonutil.MustHaveTag(Distro{}, "SpawnAllowed")
 	ExpansionsKey   = bsonutil.MustHaveTag(Distro{}, "Expansions")
+	DrainingKey     = bsonutil.MustHaveTag(Distro{}, "Draining")
 
 	// bson fields for the UserData struct
 	UserDataFileKey     = bsonutil.MustHaveTag(UserData{}, "File")
@@ -58,6 +60,12 @@ func (d *Distro) Update() error {
 	return db.UpdateId(Collection, d.Id, d)
 }
 
+// SetDraining marks the distro with the given id as draining, or as no longer
+// draining.
+func SetDraining(id string, draining bool) error {
+	return db.Update(Collection, bson.M{IdKey: id}, bson.M{"$set": bson.M{DrainingKey: draining}})
+}
+
 // Remove removes one distro.
 func Remove(id string) error {
 	return db.Remove(Collection, bson.D{{IdKey, id}})
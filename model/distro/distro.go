@@ -25,6 +25,31 @@ type Distro struct {
 
 	SpawnAllowed bool        `bson:"spawn_allowed" json:"spawn_allowed,omitempty" mapstructure:"spawn_allowed,omitempty"`
 	Expansions   []Expansion `bson:"expansions,omitempty" json:"expansions,omitempty" mapstructure:"expansions,omitempty"`
+
+	// SchedulingWeight expresses how strongly new hosts should be started in this
+	// distro, rather than in others, for tasks that can run on several; e.g. because
+	// its hosts are cheaper. Distros with higher weights are preferred.
+	SchedulingWeight int `bson:"scheduling_weight,omitempty" json:"scheduling_weight,omitempty" mapstructure:"scheduling_weight,omitempty"`
+
+	// MaxHosts caps how many hosts of the distro, including spawn hosts, may be up at
+	// once; e.g. to stay within its provider's quota. Unlike PoolSize, it also limits
+	// users' spawn hosts. Zero means no cap.
+	MaxHosts int `bson:"max_hosts,omitempty" json:"max_hosts,omitempty" mapstructure:"max_hosts,omitempty"`
+
+	// ProvisionRetries is how many times the setup of a host is retried after it
+	// reports a failure before the host is marked as having failed provisioning, for
+	// distros whose first boot is flaky. Zero means hosts are never retried.
+	ProvisionRetries int `bson:"provision_retries,omitempty" json:"provision_retries,omitempty" mapstructure:"provision_retries,omitempty"`
+
+	// Draining is set while the distro is being drained before it is scaled down or
+	// retired: its hosts aren't given new tasks, no new hosts are started for it,
+	// and its hosts are terminated as they go idle.
+	Draining bool `bson:"draining,omitempty" json:"draining,omitempty" mapstructure:"draining,omitempty"`
+
+	// ShutdownBehavior is whether shutting one of the distro's hosts down from inside
+	// it stops or terminates its instance, for providers that support it. Blank leaves
+	// it to the provider.
+	ShutdownBehavior string `bson:"shutdown_behavior,omitempty" json:"shutdown_behavior,omitempty" mapstructure:"shutdown_behavior,omitempty"`
 }
 
 type ValidateFormat string
@@ -14,6 +14,9 @@ const (
 	EventDistroAdded    = "DISTRO_ADDED"
 	EventDistroModified = "DISTRO_MODIFIED"
 	EventDistroRemoved  = "DISTRO_REMOVED"
+
+	EventDistroDrainStarted = "DISTRO_DRAIN_STARTED"
+	EventDistroDrainStopped = "DISTRO_DRAIN_STOPPED"
 )
 
 // DistroEventData implements EventData.
@@ -54,3 +57,14 @@ func LogDistroModified(distroId, userId string, data interface{}) {
 func LogDistroRemoved(distroId, userId string, data interface{}) {
 	LogDistroEvent(distroId, EventDistroRemoved, DistroEventData{UserId: userId, Data: data})
 }
+
+// LogDistroDrainStarted records that a user started draining a distro.
+func LogDistroDrainStarted(distroId, userId string) {
+	LogDistroEvent(distroId, EventDistroDrainStarted, DistroEventData{UserId: userId})
+}
+
+// LogDistroDrainStopped records that a user stopped draining a distro, restoring
+// its normal scheduling.
+func LogDistroDrainStopped(distroId, userId string) {
+	LogDistroEvent(distroId, EventDistroDrainStopped, DistroEventData{UserId: userId})
+}
@@ -1,6 +1,9 @@
 package event
 
 import (
+	"sort"
+	"time"
+
 	"github.com/evergreen-ci/evergreen/db"
 	"gopkg.in/mgo.v2/bson"
 )
@@ -15,6 +18,24 @@ func Find(coll string, query db.Q) ([]Event, error) {
 	return events, err
 }
 
+// FindTaskTimeline returns every event logged for a task, including the system
+// and process info reported while it ran, oldest first.
+func FindTaskTimeline(taskId string) ([]Event, error) {
+	events, err := Find(AllLogCollection, TaskEventsInOrder(taskId))
+	if err != nil {
+		return nil, err
+	}
+	resourceEvents, err := Find(TaskLogCollection, TaskResourceEventsInOrder(taskId))
+	if err != nil {
+		return nil, err
+	}
+	events = append(events, resourceEvents...)
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+	return events, nil
+}
+
 // === Queries ===
 
 // Host Events
@@ -33,6 +54,57 @@ func HostEventsInOrder(id string) db.Q {
 	return HostEventsForId(id).Sort([]string{TimestampKey})
 }
 
+// HostEventsInRange returns a query for a host's events between the given times,
+// oldest first. A zero start or end time leaves that end of the range open.
+func HostEventsInRange(id string, start, end time.Time) db.Q {
+	query := bson.M{
+		DataKey + "." + ResourceTypeKey: ResourceTypeHost,
+		ResourceIdKey:                   id,
+	}
+	timeRange := bson.M{}
+	if !start.IsZero() {
+		timeRange["$gte"] = start
+	}
+	if !end.IsZero() {
+		timeRange["$lte"] = end
+	}
+	if len(timeRange) > 0 {
+		query[TimestampKey] = timeRange
+	}
+	return db.Query(query).Sort([]string{TimestampKey})
+}
+
+// LastHostEventOfType returns a query for the most recent event of the given type
+// logged for a host.
+func LastHostEventOfType(id, eventType string) db.Q {
+	return db.Query(bson.M{
+		DataKey + "." + ResourceTypeKey: ResourceTypeHost,
+		ResourceIdKey:                   id,
+		TypeKey:                         eventType,
+	}).Sort([]string{"-" + TimestampKey}).Limit(1)
+}
+
+// HostTaskEventsInRange returns a query for the events recording hosts starting
+// and finishing tasks between the given times, oldest first.
+func HostTaskEventsInRange(start, end time.Time) db.Q {
+	return db.Query(bson.M{
+		DataKey + "." + ResourceTypeKey: ResourceTypeHost,
+		TypeKey: bson.M{"$in": []string{
+			EventHostRunningTaskSet, EventHostRunningTaskCleared, EventTaskFinished}},
+		TimestampKey: bson.M{"$gte": start, "$lte": end},
+	}).Sort([]string{TimestampKey})
+}
+
+// HostEventsOfTypeInRange returns a query for the events of the given type logged
+// for any host between the given times, oldest first.
+func HostEventsOfTypeInRange(eventType string, start, end time.Time) db.Q {
+	return db.Query(bson.M{
+		DataKey + "." + ResourceTypeKey: ResourceTypeHost,
+		TypeKey:                         eventType,
+		TimestampKey:                    bson.M{"$gte": start, "$lte": end},
+	}).Sort([]string{TimestampKey})
+}
+
 // Task Events
 func TaskEventsForId(id string) db.Q {
 	return db.Query(bson.D{
@@ -49,6 +121,27 @@ func TaskEventsInOrder(id string) db.Q {
 	return TaskEventsForId(id).Sort([]string{TimestampKey})
 }
 
+// TaskEventsOfTypeSince returns a query for the events of the given type logged for
+// a task since the given time.
+func TaskEventsOfTypeSince(id, eventType string, since time.Time) db.Q {
+	return db.Query(bson.M{
+		DataKey + "." + ResourceTypeKey: ResourceTypeTask,
+		ResourceIdKey:                   id,
+		TypeKey:                         eventType,
+		TimestampKey:                    bson.M{"$gte": since},
+	})
+}
+
+// TaskResourceEventsInOrder returns a query for the system and process info
+// reported while a task ran, oldest first.
+func TaskResourceEventsInOrder(id string) db.Q {
+	return db.Query(bson.M{
+		DataKey + "." + ResourceTypeKey: bson.M{"$in": []string{
+			EventTaskSystemInfo, EventTaskProcessInfo}},
+		ResourceIdKey: id,
+	}).Sort([]string{TimestampKey})
+}
+
 // Distro Events
 func DistroEventsForId(id string) db.Q {
 	return db.Query(bson.D{
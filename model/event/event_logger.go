@@ -21,3 +21,12 @@ func NewDBEventLogger(collection string) *DBEventLogger {
 func (self *DBEventLogger) LogEvent(event Event) error {
 	return db.Insert(self.collection, event)
 }
+
+// LogEvents inserts all of the events in a single batch.
+func (self *DBEventLogger) LogEvents(events ...Event) error {
+	docs := make([]interface{}, 0, len(events))
+	for _, event := range events {
+		docs = append(docs, event)
+	}
+	return db.InsertMany(self.collection, docs...)
+}
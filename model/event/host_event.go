@@ -14,6 +14,7 @@ const (
 	EventHostCreated            = "HOST_CREATED"
 	EventHostStatusChanged      = "HOST_STATUS_CHANGED"
 	EventHostDNSNameSet         = "HOST_DNS_NAME_SET"
+	EventHostDNSNameChanged     = "HOST_DNS_NAME_CHANGED"
 	EventHostProvisionFailed    = "HOST_PROVISION_FAILED"
 	EventHostProvisioned        = "HOST_PROVISIONED"
 	EventHostRunningTaskSet     = "HOST_RUNNING_TASK_SET"
@@ -22,6 +23,27 @@ const (
 	EventHostMonitorFlag        = "HOST_MONITOR_FLAG"
 	EventTaskFinished           = "HOST_TASK_FINISHED"
 	EventHostTeardown           = "HOST_TEARDOWN"
+	EventHostSecretRotated      = "HOST_SECRET_ROTATED"
+	EventHostRebooted           = "HOST_REBOOTED"
+	EventHostTagsModified       = "HOST_TAGS_MODIFIED"
+	EventHostReprovisioning     = "HOST_REPROVISIONING"
+	EventHostUserSetupScript    = "HOST_USER_SETUP_SCRIPT"
+	EventHostImageCreated       = "HOST_IMAGE_CREATED"
+	EventHostTerminationFailed  = "HOST_TERMINATION_FAILED"
+	EventHostIdleExemptionSet   = "HOST_IDLE_EXEMPTION_SET"
+	EventHostStaticAddressSet   = "HOST_STATIC_ADDRESS_SET"
+
+	// reasons a host's running task was cleared
+	RunningTaskFinished    = "finished"
+	RunningTaskReaped      = "reaped"
+	RunningTaskDeactivated = "deactivated"
+
+	// MaxHostEventLogSize is the most bytes of a script's output that are stored
+	// with a host event.
+	MaxHostEventLogSize = 64 * 1024 // 64 KB
+
+	// truncatedLogsMarker starts script output that was cut to MaxHostEventLogSize
+	truncatedLogsMarker = "[output truncated]\n"
 )
 
 // implements EventData
@@ -29,16 +51,37 @@ type HostEventData struct {
 	// necessary for IsValid
 	ResourceType string `bson:"r_type" json:"resource_type"`
 
-	OldStatus  string        `bson:"o_s,omitempty" json:"old_status,omitempty"`
-	NewStatus  string        `bson:"n_s,omitempty" json:"new_status,omitempty"`
-	Logs       string        `bson:"log,omitempty" json:"logs,omitempty"`
-	Hostname   string        `bson:"hn,omitempty" json:"hostname,omitempty"`
-	TaskId     string        `bson:"t_id,omitempty" json:"task_id,omitempty"`
-	TaskPid    string        `bson:"t_pid,omitempty" json:"task_pid,omitempty"`
-	TaskStatus string        `bson:"t_st,omitempty" json:"task_status,omitempty"`
-	MonitorOp  string        `bson:"monitor_op,omitempty" json:"monitor,omitempty"`
-	Successful bool          `bson:"successful,omitempty" json:"successful"`
-	Duration   time.Duration `bson:"duration,omitempty" json:"duration"`
+	OldStatus   string        `bson:"o_s,omitempty" json:"old_status,omitempty"`
+	NewStatus   string        `bson:"n_s,omitempty" json:"new_status,omitempty"`
+	Logs        string        `bson:"log,omitempty" json:"logs,omitempty"`
+	Hostname    string        `bson:"hn,omitempty" json:"hostname,omitempty"`
+	OldHostname string        `bson:"o_hn,omitempty" json:"old_hostname,omitempty"`
+	TaskId      string        `bson:"t_id,omitempty" json:"task_id,omitempty"`
+	TaskPid     string        `bson:"t_pid,omitempty" json:"task_pid,omitempty"`
+	TaskStatus  string        `bson:"t_st,omitempty" json:"task_status,omitempty"`
+	MonitorOp   string        `bson:"monitor_op,omitempty" json:"monitor,omitempty"`
+	User        string        `bson:"usr,omitempty" json:"user,omitempty"`
+	Reason      string        `bson:"rsn,omitempty" json:"reason,omitempty"`
+	Successful  bool          `bson:"successful" json:"successful"`
+	Duration    time.Duration `bson:"duration,omitempty" json:"duration"`
+
+	// ProvisionSteps are the commands run by a failed setup script, if its log
+	// has trace output
+	ProvisionSteps []ProvisionStep `bson:"steps,omitempty" json:"provision_steps,omitempty"`
+
+	// Tags are the cloud tags set on the host's instance
+	Tags map[string]string `bson:"tags,omitempty" json:"tags,omitempty"`
+
+	// ImageName and ImageId identify an image created from the host's instance
+	ImageName string `bson:"img_name,omitempty" json:"image_name,omitempty"`
+	ImageId   string `bson:"img_id,omitempty" json:"image_id,omitempty"`
+
+	// NoIdleTerminationUntil is the time until which a host is exempt from being
+	// terminated for being idle
+	NoIdleTerminationUntil time.Time `bson:"no_idle_until,omitempty" json:"no_idle_termination_until,omitempty"`
+
+	// StaticAddress is a static public address associated with a host's instance
+	StaticAddress string `bson:"static_addr,omitempty" json:"static_address,omitempty"`
 }
 
 func (self HostEventData) IsValid() bool {
@@ -77,6 +120,14 @@ func LogHostDNSNameSet(hostId string, dnsName string) {
 		HostEventData{Hostname: dnsName})
 }
 
+func LogHostDNSNameChanged(hostId string, oldDNSName string, newDNSName string) {
+	if oldDNSName == newDNSName {
+		return
+	}
+	LogHostEvent(hostId, EventHostDNSNameChanged,
+		HostEventData{OldHostname: oldDNSName, Hostname: newDNSName})
+}
+
 func LogHostProvisioned(hostId string) {
 	LogHostEvent(hostId, EventHostProvisioned, HostEventData{})
 }
@@ -86,17 +137,36 @@ func LogHostRunningTaskSet(hostId string, taskId string) {
 		HostEventData{TaskId: taskId})
 }
 
-func LogHostRunningTaskCleared(hostId string, taskId string) {
+func LogHostRunningTaskCleared(hostId, taskId, reason string) {
 	LogHostEvent(hostId, EventHostRunningTaskCleared,
-		HostEventData{TaskId: taskId})
+		HostEventData{TaskId: taskId, Reason: reason})
 }
 
 func LogHostTaskPidSet(hostId string, taskPid string) {
 	LogHostEvent(hostId, EventHostTaskPidSet, HostEventData{TaskPid: taskPid})
 }
 
-func LogProvisionFailed(hostId string, setupLogs string) {
-	LogHostEvent(hostId, EventHostProvisionFailed, HostEventData{Logs: setupLogs})
+// LogProvisionFailed logs a failure to provision a host, recording the end of the
+// setup script's log and the steps parsed from all of it. The log is only stored
+// once: the steps are stored without their output, which FindLastProvisionFailure
+// fills back in from the log. The exit code, if known, is recorded on the last step.
+func LogProvisionFailed(hostId string, setupLogs string, exitCode int) {
+	steps := ParseProvisionSteps(setupLogs)
+	for i := range steps {
+		steps[i].Output = ""
+	}
+	if len(steps) > 0 {
+		steps[len(steps)-1].Failed = true
+		steps[len(steps)-1].ExitCode = exitCode
+	}
+	LogHostEvent(hostId, EventHostProvisionFailed,
+		HostEventData{Logs: truncateLogs(setupLogs), ProvisionSteps: steps})
+}
+
+// LogHostTerminationFailed records that a host's instance couldn't be terminated,
+// even after retrying.
+func LogHostTerminationFailed(hostId, reason string) {
+	LogHostEvent(hostId, EventHostTerminationFailed, HostEventData{Reason: reason})
 }
 
 func LogHostTeardown(hostId, teardownLogs string, success bool, duration time.Duration) {
@@ -104,6 +174,59 @@ func LogHostTeardown(hostId, teardownLogs string, success bool, duration time.Du
 		HostEventData{Logs: teardownLogs, Successful: success, Duration: duration})
 }
 
+func LogHostSecretRotated(hostId string) {
+	LogHostEvent(hostId, EventHostSecretRotated, HostEventData{})
+}
+
+func LogHostRebooted(hostId, user string) {
+	LogHostEvent(hostId, EventHostRebooted, HostEventData{User: user})
+}
+
+// LogHostTagsModified records that a user set tags on a host's instance.
+func LogHostTagsModified(hostId, user string, tags map[string]string) {
+	LogHostEvent(hostId, EventHostTagsModified, HostEventData{User: user, Tags: tags})
+}
+
+// LogHostImageCreated records that a user created an image from a host's instance.
+func LogHostImageCreated(hostId, user, imageName, imageId string) {
+	LogHostEvent(hostId, EventHostImageCreated,
+		HostEventData{User: user, ImageName: imageName, ImageId: imageId})
+}
+
+// LogHostIdleExemptionSet records that a user exempted a host from being terminated
+// for being idle until the given time, or removed its exemption if it's zero.
+func LogHostIdleExemptionSet(hostId, user string, until time.Time) {
+	LogHostEvent(hostId, EventHostIdleExemptionSet,
+		HostEventData{User: user, NoIdleTerminationUntil: until})
+}
+
+// LogHostStaticAddressSet records that a user associated a static address with a
+// host's instance.
+func LogHostStaticAddressSet(hostId, user, address string) {
+	LogHostEvent(hostId, EventHostStaticAddressSet, HostEventData{User: user, StaticAddress: address})
+}
+
+// LogHostReprovisioning records that a user asked for a host to be provisioned again.
+func LogHostReprovisioning(hostId, user string) {
+	LogHostEvent(hostId, EventHostReprovisioning, HostEventData{User: user})
+}
+
+// LogHostUserSetupScript records the output of the setup script the host's owner
+// asked to be run on it. Only the end of long output is kept.
+func LogHostUserSetupScript(hostId, logs string, success bool, duration time.Duration) {
+	LogHostEvent(hostId, EventHostUserSetupScript,
+		HostEventData{Logs: truncateLogs(logs), Successful: success, Duration: duration})
+}
+
+// truncateLogs keeps the last MaxHostEventLogSize bytes of a script's output, which
+// are the likeliest to explain a failure, and notes that the rest were dropped.
+func truncateLogs(logs string) string {
+	if len(logs) <= MaxHostEventLogSize {
+		return logs
+	}
+	return truncatedLogsMarker + logs[len(logs)-MaxHostEventLogSize:]
+}
+
 func LogMonitorOperation(hostId string, op string) {
 	LogHostEvent(hostId, EventHostMonitorFlag, HostEventData{MonitorOp: op})
 }
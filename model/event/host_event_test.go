@@ -1,6 +1,7 @@
 package event
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -39,7 +40,7 @@ func TestLoggingHostEvents(t *testing.T) {
 			time.Sleep(1 * time.Millisecond)
 			LogHostRunningTaskSet(hostId, taskId)
 			time.Sleep(1 * time.Millisecond)
-			LogHostRunningTaskCleared(hostId, taskId)
+			LogHostRunningTaskCleared(hostId, taskId, RunningTaskFinished)
 			time.Sleep(1 * time.Millisecond)
 			LogHostTaskPidSet(hostId, taskPid)
 			time.Sleep(1 * time.Millisecond)
@@ -133,6 +134,7 @@ func TestLoggingHostEvents(t *testing.T) {
 			So(eventData.Hostname, ShouldBeBlank)
 			So(eventData.TaskId, ShouldEqual, taskId)
 			So(eventData.TaskPid, ShouldBeBlank)
+			So(eventData.Reason, ShouldEqual, RunningTaskFinished)
 
 			event = eventsForHost[6]
 			So(event.EventType, ShouldEqual, EventHostTaskPidSet)
@@ -150,3 +152,52 @@ func TestLoggingHostEvents(t *testing.T) {
 		})
 	})
 }
+
+func TestHostEventsInRange(t *testing.T) {
+	Convey("With events logged for a host", t, func() {
+		So(db.Clear(AllLogCollection), ShouldBeNil)
+
+		hostId := "host_id"
+		LogHostCreated(hostId)
+		time.Sleep(10 * time.Millisecond)
+		middle := time.Now()
+		time.Sleep(10 * time.Millisecond)
+		LogHostProvisioned(hostId)
+		LogHostCreated("other_host")
+
+		Convey("an open range should return all of the host's events in order", func() {
+			events, err := Find(AllLogCollection, HostEventsInRange(hostId, time.Time{}, time.Time{}))
+			So(err, ShouldBeNil)
+			So(len(events), ShouldEqual, 2)
+			So(events[0].EventType, ShouldEqual, EventHostCreated)
+			So(events[1].EventType, ShouldEqual, EventHostProvisioned)
+		})
+
+		Convey("a bounded range should only return the events inside it", func() {
+			events, err := Find(AllLogCollection, HostEventsInRange(hostId, middle, time.Time{}))
+			So(err, ShouldBeNil)
+			So(len(events), ShouldEqual, 1)
+			So(events[0].EventType, ShouldEqual, EventHostProvisioned)
+
+			events, err = Find(AllLogCollection, HostEventsInRange(hostId, time.Time{}, middle))
+			So(err, ShouldBeNil)
+			So(len(events), ShouldEqual, 1)
+			So(events[0].EventType, ShouldEqual, EventHostCreated)
+		})
+	})
+}
+
+func TestTruncateLogs(t *testing.T) {
+	Convey("With script output of different lengths", t, func() {
+		Convey("short output should be kept whole", func() {
+			So(truncateLogs("ok"), ShouldEqual, "ok")
+		})
+		Convey("long output should keep only its end", func() {
+			logs := strings.Repeat("a", MaxHostEventLogSize) + "failed"
+			truncated := truncateLogs(logs)
+			So(strings.HasPrefix(truncated, "[output truncated]\n"), ShouldBeTrue)
+			So(strings.HasSuffix(truncated, "failed"), ShouldBeTrue)
+			So(len(truncated), ShouldEqual, len("[output truncated]\n")+MaxHostEventLogSize)
+		})
+	})
+}
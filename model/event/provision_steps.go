@@ -0,0 +1,133 @@
+package event
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProvisionStep is one command run by a host's setup script, as recorded by the
+// shell's trace output (i.e. the "+ command" lines printed by scripts that
+// `set -x`).
+type ProvisionStep struct {
+	Command string `bson:"cmd" json:"command"`
+	// Output isn't stored with new failures, since it's already in their log; it's
+	// filled in from the log when the failure is found
+	Output string `bson:"out,omitempty" json:"output,omitempty"`
+	// Duration is only known if the script's PS4 prints the time as seconds since
+	// the epoch, e.g. PS4='+ $(date +%s) ', and the step isn't the last one
+	Duration time.Duration `bson:"duration,omitempty" json:"duration,omitempty"`
+	// Failed is set on the last step of a failed script, with the script's exit
+	// code if it is known
+	Failed   bool `bson:"failed,omitempty" json:"failed,omitempty"`
+	ExitCode int  `bson:"exit_code,omitempty" json:"exit_code,omitempty"`
+}
+
+var (
+	// traceLine matches a trace line, optionally with a timestamp after the '+'s
+	traceLine = regexp.MustCompile(`^\++ (?:(\d{9,10}(?:\.\d+)?) )?(.*)$`)
+	// exitStatus matches the exit status of a command in an error message
+	exitStatus = regexp.MustCompile(`exit status (\d+)`)
+)
+
+// ParseProvisionSteps splits a setup script's log into the commands it ran and
+// their output. Returns no steps if the log has no trace output.
+func ParseProvisionSteps(setupLog string) []ProvisionStep {
+	steps := []ProvisionStep{}
+	starts := []float64{}
+	output := []string{}
+	finishStep := func() {
+		if len(steps) > 0 {
+			steps[len(steps)-1].Output = strings.TrimRight(strings.Join(output, "\n"), "\n")
+		}
+		output = output[:0]
+	}
+
+	for _, line := range strings.Split(setupLog, "\n") {
+		line = strings.TrimRight(line, "\r")
+		match := traceLine.FindStringSubmatch(line)
+		if match == nil {
+			if len(steps) > 0 {
+				output = append(output, line)
+			}
+			continue
+		}
+		finishStep()
+		start, _ := strconv.ParseFloat(match[1], 64)
+		steps = append(steps, ProvisionStep{Command: match[2]})
+		starts = append(starts, start)
+	}
+	finishStep()
+
+	for i := 0; i+1 < len(steps); i++ {
+		if starts[i] > 0 && starts[i+1] >= starts[i] {
+			steps[i].Duration = time.Duration((starts[i+1] - starts[i]) * float64(time.Second))
+		}
+	}
+	return steps
+}
+
+// ExitCodeFromError returns the exit status reported in the error of a failed
+// command, or zero if it doesn't report one.
+func ExitCodeFromError(err error) int {
+	if err == nil {
+		return 0
+	}
+	match := exitStatus.FindStringSubmatch(err.Error())
+	if match == nil {
+		return 0
+	}
+	code, _ := strconv.Atoi(match[1])
+	return code
+}
+
+// FindLastProvisionFailure returns the data of the most recent provisioning failure
+// logged for the host, with its setup log split into steps, or nil if provisioning
+// hasn't failed.
+func FindLastProvisionFailure(hostId string) (*HostEventData, error) {
+	events, err := Find(AllLogCollection, LastHostEventOfType(hostId, EventHostProvisionFailed))
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, nil
+	}
+	data, ok := events[0].Data.Data.(*HostEventData)
+	if !ok {
+		return nil, nil
+	}
+	// failures logged before steps were recorded are parsed now
+	if len(data.ProvisionSteps) == 0 {
+		data.ProvisionSteps = ParseProvisionSteps(data.Logs)
+		if len(data.ProvisionSteps) > 0 {
+			data.ProvisionSteps[len(data.ProvisionSteps)-1].Failed = true
+		}
+		return data, nil
+	}
+	fillProvisionStepOutput(data.ProvisionSteps, data.Logs)
+	return data, nil
+}
+
+// fillProvisionStepOutput sets the output of steps parsed from a whole setup log
+// from the possibly truncated log that was stored with them. If the log was
+// truncated, only the steps whose commands are still in it get their output, and
+// the partial line the log was cut at is skipped.
+func fillProvisionStepOutput(steps []ProvisionStep, logs string) {
+	if strings.HasPrefix(logs, truncatedLogsMarker) {
+		logs = strings.TrimPrefix(logs, truncatedLogsMarker)
+		if i := strings.Index(logs, "\n"); i >= 0 {
+			logs = logs[i+1:]
+		} else {
+			logs = ""
+		}
+	}
+	parsed := ParseProvisionSteps(logs)
+	if len(parsed) > len(steps) {
+		parsed = parsed[len(parsed)-len(steps):]
+	}
+	offset := len(steps) - len(parsed)
+	for i, step := range parsed {
+		steps[offset+i].Output = step.Output
+	}
+}
@@ -0,0 +1,106 @@
+package event
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/db"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseProvisionSteps(t *testing.T) {
+	Convey("When parsing a setup script's log", t, func() {
+		Convey("a log without trace output should have no steps", func() {
+			So(ParseProvisionSteps("installing\ndone\n"), ShouldBeEmpty)
+		})
+
+		Convey("each traced command should be a step with its output", func() {
+			steps := ParseProvisionSteps("preamble\n+ apt-get update\r\nHit one\r\nHit two\r\n+ make install\n++ cc -o x\nerror: bad\n")
+			So(len(steps), ShouldEqual, 3)
+			So(steps[0].Command, ShouldEqual, "apt-get update")
+			So(steps[0].Output, ShouldEqual, "Hit one\nHit two")
+			So(steps[1].Command, ShouldEqual, "make install")
+			So(steps[1].Output, ShouldEqual, "")
+			So(steps[2].Command, ShouldEqual, "cc -o x")
+			So(steps[2].Output, ShouldEqual, "error: bad")
+			So(steps[0].Duration, ShouldEqual, 0)
+		})
+
+		Convey("timestamps in the trace output should give the steps' durations", func() {
+			steps := ParseProvisionSteps("+ 1500000000 apt-get update\n+ 1500000012.5 make\n")
+			So(len(steps), ShouldEqual, 2)
+			So(steps[0].Command, ShouldEqual, "apt-get update")
+			So(steps[0].Duration, ShouldEqual, 12500*time.Millisecond)
+			So(steps[1].Command, ShouldEqual, "make")
+			So(steps[1].Duration, ShouldEqual, 0)
+		})
+	})
+}
+
+func TestFillProvisionStepOutput(t *testing.T) {
+	Convey("When filling in the output of a setup script's steps from its log", t, func() {
+		setupLog := "+ apt-get update\nHit one\n+ make install\nerror: bad\n"
+		steps := ParseProvisionSteps(setupLog)
+		for i := range steps {
+			steps[i].Output = ""
+		}
+
+		Convey("a whole log should give every step its output", func() {
+			fillProvisionStepOutput(steps, setupLog)
+			So(steps[0].Output, ShouldEqual, "Hit one")
+			So(steps[1].Output, ShouldEqual, "error: bad")
+		})
+
+		Convey("a truncated log should only give output to the steps still in it", func() {
+			fillProvisionStepOutput(steps, truncatedLogsMarker+"t one\n+ make install\nerror: bad\n")
+			So(steps[0].Output, ShouldEqual, "")
+			So(steps[1].Output, ShouldEqual, "error: bad")
+		})
+
+		Convey("a log cut in the middle of a command should skip the partial command", func() {
+			fillProvisionStepOutput(steps, truncatedLogsMarker+"+ update\nHit one\n+ make install\nerror: bad\n")
+			So(steps[0].Output, ShouldEqual, "")
+			So(steps[1].Output, ShouldEqual, "error: bad")
+		})
+	})
+}
+
+func TestLogProvisionFailed(t *testing.T) {
+	Convey("With a failed setup script with a long log", t, func() {
+		So(db.Clear(AllLogCollection), ShouldBeNil)
+		setupLog := "+ apt-get update\n" + strings.Repeat("Hit\n", MaxHostEventLogSize/4) + "+ make install\nerror: bad\n"
+		LogProvisionFailed("h1", setupLog, 2)
+
+		Convey("the log should be stored once, truncated", func() {
+			events, err := Find(AllLogCollection, LastHostEventOfType("h1", EventHostProvisionFailed))
+			So(err, ShouldBeNil)
+			So(len(events), ShouldEqual, 1)
+			data := events[0].Data.Data.(*HostEventData)
+			So(data.Logs, ShouldEqual, truncateLogs(setupLog))
+			So(len(data.ProvisionSteps), ShouldEqual, 2)
+			for _, step := range data.ProvisionSteps {
+				So(step.Output, ShouldEqual, "")
+			}
+		})
+
+		Convey("finding the failure should fill in the output of the steps", func() {
+			failure, err := FindLastProvisionFailure("h1")
+			So(err, ShouldBeNil)
+			So(failure.ProvisionSteps[0].Command, ShouldEqual, "apt-get update")
+			So(failure.ProvisionSteps[1].Command, ShouldEqual, "make install")
+			So(failure.ProvisionSteps[1].Output, ShouldEqual, "error: bad")
+			So(failure.ProvisionSteps[1].Failed, ShouldBeTrue)
+			So(failure.ProvisionSteps[1].ExitCode, ShouldEqual, 2)
+		})
+	})
+}
+
+func TestExitCodeFromError(t *testing.T) {
+	Convey("The exit code should be found in a command's error, if it's there", t, func() {
+		So(ExitCodeFromError(errors.New("error running setup script over ssh: exit status 127")), ShouldEqual, 127)
+		So(ExitCodeFromError(errors.New("scp-ing script timed out")), ShouldEqual, 0)
+		So(ExitCodeFromError(nil), ShouldEqual, 0)
+	})
+}
@@ -11,16 +11,22 @@ const (
 	ResourceTypeTask = "TASK"
 
 	// event types
-	TaskCreated      = "TASK_CREATED"
-	TaskDispatched   = "TASK_DISPATCHED"
-	TaskUndispatched = "TASK_UNDISPATCHED"
-	TaskStarted      = "TASK_STARTED"
-	TaskFinished     = "TASK_FINISHED"
-	TaskRestarted    = "TASK_RESTARTED"
-	TaskActivated    = "TASK_ACTIVATED"
-	TaskDeactivated  = "TASK_DEACTIVATED"
-	TaskAbortRequest = "TASK_ABORT_REQUEST"
-	TaskScheduled    = "TASK_SCHEDULED"
+	TaskCreated         = "TASK_CREATED"
+	TaskDispatched      = "TASK_DISPATCHED"
+	TaskUndispatched    = "TASK_UNDISPATCHED"
+	TaskStarted         = "TASK_STARTED"
+	TaskFinished        = "TASK_FINISHED"
+	TaskRestarted       = "TASK_RESTARTED"
+	TaskActivated       = "TASK_ACTIVATED"
+	TaskDeactivated     = "TASK_DEACTIVATED"
+	TaskAbortRequest    = "TASK_ABORT_REQUEST"
+	TaskScheduled       = "TASK_SCHEDULED"
+	TaskSecretRotated   = "TASK_SECRET_ROTATED"
+	TaskPriorityChanged = "TASK_PRIORITY_CHANGED"
+
+	// TaskQueueWaitSLABreached is logged once each time a task is scheduled, if it
+	// then waits in a distro's queue longer than the queue wait SLA.
+	TaskQueueWaitSLABreached = "TASK_QUEUE_WAIT_SLA_BREACHED"
 )
 
 // implements Data
@@ -30,7 +36,12 @@ type TaskEventData struct {
 	HostId       string    `bson:"h_id,omitempty" json:"host_id,omitempty"`
 	UserId       string    `bson:"u_id,omitempty" json:"user_id,omitempty"`
 	Status       string    `bson:"s,omitempty" json:"status,omitempty"`
+	Priority     int64     `bson:"pri,omitempty" json:"priority,omitempty"`
+	Reason       string    `bson:"rsn,omitempty" json:"reason,omitempty"`
 	Timestamp    time.Time `bson:"ts,omitempty" json:"timestamp,omitempty"`
+
+	DistroId  string        `bson:"d_id,omitempty" json:"distro_id,omitempty"`
+	QueueWait time.Duration `bson:"q_wait,omitempty" json:"queue_wait,omitempty"`
 }
 
 func (self TaskEventData) IsValid() bool {
@@ -85,12 +96,28 @@ func LogTaskDeactivated(taskId string, userId string) {
 	LogTaskEvent(taskId, TaskDeactivated, TaskEventData{UserId: userId})
 }
 
-func LogTaskAbortRequest(taskId string, userId string) {
+func LogTaskAbortRequest(taskId string, userId string, reason string) {
 	LogTaskEvent(taskId, TaskAbortRequest,
-		TaskEventData{UserId: userId})
+		TaskEventData{UserId: userId, Reason: reason})
+}
+
+func LogTaskSecretRotated(taskId string, userId string) {
+	LogTaskEvent(taskId, TaskSecretRotated, TaskEventData{UserId: userId})
+}
+
+func LogTaskPriority(taskId string, userId string, priority int64) {
+	LogTaskEvent(taskId, TaskPriorityChanged,
+		TaskEventData{UserId: userId, Priority: priority})
 }
 
 func LogTaskScheduled(taskId string, scheduledTime time.Time) {
 	LogTaskEvent(taskId, TaskScheduled,
 		TaskEventData{Timestamp: scheduledTime})
 }
+
+// LogTaskQueueWaitSLABreached records that a task has waited longer than the queue
+// wait SLA in the distro's queue.
+func LogTaskQueueWaitSLABreached(taskId, distroId string, wait time.Duration) {
+	LogTaskEvent(taskId, TaskQueueWaitSLABreached,
+		TaskEventData{DistroId: distroId, QueueWait: wait})
+}
@@ -114,23 +114,27 @@ func (d TaskSystemResourceData) IsValid() bool {
 	return d.ResourceType == EventTaskSystemInfo
 }
 
-// LogTaskSystemData saves a SystemInfo object to the event log for a
-// task.
-func LogTaskSystemData(taskId string, info *message.SystemInfo) {
-	event := Event{
-		ResourceId: taskId,
-		Timestamp:  info.Base.Time,
-		EventType:  EventTaskSystemInfo,
-	}
+// LogTaskSystemData saves SystemInfo objects to the event log for a
+// task. Multiple samples are inserted in a single batch.
+func LogTaskSystemData(taskId string, infos ...*message.SystemInfo) {
+	events := make([]Event, 0, len(infos))
+	for _, info := range infos {
+		event := Event{
+			ResourceId: taskId,
+			Timestamp:  info.Base.Time,
+			EventType:  EventTaskSystemInfo,
+		}
 
-	info.Base = message.Base{}
-	data := TaskSystemResourceData{
-		ResourceType: EventTaskSystemInfo,
-		SystemInfo:   info,
+		info.Base = message.Base{}
+		data := TaskSystemResourceData{
+			ResourceType: EventTaskSystemInfo,
+			SystemInfo:   info,
+		}
+		event.Data = DataWrapper{data}
+		events = append(events, event)
 	}
-	event.Data = DataWrapper{data}
 
-	grip.Error(message.NewErrorWrap(NewDBEventLogger(TaskLogCollection).LogEvent(event),
+	grip.Error(message.NewErrorWrap(NewDBEventLogger(TaskLogCollection).LogEvents(events...),
 		"problem system info event"))
 }
 
@@ -2,6 +2,7 @@ package event
 
 import (
 	"testing"
+	"time"
 
 	"github.com/evergreen-ci/evergreen/db"
 	"github.com/evergreen-ci/evergreen/testutil"
@@ -96,3 +97,29 @@ func TestTaskInfoEvent(t *testing.T) {
 		})
 	})
 }
+
+func TestFindTaskTimeline(t *testing.T) {
+	Convey("With task events and system info logged for a task", t, func() {
+		testutil.HandleTestingErr(db.ClearCollections(AllLogCollection, TaskLogCollection), t,
+			"Error clearing event collections")
+
+		taskId := "timelineTask"
+		LogTaskDispatched(taskId, "host")
+		time.Sleep(time.Millisecond)
+		sysInfo, ok := message.CollectSystemInfo().(*message.SystemInfo)
+		So(ok, ShouldBeTrue)
+		LogTaskSystemData(taskId, sysInfo)
+		time.Sleep(time.Millisecond)
+		LogTaskStarted(taskId)
+		LogTaskStarted("otherTask")
+
+		Convey("the timeline should hold all of them, oldest first", func() {
+			events, err := FindTaskTimeline(taskId)
+			So(err, ShouldBeNil)
+			So(len(events), ShouldEqual, 3)
+			So(events[0].EventType, ShouldEqual, TaskDispatched)
+			So(events[1].EventType, ShouldEqual, EventTaskSystemInfo)
+			So(events[2].EventType, ShouldEqual, TaskStarted)
+		})
+	})
+}
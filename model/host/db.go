@@ -19,31 +19,45 @@ const (
 )
 
 var (
-	IdKey                    = bsonutil.MustHaveTag(Host{}, "Id")
-	DNSKey                   = bsonutil.MustHaveTag(Host{}, "Host")
-	SecretKey                = bsonutil.MustHaveTag(Host{}, "Secret")
-	UserKey                  = bsonutil.MustHaveTag(Host{}, "User")
-	TagKey                   = bsonutil.MustHaveTag(Host{}, "Tag")
-	DistroKey                = bsonutil.MustHaveTag(Host{}, "Distro")
-	ProviderKey              = bsonutil.MustHaveTag(Host{}, "Provider")
-	ProvisionedKey           = bsonutil.MustHaveTag(Host{}, "Provisioned")
-	RunningTaskKey           = bsonutil.MustHaveTag(Host{}, "RunningTask")
-	PidKey                   = bsonutil.MustHaveTag(Host{}, "Pid")
-	TaskDispatchTimeKey      = bsonutil.MustHaveTag(Host{}, "TaskDispatchTime")
-	CreateTimeKey            = bsonutil.MustHaveTag(Host{}, "CreationTime")
-	ExpirationTimeKey        = bsonutil.MustHaveTag(Host{}, "ExpirationTime")
-	TerminationTimeKey       = bsonutil.MustHaveTag(Host{}, "TerminationTime")
-	LTCTimeKey               = bsonutil.MustHaveTag(Host{}, "LastTaskCompletedTime")
-	LTCKey                   = bsonutil.MustHaveTag(Host{}, "LastTaskCompleted")
-	StatusKey                = bsonutil.MustHaveTag(Host{}, "Status")
-	AgentRevisionKey         = bsonutil.MustHaveTag(Host{}, "AgentRevision")
-	StartedByKey             = bsonutil.MustHaveTag(Host{}, "StartedBy")
-	InstanceTypeKey          = bsonutil.MustHaveTag(Host{}, "InstanceType")
-	NotificationsKey         = bsonutil.MustHaveTag(Host{}, "Notifications")
-	UserDataKey              = bsonutil.MustHaveTag(Host{}, "UserData")
-	LastReachabilityCheckKey = bsonutil.MustHaveTag(Host{}, "LastReachabilityCheck")
-	LastCommunicationTimeKey = bsonutil.MustHaveTag(Host{}, "LastCommunicationTime")
-	UnreachableSinceKey      = bsonutil.MustHaveTag(Host{}, "UnreachableSince")
+	IdKey                     = bsonutil.MustHaveTag(Host{}, "Id")
+	DNSKey                    = bsonutil.MustHaveTag(Host{}, "Host")
+	SecretKey                 = bsonutil.MustHaveTag(Host{}, "Secret")
+	UserKey                   = bsonutil.MustHaveTag(Host{}, "User")
+	TagKey                    = bsonutil.MustHaveTag(Host{}, "Tag")
+	DistroKey                 = bsonutil.MustHaveTag(Host{}, "Distro")
+	ProviderKey               = bsonutil.MustHaveTag(Host{}, "Provider")
+	InstanceIdKey             = bsonutil.MustHaveTag(Host{}, "InstanceId")
+	ProvisionedKey            = bsonutil.MustHaveTag(Host{}, "Provisioned")
+	ProvisionFailureKey       = bsonutil.MustHaveTag(Host{}, "ProvisionFailure")
+	ProvisionRetriesKey       = bsonutil.MustHaveTag(Host{}, "ProvisionRetries")
+	ReprovisionTimeKey        = bsonutil.MustHaveTag(Host{}, "ReprovisionTime")
+	NotifiedStatusKey         = bsonutil.MustHaveTag(Host{}, "NotifiedStatus")
+	RunningTaskKey            = bsonutil.MustHaveTag(Host{}, "RunningTask")
+	PidKey                    = bsonutil.MustHaveTag(Host{}, "Pid")
+	TaskDispatchTimeKey       = bsonutil.MustHaveTag(Host{}, "TaskDispatchTime")
+	CreateTimeKey             = bsonutil.MustHaveTag(Host{}, "CreationTime")
+	LaunchTimeKey             = bsonutil.MustHaveTag(Host{}, "LaunchTime")
+	ZoneKey                   = bsonutil.MustHaveTag(Host{}, "Zone")
+	StaticAddressKey          = bsonutil.MustHaveTag(Host{}, "StaticAddress")
+	ExpirationTimeKey         = bsonutil.MustHaveTag(Host{}, "ExpirationTime")
+	TerminationTimeKey        = bsonutil.MustHaveTag(Host{}, "TerminationTime")
+	LTCTimeKey                = bsonutil.MustHaveTag(Host{}, "LastTaskCompletedTime")
+	LTCKey                    = bsonutil.MustHaveTag(Host{}, "LastTaskCompleted")
+	StatusKey                 = bsonutil.MustHaveTag(Host{}, "Status")
+	AgentRevisionKey          = bsonutil.MustHaveTag(Host{}, "AgentRevision")
+	StartedByKey              = bsonutil.MustHaveTag(Host{}, "StartedBy")
+	InstanceTypeKey           = bsonutil.MustHaveTag(Host{}, "InstanceType")
+	NotificationsKey          = bsonutil.MustHaveTag(Host{}, "Notifications")
+	NoIdleTerminationUntilKey = bsonutil.MustHaveTag(Host{}, "NoIdleTerminationUntil")
+	UserDataKey               = bsonutil.MustHaveTag(Host{}, "UserData")
+	LastReachabilityCheckKey  = bsonutil.MustHaveTag(Host{}, "LastReachabilityCheck")
+	LastCommunicationTimeKey  = bsonutil.MustHaveTag(Host{}, "LastCommunicationTime")
+	UnreachableSinceKey       = bsonutil.MustHaveTag(Host{}, "UnreachableSince")
+	RequestKeyKey             = bsonutil.MustHaveTag(Host{}, "RequestKey")
+	SSHKeyKey                 = bsonutil.MustHaveTag(Host{}, "SSHKey")
+	ReservedTasksKey          = bsonutil.MustHaveTag(Host{}, "ReservedTasks")
+
+	StaticAddressPublicIpKey = bsonutil.MustHaveTag(StaticAddress{}, "PublicIp")
 )
 
 // === Queries ===
@@ -61,6 +75,21 @@ func ByUserWithRunningStatus(user string) db.Q {
 		})
 }
 
+// ByUserRequestSince produces a query that returns the user's hosts of the
+// distro that were requested with the given key since the given time, and
+// haven't been terminated.
+func ByUserRequestSince(user, distroId, requestKey string, since time.Time) db.Q {
+	distroIdKey := fmt.Sprintf("%v.%v", DistroKey, distro.IdKey)
+	return db.Query(
+		bson.M{
+			StartedByKey:  user,
+			distroIdKey:   distroId,
+			RequestKeyKey: requestKey,
+			CreateTimeKey: bson.M{"$gte": since},
+			StatusKey:     bson.M{"$ne": evergreen.HostTerminated},
+		})
+}
+
 // IsRunning is a query that returns all hosts that are running
 // (i.e. status != terminated).
 var IsRunning = db.Query(bson.M{StatusKey: bson.M{"$ne": evergreen.HostTerminated}})
@@ -117,14 +146,17 @@ var IsFree = db.Query(
 )
 
 // ByUnprovisionedSince produces a query that returns all hosts
-// Evergreen never finished setting up that were created before
-// the given time.
+// Evergreen never finished setting up that were created, and
+// weren't marked for reprovisioning, after the given time.
 func ByUnprovisionedSince(threshold time.Time) db.Q {
 	return db.Query(bson.M{
-		ProvisionedKey: false,
-		CreateTimeKey:  bson.M{"$lte": threshold},
-		StatusKey:      bson.M{"$ne": evergreen.HostTerminated},
-		StartedByKey:   evergreen.User,
+		ProvisionedKey:     false,
+		CreateTimeKey:      bson.M{"$lte": threshold},
+		ReprovisionTimeKey: bson.M{"$not": bson.M{"$gt": threshold}},
+		StatusKey: bson.M{
+			"$nin": []string{evergreen.HostTerminated, evergreen.HostTerminationFailed},
+		},
+		StartedByKey: evergreen.User,
 	})
 }
 
@@ -180,11 +212,42 @@ func ByDistroId(distroId string) db.Q {
 	})
 }
 
+// UpByDistroId produces a query that returns all up hosts of the given distro,
+// including those spawned by users.
+func UpByDistroId(distroId string) db.Q {
+	dId := fmt.Sprintf("%v.%v", DistroKey, distro.IdKey)
+	return db.Query(bson.M{
+		dId:       distroId,
+		StatusKey: bson.M{"$in": evergreen.UphostStatus},
+	})
+}
+
+// UpByProvider produces a query that returns all up hosts started with the given
+// provider, including those spawned by users.
+func UpByProvider(provider string) db.Q {
+	return db.Query(bson.M{
+		ProviderKey: provider,
+		StatusKey:   bson.M{"$in": evergreen.UphostStatus},
+	})
+}
+
 // ById produces a query that returns a host with the given id.
 func ById(id string) db.Q {
 	return db.Query(bson.D{{IdKey, id}})
 }
 
+// ByInstanceId produces a query that returns the unterminated host whose
+// underlying instance has the given provider id.
+func ByInstanceId(instanceId string) db.Q {
+	return db.Query(bson.M{
+		"$or": []bson.M{
+			{IdKey: instanceId},
+			{InstanceIdKey: instanceId},
+		},
+		StatusKey: bson.M{"$ne": evergreen.HostTerminated},
+	})
+}
+
 // ByIds produces a query that returns all hosts in the given list of ids.
 func ByIds(ids []string) db.Q {
 	return db.Query(bson.D{
@@ -269,7 +332,8 @@ func ByExpiringBetween(lowerBound time.Time, upperBound time.Time) db.Q {
 	return db.Query(bson.M{
 		StartedByKey: bson.M{"$ne": evergreen.User},
 		StatusKey: bson.M{
-			"$nin": []string{evergreen.HostTerminated, evergreen.HostQuarantined},
+			"$nin": []string{evergreen.HostTerminated, evergreen.HostQuarantined,
+				evergreen.HostTerminationFailed},
 		},
 		ExpirationTimeKey: bson.M{"$gte": lowerBound, "$lte": upperBound},
 	})
@@ -291,7 +355,8 @@ func ByExpiredSince(time time.Time) db.Q {
 	return db.Query(bson.M{
 		StartedByKey: bson.M{"$ne": evergreen.User},
 		StatusKey: bson.M{
-			"$nin": []string{evergreen.HostTerminated, evergreen.HostQuarantined},
+			"$nin": []string{evergreen.HostTerminated, evergreen.HostQuarantined,
+				evergreen.HostTerminationFailed},
 		},
 		ExpirationTimeKey: bson.M{"$lte": time},
 	})
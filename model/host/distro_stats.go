@@ -0,0 +1,86 @@
+package host
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/util"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// DistroHostStats summarizes what the unterminated hosts of a distro are doing.
+type DistroHostStats struct {
+	Distro string `json:"distro"`
+	Count  int    `json:"count"`
+	// the number of hosts that count toward the distro's MaxHosts, and the max,
+	// if the distro has one
+	UpCount  int `json:"up_count"`
+	MaxHosts int `json:"max_hosts,omitempty"`
+	// the number of hosts in each status
+	StatusCounts map[string]int `json:"status_counts"`
+	// the task each busy host is running, keyed by host id
+	RunningTasks map[string]string `json:"running_tasks"`
+}
+
+// FindDistroHostStats summarizes the unterminated hosts of every distro that has
+// any, or of just the given distro if it isn't empty.
+func FindDistroHostStats(distroId string) ([]DistroHostStats, error) {
+	filter := bson.M{StatusKey: bson.M{"$ne": evergreen.HostTerminated}}
+	distroIdKey := fmt.Sprintf("%v.%v", DistroKey, distro.IdKey)
+	if distroId != "" {
+		filter[distroIdKey] = distroId
+	}
+	hosts, err := Find(db.Query(filter).WithFields(IdKey, distroIdKey, StatusKey, RunningTaskKey))
+	if err != nil {
+		return nil, err
+	}
+	stats := SummarizeHostsByDistro(hosts)
+
+	distros, err := distro.Find(distro.All.WithFields(distro.IdKey, distro.MaxHostsKey))
+	if err != nil {
+		return nil, err
+	}
+	maxHosts := map[string]int{}
+	for _, d := range distros {
+		maxHosts[d.Id] = d.MaxHosts
+	}
+	for i := range stats {
+		stats[i].MaxHosts = maxHosts[stats[i].Distro]
+	}
+	return stats, nil
+}
+
+// SummarizeHostsByDistro groups the hosts by distro and counts them by status,
+// returning the summaries sorted by distro.
+func SummarizeHostsByDistro(hosts []Host) []DistroHostStats {
+	byDistro := map[string]*DistroHostStats{}
+	for _, h := range hosts {
+		stats, ok := byDistro[h.Distro.Id]
+		if !ok {
+			stats = &DistroHostStats{
+				Distro:       h.Distro.Id,
+				StatusCounts: map[string]int{},
+				RunningTasks: map[string]string{},
+			}
+			byDistro[h.Distro.Id] = stats
+		}
+		stats.Count++
+		if util.SliceContains(evergreen.UphostStatus, h.Status) {
+			stats.UpCount++
+		}
+		stats.StatusCounts[h.Status]++
+		if h.RunningTask != "" {
+			stats.RunningTasks[h.Id] = h.RunningTask
+		}
+	}
+
+	result := make([]DistroHostStats, 0, len(byDistro))
+	for _, stats := range byDistro {
+		result = append(result, *stats)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Distro < result[j].Distro })
+	return result
+}
@@ -0,0 +1,46 @@
+package host
+
+import (
+	"testing"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSummarizeHostsByDistro(t *testing.T) {
+	Convey("With hosts of several distros", t, func() {
+		hosts := []Host{
+			{Id: "h1", Distro: distro.Distro{Id: "d2"}, Status: evergreen.HostRunning, RunningTask: "t1"},
+			{Id: "h2", Distro: distro.Distro{Id: "d1"}, Status: evergreen.HostRunning},
+			{Id: "h3", Distro: distro.Distro{Id: "d2"}, Status: evergreen.HostInitializing},
+			{Id: "h4", Distro: distro.Distro{Id: "d2"}, Status: evergreen.HostRunning, RunningTask: "t2"},
+			{Id: "h5", Distro: distro.Distro{Id: "d2"}, Status: evergreen.HostDecommissioned},
+		}
+
+		Convey("the summaries should be sorted by distro and count hosts by status", func() {
+			stats := SummarizeHostsByDistro(hosts)
+			So(len(stats), ShouldEqual, 2)
+
+			So(stats[0].Distro, ShouldEqual, "d1")
+			So(stats[0].Count, ShouldEqual, 1)
+			So(stats[0].UpCount, ShouldEqual, 1)
+			So(stats[0].StatusCounts, ShouldResemble, map[string]int{evergreen.HostRunning: 1})
+			So(stats[0].RunningTasks, ShouldBeEmpty)
+
+			So(stats[1].Distro, ShouldEqual, "d2")
+			So(stats[1].Count, ShouldEqual, 4)
+			So(stats[1].UpCount, ShouldEqual, 3)
+			So(stats[1].StatusCounts, ShouldResemble, map[string]int{
+				evergreen.HostRunning:        2,
+				evergreen.HostInitializing:   1,
+				evergreen.HostDecommissioned: 1,
+			})
+			So(stats[1].RunningTasks, ShouldResemble, map[string]string{"h1": "t1", "h4": "t2"})
+		})
+
+		Convey("no hosts should give no summaries", func() {
+			So(SummarizeHostsByDistro(nil), ShouldBeEmpty)
+		})
+	})
+}
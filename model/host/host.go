@@ -24,9 +24,28 @@ type Host struct {
 	Distro   distro.Distro `bson:"distro" json:"distro"`
 	Provider string        `bson:"host_type" json:"host_type"`
 
+	// the provider's id for the underlying instance (e.g. the EC2 instance id), if it
+	// differs from Id or Tag
+	InstanceId string `bson:"instance_id,omitempty" json:"instance_id,omitempty"`
+
 	// true if the host has been set up properly
 	Provisioned bool `bson:"provisioned" json:"provisioned"`
 
+	// a short classification of why provisioning failed, if it did
+	ProvisionFailure string `bson:"provision_failure,omitempty" json:"provision_failure,omitempty"`
+
+	// the number of times provisioning has failed and been retried since the host
+	// was last provisioned successfully
+	ProvisionRetries int `bson:"provision_retries,omitempty" json:"provision_retries,omitempty"`
+
+	// the time the host was last marked for reprovisioning, which the monitor
+	// measures provisioning time from instead of its creation time
+	ReprovisionTime time.Time `bson:"reprovision_time,omitempty" json:"reprovision_time,omitempty"`
+
+	// the status of the host's instance as last reported by its provider in an
+	// instance state change notification, for providers that send them
+	NotifiedStatus string `bson:"notified_status,omitempty" json:"notified_status,omitempty"`
+
 	ProvisionOptions *ProvisionOptions `bson:"provision_options,omitempty" json:"provision_options,omitempty"`
 
 	// the task that is currently running on the host
@@ -41,6 +60,11 @@ type Host struct {
 	CreationTime     time.Time `bson:"creation_time" json:"creation_time"`
 	TerminationTime  time.Time `bson:"termination_time" json:"termination_time"`
 
+	// LaunchTime is when the provider launched the host's instance, which billing
+	// starts from. It can be later than CreationTime, which is when the intent host
+	// was created, and is zero until it has been looked up.
+	LaunchTime time.Time `bson:"launch_time,omitempty" json:"launch_time,omitempty"`
+
 	LastTaskCompletedTime time.Time `bson:"last_task_completed_time" json:"last_task_completed_time"`
 	LastTaskCompleted     string    `bson:"last_task" json:"last_task"`
 	LastCommunicationTime time.Time `bson:"last_communication" json:"last_communication"`
@@ -52,6 +76,31 @@ type Host struct {
 	AgentRevision string `bson:"agent_revision" json:"agent_revision"`
 	// for ec2 dynamic hosts, the instance type requested
 	InstanceType string `bson:"instance_type" json:"instance_type,omitempty"`
+	// for ec2 dynamic hosts, the tenancy requested, if not the default
+	Tenancy string `bson:"tenancy,omitempty" json:"tenancy,omitempty"`
+	// for ec2 dynamic hosts, the availability zone the instance was started in, from
+	// which the region it is billed in is known
+	Zone string `bson:"zone,omitempty" json:"zone,omitempty"`
+	// for ec2 dynamic hosts, whether shutting the host down from inside it stops or
+	// terminates its instance, if set at launch
+	ShutdownBehavior string `bson:"shutdown_behavior,omitempty" json:"shutdown_behavior,omitempty"`
+	// for ec2 dynamic hosts, the size in GB of the root volume requested, if not the
+	// image's size
+	RootVolumeSize int `bson:"root_volume_size,omitempty" json:"root_volume_size,omitempty"`
+	// for ec2 dynamic hosts, the Elastic IP associated with the instance, if its
+	// owner pinned one to it
+	StaticAddress *StaticAddress `bson:"static_address,omitempty" json:"static_address,omitempty"`
+	// for spawn hosts, the key the user's client sent with the spawn request, so
+	// that retried requests don't start a second host
+	RequestKey string `bson:"request_key,omitempty" json:"request_key,omitempty"`
+	// the name of the key in the settings that the host was provisioned with, so
+	// that the host stays reachable after its distro's key is rotated
+	SSHKey string `bson:"ssh_key,omitempty" json:"ssh_key,omitempty"`
+	// the tasks dispatched to the host to run, in order, after its running task
+	ReservedTasks []string `bson:"reserved_tasks,omitempty" json:"reserved_tasks,omitempty"`
+	// for spawn hosts, the time until which the monitor won't terminate the host
+	// for being idle, if its owner exempted it
+	NoIdleTerminationUntil time.Time `bson:"no_idle_termination_until,omitempty" json:"no_idle_termination_until,omitempty"`
 	// stores information on expiration notifications for spawn hosts
 	Notifications map[string]bool `bson:"notifications,omitempty" json:"notifications,omitempty"`
 
@@ -65,6 +114,16 @@ type Host struct {
 	UnreachableSince time.Time `bson:"unreachable_since,omitempty" json:"unreachable_since"`
 }
 
+// StaticAddress is a public address allocated for a host and associated with its
+// instance, which it keeps when the instance is stopped and started.
+type StaticAddress struct {
+	PublicIp string `bson:"public_ip" json:"public_ip"`
+	// the provider's ids for the address and its association with the instance,
+	// for providers that use them
+	AllocationId  string `bson:"allocation_id,omitempty" json:"allocation_id,omitempty"`
+	AssociationId string `bson:"association_id,omitempty" json:"association_id,omitempty"`
+}
+
 // ProvisionOptions is struct containing options about how a new host should be set up.
 type ProvisionOptions struct {
 	// LoadCLI indicates (if set) that while provisioning the host, the CLI binary should
@@ -77,6 +136,10 @@ type ProvisionOptions struct {
 
 	// Owner is the user associated with the host used to populate any necessary metadata.
 	OwnerId string `bson:"owner_id" json:"owner_id"`
+
+	// SetupScript, if set, is a script supplied by the host's owner that is run on the
+	// host as the distro's user after it is provisioned.
+	SetupScript string `bson:"setup_script,omitempty" json:"setup_script,omitempty"`
 }
 
 // IdleTime returns how long has this host been idle
@@ -94,8 +157,8 @@ func (h *Host) IdleTime() time.Duration {
 	}
 
 	// if the host has not run a task before, the idle time is just
-	// how long is has been since the host was created
-	return time.Now().Sub(h.CreationTime)
+	// how long is has been since the host was launched
+	return time.Now().Sub(h.BillingStart())
 }
 
 func (h *Host) SetStatus(status string) error {
@@ -157,18 +220,194 @@ func (h *Host) SetUnreachable() error {
 	return h.SetStatus(evergreen.HostUnreachable)
 }
 
-func (h *Host) SetUnprovisioned() error {
+// SetUnprovisioned marks an initializing host as having failed provisioning,
+// recording a short classification of the failure.
+func (h *Host) SetUnprovisioned(reason string) error {
+	err := UpdateOne(
+		bson.M{
+			IdKey:     h.Id,
+			StatusKey: evergreen.HostInitializing,
+		},
+		bson.M{
+			"$set": bson.M{
+				StatusKey:           evergreen.HostProvisionFailed,
+				ProvisionFailureKey: reason,
+			},
+		},
+	)
+	if err == nil {
+		h.Status = evergreen.HostProvisionFailed
+		h.ProvisionFailure = reason
+	}
+	return err
+}
+
+// BillingStart returns when the host's instance was launched, if that is known,
+// and otherwise when the host was created.
+func (h *Host) BillingStart() time.Time {
+	if !util.IsZeroTime(h.LaunchTime) {
+		return h.LaunchTime
+	}
+	return h.CreationTime
+}
+
+// SetLaunchTime records when the provider launched the host's instance.
+func (h *Host) SetLaunchTime(launchTime time.Time) error {
+	h.LaunchTime = launchTime
 	return UpdateOne(
+		bson.M{IdKey: h.Id},
+		bson.M{"$set": bson.M{LaunchTimeKey: launchTime}},
+	)
+}
+
+// SetZone records the availability zone the host's instance was started in.
+func (h *Host) SetZone(zone string) error {
+	h.Zone = zone
+	return UpdateOne(
+		bson.M{IdKey: h.Id},
+		bson.M{"$set": bson.M{ZoneKey: zone}},
+	)
+}
+
+// SetStaticAddress records the static address associated with the host's instance.
+// It's only recorded if the host has no static address or already has the same
+// one, so that of two concurrent requests only one claims the host;
+// mgo.ErrNotFound is returned to the other.
+func (h *Host) SetStaticAddress(address *StaticAddress) error {
+	err := UpdateOne(
+		bson.M{
+			IdKey: h.Id,
+			"$or": []bson.M{
+				{StaticAddressKey: bson.M{"$exists": false}},
+				{StaticAddressKey + "." + StaticAddressPublicIpKey: address.PublicIp},
+			},
+		},
+		bson.M{"$set": bson.M{StaticAddressKey: address}},
+	)
+	if err != nil {
+		return err
+	}
+	h.StaticAddress = address
+	return nil
+}
+
+// ClearStaticAddress records that the host's instance no longer has a static address.
+func (h *Host) ClearStaticAddress() error {
+	err := UpdateOne(
+		bson.M{IdKey: h.Id},
+		bson.M{"$unset": bson.M{StaticAddressKey: 1}},
+	)
+	if err != nil {
+		return err
+	}
+	h.StaticAddress = nil
+	return nil
+}
+
+// SetTerminationFailed marks a host whose instance couldn't be terminated, so that
+// the monitor stops trying to terminate it and an operator can intervene.
+func (h *Host) SetTerminationFailed(reason string) error {
+	if err := h.SetStatus(evergreen.HostTerminationFailed); err != nil {
+		return err
+	}
+	event.LogHostTerminationFailed(h.Id, reason)
+	return nil
+}
+
+// ReprovisionableStatuses are the statuses of hosts that can be provisioned again.
+var ReprovisionableStatuses = []string{evergreen.HostProvisionFailed, evergreen.HostRunning}
+
+// SetReprovisioning marks a host as uninitialized again, so that hostinit calls
+// its provider's OnUp and runs its distro's setup script on it again. Only hosts
+// in one of ReprovisionableStatuses that aren't running a task can be marked;
+// mgo.ErrNotFound is returned for others. The time it's marked is recorded, so
+// that the monitor doesn't treat it as taking too long to provision.
+func (h *Host) SetReprovisioning() error {
+	now := time.Now()
+	err := UpdateOne(
+		bson.M{
+			IdKey:          h.Id,
+			StatusKey:      bson.M{"$in": ReprovisionableStatuses},
+			RunningTaskKey: bson.M{"$in": []interface{}{nil, ""}},
+		},
+		bson.M{
+			"$set": bson.M{
+				StatusKey:          evergreen.HostUninitialized,
+				ProvisionedKey:     false,
+				ReprovisionTimeKey: now,
+			},
+			"$unset": bson.M{
+				ProvisionFailureKey: 1,
+				ProvisionRetriesKey: 1,
+			},
+		},
+	)
+	if err == nil {
+		event.LogHostStatusChanged(h.Id, h.Status, evergreen.HostUninitialized)
+		h.Status = evergreen.HostUninitialized
+		h.Provisioned = false
+		h.ProvisionFailure = ""
+		h.ProvisionRetries = 0
+		h.ReprovisionTime = now
+	}
+	return err
+}
+
+// RetryProvisioning marks an initializing host whose setup failed as uninitialized
+// again, so that hostinit runs its distro's setup script on it again, and counts
+// the retry. mgo.ErrNotFound is returned if the host isn't initializing.
+func (h *Host) RetryProvisioning() error {
+	err := UpdateOne(
 		bson.M{
 			IdKey:     h.Id,
 			StatusKey: evergreen.HostInitializing,
 		},
 		bson.M{
 			"$set": bson.M{
-				StatusKey: evergreen.HostProvisionFailed,
+				StatusKey: evergreen.HostUninitialized,
+			},
+			"$inc": bson.M{
+				ProvisionRetriesKey: 1,
 			},
 		},
 	)
+	if err == nil {
+		event.LogHostStatusChanged(h.Id, h.Status, evergreen.HostUninitialized)
+		h.Status = evergreen.HostUninitialized
+		h.ProvisionRetries++
+	}
+	return err
+}
+
+// SetInstanceId records the provider's id for the host's underlying instance.
+func (h *Host) SetInstanceId(instanceId string) error {
+	err := UpdateOne(
+		bson.M{IdKey: h.Id},
+		bson.M{"$set": bson.M{InstanceIdKey: instanceId}},
+	)
+	if err == nil {
+		h.InstanceId = instanceId
+	}
+	return err
+}
+
+// SetNotifiedStatus records the status of the host's instance reported by its
+// provider in an instance state change notification.
+func (h *Host) SetNotifiedStatus(status string) error {
+	err := UpdateOne(
+		bson.M{IdKey: h.Id},
+		bson.M{"$set": bson.M{NotifiedStatusKey: status}},
+	)
+	if err == nil {
+		h.NotifiedStatus = status
+	}
+	return err
+}
+
+// IsDedicated returns true if the host runs on hardware dedicated to it, which
+// is billed at a higher rate than shared hardware.
+func (h *Host) IsDedicated() bool {
+	return h.Tenancy != "" && h.Tenancy != evergreen.HostTenancyDefault
 }
 
 func (h *Host) SetQuarantined(status string) error {
@@ -190,6 +429,16 @@ func (h *Host) CreateSecret() error {
 	return nil
 }
 
+// RotateSecret replaces the host's existing secret with a newly generated one,
+// invalidating the old secret immediately.
+func (h *Host) RotateSecret() error {
+	if err := h.CreateSecret(); err != nil {
+		return err
+	}
+	event.LogHostSecretRotated(h.Id)
+	return nil
+}
+
 // UpdateLastCommunicated sets the host's last communication time to the current time.
 func (h *Host) UpdateLastCommunicated() error {
 	now := time.Now()
@@ -222,6 +471,21 @@ func (h *Host) Terminate() error {
 	)
 }
 
+// DNSAlias returns the name the host is referred to by in links and messages,
+// as set by the settings' HostDNSFormat. Connections to the host use its DNS name.
+func (h *Host) DNSAlias(settings *evergreen.Settings) string {
+	return settings.HostDNSName(h.Id, h.Distro.Id, h.Host)
+}
+
+// SSHKeyName returns the name of the settings' key used to connect to the host.
+// Hosts created before their key was recorded use their distro's key.
+func (h *Host) SSHKeyName() string {
+	if h.SSHKey != "" {
+		return h.SSHKey
+	}
+	return h.Distro.SSHKey
+}
+
 // SetDNSName updates the DNS name for a given host once
 func (h *Host) SetDNSName(dnsName string) error {
 	err := UpdateOne(
@@ -245,10 +509,43 @@ func (h *Host) SetDNSName(dnsName string) error {
 	return err
 }
 
+// UpdateDNSName replaces the DNS name for a host whose address has changed,
+// e.g. after a stop/start that assigned it a new public IP. The update is a
+// no-op if another process has already changed the host's DNS name.
+func (h *Host) UpdateDNSName(dnsName string) error {
+	if h.Host == dnsName {
+		return nil
+	}
+	if h.Host == "" {
+		return h.SetDNSName(dnsName)
+	}
+	err := UpdateOne(
+		bson.M{
+			IdKey:  h.Id,
+			DNSKey: h.Host,
+		},
+		bson.M{
+			"$set": bson.M{
+				DNSKey: dnsName,
+			},
+		},
+	)
+	if err == nil {
+		event.LogHostDNSNameChanged(h.Id, h.Host, dnsName)
+		h.Host = dnsName
+	}
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
 func (h *Host) MarkAsProvisioned() error {
 	event.LogHostProvisioned(h.Id)
 	h.Status = evergreen.HostRunning
 	h.Provisioned = true
+	h.ProvisionFailure = ""
+	h.ProvisionRetries = 0
 	return UpdateOne(
 		bson.M{
 			IdKey: h.Id,
@@ -258,17 +555,21 @@ func (h *Host) MarkAsProvisioned() error {
 				StatusKey:      evergreen.HostRunning,
 				ProvisionedKey: true,
 			},
+			"$unset": bson.M{
+				ProvisionFailureKey: 1,
+				ProvisionRetriesKey: 1,
+			},
 		},
 	)
 }
 
 // ClearRunningTask unsets the running task key on the host and updates the last task
-// completed fields.
-func (host *Host) ClearRunningTask(prevTaskId string, finishTime time.Time) error {
+// completed fields. The reason is recorded in the host's event log.
+func (host *Host) ClearRunningTask(prevTaskId string, finishTime time.Time, reason string) error {
 	host.LastTaskCompleted = prevTaskId
 	host.LastTaskCompletedTime = finishTime
 	host.RunningTask = ""
-	event.LogHostRunningTaskCleared(host.Id, prevTaskId)
+	event.LogHostRunningTaskCleared(host.Id, prevTaskId, reason)
 	return UpdateOne(
 		bson.M{
 			IdKey: host.Id,
@@ -323,6 +624,64 @@ func (host *Host) UpdateRunningTask(prevTaskId, newTaskId string,
 	return true, nil
 }
 
+// ReserveTasks adds the tasks to the end of those reserved for the host to run
+// after its running task.
+func (h *Host) ReserveTasks(taskIds []string) error {
+	if len(taskIds) == 0 {
+		return nil
+	}
+	err := UpdateOne(
+		bson.M{
+			IdKey: h.Id,
+		},
+		bson.M{
+			"$push": bson.M{
+				ReservedTasksKey: bson.M{"$each": taskIds},
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	h.ReservedTasks = append(h.ReservedTasks, taskIds...)
+	return nil
+}
+
+// UnreserveTask removes the task from those reserved for the host.
+func (h *Host) UnreserveTask(taskId string) error {
+	err := UpdateOne(
+		bson.M{
+			IdKey: h.Id,
+		},
+		bson.M{
+			"$pull": bson.M{
+				ReservedTasksKey: taskId,
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	reserved := make([]string, 0, len(h.ReservedTasks))
+	for _, id := range h.ReservedTasks {
+		if id != taskId {
+			reserved = append(reserved, id)
+		}
+	}
+	h.ReservedTasks = reserved
+	return nil
+}
+
+// HasReserved returns true if the task is reserved for the host.
+func (h *Host) HasReserved(taskId string) bool {
+	for _, id := range h.ReservedTasks {
+		if id == taskId {
+			return true
+		}
+	}
+	return false
+}
+
 // Marks that the specified task was started on the host at the specified time.
 // TODO: This should be be removed once the task runner stops assigning tasks. (EVG-1586)
 func (h *Host) SetRunningTask(taskId, agentRevision string,
@@ -377,6 +736,26 @@ func (h *Host) SetExpirationTime(expirationTime time.Time) error {
 	)
 }
 
+// SetNoIdleTerminationUntil exempts a spawn host from being terminated for being
+// idle until the given time. The zero time removes the exemption.
+func (h *Host) SetNoIdleTerminationUntil(until time.Time) error {
+	update := bson.M{"$set": bson.M{NoIdleTerminationUntilKey: until}}
+	if until.IsZero() {
+		update = bson.M{"$unset": bson.M{NoIdleTerminationUntilKey: 1}}
+	}
+	if err := UpdateOne(bson.M{IdKey: h.Id}, update); err != nil {
+		return err
+	}
+	h.NoIdleTerminationUntil = until
+	return nil
+}
+
+// IsIdleTerminationExempt returns true if the host's owner exempted it from being
+// terminated for being idle, and the exemption hasn't run out by now.
+func (h *Host) IsIdleTerminationExempt(now time.Time) bool {
+	return now.Before(h.NoIdleTerminationUntil)
+}
+
 // SetUserData updates the userdata field of a spawn host
 func (h *Host) SetUserData(userData string) error {
 	// update the in-memory host, then the database
@@ -8,8 +8,10 @@ import (
 	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/db"
 	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/event"
 	"github.com/evergreen-ci/evergreen/testutil"
 	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
 
@@ -300,6 +302,130 @@ func TestHostSetDNSName(t *testing.T) {
 	})
 }
 
+func TestHostSSHKeyName(t *testing.T) {
+	Convey("With a host provisioned with a key", t, func() {
+		host := &Host{Id: "hostOne", SSHKey: "old", Distro: distro.Distro{SSHKey: "new"}}
+
+		Convey("its own key should be used after its distro's key changes", func() {
+			So(host.SSHKeyName(), ShouldEqual, "old")
+		})
+		Convey("its distro's key should be used if its own wasn't recorded", func() {
+			host.SSHKey = ""
+			So(host.SSHKeyName(), ShouldEqual, "new")
+		})
+	})
+}
+
+func TestHostReserveTasks(t *testing.T) {
+	Convey("With a host", t, func() {
+		testutil.HandleTestingErr(db.Clear(Collection), t, "Error"+
+			" clearing '%v' collection", Collection)
+
+		h := &Host{Id: "hostOne"}
+		So(h.Insert(), ShouldBeNil)
+
+		Convey("reserved tasks should be kept in order until unreserved", func() {
+			So(h.ReserveTasks([]string{"t1", "t2"}), ShouldBeNil)
+			So(h.ReserveTasks([]string{"t3"}), ShouldBeNil)
+			So(h.HasReserved("t2"), ShouldBeTrue)
+
+			So(h.UnreserveTask("t2"), ShouldBeNil)
+			So(h.HasReserved("t2"), ShouldBeFalse)
+			So(h.ReservedTasks, ShouldResemble, []string{"t1", "t3"})
+
+			dbHost, err := FindOne(ById(h.Id))
+			So(err, ShouldBeNil)
+			So(dbHost.ReservedTasks, ShouldResemble, []string{"t1", "t3"})
+		})
+	})
+}
+
+func TestHostSetStaticAddress(t *testing.T) {
+	Convey("With a host", t, func() {
+		testutil.HandleTestingErr(db.Clear(Collection), t, "Error"+
+			" clearing '%v' collection", Collection)
+
+		h := &Host{Id: "hostOne"}
+		So(h.Insert(), ShouldBeNil)
+
+		Convey("a static address should be recorded until it's removed", func() {
+			address := &StaticAddress{PublicIp: "203.0.113.7", AllocationId: "eipalloc-1", AssociationId: "eipassoc-1"}
+			So(h.SetStaticAddress(address), ShouldBeNil)
+			dbHost, err := FindOne(ById(h.Id))
+			So(err, ShouldBeNil)
+			So(dbHost.StaticAddress, ShouldResemble, address)
+
+			So(h.ClearStaticAddress(), ShouldBeNil)
+			So(h.StaticAddress, ShouldBeNil)
+			dbHost, err = FindOne(ById(h.Id))
+			So(err, ShouldBeNil)
+			So(dbHost.StaticAddress, ShouldBeNil)
+		})
+
+		Convey("a second address should not replace the first", func() {
+			first := &StaticAddress{PublicIp: "203.0.113.7", AllocationId: "eipalloc-1"}
+			So(h.SetStaticAddress(first), ShouldBeNil)
+
+			first.AssociationId = "eipassoc-1"
+			So(h.SetStaticAddress(first), ShouldBeNil)
+
+			second := &StaticAddress{PublicIp: "203.0.113.8", AllocationId: "eipalloc-2"}
+			So(h.SetStaticAddress(second), ShouldEqual, mgo.ErrNotFound)
+			dbHost, err := FindOne(ById(h.Id))
+			So(err, ShouldBeNil)
+			So(dbHost.StaticAddress, ShouldResemble, first)
+		})
+	})
+}
+
+func TestHostUpdateDNSName(t *testing.T) {
+
+	Convey("With a host that has a DNS name", t, func() {
+
+		testutil.HandleTestingErr(db.ClearCollections(Collection, event.AllLogCollection), t,
+			"Error clearing collections")
+
+		host := &Host{
+			Id:   "hostOne",
+			Host: "hostname",
+		}
+		So(host.Insert(), ShouldBeNil)
+
+		Convey("updating the hostname should update both the in-memory and"+
+			" database copies of the host and log the change", func() {
+
+			So(host.UpdateDNSName("hostname2"), ShouldBeNil)
+			So(host.Host, ShouldEqual, "hostname2")
+
+			dbHost, err := FindOne(ById(host.Id))
+			So(err, ShouldBeNil)
+			So(dbHost.Host, ShouldEqual, "hostname2")
+
+			events, err := event.Find(event.AllLogCollection, event.HostEventsInOrder(host.Id))
+			So(err, ShouldBeNil)
+			So(len(events), ShouldEqual, 1)
+			So(events[0].EventType, ShouldEqual, event.EventHostDNSNameChanged)
+			eventData, ok := events[0].Data.Data.(*event.HostEventData)
+			So(ok, ShouldBeTrue)
+			So(eventData.OldHostname, ShouldEqual, "hostname")
+			So(eventData.Hostname, ShouldEqual, "hostname2")
+		})
+
+		Convey("updating a stale copy of the host should not overwrite the"+
+			" current DNS name", func() {
+
+			stale := *host
+			So(host.UpdateDNSName("hostname2"), ShouldBeNil)
+			So(stale.UpdateDNSName("hostname3"), ShouldBeNil)
+
+			dbHost, err := FindOne(ById(host.Id))
+			So(err, ShouldBeNil)
+			So(dbHost.Host, ShouldEqual, "hostname2")
+		})
+
+	})
+}
+
 func TestMarkAsProvisioned(t *testing.T) {
 
 	Convey("With a host", t, func() {
@@ -330,6 +456,101 @@ func TestMarkAsProvisioned(t *testing.T) {
 	})
 }
 
+func TestSetReprovisioning(t *testing.T) {
+
+	Convey("With a host that failed provisioning", t, func() {
+
+		testutil.HandleTestingErr(db.Clear(Collection), t, "Error"+
+			" clearing '%v' collection", Collection)
+
+		host := &Host{
+			Id:               "hostOne",
+			Status:           evergreen.HostProvisionFailed,
+			ProvisionFailure: "ssh",
+			StartedBy:        evergreen.User,
+			CreationTime:     time.Now().Add(-time.Hour),
+		}
+		So(host.Insert(), ShouldBeNil)
+
+		Convey("marking it for reprovisioning should make it uninitialized"+
+			" and clear the failure", func() {
+
+			So(host.SetReprovisioning(), ShouldBeNil)
+			So(host.Status, ShouldEqual, evergreen.HostUninitialized)
+
+			host, err := FindOne(ById(host.Id))
+			So(err, ShouldBeNil)
+			So(host.Status, ShouldEqual, evergreen.HostUninitialized)
+			So(host.Provisioned, ShouldBeFalse)
+			So(host.ProvisionFailure, ShouldEqual, "")
+		})
+
+		Convey("it should not count as taking too long to provision", func() {
+			threshold := time.Now().Add(-time.Minute)
+			hosts, err := Find(ByUnprovisionedSince(threshold))
+			So(err, ShouldBeNil)
+			So(len(hosts), ShouldEqual, 1)
+
+			So(host.SetReprovisioning(), ShouldBeNil)
+			hosts, err = Find(ByUnprovisionedSince(threshold))
+			So(err, ShouldBeNil)
+			So(len(hosts), ShouldEqual, 0)
+		})
+
+		Convey("hosts running a task or in other statuses should not be marked", func() {
+			So(host.SetRunningTask("task", "rev", time.Now()), ShouldBeNil)
+			So(host.SetReprovisioning(), ShouldNotBeNil)
+
+			other := &Host{Id: "hostTwo", Status: evergreen.HostTerminated}
+			So(other.Insert(), ShouldBeNil)
+			So(other.SetReprovisioning(), ShouldNotBeNil)
+		})
+
+	})
+}
+
+func TestRetryProvisioning(t *testing.T) {
+
+	Convey("With a host being provisioned", t, func() {
+
+		testutil.HandleTestingErr(db.Clear(Collection), t, "Error"+
+			" clearing '%v' collection", Collection)
+
+		host := &Host{
+			Id:     "hostOne",
+			Status: evergreen.HostInitializing,
+		}
+		So(host.Insert(), ShouldBeNil)
+
+		Convey("retrying its provisioning should make it uninitialized"+
+			" and count the retry", func() {
+
+			So(host.RetryProvisioning(), ShouldBeNil)
+			So(host.Status, ShouldEqual, evergreen.HostUninitialized)
+			So(host.ProvisionRetries, ShouldEqual, 1)
+
+			host, err := FindOne(ById(host.Id))
+			So(err, ShouldBeNil)
+			So(host.Status, ShouldEqual, evergreen.HostUninitialized)
+			So(host.ProvisionRetries, ShouldEqual, 1)
+
+			Convey("and provisioning it should reset the count", func() {
+				So(host.MarkAsProvisioned(), ShouldBeNil)
+				host, err := FindOne(ById(host.Id))
+				So(err, ShouldBeNil)
+				So(host.ProvisionRetries, ShouldEqual, 0)
+			})
+		})
+
+		Convey("hosts that aren't being provisioned should not be retried", func() {
+			other := &Host{Id: "hostTwo", Status: evergreen.HostRunning}
+			So(other.Insert(), ShouldBeNil)
+			So(other.RetryProvisioning(), ShouldNotBeNil)
+		})
+
+	})
+}
+
 func TestHostCreateSecret(t *testing.T) {
 	Convey("With a host with no secret", t, func() {
 
@@ -356,6 +577,27 @@ func TestHostCreateSecret(t *testing.T) {
 	})
 }
 
+func TestHostRotateSecret(t *testing.T) {
+	Convey("With a host with a secret", t, func() {
+
+		testutil.HandleTestingErr(db.Clear(Collection), t,
+			"Error clearing '%v' collection", Collection)
+
+		host := &Host{Id: "hostOne", Secret: "old"}
+		So(host.Insert(), ShouldBeNil)
+
+		Convey("rotating the secret should replace it in memory and in the database", func() {
+			So(host.RotateSecret(), ShouldBeNil)
+			So(host.Secret, ShouldNotEqual, "old")
+			So(host.Secret, ShouldNotEqual, "")
+
+			dbHost, err := FindOne(ById(host.Id))
+			So(err, ShouldBeNil)
+			So(dbHost.Secret, ShouldEqual, host.Secret)
+		})
+	})
+}
+
 func TestHostSetRunningTask(t *testing.T) {
 
 	Convey("With a host", t, func() {
@@ -548,7 +790,7 @@ func TestHostClearRunningTask(t *testing.T) {
 			" and task dispatch time fields from both the in-memory and"+
 			" database copies of the host", func() {
 
-			So(host.ClearRunningTask("prevTask", time.Now()), ShouldBeNil)
+			So(host.ClearRunningTask("prevTask", time.Now(), event.RunningTaskFinished), ShouldBeNil)
 			So(host.RunningTask, ShouldEqual, "")
 			So(host.LastTaskCompleted, ShouldEqual, "prevTask")
 
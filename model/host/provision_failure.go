@@ -0,0 +1,46 @@
+package host
+
+import "strings"
+
+// Classifications for the cause of a provisioning failure.
+const (
+	ProvisionFailureDiskFull        = "disk-full"
+	ProvisionFailureOutOfMemory     = "out-of-memory"
+	ProvisionFailureSSHTimeout      = "ssh-timeout"
+	ProvisionFailureSSHAuth         = "ssh-auth-error"
+	ProvisionFailureNetwork         = "network-error"
+	ProvisionFailureCloudInit       = "cloud-init-error"
+	ProvisionFailureCommandNotFound = "command-not-found"
+	ProvisionFailureUnknown         = "unknown"
+)
+
+// provisionFailurePatterns maps each classification to the lowercase log fragments
+// that indicate it. Classifications are checked in order, so more specific causes
+// come before ones that are often a side effect of them.
+var provisionFailurePatterns = []struct {
+	reason   string
+	patterns []string
+}{
+	{ProvisionFailureDiskFull, []string{"no space left on device", "disk quota exceeded"}},
+	{ProvisionFailureOutOfMemory, []string{"cannot allocate memory", "out of memory"}},
+	{ProvisionFailureSSHAuth, []string{"permission denied (publickey", "host key verification failed"}},
+	{ProvisionFailureSSHTimeout, []string{"connection timed out", "operation timed out", "i/o timeout"}},
+	{ProvisionFailureNetwork, []string{"could not resolve host", "temporary failure in name resolution",
+		"name or service not known", "connection refused", "network is unreachable"}},
+	{ProvisionFailureCloudInit, []string{"cloud-init"}},
+	{ProvisionFailureCommandNotFound, []string{"command not found"}},
+}
+
+// ClassifyProvisionFailure scans a host's setup log for signs of common causes of
+// provisioning failures and returns a short classification of the failure.
+func ClassifyProvisionFailure(setupLog string) string {
+	setupLog = strings.ToLower(setupLog)
+	for _, p := range provisionFailurePatterns {
+		for _, pattern := range p.patterns {
+			if strings.Contains(setupLog, pattern) {
+				return p.reason
+			}
+		}
+	}
+	return ProvisionFailureUnknown
+}
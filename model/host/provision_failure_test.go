@@ -0,0 +1,35 @@
+package host
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestClassifyProvisionFailure(t *testing.T) {
+	Convey("When classifying provisioning failures from setup logs", t, func() {
+
+		Convey("known failures should be classified by their log output", func() {
+			So(ClassifyProvisionFailure("cp: write error: No space left on device"),
+				ShouldEqual, ProvisionFailureDiskFull)
+			So(ClassifyProvisionFailure("ssh: connect to host 10.0.0.1 port 22: Connection timed out"),
+				ShouldEqual, ProvisionFailureSSHTimeout)
+			So(ClassifyProvisionFailure("Permission denied (publickey)."),
+				ShouldEqual, ProvisionFailureSSHAuth)
+			So(ClassifyProvisionFailure("Cloud-init v. 0.7.5 finished with errors"),
+				ShouldEqual, ProvisionFailureCloudInit)
+			So(ClassifyProvisionFailure("setup.sh: line 3: pip: command not found"),
+				ShouldEqual, ProvisionFailureCommandNotFound)
+		})
+
+		Convey("the most specific cause should win when several match", func() {
+			So(ClassifyProvisionFailure("cloud-init: No space left on device"),
+				ShouldEqual, ProvisionFailureDiskFull)
+		})
+
+		Convey("unrecognized failures should be classified as unknown", func() {
+			So(ClassifyProvisionFailure("exit status 1"), ShouldEqual, ProvisionFailureUnknown)
+			So(ClassifyProvisionFailure(""), ShouldEqual, ProvisionFailureUnknown)
+		})
+	})
+}
@@ -0,0 +1,67 @@
+package host
+
+import (
+	"time"
+
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/db/bsonutil"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// RequestKeyCollection holds the request keys that spawn requests have claimed.
+const RequestKeyCollection = "spawn_request_keys"
+
+// RequestKeyId identifies a user's request for a host of a distro.
+type RequestKeyId struct {
+	User   string `bson:"user"`
+	Distro string `bson:"distro"`
+	Key    string `bson:"key"`
+}
+
+// RequestKeyClaim records when a request key was claimed by a spawn request.
+type RequestKeyClaim struct {
+	Id        RequestKeyId `bson:"_id"`
+	ClaimedAt time.Time    `bson:"claimed_at"`
+}
+
+var (
+	RequestKeyClaimIdKey        = bsonutil.MustHaveTag(RequestKeyClaim{}, "Id")
+	RequestKeyClaimClaimedAtKey = bsonutil.MustHaveTag(RequestKeyClaim{}, "ClaimedAt")
+)
+
+// ClaimRequestKey claims the user's request key for the distro, so that only one
+// of the requests sent with it spawns a host. It returns false if another request
+// claimed the key within the given window. The claim is made with a single upsert
+// of a document keyed by the request, so concurrent requests can't both succeed:
+// an expired claim is taken over, and otherwise the insert fails on the duplicate id.
+func ClaimRequestKey(user, distroId, key string, window time.Duration) (bool, error) {
+	now := time.Now()
+	_, err := db.Upsert(
+		RequestKeyCollection,
+		bson.M{
+			RequestKeyClaimIdKey:        RequestKeyId{User: user, Distro: distroId, Key: key},
+			RequestKeyClaimClaimedAtKey: bson.M{"$lt": now.Add(-window)},
+		},
+		bson.M{"$set": bson.M{RequestKeyClaimClaimedAtKey: now}},
+	)
+	if mgo.IsDup(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ReleaseRequestKey removes the claim on the user's request key for the distro,
+// so that a retry of a request that failed before spawning a host can spawn one.
+func ReleaseRequestKey(user, distroId, key string) error {
+	err := db.Remove(RequestKeyCollection, bson.M{
+		RequestKeyClaimIdKey: RequestKeyId{User: user, Distro: distroId, Key: key},
+	})
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+package host
+
+import (
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/testutil"
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestClaimRequestKey(t *testing.T) {
+	Convey("When claiming spawn request keys", t, func() {
+		testutil.HandleTestingErr(db.Clear(RequestKeyCollection), t, "error clearing request keys")
+
+		Convey("only the first claim of a key should succeed", func() {
+			claimed, err := ClaimRequestKey("me", "d1", "key", time.Hour)
+			So(err, ShouldBeNil)
+			So(claimed, ShouldBeTrue)
+
+			claimed, err = ClaimRequestKey("me", "d1", "key", time.Hour)
+			So(err, ShouldBeNil)
+			So(claimed, ShouldBeFalse)
+		})
+
+		Convey("keys should be claimed separately per user and distro", func() {
+			for _, id := range []RequestKeyId{{"me", "d1", "key"}, {"you", "d1", "key"}, {"me", "d2", "key"}} {
+				claimed, err := ClaimRequestKey(id.User, id.Distro, id.Key, time.Hour)
+				So(err, ShouldBeNil)
+				So(claimed, ShouldBeTrue)
+			}
+		})
+
+		Convey("an expired claim should be taken over", func() {
+			So(db.Insert(RequestKeyCollection, RequestKeyClaim{
+				Id:        RequestKeyId{User: "me", Distro: "d1", Key: "key"},
+				ClaimedAt: time.Now().Add(-2 * time.Hour),
+			}), ShouldBeNil)
+			claimed, err := ClaimRequestKey("me", "d1", "key", time.Hour)
+			So(err, ShouldBeNil)
+			So(claimed, ShouldBeTrue)
+			count, err := db.Count(RequestKeyCollection, bson.M{})
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 1)
+		})
+
+		Convey("a released key should be claimable again", func() {
+			claimed, err := ClaimRequestKey("me", "d1", "key", time.Hour)
+			So(err, ShouldBeNil)
+			So(claimed, ShouldBeTrue)
+			So(ReleaseRequestKey("me", "d1", "key"), ShouldBeNil)
+			So(ReleaseRequestKey("me", "d1", "key"), ShouldBeNil)
+			claimed, err = ClaimRequestKey("me", "d1", "key", time.Hour)
+			So(err, ShouldBeNil)
+			So(claimed, ShouldBeTrue)
+		})
+	})
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/db"
 	"github.com/evergreen-ci/evergreen/model/build"
+	"github.com/evergreen-ci/evergreen/model/event"
 	"github.com/evergreen-ci/evergreen/model/patch"
 	"github.com/evergreen-ci/evergreen/model/task"
 	"github.com/evergreen-ci/evergreen/model/version"
@@ -169,6 +170,20 @@ func MarkVersionCompleted(versionId string, finishTime time.Time) error {
 	)
 }
 
+// SetTaskPriority sets the priority of a task and the tasks it depends on, and
+// updates the task's entries in the distro task queues so that the new priority
+// is honored when the next task is dispatched.
+func SetTaskPriority(t *task.Task, priority int64, caller string) error {
+	if err := t.SetPriority(priority); err != nil {
+		return fmt.Errorf("error setting priority for task %v: %v", t.Id, err)
+	}
+	if err := SetTaskQueueItemPriority(t.Id, priority); err != nil {
+		return fmt.Errorf("error updating queued priority for task %v: %v", t.Id, err)
+	}
+	event.LogTaskPriority(t.Id, caller, priority)
+	return nil
+}
+
 // SetBuildPriority updates the priority field of all tasks associated with the given build id.
 func SetBuildPriority(buildId string, priority int64) error {
 	modifier := bson.M{task.PriorityKey: priority}
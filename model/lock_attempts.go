@@ -0,0 +1,84 @@
+package model
+
+import (
+	"time"
+
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/db/bsonutil"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	LockAttemptsCollection = "global_lock_attempts"
+
+	// lockAttemptsBucketSize is the length of time whose global lock attempts are
+	// counted together.
+	lockAttemptsBucketSize = time.Minute
+
+	// lockAttemptsRetention is how long counts of global lock attempts are kept.
+	lockAttemptsRetention = 24 * time.Hour
+)
+
+// LockAttemptCounts counts the attempts that every server made to acquire the
+// global lock within a minute, and how many of them timed out.
+type LockAttemptCounts struct {
+	Start    time.Time `bson:"_id"`
+	Attempts int       `bson:"attempts"`
+	Timeouts int       `bson:"timeouts"`
+}
+
+var (
+	LockAttemptsStartKey    = bsonutil.MustHaveTag(LockAttemptCounts{}, "Start")
+	LockAttemptsAttemptsKey = bsonutil.MustHaveTag(LockAttemptCounts{}, "Attempts")
+	LockAttemptsTimeoutsKey = bsonutil.MustHaveTag(LockAttemptCounts{}, "Timeouts")
+)
+
+// RecordGlobalLockAttempt counts an attempt to acquire the global lock made at the
+// given time. Counts older than a day are removed whenever a new minute's counts
+// are started.
+func RecordGlobalLockAttempt(timedOut bool, at time.Time) error {
+	start := at.Truncate(lockAttemptsBucketSize)
+	inc := bson.M{LockAttemptsAttemptsKey: 1}
+	if timedOut {
+		inc[LockAttemptsTimeoutsKey] = 1
+	}
+	info, err := db.Upsert(
+		LockAttemptsCollection,
+		bson.M{LockAttemptsStartKey: start},
+		bson.M{"$inc": inc},
+	)
+	if err != nil {
+		return err
+	}
+	if info.UpsertedId != nil {
+		return db.RemoveAll(LockAttemptsCollection, bson.M{
+			LockAttemptsStartKey: bson.M{"$lt": start.Add(-lockAttemptsRetention)},
+		})
+	}
+	return nil
+}
+
+// GlobalLockTimeoutRate returns the fraction of the attempts to acquire the global
+// lock made by any server between start and end that timed out. Attempts are
+// counted by the minute, so those in the minute that start falls in are included.
+func GlobalLockTimeoutRate(start, end time.Time) (float64, error) {
+	totals := []LockAttemptCounts{}
+	pipeline := []bson.M{
+		{"$match": bson.M{LockAttemptsStartKey: bson.M{
+			"$gte": start.Truncate(lockAttemptsBucketSize),
+			"$lte": end,
+		}}},
+		{"$group": bson.M{
+			"_id":                   nil,
+			LockAttemptsAttemptsKey: bson.M{"$sum": "$" + LockAttemptsAttemptsKey},
+			LockAttemptsTimeoutsKey: bson.M{"$sum": "$" + LockAttemptsTimeoutsKey},
+		}},
+	}
+	if err := db.Aggregate(LockAttemptsCollection, pipeline, &totals); err != nil {
+		return 0, err
+	}
+	if len(totals) == 0 || totals[0].Attempts == 0 {
+		return 0, nil
+	}
+	return float64(totals[0].Timeouts) / float64(totals[0].Attempts), nil
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/testutil"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGlobalLockTimeoutRate(t *testing.T) {
+	Convey("With global lock attempts recorded", t, func() {
+		testutil.HandleTestingErr(db.Clear(LockAttemptsCollection), t, "error clearing lock attempts")
+		now := time.Now()
+
+		Convey("no attempts should have no timeouts", func() {
+			rate, err := GlobalLockTimeoutRate(now.Add(-time.Hour), now)
+			So(err, ShouldBeNil)
+			So(rate, ShouldEqual, 0)
+		})
+
+		Convey("only attempts within the range should be counted", func() {
+			So(RecordGlobalLockAttempt(true, now.Add(-2*time.Hour)), ShouldBeNil)
+			So(RecordGlobalLockAttempt(true, now.Add(-10*time.Minute)), ShouldBeNil)
+			So(RecordGlobalLockAttempt(false, now.Add(-10*time.Minute)), ShouldBeNil)
+			So(RecordGlobalLockAttempt(false, now), ShouldBeNil)
+			So(RecordGlobalLockAttempt(false, now), ShouldBeNil)
+
+			rate, err := GlobalLockTimeoutRate(now.Add(-time.Hour), now)
+			So(err, ShouldBeNil)
+			So(rate, ShouldEqual, 0.25)
+		})
+
+		Convey("counts older than a day should be removed", func() {
+			So(RecordGlobalLockAttempt(true, now.Add(-2*lockAttemptsRetention)), ShouldBeNil)
+			So(RecordGlobalLockAttempt(false, now), ShouldBeNil)
+			count, err := db.Count(LockAttemptsCollection, struct{}{})
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 1)
+		})
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/apimodels"
 	"github.com/evergreen-ci/evergreen/command"
 	"github.com/evergreen-ci/evergreen/db/bsonutil"
 	"github.com/evergreen-ci/evergreen/model/build"
@@ -71,6 +72,10 @@ type BuildVariantTask struct {
 
 	// the distros that the task can be run on
 	Distros []string `yaml:"distros,omitempty" bson:"distros"`
+
+	// Expansions override the task's expansions on this build variant; see
+	// ExpansionSources
+	Expansions map[string]string `yaml:"expansions,omitempty" bson:"expansions,omitempty"`
 }
 
 // Populate updates the base fields of the BuildVariantTask with
@@ -190,6 +195,41 @@ type PluginCommandConf struct {
 	Vars map[string]string `yaml:"vars,omitempty" bson:"vars"`
 }
 
+// UnmarshalYAML converts the maps nested in the command's params, which the YAML
+// parser gives interface{} keys, to maps with string keys, so that the params can
+// be marshaled to JSON.
+func (p *PluginCommandConf) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type pluginCommandConf PluginCommandConf
+	conf := pluginCommandConf{}
+	if err := unmarshal(&conf); err != nil {
+		return err
+	}
+	for key, value := range conf.Params {
+		conf.Params[key] = stringifyMapKeys(value)
+	}
+	*p = PluginCommandConf(conf)
+	return nil
+}
+
+// stringifyMapKeys returns the value with the keys of any maps within it, however
+// deeply nested, converted to strings.
+func stringifyMapKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, elem := range v {
+			out[fmt.Sprintf("%v", key)] = stringifyMapKeys(elem)
+		}
+		return out
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = stringifyMapKeys(elem)
+		}
+		return v
+	}
+	return value
+}
+
 type ArtifactInstructions struct {
 	Include      []string `yaml:"include,omitempty" bson:"include"`
 	ExcludeFiles []string `yaml:"excludefiles,omitempty" bson:"exclude_files"`
@@ -280,6 +320,10 @@ type ProjectTask struct {
 	//   3. false = overriding the project setting with false
 	Patchable *bool `yaml:"patchable,omitempty" bson:"patchable,omitempty"`
 	Stepback  *bool `yaml:"stepback,omitempty" bson:"stepback,omitempty"`
+
+	// Expansions override the task's expansions on every build variant; see
+	// ExpansionSources
+	Expansions map[string]string `yaml:"expansions,omitempty" bson:"expansions,omitempty"`
 }
 
 type TaskConfig struct {
@@ -457,11 +501,11 @@ func NewTaskConfig(d *distro.Distro, v *version.Version, p *Project, t *task.Tas
 		return nil, fmt.Errorf("couldn't find buildvariant: '%v'", t.BuildVariant)
 	}
 
-	e := populateExpansions(d, v, bv, t)
+	e := populateExpansions(d, v, p, bv, t)
 	return &TaskConfig{d, v, r, p, t, bv, e, d.WorkDir}, nil
 }
 
-func populateExpansions(d *distro.Distro, v *version.Version, bv *BuildVariant, t *task.Task) *command.Expansions {
+func populateExpansions(d *distro.Distro, v *version.Version, p *Project, bv *BuildVariant, t *task.Task) *command.Expansions {
 	expansions := command.NewExpansions(map[string]string{})
 	expansions.Put("execution", fmt.Sprintf("%v", t.Execution))
 	expansions.Put("version_id", t.Version)
@@ -481,9 +525,71 @@ func populateExpansions(d *distro.Distro, v *version.Version, bv *BuildVariant,
 		expansions.Put(e.Key, e.Value)
 	}
 	expansions.Update(bv.Expansions)
+	expansions.Update(p.TaskExpansions(t.DisplayName, bv.Name))
+	return expansions
+}
+
+// TaskExpansions returns the expansions the project overrides for a task on a build
+// variant: those of the task's definition, overridden by those of the task's entry
+// in the build variant.
+func (p *Project) TaskExpansions(taskName, variant string) map[string]string {
+	expansions := map[string]string{}
+	if pt := p.FindProjectTask(taskName); pt != nil {
+		for key, val := range pt.Expansions {
+			expansions[key] = val
+		}
+	}
+	if bv := p.FindBuildVariant(variant); bv != nil {
+		for _, bvt := range bv.Tasks {
+			if bvt.Name != taskName {
+				continue
+			}
+			for key, val := range bvt.Expansions {
+				expansions[key] = val
+			}
+		}
+	}
 	return expansions
 }
 
+// ExpansionSources names the sources of a task's expansions, from lowest to
+// highest precedence. The project's variables take precedence over the expansions
+// in its config, including task overrides, as they do in the agent.
+var ExpansionSources = []string{"deployment", "task", "distro", "build_variant",
+	"project_task", "build_variant_task", "project_vars"}
+
+// MergeExpansions returns every expansion a task runs with: the deployment's
+// expansions listed in TaskExpansions, the task's built-in expansions, the distro's
+// expansions, the build variant's expansions, the expansions of the task's
+// definition and of its entry in the build variant, and the project's variables. Each source, in the order of
+// ExpansionSources, overrides the expansions of the sources before it.
+func MergeExpansions(settings *evergreen.Settings, d *distro.Distro, v *version.Version, p *Project,
+	t *task.Task, projectVars map[string]string) (apimodels.ExpansionVars, error) {
+	bv := p.FindBuildVariant(t.BuildVariant)
+	if bv == nil {
+		return nil, fmt.Errorf("couldn't find buildvariant: '%v'", t.BuildVariant)
+	}
+
+	deployment := map[string]string{}
+	for _, key := range settings.TaskExpansions {
+		if val, ok := settings.Expansions[key]; ok {
+			deployment[key] = val
+		}
+	}
+
+	merged := apimodels.ExpansionVars{}
+	for _, source := range []map[string]string{
+		deployment,
+		*populateExpansions(d, v, p, bv, t),
+		projectVars,
+	} {
+		for key, val := range source {
+			merged[key] = val
+		}
+	}
+	return merged, nil
+}
+
 // GetSpecForTask returns a ProjectTask spec for the given name.
 // Returns an empty ProjectTask if none exists.
 func (p Project) GetSpecForTask(name string) ProjectTask {
@@ -555,6 +661,17 @@ func (m *Module) GetRepoOwnerAndName() (string, string) {
 }
 
 func FindProject(revision string, projectRef *ProjectRef) (*Project, error) {
+	return findProject(revision, projectRef, false)
+}
+
+// FindLastKnownGoodProject returns the project's last known good configuration, as
+// FindProject does for an empty revision, reading it from a secondary if one is
+// configured. It's for read-only callers that can tolerate replication lag.
+func FindLastKnownGoodProject(projectRef *ProjectRef) (*Project, error) {
+	return findProject("", projectRef, true)
+}
+
+func findProject(revision string, projectRef *ProjectRef, secondaryOk bool) (*Project, error) {
 	if projectRef == nil {
 		return nil, fmt.Errorf("projectRef given is nil")
 	}
@@ -568,7 +685,11 @@ func FindProject(revision string, projectRef *ProjectRef) (*Project, error) {
 	// If the last known good configuration does not exist,
 	// load the configuration from the local config in the project ref.
 	if revision == "" {
-		lastGoodVersion, err := version.FindOne(version.ByLastKnownGoodConfig(projectRef.Identifier))
+		lastGoodQuery := version.ByLastKnownGoodConfig(projectRef.Identifier)
+		if secondaryOk {
+			lastGoodQuery = lastGoodQuery.SecondaryOk()
+		}
+		lastGoodVersion, err := version.FindOne(lastGoodQuery)
 		if err != nil {
 			return nil, fmt.Errorf("Error finding recent valid version for %v: %v", projectRef.Identifier, err)
 		}
@@ -0,0 +1,152 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/cloud"
+	"github.com/evergreen-ci/evergreen/cloud/providers"
+	"github.com/evergreen-ci/evergreen/model/event"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/mongodb/grip"
+)
+
+// ProjectCost holds the cost of the host time a project's tasks used.
+type ProjectCost struct {
+	Project string  `json:"project"`
+	Cost    float64 `json:"cost"`
+	// the number of task runs the cost covers
+	NumTasks int `json:"num_tasks"`
+}
+
+// hostTaskSpan is a span of time during which a host was running a task.
+type hostTaskSpan struct {
+	HostId string
+	TaskId string
+	Start  time.Time
+	End    time.Time
+}
+
+// hostTaskSpans reconstructs when each host was running each task from the events
+// logged between start and end, which must be in chronological order. Spans are
+// clipped to the range, so a task that was already running at the start of the
+// range is counted from start, and one still running at the end is counted until end.
+func hostTaskSpans(events []event.Event, start, end time.Time) []hostTaskSpan {
+	type runKey struct{ hostId, taskId string }
+	running := map[runKey]time.Time{}
+	finished := map[runKey]bool{}
+	spans := []hostTaskSpan{}
+
+	for _, e := range events {
+		data, ok := e.Data.Data.(*event.HostEventData)
+		if !ok || data.TaskId == "" {
+			continue
+		}
+		key := runKey{e.ResourceId, data.TaskId}
+		switch e.EventType {
+		case event.EventHostRunningTaskSet:
+			if _, ok := running[key]; !ok {
+				running[key] = e.Timestamp
+			}
+			delete(finished, key)
+		case event.EventHostRunningTaskCleared, event.EventTaskFinished:
+			// a task run can be logged as both cleared and finished
+			if finished[key] {
+				continue
+			}
+			spanStart, ok := running[key]
+			if !ok {
+				spanStart = start
+			}
+			spans = append(spans, hostTaskSpan{e.ResourceId, data.TaskId, spanStart, e.Timestamp})
+			delete(running, key)
+			finished[key] = true
+		}
+	}
+	for key, spanStart := range running {
+		spans = append(spans, hostTaskSpan{key.hostId, key.taskId, spanStart, end})
+	}
+	return spans
+}
+
+// ComputeProjectCosts totals the cost of host time spent running each project's
+// tasks between start and end, using the host event log to tell which task each
+// host was running. Hosts whose providers can't calculate costs are not counted.
+func ComputeProjectCosts(settings *evergreen.Settings, start, end time.Time) ([]ProjectCost, error) {
+	events, err := event.Find(event.AllLogCollection, event.HostTaskEventsInRange(start, end))
+	if err != nil {
+		return nil, fmt.Errorf("error finding host task events: %v", err)
+	}
+
+	hosts := map[string]*host.Host{}
+	projects := map[string]string{}
+	calculators := map[string]cloud.CloudCostCalculator{}
+	totals := map[string]*ProjectCost{}
+
+	for _, span := range hostTaskSpans(events, start, end) {
+		h, ok := hosts[span.HostId]
+		if !ok {
+			h, err = host.FindOne(host.ById(span.HostId))
+			if err != nil {
+				return nil, fmt.Errorf("error finding host %v: %v", span.HostId, err)
+			}
+			hosts[span.HostId] = h
+		}
+		if h == nil {
+			grip.Warningf("Host %v is no longer recorded; not counting its cost", span.HostId)
+			continue
+		}
+
+		calc, ok := calculators[h.Provider]
+		if !ok {
+			manager, err := providers.GetCloudManager(h.Provider, settings)
+			if err != nil {
+				return nil, fmt.Errorf("error loading provider for host %v: %v", h.Id, err)
+			}
+			calc, _ = manager.(cloud.CloudCostCalculator)
+			calculators[h.Provider] = calc
+		}
+		if calc == nil {
+			continue
+		}
+
+		project, ok := projects[span.TaskId]
+		if !ok {
+			t, err := task.FindOne(task.ById(span.TaskId))
+			if err != nil {
+				return nil, fmt.Errorf("error finding task %v: %v", span.TaskId, err)
+			}
+			if t != nil {
+				project = t.Project
+			}
+			projects[span.TaskId] = project
+		}
+		if project == "" {
+			grip.Warningf("Can't find the project of task %v; not counting its cost", span.TaskId)
+			continue
+		}
+
+		cost, err := calc.CostForDuration(h, span.Start, span.End)
+		if err != nil {
+			return nil, fmt.Errorf("error calculating cost of task %v on host %v: %v",
+				span.TaskId, h.Id, err)
+		}
+		total, ok := totals[project]
+		if !ok {
+			total = &ProjectCost{Project: project}
+			totals[project] = total
+		}
+		total.Cost += cost
+		total.NumTasks++
+	}
+
+	result := make([]ProjectCost, 0, len(totals))
+	for _, total := range totals {
+		result = append(result, *total)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Project < result[j].Project })
+	return result, nil
+}
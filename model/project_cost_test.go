@@ -0,0 +1,44 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/model/event"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func hostTaskEvent(eventType, hostId, taskId string, ts time.Time) event.Event {
+	return event.Event{
+		Timestamp:  ts,
+		ResourceId: hostId,
+		EventType:  eventType,
+		Data:       event.DataWrapper{Data: &event.HostEventData{ResourceType: event.ResourceTypeHost, TaskId: taskId}},
+	}
+}
+
+func TestHostTaskSpans(t *testing.T) {
+	Convey("With host task events over a time range", t, func() {
+		start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		end := start.Add(10 * time.Hour)
+		at := func(h int) time.Time { return start.Add(time.Duration(h) * time.Hour) }
+
+		events := []event.Event{
+			// t1 was already running on h1 when the range began
+			hostTaskEvent(event.EventTaskFinished, "h1", "t1", at(1)),
+			hostTaskEvent(event.EventHostRunningTaskCleared, "h1", "t1", at(1)),
+			hostTaskEvent(event.EventHostRunningTaskSet, "h1", "t2", at(2)),
+			hostTaskEvent(event.EventTaskFinished, "h1", "t2", at(4)),
+			// t3 is still running on h2 when the range ends
+			hostTaskEvent(event.EventHostRunningTaskSet, "h2", "t3", at(5)),
+		}
+
+		Convey("each task run should produce one span clipped to the range", func() {
+			spans := hostTaskSpans(events, start, end)
+			So(len(spans), ShouldEqual, 3)
+			So(spans[0], ShouldResemble, hostTaskSpan{"h1", "t1", start, at(1)})
+			So(spans[1], ShouldResemble, hostTaskSpan{"h1", "t2", at(2), at(4)})
+			So(spans[2], ShouldResemble, hostTaskSpan{"h2", "t3", at(5), end})
+		})
+	})
+}
@@ -75,6 +75,7 @@ type parserTask struct {
 	Tags            parserStringSlice   `yaml:"tags"`
 	Patchable       *bool               `yaml:"patchable"`
 	Stepback        *bool               `yaml:"stepback"`
+	Expansions      map[string]string   `yaml:"expansions"`
 }
 
 // helper methods for task tag evaluations
@@ -275,6 +276,7 @@ type parserBVTask struct {
 	Stepback        *bool              `yaml:"stepback"`
 	Distros         parserStringSlice  `yaml:"distros"`
 	RunOn           parserStringSlice  `yaml:"run_on"` // Alias for "Distros" TODO: deprecate Distros
+	Expansions      map[string]string  `yaml:"expansions"`
 }
 
 // UnmarshalYAML allows the YAML parser to read both a single selector string or
@@ -465,6 +467,7 @@ func evaluateTasks(tse *taskSelectorEvaluator, vse *variantSelectorEvaluator,
 			Tags:            pt.Tags,
 			Patchable:       pt.Patchable,
 			Stepback:        pt.Stepback,
+			Expansions:      pt.Expansions,
 		}
 		t.DependsOn, errs = evaluateDependsOn(tse, vse, pt.DependsOn)
 		evalErrs = append(evalErrs, errs...)
@@ -571,6 +574,7 @@ func evaluateBVTasks(tse *taskSelectorEvaluator, vse *variantSelectorEvaluator,
 				ExecTimeoutSecs: pt.ExecTimeoutSecs,
 				Stepback:        pt.Stepback,
 				Distros:         pt.Distros,
+				Expansions:      pt.Expansions,
 			}
 			t.DependsOn, errs = evaluateDependsOn(tse, vse, pt.DependsOn)
 			evalErrs = append(evalErrs, errs...)
@@ -436,6 +436,23 @@ func TestTranslateBuildVariants(t *testing.T) {
 			So(bvts[1].DependsOn[0].Name, ShouldEqual, "t3")
 			So(bvts[2].Requires[0].Name, ShouldEqual, "t1")
 		})
+		Convey("task expansion overrides should be kept", func() {
+			pp.Tasks = []parserTask{
+				{Name: "t1", Expansions: map[string]string{"a": "task"}},
+			}
+			pp.BuildVariants = []parserBV{{
+				Name: "v1",
+				Tasks: parserBVTasks{
+					{Name: "t1", Expansions: map[string]string{"a": "variant"}},
+				},
+			}}
+			out, errs := translateProject(pp)
+			So(out, ShouldNotBeNil)
+			So(len(errs), ShouldEqual, 0)
+			So(out.Tasks[0].Expansions["a"], ShouldEqual, "task")
+			So(out.BuildVariants[0].Tasks[0].Expansions["a"], ShouldEqual, "variant")
+			So(out.TaskExpansions("t1", "v1")["a"], ShouldEqual, "variant")
+		})
 		Convey("a bvtask with erroneous requirements should fail", func() {
 			pp.Tasks = []parserTask{
 				{Name: "t1"},
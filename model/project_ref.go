@@ -110,16 +110,19 @@ func FindOneProjectRef(identifier string) (*ProjectRef, error) {
 // that are currently being tracked (i.e. their project files
 // still exist)
 func FindAllTrackedProjectRefs() ([]ProjectRef, error) {
+	return FindProjectRefs(TrackedProjectRefs())
+}
+
+// TrackedProjectRefs returns a query for the project refs that are currently
+// being tracked.
+func TrackedProjectRefs() db.Q {
+	return db.Query(bson.M{ProjectRefTrackedKey: true})
+}
+
+// FindProjectRefs returns the project refs matching the query.
+func FindProjectRefs(query db.Q) ([]ProjectRef, error) {
 	projectRefs := []ProjectRef{}
-	err := db.FindAll(
-		ProjectRefCollection,
-		bson.M{ProjectRefTrackedKey: true},
-		db.NoProjection,
-		db.NoSort,
-		db.NoSkip,
-		db.NoLimit,
-		&projectRefs,
-	)
+	err := db.FindAllQ(ProjectRefCollection, query, &projectRefs)
 	return projectRefs, err
 }
 
@@ -1,9 +1,12 @@
 package model
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/task"
 	"github.com/evergreen-ci/evergreen/model/version"
 	"github.com/evergreen-ci/evergreen/testutil"
 	"github.com/evergreen-ci/evergreen/util"
@@ -270,6 +273,87 @@ func TestIgnoresAllFiles(t *testing.T) {
 	})
 }
 
+func TestMergeExpansions(t *testing.T) {
+	Convey("With a task whose expansions are set by every source", t, func() {
+		settings := &evergreen.Settings{
+			Expansions: map[string]string{
+				"deploy": "deploy", "distro": "deploy", "bv": "deploy", "vars": "deploy", "secret": "deploy"},
+			TaskExpansions: []string{"deploy", "distro", "bv", "vars"},
+		}
+		d := &distro.Distro{WorkDir: "/data", Expansions: []distro.Expansion{
+			{Key: "distro", Value: "distro"}, {Key: "bv", Value: "distro"}, {Key: "vars", Value: "distro"}}}
+		v := &version.Version{Branch: "master"}
+		p := &Project{
+			BuildVariants: []BuildVariant{{Name: "bv1", Expansions: map[string]string{
+				"bv": "bv", "pt": "bv", "bvt": "bv", "vars": "bv"},
+				Tasks: []BuildVariantTask{{Name: "compile", Expansions: map[string]string{
+					"bvt": "bvt", "vars": "bvt"}}}}},
+			Tasks: []ProjectTask{{Name: "compile", Expansions: map[string]string{
+				"pt": "pt", "bvt": "pt", "vars": "pt"}}},
+		}
+		tsk := &task.Task{Id: "t1", DisplayName: "compile", BuildVariant: "bv1"}
+		vars := map[string]string{"vars": "vars"}
+
+		Convey("later sources should override earlier ones", func() {
+			merged, err := MergeExpansions(settings, d, v, p, tsk, vars)
+			So(err, ShouldBeNil)
+			So(merged["deploy"], ShouldEqual, "deploy")
+			So(merged["distro"], ShouldEqual, "distro")
+			So(merged["bv"], ShouldEqual, "bv")
+			So(merged["pt"], ShouldEqual, "pt")
+			So(merged["bvt"], ShouldEqual, "bvt")
+			So(merged["vars"], ShouldEqual, "vars")
+			So(merged["task_id"], ShouldEqual, "t1")
+			So(merged["workdir"], ShouldEqual, "/data")
+		})
+		Convey("deployment expansions that aren't given to tasks should be left out", func() {
+			merged, err := MergeExpansions(settings, d, v, p, tsk, vars)
+			So(err, ShouldBeNil)
+			_, ok := merged["secret"]
+			So(ok, ShouldBeFalse)
+		})
+		Convey("a task on an unknown build variant should error", func() {
+			tsk.BuildVariant = "bv2"
+			_, err := MergeExpansions(settings, d, v, p, tsk, vars)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestProjectTaskJSON(t *testing.T) {
+	Convey("With tasks that have dependencies and commands with nested params", t, func() {
+		p := &Project{}
+		err := LoadProjectInto([]byte(`
+tasks:
+- name: lint
+- name: compile
+  depends_on:
+  - name: lint
+    status: "*"
+  commands:
+  - command: shell.exec
+    params:
+      script: make
+      env:
+        GOPATH: /data/go
+      files: [a, {b: c}]
+`), "proj", p)
+		So(err, ShouldBeNil)
+
+		Convey("their dependencies and commands should round-trip through JSON", func() {
+			out, err := json.Marshal(p.Tasks)
+			So(err, ShouldBeNil)
+			tasks := []ProjectTask{}
+			So(json.Unmarshal(out, &tasks), ShouldBeNil)
+			So(len(tasks), ShouldEqual, 2)
+			So(tasks[1].DependsOn, ShouldResemble, p.Tasks[1].DependsOn)
+			So(tasks[1].Commands, ShouldResemble, p.Tasks[1].Commands)
+			So(tasks[1].Commands[0].Params["env"], ShouldResemble,
+				map[string]interface{}{"GOPATH": "/data/go"})
+		})
+	})
+}
+
 func boolPtr(b bool) *bool {
 	return &b
 }
@@ -0,0 +1,110 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/model/event"
+	"github.com/mongodb/grip"
+)
+
+// provisionFailureSampleLines is how many of the last lines of a failed setup
+// script's log are kept as a distro's sample failure.
+const provisionFailureSampleLines = 20
+
+// DistroProvisionFailures summarizes the failures to provision a distro's hosts,
+// with the most recent failure as a sample.
+type DistroProvisionFailures struct {
+	Distro      string `json:"distro"`
+	NumFailures int    `json:"num_failures"`
+	NumHosts    int    `json:"num_hosts"`
+
+	LastFailure  time.Time `json:"last_failure"`
+	SampleHostId string    `json:"sample_host_id"`
+	SampleReason string    `json:"sample_reason,omitempty"`
+	SampleLogs   string    `json:"sample_logs,omitempty"`
+}
+
+// ComputeProvisionFailures reads the provisioning failures logged between start
+// and end and groups them by the distro of the host that failed, most failures
+// first. Failures of hosts that are no longer recorded are not counted.
+func ComputeProvisionFailures(start, end time.Time) ([]DistroProvisionFailures, error) {
+	events, err := event.Find(event.AllLogCollection,
+		event.HostEventsOfTypeInRange(event.EventHostProvisionFailed, start, end))
+	if err != nil {
+		return nil, fmt.Errorf("error finding host provision failure events: %v", err)
+	}
+
+	distros, err := hostDistroIds(events)
+	if err != nil {
+		return nil, err
+	}
+	totals := map[string]*DistroProvisionFailures{}
+	hosts := map[string]map[string]bool{}
+	for _, e := range events {
+		data, ok := e.Data.Data.(*event.HostEventData)
+		if !ok {
+			continue
+		}
+
+		distroId := distros[e.ResourceId]
+		if distroId == "" {
+			grip.Warningf("Can't find the distro of host %v; not counting its provision failure", e.ResourceId)
+			continue
+		}
+
+		total, ok := totals[distroId]
+		if !ok {
+			total = &DistroProvisionFailures{Distro: distroId}
+			totals[distroId] = total
+			hosts[distroId] = map[string]bool{}
+		}
+		total.NumFailures++
+		hosts[distroId][e.ResourceId] = true
+		// events are oldest first, so the last one seen is the most recent
+		total.LastFailure = e.Timestamp
+		total.SampleHostId = e.ResourceId
+		total.SampleReason = provisionFailureReason(data)
+		total.SampleLogs = lastLines(data.Logs, provisionFailureSampleLines)
+	}
+
+	result := make([]DistroProvisionFailures, 0, len(totals))
+	for distroId, total := range totals {
+		total.NumHosts = len(hosts[distroId])
+		result = append(result, *total)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].NumFailures != result[j].NumFailures {
+			return result[i].NumFailures > result[j].NumFailures
+		}
+		return result[i].Distro < result[j].Distro
+	})
+	return result, nil
+}
+
+// provisionFailureReason describes why provisioning failed: the step of the setup
+// script that failed, if it could be parsed from the log, or else the log's last line.
+func provisionFailureReason(data *event.HostEventData) string {
+	for i := len(data.ProvisionSteps) - 1; i >= 0; i-- {
+		step := data.ProvisionSteps[i]
+		if !step.Failed {
+			continue
+		}
+		if step.ExitCode != 0 {
+			return fmt.Sprintf("'%v' failed with exit code %v", step.Command, step.ExitCode)
+		}
+		return fmt.Sprintf("'%v' failed", step.Command)
+	}
+	return lastLines(data.Logs, 1)
+}
+
+// lastLines returns the last n non-blank lines of s.
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/evergreen-ci/evergreen/model/event"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestProvisionFailureReason(t *testing.T) {
+	Convey("With the data of a provision failure", t, func() {
+		data := &event.HostEventData{Logs: "+ apt-get update\nok\n+ make install\nmake: *** no rule\n"}
+
+		Convey("the failed step should be the reason if it was parsed", func() {
+			data.ProvisionSteps = []event.ProvisionStep{
+				{Command: "apt-get update"},
+				{Command: "make install", Failed: true, ExitCode: 2},
+			}
+			So(provisionFailureReason(data), ShouldEqual, "'make install' failed with exit code 2")
+		})
+
+		Convey("the log's last line should be the reason otherwise", func() {
+			So(provisionFailureReason(data), ShouldEqual, "make: *** no rule")
+		})
+	})
+
+	Convey("The last lines of a log should be kept", t, func() {
+		So(lastLines("a\nb\nc\n", 2), ShouldEqual, "b\nc")
+		So(lastLines("a\nb\n", 5), ShouldEqual, "a\nb")
+		So(lastLines("", 5), ShouldEqual, "")
+	})
+}
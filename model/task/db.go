@@ -49,6 +49,9 @@ var (
 	StatusKey              = bsonutil.MustHaveTag(Task{}, "Status")
 	DetailsKey             = bsonutil.MustHaveTag(Task{}, "Details")
 	AbortedKey             = bsonutil.MustHaveTag(Task{}, "Aborted")
+	AbortReasonKey         = bsonutil.MustHaveTag(Task{}, "AbortReason")
+	EndReasonKey           = bsonutil.MustHaveTag(Task{}, "EndReason")
+	LastLogTimeKey         = bsonutil.MustHaveTag(Task{}, "LastLogTime")
 	TimeTakenKey           = bsonutil.MustHaveTag(Task{}, "TimeTaken")
 	ExpectedDurationKey    = bsonutil.MustHaveTag(Task{}, "ExpectedDuration")
 	TestResultsKey         = bsonutil.MustHaveTag(Task{}, "TestResults")
@@ -134,6 +137,20 @@ func ByVersion(version string) db.Q {
 	})
 }
 
+// ByProjectVersionAndStatuses creates a query to return a project's tasks that have
+// any of the given statuses, newest first. An empty version matches tasks in every
+// version, and no statuses matches tasks of any status.
+func ByProjectVersionAndStatuses(project, version string, statuses []string) db.Q {
+	filter := bson.M{ProjectKey: project}
+	if version != "" {
+		filter[VersionKey] = version
+	}
+	if len(statuses) > 0 {
+		filter[StatusKey] = bson.M{"$in": statuses}
+	}
+	return db.Query(filter).Sort([]string{"-" + CreateTimeKey, IdKey})
+}
+
 // ByIdsBuildIdAndStatus creates a query to return tasks with a certain build id and statuses
 func ByIdsBuildAndStatus(taskIds []string, buildId string, statuses []string) db.Q {
 	return db.Query(bson.M{
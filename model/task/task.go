@@ -9,6 +9,7 @@ import (
 	"github.com/evergreen-ci/evergreen/db"
 	"github.com/evergreen-ci/evergreen/model/event"
 	"github.com/evergreen-ci/evergreen/util"
+	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
 
@@ -16,6 +17,25 @@ var (
 	AgentHeartbeat = "heartbeat"
 )
 
+// Reasons a task ended, recorded in its EndReason.
+const (
+	// EndReasonCompleted means the agent ran the task to completion.
+	EndReasonCompleted = "completed"
+	// EndReasonTimedOut means the agent stopped the task after it timed out.
+	EndReasonTimedOut = "timed_out"
+	// EndReasonSystemFailure means a system command failed, rather than the test itself.
+	EndReasonSystemFailure = "system_failure"
+	// EndReasonAborted means the agent stopped the task because it was aborted.
+	EndReasonAborted = "aborted"
+	// EndReasonHeartbeatTimeout means the task was ended by the monitor after its
+	// agent stopped sending heartbeats.
+	EndReasonHeartbeatTimeout = "heartbeat_timeout"
+)
+
+// AbortReasonNoOutput is the abort reason of tasks aborted for going too long
+// without log output.
+const AbortReasonNoOutput = "no log output"
+
 type Task struct {
 	Id     string `bson:"_id" json:"id"`
 	Secret string `bson:"secret" json:"secret"`
@@ -42,6 +62,8 @@ type Task struct {
 	// only relevant if the task is running.  the time of the last heartbeat
 	// sent back by the agent
 	LastHeartbeat time.Time `bson:"last_heartbeat"`
+	// LastLogTime is when the task's agent last sent log output
+	LastLogTime time.Time `bson:"last_log_time,omitempty" json:"last_log_time,omitempty"`
 
 	// used to indicate whether task should be scheduled to run
 	Activated     bool         `bson:"activated" json:"activated"`
@@ -79,6 +101,11 @@ type Task struct {
 	Status  string                  `bson:"status" json:"status"`
 	Details apimodels.TaskEndDetail `bson:"details" json:"task_end_details"`
 	Aborted bool                    `bson:"abort,omitempty" json:"abort"`
+	// AbortReason is the reason, if one was given, that the task was aborted
+	AbortReason string `bson:"abort_reason,omitempty" json:"abort_reason,omitempty"`
+	// EndReason classifies why the task ended, separating failures of the
+	// infrastructure from failures of the task itself
+	EndReason string `bson:"end_reason,omitempty" json:"end_reason,omitempty"`
 
 	// TimeTaken is how long the task took to execute.  meaningless if the task is not finished
 	TimeTaken time.Duration `bson:"time_taken" json:"time_taken"`
@@ -311,6 +338,7 @@ func (t *Task) MarkAsDispatched(hostId string, distroId string, dispatchTime tim
 			},
 			"$unset": bson.M{
 				AbortedKey:     "",
+				AbortReasonKey: "",
 				TestResultsKey: "",
 				DetailsKey:     "",
 			},
@@ -341,6 +369,7 @@ func (t *Task) MarkAsUndispatched() error {
 				DistroIdKey:      "",
 				HostIdKey:        "",
 				AbortedKey:       "",
+				AbortReasonKey:   "",
 				TestResultsKey:   "",
 				DetailsKey:       "",
 			},
@@ -399,19 +428,50 @@ func (t *Task) MarkFailed() error {
 	)
 }
 
-// SetAborted sets the abort field of task to aborted
-func (t *Task) SetAborted() error {
+// SetAborted sets the abort field of task to aborted, recording the reason
+// for the abort if one is given
+func (t *Task) SetAborted(reason string) error {
 	t.Aborted = true
+	t.AbortReason = reason
+	update := bson.M{"$set": bson.M{AbortedKey: true}}
+	if reason != "" {
+		update["$set"].(bson.M)[AbortReasonKey] = reason
+	} else {
+		update["$unset"] = bson.M{AbortReasonKey: ""}
+	}
 	return UpdateOne(
 		bson.M{
 			IdKey: t.Id,
 		},
+		update,
+	)
+}
+
+// RotateSecret replaces the task's secret with a newly generated one. The
+// secret is only rotated while the task is dispatched or started; an error is
+// returned for tasks in any other state.
+func (t *Task) RotateSecret(caller string) error {
+	secret := util.RandomString()
+	err := UpdateOne(
+		bson.M{
+			IdKey:     t.Id,
+			StatusKey: bson.M{"$in": evergreen.AbortableStatuses},
+		},
 		bson.M{
 			"$set": bson.M{
-				AbortedKey: true,
+				SecretKey: secret,
 			},
 		},
 	)
+	if err == mgo.ErrNotFound {
+		return fmt.Errorf("task '%v' is not running", t.Id)
+	}
+	if err != nil {
+		return err
+	}
+	t.Secret = secret
+	event.LogTaskSecretRotated(t.Id, caller)
+	return nil
 }
 
 // ActivateTask will set the ActivatedBy field to the caller and set the active state to be true
@@ -447,13 +507,15 @@ func (t *Task) DeactivateTask(caller string) error {
 	)
 }
 
-// MarkEnd handles the Task updates associated with ending a task.
-func (t *Task) MarkEnd(caller string, finishTime time.Time, detail *apimodels.TaskEndDetail) error {
+// MarkEnd handles the Task updates associated with ending a task, recording
+// the reason it ended.
+func (t *Task) MarkEnd(caller string, finishTime time.Time, detail *apimodels.TaskEndDetail, endReason string) error {
 	// record that the task has finished, in memory and in the db
 	t.Status = detail.Status
 	t.FinishTime = finishTime
 	t.TimeTaken = finishTime.Sub(t.StartTime)
 	t.Details = *detail
+	t.EndReason = endReason
 	return UpdateOne(
 		bson.M{
 			IdKey: t.Id,
@@ -464,9 +526,11 @@ func (t *Task) MarkEnd(caller string, finishTime time.Time, detail *apimodels.Ta
 				StatusKey:     detail.Status,
 				TimeTakenKey:  t.TimeTaken,
 				DetailsKey:    t.Details,
+				EndReasonKey:  endReason,
 			},
 			"$unset": bson.M{
-				AbortedKey: "",
+				AbortedKey:     "",
+				AbortReasonKey: "",
 			},
 		})
 
@@ -482,6 +546,7 @@ func (t *Task) Reset() error {
 	t.ScheduledTime = util.ZeroTime
 	t.FinishTime = util.ZeroTime
 	t.TestResults = []TestResult{}
+	t.EndReason = ""
 	reset := bson.M{
 		"$set": bson.M{
 			ActivatedKey:     true,
@@ -494,7 +559,8 @@ func (t *Task) Reset() error {
 			TestResultsKey:   []TestResult{},
 		},
 		"$unset": bson.M{
-			DetailsKey: "",
+			DetailsKey:   "",
+			EndReasonKey: "",
 		},
 	}
 
@@ -521,7 +587,8 @@ func ResetTasks(taskIds []string) error {
 			TestResultsKey:   []TestResult{},
 		},
 		"$unset": bson.M{
-			DetailsKey: "",
+			DetailsKey:   "",
+			EndReasonKey: "",
 		},
 	}
 
@@ -550,6 +617,53 @@ func (t *Task) UpdateHeartbeat() error {
 	)
 }
 
+// UpdateReservedHeartbeats records a heartbeat for the tasks dispatched to the host
+// that it hasn't started yet, so that they aren't cleaned up while they wait for
+// the host's running task to finish.
+func UpdateReservedHeartbeats(taskIds []string, hostId string) error {
+	if len(taskIds) == 0 {
+		return nil
+	}
+	_, err := UpdateAll(
+		bson.M{
+			IdKey:     bson.M{"$in": taskIds},
+			HostIdKey: hostId,
+			StatusKey: evergreen.TaskDispatched,
+		},
+		bson.M{
+			"$set": bson.M{
+				LastHeartbeatKey: time.Now(),
+			},
+		},
+	)
+	return err
+}
+
+// UpdateLastLogTime records that the task's agent just sent log output.
+func (t *Task) UpdateLastLogTime() error {
+	t.LastLogTime = time.Now()
+	return UpdateOne(
+		bson.M{
+			IdKey: t.Id,
+		},
+		bson.M{
+			"$set": bson.M{
+				LastLogTimeKey: t.LastLogTime,
+			},
+		},
+	)
+}
+
+// TimeSinceOutput returns how long it has been since the running task last sent
+// log output, or since it started if it hasn't sent any in this execution.
+func (t *Task) TimeSinceOutput(now time.Time) time.Duration {
+	last := t.StartTime
+	if t.LastLogTime.After(last) {
+		last = t.LastLogTime
+	}
+	return now.Sub(last)
+}
+
 // SetPriority sets the priority of the tasks and the tasks that they depend on
 func (t *Task) SetPriority(priority int64) error {
 	t.Priority = priority
@@ -635,6 +749,81 @@ func (t *Task) SetResults(results []TestResult) error {
 	)
 }
 
+// appendResultsAttempts is how many times AppendResults tries to update a task's
+// results before giving up because other results keep being added at the same time.
+const appendResultsAttempts = 10
+
+// AppendResults merges results into the task's TestResults. Earlier results for a
+// test with the same name as one in results are replaced by the new result, which
+// is moved to the end along with the other new results. The merged results are
+// written with a single update that only applies if the stored results haven't
+// changed since they were read, so results appended concurrently aren't lost; the
+// merge is retried if they have.
+func (t *Task) AppendResults(results []TestResult) error {
+	results = MergeTestResults(nil, results)
+	for attempt := 0; attempt < appendResultsAttempts; attempt++ {
+		// the stored results are compared as they were read, byte for byte
+		stored := struct {
+			TestResults *[]bson.Raw `bson:"test_results"`
+		}{}
+		err := db.FindOne(Collection, bson.M{IdKey: t.Id}, bson.M{TestResultsKey: 1}, db.NoSort, &stored)
+		if err != nil {
+			return err
+		}
+		existing := []TestResult{}
+		var unchanged interface{}
+		if stored.TestResults != nil {
+			for _, raw := range *stored.TestResults {
+				result := TestResult{}
+				if err = raw.Unmarshal(&result); err != nil {
+					return err
+				}
+				existing = append(existing, result)
+			}
+			unchanged = *stored.TestResults
+		}
+
+		merged := MergeTestResults(existing, results)
+		err = UpdateOne(
+			bson.M{
+				IdKey:          t.Id,
+				TestResultsKey: unchanged,
+			},
+			bson.M{
+				"$set": bson.M{
+					TestResultsKey: merged,
+				},
+			},
+		)
+		if err == mgo.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		t.TestResults = merged
+		return nil
+	}
+	return fmt.Errorf("results of task %v kept changing while appending to them", t.Id)
+}
+
+// MergeTestResults returns existing followed by incoming, dropping any earlier
+// result for a test that has a later result with the same name.
+func MergeTestResults(existing, incoming []TestResult) []TestResult {
+	all := append(append([]TestResult{}, existing...), incoming...)
+	last := make(map[string]int, len(all))
+	for i, result := range all {
+		last[result.TestFile] = i
+	}
+	merged := make([]TestResult, 0, len(last))
+	for i, result := range all {
+		if last[result.TestFile] == i {
+			merged = append(merged, result)
+		}
+	}
+	return merged
+}
+
 // MarkUnscheduled marks the task as undispatched and updates it in the database
 func (t *Task) MarkUnscheduled() error {
 	t.Status = evergreen.TaskUndispatched
@@ -1,6 +1,8 @@
 package task
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -8,6 +10,7 @@ import (
 	"github.com/evergreen-ci/evergreen/db"
 	"github.com/evergreen-ci/evergreen/model/build"
 	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/event"
 	"github.com/evergreen-ci/evergreen/model/host"
 	"github.com/evergreen-ci/evergreen/testutil"
 	"github.com/evergreen-ci/evergreen/util"
@@ -417,6 +420,42 @@ func TestFindTasksByIds(t *testing.T) {
 	})
 }
 
+func TestFindTasksByProjectVersionAndStatuses(t *testing.T) {
+	Convey("With tasks in several projects, versions and statuses", t, func() {
+		So(db.Clear(Collection), ShouldBeNil)
+		now := time.Now()
+		tasks := []Task{
+			{Id: "t1", Project: "p1", Version: "v1", Status: evergreen.TaskFailed, CreateTime: now.Add(-3 * time.Minute)},
+			{Id: "t2", Project: "p1", Version: "v1", Status: evergreen.TaskSucceeded, CreateTime: now.Add(-2 * time.Minute)},
+			{Id: "t3", Project: "p1", Version: "v2", Status: evergreen.TaskFailed, CreateTime: now.Add(-time.Minute)},
+			{Id: "t4", Project: "p2", Version: "v3", Status: evergreen.TaskFailed, CreateTime: now},
+		}
+		for _, task := range tasks {
+			So(task.Insert(), ShouldBeNil)
+		}
+
+		Convey("tasks should be filtered by project and status, newest first", func() {
+			dbTasks, err := Find(ByProjectVersionAndStatuses("p1", "", []string{evergreen.TaskFailed}))
+			So(err, ShouldBeNil)
+			So(len(dbTasks), ShouldEqual, 2)
+			So(dbTasks[0].Id, ShouldEqual, "t3")
+			So(dbTasks[1].Id, ShouldEqual, "t1")
+		})
+		Convey("tasks should be filtered by version if one is given", func() {
+			dbTasks, err := Find(ByProjectVersionAndStatuses("p1", "v1", nil))
+			So(err, ShouldBeNil)
+			So(len(dbTasks), ShouldEqual, 2)
+			So(dbTasks[0].Id, ShouldEqual, "t2")
+		})
+		Convey("results should page with skip and limit", func() {
+			dbTasks, err := Find(ByProjectVersionAndStatuses("p1", "", nil).Skip(1).Limit(1))
+			So(err, ShouldBeNil)
+			So(len(dbTasks), ShouldEqual, 1)
+			So(dbTasks[0].Id, ShouldEqual, "t2")
+		})
+	})
+}
+
 func TestCountSimilarFailingTasks(t *testing.T) {
 	Convey("When calling CountSimilarFailingTasks...", t, func() {
 		So(db.Clear(Collection), ShouldBeNil)
@@ -590,6 +629,45 @@ func TestMarkAsDispatched(t *testing.T) {
 
 }
 
+func TestTaskRotateSecret(t *testing.T) {
+	Convey("With a running task with a secret", t, func() {
+		testutil.HandleTestingErr(db.ClearCollections(Collection, event.AllLogCollection), t,
+			"Error clearing test collections")
+
+		task := &Task{Id: "t1", Secret: "old", Status: evergreen.TaskStarted}
+		So(task.Insert(), ShouldBeNil)
+
+		Convey("rotating the secret should replace it in memory and in the database", func() {
+			So(task.RotateSecret("admin"), ShouldBeNil)
+			So(task.Secret, ShouldNotEqual, "old")
+			So(task.Secret, ShouldNotEqual, "")
+
+			dbTask, err := FindOne(ById(task.Id))
+			So(err, ShouldBeNil)
+			So(dbTask.Secret, ShouldEqual, task.Secret)
+
+			Convey("and log who rotated it", func() {
+				events, err := event.Find(event.AllLogCollection, event.TaskEventsInOrder(task.Id))
+				So(err, ShouldBeNil)
+				So(len(events), ShouldEqual, 1)
+				So(events[0].EventType, ShouldEqual, event.TaskSecretRotated)
+				So(events[0].Data.Data.(*event.TaskEventData).UserId, ShouldEqual, "admin")
+			})
+		})
+
+		Convey("rotating the secret of a finished task should fail and keep its secret", func() {
+			finished := &Task{Id: "t2", Secret: "old", Status: evergreen.TaskSucceeded}
+			So(finished.Insert(), ShouldBeNil)
+			So(finished.RotateSecret("admin"), ShouldNotBeNil)
+			So(finished.Secret, ShouldEqual, "old")
+
+			dbTask, err := FindOne(ById(finished.Id))
+			So(err, ShouldBeNil)
+			So(dbTask.Secret, ShouldEqual, "old")
+		})
+	})
+}
+
 func TestTimeAggregations(t *testing.T) {
 	Convey("With multiple tasks with different times", t, func() {
 		So(db.Clear(Collection), ShouldBeNil)
@@ -675,3 +753,119 @@ func TestTimeAggregations(t *testing.T) {
 		})
 	})
 }
+
+func TestTimeSinceOutput(t *testing.T) {
+	Convey("With a running task", t, func() {
+		now := time.Now()
+		task := &Task{StartTime: now.Add(-time.Hour)}
+
+		Convey("without log output, the time should be measured from its start", func() {
+			So(task.TimeSinceOutput(now), ShouldEqual, time.Hour)
+		})
+		Convey("with log output, the time should be measured from the last output", func() {
+			task.LastLogTime = now.Add(-time.Minute)
+			So(task.TimeSinceOutput(now), ShouldEqual, time.Minute)
+		})
+		Convey("output from a previous execution should be ignored", func() {
+			task.LastLogTime = now.Add(-2 * time.Hour)
+			So(task.TimeSinceOutput(now), ShouldEqual, time.Hour)
+		})
+	})
+}
+
+func TestAppendResults(t *testing.T) {
+	Convey("With a task with test results", t, func() {
+		testutil.HandleTestingErr(db.Clear(Collection), t, "Error clearing '%v' collection", Collection)
+		task := &Task{
+			Id: "t1",
+			TestResults: []TestResult{
+				{TestFile: "a", Status: evergreen.TestFailedStatus},
+				{TestFile: "b", Status: evergreen.TestSucceededStatus},
+			},
+		}
+		So(task.Insert(), ShouldBeNil)
+
+		Convey("appended results should be merged into the stored results", func() {
+			So(task.AppendResults([]TestResult{
+				{TestFile: "c", Status: evergreen.TestSucceededStatus},
+				{TestFile: "a", Status: evergreen.TestSucceededStatus},
+			}), ShouldBeNil)
+			dbTask, err := FindOne(ById(task.Id))
+			So(err, ShouldBeNil)
+			So(dbTask.TestResults, ShouldResemble, []TestResult{
+				{TestFile: "b", Status: evergreen.TestSucceededStatus},
+				{TestFile: "c", Status: evergreen.TestSucceededStatus},
+				{TestFile: "a", Status: evergreen.TestSucceededStatus},
+			})
+			So(task.TestResults, ShouldResemble, dbTask.TestResults)
+		})
+
+		Convey("results appended through a stale copy of the task should not drop others", func() {
+			stale, err := FindOne(ById(task.Id))
+			So(err, ShouldBeNil)
+			So(task.AppendResults([]TestResult{{TestFile: "c"}}), ShouldBeNil)
+			So(stale.AppendResults([]TestResult{{TestFile: "d"}}), ShouldBeNil)
+			dbTask, err := FindOne(ById(task.Id))
+			So(err, ShouldBeNil)
+			So(len(dbTask.TestResults), ShouldEqual, 4)
+			So(dbTask.TestResults[2].TestFile, ShouldEqual, "c")
+			So(dbTask.TestResults[3].TestFile, ShouldEqual, "d")
+		})
+
+		Convey("results appended concurrently should all be kept", func() {
+			var wg sync.WaitGroup
+			errs := make(chan error, 5)
+			for i := 0; i < 5; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					errs <- (&Task{Id: task.Id}).AppendResults([]TestResult{{TestFile: fmt.Sprintf("new%d", i)}})
+				}(i)
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				So(err, ShouldBeNil)
+			}
+			dbTask, err := FindOne(ById(task.Id))
+			So(err, ShouldBeNil)
+			So(len(dbTask.TestResults), ShouldEqual, 7)
+		})
+
+		Convey("results can be appended to a task without any", func() {
+			empty := &Task{Id: "t2"}
+			So(empty.Insert(), ShouldBeNil)
+			So(empty.AppendResults([]TestResult{{TestFile: "a"}}), ShouldBeNil)
+			dbTask, err := FindOne(ById(empty.Id))
+			So(err, ShouldBeNil)
+			So(dbTask.TestResults, ShouldResemble, []TestResult{{TestFile: "a"}})
+		})
+	})
+}
+
+func TestMergeTestResults(t *testing.T) {
+	Convey("When merging test results", t, func() {
+		existing := []TestResult{
+			{TestFile: "a", Status: evergreen.TestFailedStatus},
+			{TestFile: "b", Status: evergreen.TestSucceededStatus},
+		}
+		incoming := []TestResult{
+			{TestFile: "c", Status: evergreen.TestSucceededStatus},
+			{TestFile: "a", Status: evergreen.TestSucceededStatus},
+		}
+
+		Convey("new results should be appended and replace results of the same name", func() {
+			merged := MergeTestResults(existing, incoming)
+			So(len(merged), ShouldEqual, 3)
+			So(merged[0].TestFile, ShouldEqual, "b")
+			So(merged[1].TestFile, ShouldEqual, "c")
+			So(merged[2].TestFile, ShouldEqual, "a")
+			So(merged[2].Status, ShouldEqual, evergreen.TestSucceededStatus)
+		})
+		Convey("the existing results should not be modified", func() {
+			MergeTestResults(existing, incoming)
+			So(existing[0].Status, ShouldEqual, evergreen.TestFailedStatus)
+			So(len(existing), ShouldEqual, 2)
+		})
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/evergreen-ci/evergreen/apimodels"
 	"github.com/evergreen-ci/evergreen/model/build"
 	"github.com/evergreen-ci/evergreen/model/event"
+	"github.com/evergreen-ci/evergreen/model/host"
 	"github.com/evergreen-ci/evergreen/model/patch"
 	"github.com/evergreen-ci/evergreen/model/task"
 	"github.com/evergreen-ci/evergreen/util"
@@ -148,7 +149,7 @@ func TryResetTask(taskId, user, origin string, p *Project, detail *apimodels.Tas
 	}
 
 	if detail != nil {
-		if err = t.MarkEnd(origin, time.Now(), detail); err != nil {
+		if err = t.MarkEnd(origin, time.Now(), detail, taskEndReason(t, detail)); err != nil {
 			return fmt.Errorf("Error marking task as ended: %v", err)
 		}
 	}
@@ -163,7 +164,9 @@ func TryResetTask(taskId, user, origin string, p *Project, detail *apimodels.Tas
 	return err
 }
 
-func AbortTask(taskId, caller string) error {
+// AbortTask marks the task to be aborted by its agent, recording the reason for
+// the abort if one is given.
+func AbortTask(taskId, caller, reason string) error {
 	t, err := task.FindOne(task.ById(taskId))
 	if err != nil {
 		return err
@@ -179,8 +182,8 @@ func AbortTask(taskId, caller string) error {
 	if err = SetActiveState(t.Id, caller, false); err != nil {
 		return err
 	}
-	event.LogTaskAbortRequest(t.Id, caller)
-	return t.SetAborted()
+	event.LogTaskAbortRequest(t.Id, caller, reason)
+	return t.SetAborted(reason)
 }
 
 // Deactivate any previously activated but undispatched
@@ -258,6 +261,23 @@ func doStepback(t *task.Task, detail *apimodels.TaskEndDetail, deactivatePreviou
 	return ActivatePreviousTask(t.Id, evergreen.StepbackTaskActivator)
 }
 
+// taskEndReason classifies why a task is ending, from its end details and whether
+// it was aborted.
+func taskEndReason(t *task.Task, detail *apimodels.TaskEndDetail) string {
+	switch {
+	case detail.TimedOut && detail.Description == task.AgentHeartbeat:
+		return task.EndReasonHeartbeatTimeout
+	case t.Aborted || detail.Status == evergreen.TaskUndispatched:
+		return task.EndReasonAborted
+	case detail.TimedOut:
+		return task.EndReasonTimedOut
+	case detail.Status == evergreen.TaskFailed && detail.Type == SystemCommandType:
+		return task.EndReasonSystemFailure
+	default:
+		return task.EndReasonCompleted
+	}
+}
+
 // MarkEnd updates the task as being finished, performs a stepback if necessary, and updates the build status
 func MarkEnd(taskId, caller string, finishTime time.Time, detail *apimodels.TaskEndDetail,
 	p *Project, deactivatePrevious bool) error {
@@ -284,7 +304,7 @@ func MarkEnd(taskId, caller string, finishTime time.Time, detail *apimodels.Task
 		return nil
 	}
 
-	err = t.MarkEnd(caller, finishTime, detail)
+	err = t.MarkEnd(caller, finishTime, detail, taskEndReason(t, detail))
 	if err != nil {
 		return err
 	}
@@ -570,6 +590,21 @@ func MarkTaskUndispatched(t *task.Task) error {
 	return nil
 }
 
+// ReleaseReservedTask undispatches a task that was reserved for the host but not
+// started, so that it can be dispatched again.
+func ReleaseReservedTask(t *task.Task, h *host.Host) error {
+	if err := h.UnreserveTask(t.Id); err != nil {
+		return fmt.Errorf("error unreserving task %v on host %v: %v", t.Id, h.Id, err)
+	}
+	if t.Status != evergreen.TaskDispatched || t.HostId != h.Id {
+		return nil
+	}
+	if err := MarkTaskUndispatched(t); err != nil {
+		return fmt.Errorf("error undispatching task %v from host %v: %v", t.Id, h.Id, err)
+	}
+	return nil
+}
+
 func MarkTaskDispatched(t *task.Task, hostId, distroId string) error {
 	// record that the task was dispatched on the host
 	if err := t.MarkAsDispatched(hostId, distroId, time.Now()); err != nil {
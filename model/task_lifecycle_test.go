@@ -496,6 +496,41 @@ func TestMarkEnd(t *testing.T) {
 			}
 			So(MarkEnd(testTask.Id, userName, time.Now(), &details, p, false), ShouldBeNil)
 
+			dbTask, err := task.FindOne(task.ById(testTask.Id))
+			So(err, ShouldBeNil)
+			So(dbTask.EndReason, ShouldEqual, task.EndReasonCompleted)
+		})
+	})
+}
+
+func TestTaskEndReason(t *testing.T) {
+	Convey("When classifying why a task ended", t, func() {
+		tsk := &task.Task{Id: "t1"}
+		Convey("a task that ran to completion should be completed", func() {
+			detail := &apimodels.TaskEndDetail{Status: evergreen.TaskFailed, Type: TestCommandType}
+			So(taskEndReason(tsk, detail), ShouldEqual, task.EndReasonCompleted)
+			detail.Status = evergreen.TaskSucceeded
+			So(taskEndReason(tsk, detail), ShouldEqual, task.EndReasonCompleted)
+		})
+		Convey("a task whose system command failed should be a system failure", func() {
+			detail := &apimodels.TaskEndDetail{Status: evergreen.TaskFailed, Type: SystemCommandType}
+			So(taskEndReason(tsk, detail), ShouldEqual, task.EndReasonSystemFailure)
+		})
+		Convey("a task that timed out should be timed out", func() {
+			detail := &apimodels.TaskEndDetail{Status: evergreen.TaskFailed, TimedOut: true}
+			So(taskEndReason(tsk, detail), ShouldEqual, task.EndReasonTimedOut)
+		})
+		Convey("a task whose agent stopped heartbeating should be a heartbeat timeout", func() {
+			detail := &apimodels.TaskEndDetail{Status: evergreen.TaskFailed, TimedOut: true,
+				Description: task.AgentHeartbeat}
+			So(taskEndReason(tsk, detail), ShouldEqual, task.EndReasonHeartbeatTimeout)
+		})
+		Convey("a task that was aborted should be aborted", func() {
+			detail := &apimodels.TaskEndDetail{Status: evergreen.TaskUndispatched}
+			So(taskEndReason(tsk, detail), ShouldEqual, task.EndReasonAborted)
+			tsk.Aborted = true
+			detail.Status = evergreen.TaskFailed
+			So(taskEndReason(tsk, detail), ShouldEqual, task.EndReasonAborted)
 		})
 	})
 }
@@ -664,14 +699,15 @@ func TestAbortTask(t *testing.T) {
 		So(testTask.Insert(), ShouldBeNil)
 		So(finishedTask.Insert(), ShouldBeNil)
 		Convey("with a task that has started, aborting a task should work", func() {
-			So(AbortTask(testTask.Id, userName), ShouldBeNil)
+			So(AbortTask(testTask.Id, userName, "no longer needed"), ShouldBeNil)
 			testTask, err := task.FindOne(task.ById(testTask.Id))
 			So(err, ShouldBeNil)
 			So(testTask.Activated, ShouldEqual, false)
 			So(testTask.Aborted, ShouldEqual, true)
+			So(testTask.AbortReason, ShouldEqual, "no longer needed")
 		})
 		Convey("a task that is finished should error when aborting", func() {
-			So(AbortTask(finishedTask.Id, userName), ShouldNotBeNil)
+			So(AbortTask(finishedTask.Id, userName, ""), ShouldNotBeNil)
 		})
 	})
 
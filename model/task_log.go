@@ -173,6 +173,31 @@ func FindTaskLogsBeforeTime(taskId string, execution int, ts time.Time, limit in
 	return result, err
 }
 
+// FindTaskLogsSinceTime returns the task's log chunks started at or after ts, oldest
+// first.
+func FindTaskLogsSinceTime(taskId string, execution int, ts time.Time) ([]TaskLog, error) {
+	session, db, err := getSessionAndDB()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	query := bson.M{
+		TaskLogTaskIdKey:    taskId,
+		TaskLogExecutionKey: execution,
+		TaskLogTimestampKey: bson.M{
+			"$gte": ts,
+		},
+	}
+
+	result := []TaskLog{}
+	err = db.C(TaskLogCollection).Find(query).Sort(TaskLogTimestampKey).All(&result)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	return result, err
+}
+
 func GetRawTaskLogChannel(taskId string, execution int, severities []string,
 	msgTypes []string) (chan LogMessage, error) {
 	session, db, err := getSessionAndDB()
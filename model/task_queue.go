@@ -2,10 +2,13 @@ package model
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/evergreen-ci/evergreen/db"
 	"github.com/evergreen-ci/evergreen/db/bsonutil"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/util"
 	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
@@ -23,6 +26,9 @@ type TaskQueue struct {
 	Id     bson.ObjectId   `bson:"_id,omitempty" json:"_id"`
 	Distro string          `bson:"distro" json:"distro"`
 	Queue  []TaskQueueItem `bson:"queue" json:"queue"`
+
+	// the last time the queue was saved with tasks in it
+	LastNonEmpty time.Time `bson:"last_nonempty,omitempty" json:"last_nonempty"`
 }
 
 type TaskDep struct {
@@ -43,9 +49,10 @@ type TaskQueueItem struct {
 
 var (
 	// bson fields for the task queue struct
-	TaskQueueIdKey     = bsonutil.MustHaveTag(TaskQueue{}, "Id")
-	TaskQueueDistroKey = bsonutil.MustHaveTag(TaskQueue{}, "Distro")
-	TaskQueueQueueKey  = bsonutil.MustHaveTag(TaskQueue{}, "Queue")
+	TaskQueueIdKey           = bsonutil.MustHaveTag(TaskQueue{}, "Id")
+	TaskQueueDistroKey       = bsonutil.MustHaveTag(TaskQueue{}, "Distro")
+	TaskQueueQueueKey        = bsonutil.MustHaveTag(TaskQueue{}, "Queue")
+	TaskQueueLastNonEmptyKey = bsonutil.MustHaveTag(TaskQueue{}, "LastNonEmpty")
 
 	// bson fields for the individual task queue items
 	TaskQueueItemIdKey          = bsonutil.MustHaveTag(TaskQueueItem{}, "Id")
@@ -75,8 +82,29 @@ func (self *TaskQueue) IsEmpty() bool {
 	return len(self.Queue) == 0
 }
 
+// NextTask returns the highest priority item in the queue. Items with equal
+// priority are returned in queue order.
 func (self *TaskQueue) NextTask() TaskQueueItem {
-	return self.Queue[0]
+	next := self.Queue[0]
+	for _, item := range self.Queue[1:] {
+		if item.Priority > next.Priority {
+			next = item
+		}
+	}
+	return next
+}
+
+// DispatchOrder returns the indexes of the queue's items in the order that
+// successive calls to NextTask would return them.
+func (self *TaskQueue) DispatchOrder() []int {
+	order := make([]int, len(self.Queue))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return self.Queue[order[i]].Priority > self.Queue[order[j]].Priority
+	})
+	return order
 }
 
 func (self *TaskQueue) Save() error {
@@ -84,14 +112,33 @@ func (self *TaskQueue) Save() error {
 }
 
 func UpdateTaskQueue(distro string, taskQueue []TaskQueueItem) error {
+	update := bson.M{TaskQueueQueueKey: taskQueue}
+	if len(taskQueue) > 0 {
+		update[TaskQueueLastNonEmptyKey] = time.Now()
+	}
 	_, err := db.Upsert(
 		TaskQueuesCollection,
 		bson.M{
 			TaskQueueDistroKey: distro,
 		},
+		bson.M{
+			"$set": update,
+		},
+	)
+	return err
+}
+
+// SetTaskQueueItemPriority updates the priority of the given task in every
+// task queue it appears in.
+func SetTaskQueueItemPriority(taskId string, priority int64) error {
+	_, err := db.UpdateAll(
+		TaskQueuesCollection,
+		bson.M{
+			fmt.Sprintf("%v.%v", TaskQueueQueueKey, TaskQueueItemIdKey): taskId,
+		},
 		bson.M{
 			"$set": bson.M{
-				TaskQueueQueueKey: taskQueue,
+				fmt.Sprintf("%v.$.%v", TaskQueueQueueKey, TaskQueuePriorityKey): priority,
 			},
 		},
 	)
@@ -161,6 +208,37 @@ func FindAllTaskQueues() ([]TaskQueue, error) {
 	return taskQueues, err
 }
 
+// FindOldestQueuedTaskScheduledTime returns the earliest time that any task in a
+// distro's task queue was scheduled, or the zero time if there are none.
+func FindOldestQueuedTaskScheduledTime() (time.Time, error) {
+	scheduledTimeKey := "task." + task.ScheduledTimeKey
+	pipeline := []bson.M{
+		{"$unwind": "$" + TaskQueueQueueKey},
+		{"$lookup": bson.M{
+			"from":         task.Collection,
+			"localField":   TaskQueueQueueKey + "." + TaskQueueItemIdKey,
+			"foreignField": task.IdKey,
+			"as":           "task",
+		}},
+		{"$unwind": "$task"},
+		{"$match": bson.M{scheduledTimeKey: bson.M{"$gt": util.ZeroTime}}},
+		{"$group": bson.M{
+			"_id":    nil,
+			"oldest": bson.M{"$min": "$" + scheduledTimeKey},
+		}},
+	}
+	out := []struct {
+		Oldest time.Time `bson:"oldest"`
+	}{}
+	if err := db.Aggregate(TaskQueuesCollection, pipeline, &out); err != nil {
+		return time.Time{}, err
+	}
+	if len(out) == 0 {
+		return time.Time{}, nil
+	}
+	return out[0].Oldest, nil
+}
+
 // pull out the task with the specified id from both the in-memory and db
 // versions of the task queue
 func (self *TaskQueue) DequeueTask(taskId string) error {
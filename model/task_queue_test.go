@@ -3,9 +3,12 @@ package model
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model/task"
 	"github.com/evergreen-ci/evergreen/testutil"
+	"github.com/evergreen-ci/evergreen/util"
 	"github.com/mongodb/grip"
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -76,3 +79,81 @@ func TestDequeueTask(t *testing.T) {
 
 	})
 }
+
+func TestNextTaskPriority(t *testing.T) {
+
+	Convey("With a task queue", t, func() {
+
+		taskQueue := &TaskQueue{
+			Distro: "d1",
+			Queue: []TaskQueueItem{
+				{Id: "t1"},
+				{Id: "t2", Priority: 10},
+				{Id: "t3", Priority: 10},
+			},
+		}
+
+		Convey("the highest priority task should be returned first, with ties"+
+			" broken by queue order", func() {
+			So(taskQueue.NextTask().Id, ShouldEqual, "t2")
+			So(taskQueue.Save(), ShouldBeNil)
+
+			Convey("and updating a queued task's priority should change the"+
+				" next task", func() {
+				So(SetTaskQueueItemPriority("t1", 20), ShouldBeNil)
+				taskQueue, err := FindTaskQueueForDistro("d1")
+				So(err, ShouldBeNil)
+				So(taskQueue.NextTask().Id, ShouldEqual, "t1")
+			})
+		})
+
+	})
+}
+
+func TestDispatchOrder(t *testing.T) {
+
+	Convey("With a task queue with tasks of mixed priority", t, func() {
+
+		taskQueue := &TaskQueue{
+			Distro: "d1",
+			Queue: []TaskQueueItem{
+				{Id: "t1"},
+				{Id: "t2", Priority: 10},
+				{Id: "t3"},
+				{Id: "t4", Priority: 10},
+			},
+		}
+
+		Convey("the dispatch order should be by priority, then queue order", func() {
+			So(taskQueue.DispatchOrder(), ShouldResemble, []int{1, 3, 0, 2})
+		})
+
+	})
+}
+
+func TestFindOldestQueuedTaskScheduledTime(t *testing.T) {
+	Convey("With tasks queued for two distros", t, func() {
+		testutil.HandleTestingErr(db.ClearCollections(TaskQueuesCollection, task.Collection), t,
+			"error clearing collections")
+		now := time.Now().Round(time.Millisecond)
+		So((&task.Task{Id: "t1", ScheduledTime: now.Add(-time.Minute)}).Insert(), ShouldBeNil)
+		So((&task.Task{Id: "t2", ScheduledTime: now.Add(-time.Hour)}).Insert(), ShouldBeNil)
+		So((&task.Task{Id: "t3", ScheduledTime: now.Add(-2 * time.Hour)}).Insert(), ShouldBeNil)
+		So(UpdateTaskQueue("d1", []TaskQueueItem{{Id: "t1"}}), ShouldBeNil)
+		So(UpdateTaskQueue("d2", []TaskQueueItem{{Id: "t2"}, {Id: "missing"}}), ShouldBeNil)
+
+		Convey("the earliest scheduled time of a queued task should be found", func() {
+			oldest, err := FindOldestQueuedTaskScheduledTime()
+			So(err, ShouldBeNil)
+			So(oldest.Equal(now.Add(-time.Hour)), ShouldBeTrue)
+		})
+
+		Convey("empty queues should have no oldest task", func() {
+			So(UpdateTaskQueue("d1", []TaskQueueItem{}), ShouldBeNil)
+			So(UpdateTaskQueue("d2", []TaskQueueItem{}), ShouldBeNil)
+			oldest, err := FindOldestQueuedTaskScheduledTime()
+			So(err, ShouldBeNil)
+			So(util.IsZeroTime(oldest), ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,128 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/event"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/mongodb/grip"
+)
+
+// DistroTeardownStats summarizes how reliably and quickly the teardown script of a
+// distro's hosts ran.
+type DistroTeardownStats struct {
+	Distro       string  `json:"distro"`
+	NumTeardowns int     `json:"num_teardowns"`
+	NumFailed    int     `json:"num_failed"`
+	SuccessRate  float64 `json:"success_rate"`
+
+	// percentiles of how long teardown scripts took to run
+	DurationP50 time.Duration `json:"duration_p50"`
+	DurationP90 time.Duration `json:"duration_p90"`
+	DurationP99 time.Duration `json:"duration_p99"`
+	DurationMax time.Duration `json:"duration_max"`
+}
+
+// ComputeTeardownStats reads the teardowns logged between start and end and
+// summarizes them by the distro of the host torn down. Teardowns of hosts that are
+// no longer recorded are not counted.
+func ComputeTeardownStats(start, end time.Time) ([]DistroTeardownStats, error) {
+	events, err := event.Find(event.AllLogCollection,
+		event.HostEventsOfTypeInRange(event.EventHostTeardown, start, end))
+	if err != nil {
+		return nil, fmt.Errorf("error finding host teardown events: %v", err)
+	}
+
+	distros, err := hostDistroIds(events)
+	if err != nil {
+		return nil, err
+	}
+	totals := map[string]*DistroTeardownStats{}
+	durations := map[string][]time.Duration{}
+	for _, e := range events {
+		data, ok := e.Data.Data.(*event.HostEventData)
+		if !ok {
+			continue
+		}
+
+		distroId := distros[e.ResourceId]
+		if distroId == "" {
+			grip.Warningf("Can't find the distro of host %v; not counting its teardown", e.ResourceId)
+			continue
+		}
+
+		total, ok := totals[distroId]
+		if !ok {
+			total = &DistroTeardownStats{Distro: distroId}
+			totals[distroId] = total
+		}
+		total.NumTeardowns++
+		if !data.Successful {
+			total.NumFailed++
+		}
+		durations[distroId] = append(durations[distroId], data.Duration)
+	}
+
+	result := make([]DistroTeardownStats, 0, len(totals))
+	for distroId, total := range totals {
+		total.SuccessRate = float64(total.NumTeardowns-total.NumFailed) / float64(total.NumTeardowns)
+		sorted := durations[distroId]
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		total.DurationP50 = durationPercentile(sorted, 50)
+		total.DurationP90 = durationPercentile(sorted, 90)
+		total.DurationP99 = durationPercentile(sorted, 99)
+		total.DurationMax = sorted[len(sorted)-1]
+		result = append(result, *total)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Distro < result[j].Distro })
+	return result, nil
+}
+
+// hostDistroBatchSize is how many hosts are looked up at once when finding the
+// distros of the hosts that events are about.
+const hostDistroBatchSize = 1000
+
+// hostDistroIds returns the distro of each recorded host that the events are
+// about, keyed by host id. Hosts that are no longer recorded are left out.
+func hostDistroIds(events []event.Event) (map[string]string, error) {
+	ids := []string{}
+	seen := map[string]bool{}
+	for _, e := range events {
+		if !seen[e.ResourceId] {
+			seen[e.ResourceId] = true
+			ids = append(ids, e.ResourceId)
+		}
+	}
+
+	distroIdKey := fmt.Sprintf("%v.%v", host.DistroKey, distro.IdKey)
+	distros := map[string]string{}
+	for start := 0; start < len(ids); start += hostDistroBatchSize {
+		end := start + hostDistroBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		hosts, err := host.Find(host.ByIds(ids[start:end]).WithFields(host.IdKey, distroIdKey))
+		if err != nil {
+			return nil, fmt.Errorf("error finding hosts: %v", err)
+		}
+		for _, h := range hosts {
+			distros[h.Id] = h.Distro.Id
+		}
+	}
+	return distros, nil
+}
+
+// durationPercentile returns the nearest-rank percentile of sorted durations.
+func durationPercentile(sorted []time.Duration, percentile int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (percentile*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/event"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/evergreen-ci/evergreen/testutil"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDurationPercentile(t *testing.T) {
+	Convey("With sorted durations", t, func() {
+		sorted := []time.Duration{}
+		for i := 1; i <= 10; i++ {
+			sorted = append(sorted, time.Duration(i)*time.Second)
+		}
+
+		Convey("percentiles should be the nearest-rank durations", func() {
+			So(durationPercentile(sorted, 50), ShouldEqual, 5*time.Second)
+			So(durationPercentile(sorted, 90), ShouldEqual, 9*time.Second)
+			So(durationPercentile(sorted, 99), ShouldEqual, 10*time.Second)
+			So(durationPercentile(sorted[:1], 50), ShouldEqual, time.Second)
+		})
+		Convey("no durations should have a zero percentile", func() {
+			So(durationPercentile(nil, 50), ShouldEqual, 0)
+		})
+	})
+}
+
+func TestComputeTeardownStats(t *testing.T) {
+	Convey("With teardowns logged for recorded and forgotten hosts", t, func() {
+		testutil.HandleTestingErr(db.ClearCollections(host.Collection, event.AllLogCollection), t,
+			"error clearing collections")
+		start := time.Now().Add(-time.Minute)
+		So((&host.Host{Id: "h1", Distro: distro.Distro{Id: "d1"}}).Insert(), ShouldBeNil)
+		So((&host.Host{Id: "h2", Distro: distro.Distro{Id: "d1"}}).Insert(), ShouldBeNil)
+		So((&host.Host{Id: "h3", Distro: distro.Distro{Id: "d2"}}).Insert(), ShouldBeNil)
+		event.LogHostTeardown("h1", "", true, time.Second)
+		event.LogHostTeardown("h1", "", false, 3*time.Second)
+		event.LogHostTeardown("h2", "", true, 2*time.Second)
+		event.LogHostTeardown("h3", "", true, time.Second)
+		event.LogHostTeardown("gone", "", true, time.Second)
+
+		Convey("teardowns should be counted by the distros of the recorded hosts", func() {
+			stats, err := ComputeTeardownStats(start, time.Now().Add(time.Minute))
+			So(err, ShouldBeNil)
+			So(len(stats), ShouldEqual, 2)
+			So(stats[0].Distro, ShouldEqual, "d1")
+			So(stats[0].NumTeardowns, ShouldEqual, 3)
+			So(stats[0].NumFailed, ShouldEqual, 1)
+			So(stats[0].DurationMax, ShouldEqual, 3*time.Second)
+			So(stats[1].Distro, ShouldEqual, "d2")
+			So(stats[1].NumTeardowns, ShouldEqual, 1)
+		})
+	})
+}
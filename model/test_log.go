@@ -2,6 +2,7 @@ package model
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/evergreen-ci/evergreen/db"
 	"github.com/evergreen-ci/evergreen/db/bsonutil"
@@ -17,6 +18,10 @@ type TestLog struct {
 	Task          string   `json:"task" bson:"task"`
 	TaskExecution int      `json:"execution" bson:"execution"`
 	Lines         []string `json:"lines" bson:"lines"`
+
+	// optional attributes of the log, such as the test framework or suite that
+	// produced it, by which logs can be filtered
+	Metadata map[string]string `json:"metadata,omitempty" bson:"metadata,omitempty"`
 }
 
 var (
@@ -25,6 +30,7 @@ var (
 	TestLogTaskKey          = bsonutil.MustHaveTag(TestLog{}, "Task")
 	TestLogTaskExecutionKey = bsonutil.MustHaveTag(TestLog{}, "TaskExecution")
 	TestLogLinesKey         = bsonutil.MustHaveTag(TestLog{}, "Lines")
+	TestLogMetadataKey      = bsonutil.MustHaveTag(TestLog{}, "Metadata")
 )
 
 func FindOneTestLogById(id string) (*TestLog, error) {
@@ -65,6 +71,32 @@ func FindOneTestLog(name, task string, execution int) (*TestLog, error) {
 	return tl, err
 }
 
+// FindTestLogsByMetadata returns the logs of a task's execution whose metadata
+// includes every key and value given, without their lines.
+func FindTestLogsByMetadata(task string, execution int, metadata map[string]string) ([]TestLog, error) {
+	query := bson.M{
+		TestLogTaskKey:          task,
+		TestLogTaskExecutionKey: execution,
+	}
+	for key, value := range metadata {
+		if err := ValidateTestLogMetadataKey(key); err != nil {
+			return nil, err
+		}
+		query[fmt.Sprintf("%v.%v", TestLogMetadataKey, key)] = value
+	}
+	logs := []TestLog{}
+	err := db.FindAll(
+		TestLogCollection,
+		query,
+		bson.M{TestLogLinesKey: 0},
+		[]string{TestLogNameKey},
+		db.NoSkip,
+		db.NoLimit,
+		&logs,
+	)
+	return logs, err
+}
+
 // Insert inserts the TestLog into the database
 func (self *TestLog) Insert() error {
 	self.Id = bson.NewObjectId().Hex()
@@ -83,9 +115,23 @@ func (self *TestLog) Validate() error {
 		return fmt.Errorf("test log requires a 'Name' field")
 	case self.Task == "":
 		return fmt.Errorf("test log requires a 'Task' field")
-	default:
-		return nil
 	}
+	for key := range self.Metadata {
+		if err := ValidateTestLogMetadataKey(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateTestLogMetadataKey returns an error if the metadata key can't be stored
+// or queried as a field of a test log's metadata.
+func ValidateTestLogMetadataKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "$") || strings.Contains(key, ".") {
+		return fmt.Errorf("invalid test log metadata key '%v': keys must be non-empty, "+
+			"and can't start with '$' or contain '.'", key)
+	}
+	return nil
 }
 
 // URL returns the path to access the log based on its current fields.
@@ -47,3 +47,47 @@ func TestTestLogInsertAndFind(t *testing.T) {
 	})
 
 }
+
+func TestTestLogMetadata(t *testing.T) {
+	Convey("With test logs that have metadata", t, func() {
+
+		testutil.HandleTestingErr(
+			db.Clear(TestLogCollection), t,
+			"error clearing test log collection")
+
+		for _, log := range []*TestLog{
+			{Name: "a", Task: "t1", Metadata: map[string]string{"framework": "jstest", "suite": "core"}},
+			{Name: "b", Task: "t1", Metadata: map[string]string{"framework": "jstest", "suite": "auth"}},
+			{Name: "c", Task: "t1", Metadata: map[string]string{"framework": "gotest"}},
+			{Name: "d", Task: "t1", TaskExecution: 1, Metadata: map[string]string{"framework": "jstest"}},
+		} {
+			So(log.Insert(), ShouldBeNil)
+		}
+
+		Convey("logs should be found by their metadata, without their lines", func() {
+			logs, err := FindTestLogsByMetadata("t1", 0, map[string]string{"framework": "jstest"})
+			So(err, ShouldBeNil)
+			So(len(logs), ShouldEqual, 2)
+			So(logs[0].Name, ShouldEqual, "a")
+			So(logs[0].Metadata["suite"], ShouldEqual, "core")
+			So(logs[1].Name, ShouldEqual, "b")
+
+			logs, err = FindTestLogsByMetadata("t1", 0,
+				map[string]string{"framework": "jstest", "suite": "auth"})
+			So(err, ShouldBeNil)
+			So(len(logs), ShouldEqual, 1)
+			So(logs[0].Name, ShouldEqual, "b")
+
+			logs, err = FindTestLogsByMetadata("t1", 0, nil)
+			So(err, ShouldBeNil)
+			So(len(logs), ShouldEqual, 3)
+		})
+
+		Convey("metadata keys that can't be stored should be rejected", func() {
+			log := &TestLog{Name: "e", Task: "t1", Metadata: map[string]string{"a.b": "c"}}
+			So(log.Insert(), ShouldNotBeNil)
+			_, err := FindTestLogsByMetadata("t1", 0, map[string]string{"$where": "1"})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
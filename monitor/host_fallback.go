@@ -0,0 +1,62 @@
+package monitor
+
+import (
+	"fmt"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/cloud"
+	"github.com/evergreen-ci/evergreen/cloud/providers"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/mongodb/grip"
+)
+
+// fallBackUnstartedHosts is a hostMonitoringFunc that replaces hosts that haven't
+// come up, such as unfulfilled spot requests, with hosts of their provider's
+// fallback provider. Hosts are only replaced if the settings still allow spawning
+// hosts with the fallback provider.
+func fallBackUnstartedHosts(settings *evergreen.Settings) []error {
+	grip.Info("Checking for hosts to fall back from...")
+
+	hosts, err := host.Find(host.IsUninitialized)
+	if err != nil {
+		return []error{fmt.Errorf("error finding uninitialized hosts: %v", err)}
+	}
+
+	var errs []error
+	for i := range hosts {
+		h := &hosts[i]
+		cloudManager, err := providers.GetCloudManager(h.Provider, settings)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error getting cloud manager for host %v: %v", h.Id, err))
+			continue
+		}
+		fallbacker, ok := cloudManager.(cloud.Fallbacker)
+		if !ok {
+			continue
+		}
+
+		needsFallback, err := fallbacker.NeedsFallback(h)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error checking whether host %v needs replacing: %v", h.Id, err))
+			continue
+		}
+		if !needsFallback {
+			continue
+		}
+
+		if err = providers.CheckSpawnAllowed(fallbacker.FallbackProvider(), settings); err != nil {
+			grip.Warningf("Not replacing host %s: %+v", h.Id, err)
+			continue
+		}
+
+		newHost, err := fallbacker.FallBack(h)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error replacing host %v: %v", h.Id, err))
+			continue
+		}
+		if newHost != nil {
+			grip.Infof("Replaced host %s with %s host %s", h.Id, newHost.Provider, newHost.Id)
+		}
+	}
+	return errs
+}
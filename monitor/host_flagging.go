@@ -22,7 +22,12 @@ const (
 	// IdleTimeCutoff is the amount of time we wait for an idle host to be marked as idle.
 	IdleTimeCutoff = 15 * time.Minute
 
-	// MaxTimeNextPayment is the amount of time we wait to have left before marking a host as idle
+	// DedicatedIdleTimeCutoff is the idle time cutoff for hosts on dedicated hardware,
+	// which is shorter since those hosts cost more to keep running.
+	DedicatedIdleTimeCutoff = 5 * time.Minute
+
+	// MaxTimeTilNextPayment is the default amount of time we wait to have left
+	// before marking a host as idle
 	MaxTimeTilNextPayment = 5 * time.Minute
 
 	// CommunicationTimeCutoff is the limit to how much time has passed before the host is marked as idle
@@ -66,6 +71,7 @@ func flagUnreachableHosts(d []distro.Distro, s *evergreen.Settings) ([]host.Host
 func flagIdleHosts(d []distro.Distro, s *evergreen.Settings) ([]host.Host, error) {
 	// will ultimately contain all of the hosts determined to be idle
 	idleHosts := []host.Host{}
+	maxTilNextPayment := maxTimeTilNextPayment(s)
 
 	// fetch all hosts not currently running a task
 	freeHosts, err := host.Find(host.IsFree)
@@ -77,6 +83,12 @@ func flagIdleHosts(d []distro.Distro, s *evergreen.Settings) ([]host.Host, error
 	// be terminated
 	for _, freeHost := range freeHosts {
 
+		if freeHost.IsIdleTerminationExempt(time.Now()) {
+			grip.Debugf("Keeping idle host %v, which is exempt from idle termination until %v",
+				freeHost.Id, freeHost.NoIdleTerminationUntil)
+			continue
+		}
+
 		// ask the host how long it has been idle
 		idleTime := freeHost.IdleTime()
 
@@ -102,12 +114,24 @@ func flagIdleHosts(d []distro.Distro, s *evergreen.Settings) ([]host.Host, error
 		// ask how long until the next payment for the host
 		tilNextPayment := cloudManager.TimeTilNextPayment(&freeHost)
 
+		idleCutoff := IdleTimeCutoff
+		if freeHost.IsDedicated() {
+			idleCutoff = DedicatedIdleTimeCutoff
+		}
+
 		// current determinants for idle:
-		//  idle for at least 15 minutes or last communication time has been more than 10 mins and
-		//  less than 5 minutes til next payment
-		if (communicationTime >= CommunicationTimeCutoff || idleTime >= IdleTimeCutoff) &&
-			tilNextPayment <= MaxTimeTilNextPayment {
-			idleHosts = append(idleHosts, freeHost)
+		//  idle for at least 15 minutes (5 for dedicated hosts) or last communication time has
+		//  been more than 10 mins, and less than 5 minutes (by default) til next payment.
+		//  Hosts still early in a paid interval are kept and reconsidered on later runs,
+		//  while hosts billed by the second have next to no time til next payment and
+		//  so are terminated as soon as they are idle.
+		if communicationTime >= CommunicationTimeCutoff || idleTime >= idleCutoff {
+			if tilNextPayment <= maxTilNextPayment {
+				idleHosts = append(idleHosts, freeHost)
+			} else {
+				grip.Debugf("Keeping idle host %v until it is within %v of its next payment (%v left)",
+					freeHost.Id, maxTilNextPayment, tilNextPayment)
+			}
 		}
 
 	}
@@ -115,6 +139,48 @@ func flagIdleHosts(d []distro.Distro, s *evergreen.Settings) ([]host.Host, error
 	return idleHosts, nil
 }
 
+// flagDrainingHosts is a hostFlaggingFunc to get the hosts of draining distros
+// that have finished their tasks, so the distros are scaled down as their hosts
+// go idle
+func flagDrainingHosts(distros []distro.Distro, s *evergreen.Settings) ([]host.Host, error) {
+	drainedHosts := []host.Host{}
+	for _, d := range distros {
+		if !d.Draining {
+			continue
+		}
+		hosts, err := host.Find(host.ByDistroId(d.Id))
+		if err != nil {
+			return nil, fmt.Errorf("error finding hosts of draining distro %v: %v", d.Id, err)
+		}
+		idle := 0
+		for _, h := range hosts {
+			if h.RunningTask != "" || len(h.ReservedTasks) > 0 {
+				continue
+			}
+			canTerminate, err := hostCanBeTerminated(h, s)
+			if err != nil {
+				return nil, fmt.Errorf("error checking if host %v can be terminated: %v", h.Id, err)
+			}
+			if canTerminate {
+				drainedHosts = append(drainedHosts, h)
+				idle++
+			}
+		}
+		grip.Infof("Draining distro %s has %d hosts left, %d of them idle",
+			d.Id, len(hosts), idle)
+	}
+	return drainedHosts, nil
+}
+
+// maxTimeTilNextPayment returns how close an idle host must be to its next payment
+// before it is terminated, using the default if the settings don't configure one.
+func maxTimeTilNextPayment(s *evergreen.Settings) time.Duration {
+	if s != nil && s.Monitor.MaxTimeTilNextPaymentSecs > 0 {
+		return time.Duration(s.Monitor.MaxTimeTilNextPaymentSecs) * time.Second
+	}
+	return MaxTimeTilNextPayment
+}
+
 // flagExcessHosts is a hostFlaggingFunc to get all hosts that push their
 // distros over the specified max hosts
 func flagExcessHosts(distros []distro.Distro, s *evergreen.Settings) ([]host.Host, error) {
@@ -197,14 +263,26 @@ func flagProvisioningFailedHosts(d []distro.Distro, s *evergreen.Settings) ([]ho
 }
 
 // flagExpiredHosts is a hostFlaggingFunc to get all user-spawned hosts
-// that have expired
+// that have expired, other than those their owners exempted from idle
+// termination
 func flagExpiredHosts(d []distro.Distro, s *evergreen.Settings) ([]host.Host, error) {
 	// fetch the expired hosts
-	hosts, err := host.Find(host.ByExpiredSince(time.Now()))
+	now := time.Now()
+	hosts, err := host.Find(host.ByExpiredSince(now))
 	if err != nil {
 		return nil, fmt.Errorf("error finding expired spawned hosts: %v", err)
 	}
-	return hosts, nil
+
+	expiredHosts := []host.Host{}
+	for _, h := range hosts {
+		if h.IsIdleTerminationExempt(now) {
+			grip.Debugf("Keeping expired host %v, which is exempt from idle termination until %v",
+				h.Id, h.NoIdleTerminationUntil)
+			continue
+		}
+		expiredHosts = append(expiredHosts, h)
+	}
+	return expiredHosts, nil
 
 }
 
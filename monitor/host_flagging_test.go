@@ -183,6 +183,59 @@ func TestFlaggingIdleHosts(t *testing.T) {
 			So(len(idle), ShouldEqual, 1)
 			So(idle[0].Id, ShouldEqual, "h1")
 		})
+		Convey("idle hosts with plenty of paid time left should not be flagged"+
+			" unless the configured threshold covers it", func() {
+			paidHost := host.Host{
+				Id:                    "h4",
+				Provider:              mock.ProviderName,
+				LastTaskCompleted:     "t1",
+				LastTaskCompletedTime: time.Now().Add(-time.Minute * 20),
+				LastCommunicationTime: time.Now(),
+				Status:                evergreen.HostRunning,
+				StartedBy:             evergreen.User,
+			}
+			So(paidHost.Insert(), ShouldBeNil)
+			mock.MockInstances["h4"] = mock.MockInstance{
+				IsUp:               true,
+				TimeTilNextPayment: 30 * time.Minute,
+			}
+			defer delete(mock.MockInstances, "h4")
+
+			idle, err := flagIdleHosts(nil, nil)
+			So(err, ShouldBeNil)
+			So(len(idle), ShouldEqual, 0)
+
+			settings := &evergreen.Settings{}
+			settings.Monitor.MaxTimeTilNextPaymentSecs = 45 * 60
+			idle, err = flagIdleHosts(nil, settings)
+			So(err, ShouldBeNil)
+			So(len(idle), ShouldEqual, 1)
+			So(idle[0].Id, ShouldEqual, "h4")
+		})
+		Convey("idle hosts exempt from idle termination should not be flagged"+
+			" until the exemption runs out", func() {
+			exemptHost := host.Host{
+				Id:                     "h5",
+				Provider:               mock.ProviderName,
+				LastTaskCompleted:      "t1",
+				LastTaskCompletedTime:  time.Now().Add(-time.Minute * 20),
+				LastCommunicationTime:  time.Now(),
+				Status:                 evergreen.HostRunning,
+				StartedBy:              evergreen.User,
+				NoIdleTerminationUntil: time.Now().Add(time.Hour),
+			}
+			So(exemptHost.Insert(), ShouldBeNil)
+
+			idle, err := flagIdleHosts(nil, nil)
+			So(err, ShouldBeNil)
+			So(len(idle), ShouldEqual, 0)
+
+			So(exemptHost.SetNoIdleTerminationUntil(time.Now().Add(-time.Minute)), ShouldBeNil)
+			idle, err = flagIdleHosts(nil, nil)
+			So(err, ShouldBeNil)
+			So(len(idle), ShouldEqual, 1)
+			So(idle[0].Id, ShouldEqual, "h5")
+		})
 
 	})
 
@@ -586,8 +639,8 @@ func TestFlaggingExpiredHosts(t *testing.T) {
 
 		})
 
-		Convey("hosts that are terminated or quarantined should be filtered"+
-			" out", func() {
+		Convey("hosts that are terminated, quarantined or failed to terminate"+
+			" should be filtered out", func() {
 
 			host1 := &host.Host{
 				Id:     "h1",
@@ -601,6 +654,12 @@ func TestFlaggingExpiredHosts(t *testing.T) {
 			}
 			testutil.HandleTestingErr(host2.Insert(), t, "error inserting host")
 
+			host3 := &host.Host{
+				Id:     "h3",
+				Status: evergreen.HostTerminationFailed,
+			}
+			testutil.HandleTestingErr(host3.Insert(), t, "error inserting host")
+
 			expired, err := flagExpiredHosts(nil, nil)
 			So(err, ShouldBeNil)
 			So(len(expired), ShouldEqual, 0)
@@ -633,6 +692,67 @@ func TestFlaggingExpiredHosts(t *testing.T) {
 
 		})
 
+		Convey("expired spawn hosts exempt from idle termination should not be"+
+			" flagged until the exemption runs out", func() {
+
+			spawnHost := &host.Host{
+				Id:                     "h1",
+				Status:                 evergreen.HostRunning,
+				StartedBy:              "user1",
+				ExpirationTime:         time.Now().Add(-time.Minute * 10),
+				NoIdleTerminationUntil: time.Now().Add(time.Hour),
+			}
+			testutil.HandleTestingErr(spawnHost.Insert(), t, "error inserting host")
+
+			expired, err := flagExpiredHosts(nil, nil)
+			So(err, ShouldBeNil)
+			So(len(expired), ShouldEqual, 0)
+
+			So(spawnHost.SetNoIdleTerminationUntil(time.Now().Add(-time.Minute)), ShouldBeNil)
+			expired, err = flagExpiredHosts(nil, nil)
+			So(err, ShouldBeNil)
+			So(len(expired), ShouldEqual, 1)
+			So(expired[0].Id, ShouldEqual, "h1")
+
+		})
+
 	})
 
 }
+
+func TestFlaggingDrainingHosts(t *testing.T) {
+
+	testConfig := testutil.TestConfig()
+
+	db.SetGlobalSessionProvider(db.SessionFactoryFromConfig(testConfig))
+
+	Convey("When flagging the hosts of draining distros", t, func() {
+
+		testutil.HandleTestingErr(db.ClearCollections(host.Collection),
+			t, "error clearing hosts collection")
+
+		distros := []distro.Distro{
+			{Id: "draining", Draining: true},
+			{Id: "normal"},
+		}
+		hosts := []host.Host{
+			{Id: "idle", Distro: distro.Distro{Id: "draining"}},
+			{Id: "busy", Distro: distro.Distro{Id: "draining"}, RunningTask: "t1"},
+			{Id: "reserved", Distro: distro.Distro{Id: "draining"}, ReservedTasks: []string{"t2"}},
+			{Id: "other", Distro: distro.Distro{Id: "normal"}},
+		}
+		for _, h := range hosts {
+			h.Status = evergreen.HostRunning
+			h.StartedBy = evergreen.User
+			h.Provider = mock.ProviderName
+			testutil.HandleTestingErr(h.Insert(), t, "error inserting host")
+		}
+
+		Convey("only idle hosts of draining distros should be flagged", func() {
+			drained, err := flagDrainingHosts(distros, nil)
+			So(err, ShouldBeNil)
+			So(len(drained), ShouldEqual, 1)
+			So(drained[0].Id, ShouldEqual, "idle")
+		})
+	})
+}
@@ -101,6 +101,28 @@ func checkHostReachability(host host.Host, settings *evergreen.Settings) error {
 	// take different action, depending on how the cloud provider reports the host's status
 	switch cloudStatus {
 	case cloud.StatusRunning:
+		// refresh the host's DNS name, in case its address changed since it was set
+		dnsName, err := cloudHost.GetDNSName()
+		if err != nil {
+			return fmt.Errorf("error getting DNS name for host %v: %v", host.Id, err)
+		}
+		if dnsName != "" && dnsName != host.Host {
+			grip.Infof("DNS name for host %s changed from '%s' to '%s'", host.Id, host.Host, dnsName)
+			if err := host.UpdateDNSName(dnsName); err != nil {
+				return fmt.Errorf("error updating DNS name for host %v: %v", host.Id, err)
+			}
+		}
+
+		// backfill the provider's instance id for hosts created before it was recorded
+		if host.InstanceId == "" {
+			instanceId, err := cloudHost.GetInstanceID()
+			if err != nil {
+				grip.Warningf("Error getting instance id for host %s: %+v", host.Id, err)
+			} else if err = host.SetInstanceId(instanceId); err != nil {
+				return fmt.Errorf("error setting instance id for host %v: %v", host.Id, err)
+			}
+		}
+
 		// check if the host is reachable via SSH
 		reachable, err := cloudHost.IsSSHReachable()
 		if err != nil {
@@ -118,10 +140,25 @@ func checkHostReachability(host host.Host, settings *evergreen.Settings) error {
 		if err := host.UpdateReachability(reachable); err != nil {
 			return fmt.Errorf("error updating reachability for host %v: %v", host.Id, err)
 		}
+	case cloud.StatusStopped:
+		// the host was shut down from inside with a stop shutdown behavior, so its
+		// instance is kept but it can't run tasks until it's started again
+		if host.Status != evergreen.HostUnreachable {
+			grip.Infof("Host %s stopped; setting it as unreachable", host.Id)
+		}
+		if err := host.UpdateReachability(false); err != nil {
+			return fmt.Errorf("error updating reachability for host %v: %v", host.Id, err)
+		}
 	case cloud.StatusTerminated:
 		grip.Infof("Host %s terminated externally; updating db status to terminated", host.Id)
 
-		// the instance was terminated from outside our control
+		// the instance was terminated from outside our control, so its static
+		// address wasn't released along with it
+		if associator, ok := cloudHost.CloudMgr.(cloud.StaticAddressAssociator); ok {
+			if err := associator.ReleaseStaticAddress(&host); err != nil {
+				grip.Errorf("Error releasing static address of host %s: %+v", host.Id, err)
+			}
+		}
 		if err := host.SetTerminated(); err != nil {
 			return fmt.Errorf("error setting host %v terminated: %v", host.Id, err)
 		}
@@ -92,6 +92,7 @@ func TestMonitorReachability(t *testing.T) {
 			host1, err := host.FindOne(host.ById("h1"))
 			So(err, ShouldBeNil)
 			So(host1.Status, ShouldEqual, evergreen.HostRunning)
+			So(host1.InstanceId, ShouldEqual, "h1")
 
 			// refresh the second host - its status should not have been updated
 			host1, err = host.FindOne(host.ById("h2"))
@@ -100,6 +101,59 @@ func TestMonitorReachability(t *testing.T) {
 
 		})
 
+		Convey("running hosts whose address has changed should have their"+
+			" DNS names updated", func() {
+
+			mock.MockInstances["h1"] = mock.MockInstance{
+				IsUp:           true,
+				IsSSHReachable: true,
+				Status:         cloud.StatusRunning,
+				DNSName:        "new.example.com",
+			}
+
+			h := &host.Host{
+				Id:   "h1",
+				Host: "old.example.com",
+				LastReachabilityCheck: time.Now().Add(-15 * time.Minute),
+				Status:                evergreen.HostRunning,
+				Provider:              mock.ProviderName,
+				StartedBy:             evergreen.User,
+			}
+			testutil.HandleTestingErr(h.Insert(), t, "error inserting host")
+
+			So(monitorReachability(nil), ShouldBeNil)
+
+			h, err := host.FindOne(host.ById("h1"))
+			So(err, ShouldBeNil)
+			So(h.Host, ShouldEqual, "new.example.com")
+
+		})
+
+		Convey("stopped hosts should be marked unreachable but not"+
+			" terminated", func() {
+
+			mock.MockInstances["h1"] = mock.MockInstance{
+				IsUp:   false,
+				Status: cloud.StatusStopped,
+			}
+
+			h := &host.Host{
+				Id: "h1",
+				LastReachabilityCheck: time.Now().Add(-15 * time.Minute),
+				Status:                evergreen.HostRunning,
+				Provider:              mock.ProviderName,
+				StartedBy:             evergreen.User,
+			}
+			testutil.HandleTestingErr(h.Insert(), t, "error inserting host")
+
+			So(monitorReachability(nil), ShouldBeNil)
+
+			h, err := host.FindOne(host.ById("h1"))
+			So(err, ShouldBeNil)
+			So(h.Status, ShouldEqual, evergreen.HostUnreachable)
+
+		})
+
 	})
 
 }
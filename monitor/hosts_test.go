@@ -0,0 +1,50 @@
+package monitor
+
+import (
+	"testing"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/cloud"
+	"github.com/evergreen-ci/evergreen/cloud/providers/mock"
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/evergreen-ci/evergreen/testutil"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTerminateHost(t *testing.T) {
+	db.SetGlobalSessionProvider(db.SessionFactoryFromConfig(testutil.TestConfig()))
+
+	Convey("With a host whose ssh key was removed from the settings", t, func() {
+		testutil.HandleTestingErr(db.ClearCollections(host.Collection), t,
+			"error clearing hosts collection")
+		mock.Clear()
+		mock.MockInstances["h1"] = mock.MockInstance{IsUp: true, Status: cloud.StatusRunning}
+
+		settings := &evergreen.Settings{Keys: map[string]string{"current": "/keys/current"}}
+		h := &host.Host{
+			Id:          "h1",
+			Provider:    mock.ProviderName,
+			Status:      evergreen.HostRunning,
+			Provisioned: true,
+			SSHKey:      "rotated",
+			Distro:      distro.Distro{Id: "d1", SSHKey: "current"},
+		}
+		So(h.Insert(), ShouldBeNil)
+
+		Convey("the host should still be terminated", func() {
+			So(terminateHost(h, settings), ShouldBeNil)
+			So(mock.MockInstances["h1"].Status, ShouldEqual, cloud.StatusTerminated)
+			dbHost, err := host.FindOne(host.ById("h1"))
+			So(err, ShouldBeNil)
+			So(dbHost.Status, ShouldEqual, evergreen.HostTerminated)
+		})
+
+		Convey("the host should be terminated even though its teardown can't run", func() {
+			h.Distro.Teardown = "echo tearing down"
+			So(terminateHost(h, settings), ShouldBeNil)
+			So(mock.MockInstances["h1"].Status, ShouldEqual, cloud.StatusTerminated)
+		})
+	})
+}
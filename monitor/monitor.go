@@ -26,6 +26,7 @@ var (
 		{flagDecommissionedHosts, "decommissioned"},
 		{flagUnreachableHosts, "unreachable"},
 		{flagIdleHosts, "idle"},
+		{flagDrainingHosts, "draining"},
 		{flagExcessHosts, "excess"},
 		{flagUnprovisionedHosts, "provision_timeout"},
 		{flagProvisioningFailedHosts, "provision_failed"},
@@ -35,6 +36,8 @@ var (
 	// the functions the host monitor will run through to do simpler checks
 	defaultHostMonitoringFuncs = []hostMonitoringFunc{
 		monitorReachability,
+		checkQueueWaits,
+		fallBackUnstartedHosts,
 	}
 
 	// the functions the notifier will use to build notifications that need
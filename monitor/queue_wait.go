@@ -0,0 +1,87 @@
+package monitor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/event"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/mongodb/grip"
+)
+
+// queueWaitSLA returns how long tasks may wait in a queue before an event is
+// logged for them, or zero if they may wait indefinitely.
+func queueWaitSLA(s *evergreen.Settings) time.Duration {
+	if s == nil || s.Monitor.QueueWaitSLASecs <= 0 {
+		return 0
+	}
+	return time.Duration(s.Monitor.QueueWaitSLASecs) * time.Second
+}
+
+// checkQueueWaits is a hostMonitoringFunc that logs an event for each task that
+// has waited in a distro's queue longer than the queue wait SLA. The event is
+// logged once each time the task is scheduled.
+func checkQueueWaits(settings *evergreen.Settings) []error {
+	sla := queueWaitSLA(settings)
+	if sla == 0 {
+		return nil
+	}
+	grip.Info("Checking queue waits...")
+
+	queues, err := model.FindAllTaskQueues()
+	if err != nil {
+		return []error{fmt.Errorf("error finding task queues: %v", err)}
+	}
+
+	var errs []error
+	now := time.Now()
+	for _, queue := range queues {
+		if queue.IsEmpty() {
+			continue
+		}
+		ids := make([]string, 0, queue.Length())
+		for _, item := range queue.Queue {
+			ids = append(ids, item.Id)
+		}
+		tasks, err := task.Find(task.ByIds(ids).WithFields(task.IdKey, task.ScheduledTimeKey))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error finding tasks queued for distro %v: %v",
+				queue.Distro, err))
+			continue
+		}
+
+		for _, t := range overdueTasks(tasks, sla, now) {
+			logged, err := event.Find(event.AllLogCollection,
+				event.TaskEventsOfTypeSince(t.Id, event.TaskQueueWaitSLABreached, t.ScheduledTime))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("error finding queue wait events of task %v: %v",
+					t.Id, err))
+				continue
+			}
+			if len(logged) > 0 {
+				continue
+			}
+			wait := now.Sub(t.ScheduledTime)
+			grip.Warningf("Task %v has waited %v in the queue for distro %v", t.Id, wait, queue.Distro)
+			event.LogTaskQueueWaitSLABreached(t.Id, queue.Distro, wait)
+		}
+	}
+	return errs
+}
+
+// overdueTasks returns the tasks that were scheduled more than sla before now.
+func overdueTasks(tasks []task.Task, sla time.Duration, now time.Time) []task.Task {
+	overdue := []task.Task{}
+	for _, t := range tasks {
+		if util.IsZeroTime(t.ScheduledTime) {
+			continue
+		}
+		if now.Sub(t.ScheduledTime) > sla {
+			overdue = append(overdue, t)
+		}
+	}
+	return overdue
+}
@@ -0,0 +1,33 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model/task"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestOverdueTasks(t *testing.T) {
+	Convey("With a queue wait SLA of ten minutes", t, func() {
+		settings := &evergreen.Settings{}
+		So(queueWaitSLA(settings), ShouldEqual, 0)
+		settings.Monitor.QueueWaitSLASecs = 10 * 60
+		sla := queueWaitSLA(settings)
+		So(sla, ShouldEqual, 10*time.Minute)
+
+		now := time.Now()
+		tasks := []task.Task{
+			{Id: "waited", ScheduledTime: now.Add(-11 * time.Minute)},
+			{Id: "recent", ScheduledTime: now.Add(-9 * time.Minute)},
+			{Id: "unscheduled"},
+		}
+
+		Convey("only tasks scheduled more than ten minutes ago are overdue", func() {
+			overdue := overdueTasks(tasks, sla, now)
+			So(len(overdue), ShouldEqual, 1)
+			So(overdue[0].Id, ShouldEqual, "waited")
+		})
+	})
+}
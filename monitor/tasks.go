@@ -7,6 +7,7 @@ import (
 	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/apimodels"
 	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/event"
 	"github.com/evergreen-ci/evergreen/model/host"
 	"github.com/evergreen-ci/evergreen/model/task"
 	"github.com/mongodb/grip"
@@ -101,6 +102,12 @@ func cleanUpTask(wrapper doomedTaskWrapper, projects map[string]model.Project) e
 		return wrapper.task.MarkUnscheduled()
 	}
 
+	// tasks reserved for a host whose agent stopped heartbeating are released, so
+	// that other hosts can run them
+	if host.RunningTask != wrapper.task.Id && host.HasReserved(wrapper.task.Id) {
+		return model.ReleaseReservedTask(&wrapper.task, host)
+	}
+
 	// sanity check that the host is actually running the task
 	if host.RunningTask != wrapper.task.Id {
 		return fmt.Errorf("task %v says it is running on host %v, but the"+
@@ -139,7 +146,7 @@ func cleanUpTimedOutHeartbeat(t task.Task, project model.Project, host *host.Hos
 	}
 
 	// clear out the host's running task
-	if err := host.ClearRunningTask(t.Id, time.Now()); err != nil {
+	if err := host.ClearRunningTask(t.Id, time.Now(), event.RunningTaskReaped); err != nil {
 		return fmt.Errorf("error clearing running task %v from host %v: %v",
 			t.Id, host.Id, err)
 	}
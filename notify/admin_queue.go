@@ -0,0 +1,91 @@
+package notify
+
+import (
+	"sync"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/mongodb/grip"
+)
+
+// DefaultAdminQueueSize is how many notifications an AdminQueue holds while
+// waiting to send them.
+const DefaultAdminQueueSize = 100
+
+type adminNotification struct {
+	subject string
+	message string
+}
+
+// AdminQueue sends notifications to the admins in the background, so that
+// callers, such as request handlers, don't wait on the mail server. Failures to
+// send are logged. Notifications queued while the queue is full are logged and
+// dropped rather than blocking the caller.
+type AdminQueue struct {
+	notifications chan adminNotification
+	send          func(subject, message string) error
+
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+// NewAdminQueue returns a queue that sends up to size pending notifications with
+// the settings' mailer, and starts sending them.
+func NewAdminQueue(settings *evergreen.Settings, size int) *AdminQueue {
+	return newAdminQueue(size, func(subject, message string) error {
+		return NotifyAdmins(subject, message, settings)
+	})
+}
+
+func newAdminQueue(size int, send func(subject, message string) error) *AdminQueue {
+	q := &AdminQueue{
+		notifications: make(chan adminNotification, size),
+		send:          send,
+		done:          make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+func (q *AdminQueue) run() {
+	defer close(q.done)
+	for n := range q.notifications {
+		q.sendNow(n)
+	}
+}
+
+func (q *AdminQueue) sendNow(n adminNotification) {
+	if err := q.send(n.subject, n.message); err != nil {
+		grip.Errorf("Error notifying admins of '%s': %+v", n.subject, err)
+	}
+}
+
+// Notify queues a notification to the admins. Once the queue is closed,
+// notifications are sent before Notify returns.
+func (q *AdminQueue) Notify(subject, message string) {
+	n := adminNotification{subject: subject, message: message}
+
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		q.sendNow(n)
+		return
+	}
+	select {
+	case q.notifications <- n:
+	default:
+		grip.Errorf("Dropping notification to admins because the queue is full: %s: %s",
+			subject, message)
+	}
+}
+
+// Close stops queueing notifications and waits for the queued ones to be sent.
+func (q *AdminQueue) Close() {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.notifications)
+	}
+	q.mu.Unlock()
+	<-q.done
+}
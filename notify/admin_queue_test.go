@@ -0,0 +1,41 @@
+package notify
+
+import (
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAdminQueue(t *testing.T) {
+	Convey("With an admin queue whose sends are blocked", t, func() {
+		mu := sync.Mutex{}
+		sent := []string{}
+		unblock := make(chan struct{})
+		q := newAdminQueue(2, func(subject, message string) error {
+			<-unblock
+			mu.Lock()
+			defer mu.Unlock()
+			sent = append(sent, subject)
+			return nil
+		})
+
+		Convey("notifying should not wait for notifications to be sent", func() {
+			q.Notify("one", "")
+			q.Notify("two", "")
+			q.Notify("three", "")
+			close(unblock)
+
+			Convey("and closing the queue should send the queued ones", func() {
+				q.Close()
+				So(len(sent), ShouldBeGreaterThanOrEqualTo, 2)
+				So(sent[0], ShouldEqual, "one")
+
+				Convey("after which notifications are sent right away", func() {
+					q.Notify("four", "")
+					So(sent[len(sent)-1], ShouldEqual, "four")
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,43 @@
+package notify
+
+import (
+	"github.com/evergreen-ci/evergreen"
+)
+
+// EmailChannel is the name of the channel that notifies admins by email.
+const EmailChannel = "email"
+
+// ChannelResult is the outcome of sending a test notification through one of the
+// channels admins are notified on.
+type ChannelResult struct {
+	Channel    string `json:"channel"`
+	Configured bool   `json:"configured"`
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+}
+
+// SendTestNotification sends a notification to the admins through each channel
+// they are notified on, so that the channels' settings can be checked without
+// waiting for a real failure, and returns the outcome for each. Unlike ordinary
+// notifications, each channel is only tried once, so that errors are reported
+// promptly.
+func SendTestNotification(settings *evergreen.Settings, subject, message string) []ChannelResult {
+	return []ChannelResult{
+		sendTestEmail(settings.Notify.SMTP, ConstructMailer(settings.Notify), subject, message),
+	}
+}
+
+func sendTestEmail(config *evergreen.SMTPConfig, mailer Mailer, subject, message string) ChannelResult {
+	result := ChannelResult{Channel: EmailChannel}
+	if config == nil || len(config.AdminEmail) == 0 {
+		result.Error = "admin_email not set"
+		return result
+	}
+	result.Configured = true
+	if err := mailer.SendMail(config.AdminEmail, subject, message); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Success = true
+	return result
+}
@@ -0,0 +1,43 @@
+package notify
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type failingMailer struct{}
+
+func (failingMailer) SendMail(recipients []string, subject, body string) error {
+	return errors.New("connection refused")
+}
+
+func TestSendTestEmail(t *testing.T) {
+	Convey("When sending a test notification by email", t, func() {
+		config := &evergreen.SMTPConfig{AdminEmail: []string{"admin@example.com"}}
+
+		Convey("an unconfigured channel should be reported as such", func() {
+			result := sendTestEmail(nil, MockMailer{}, "subject", "message")
+			So(result.Channel, ShouldEqual, EmailChannel)
+			So(result.Configured, ShouldBeFalse)
+			So(result.Success, ShouldBeFalse)
+			So(result.Error, ShouldContainSubstring, "admin_email")
+		})
+
+		Convey("a successful send should be reported", func() {
+			result := sendTestEmail(config, MockMailer{}, "subject", "message")
+			So(result.Configured, ShouldBeTrue)
+			So(result.Success, ShouldBeTrue)
+			So(result.Error, ShouldEqual, "")
+		})
+
+		Convey("a failed send should report its error", func() {
+			result := sendTestEmail(config, failingMailer{}, "subject", "message")
+			So(result.Configured, ShouldBeTrue)
+			So(result.Success, ShouldBeFalse)
+			So(result.Error, ShouldEqual, "connection refused")
+		})
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/codegangsta/negroni"
 	"github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/model/artifact"
 	"github.com/evergreen-ci/evergreen/model/task"
@@ -135,6 +136,17 @@ type APIPlugin interface {
 	GetAPIHandler() http.Handler
 }
 
+// APIMiddlewarePlugin is an optional extension of APIPlugin, implemented by plugins
+// that need to run their own middleware (e.g. auth, logging, or metrics) in front of
+// the API handler they install. The middleware only applies to the plugin's own routes
+// and runs after the task for the request has been loaded, in the order returned.
+type APIMiddlewarePlugin interface {
+	APIPlugin
+
+	// GetAPIMiddleware returns the middleware to wrap the plugin's API handler with.
+	GetAPIMiddleware() []negroni.Handler
+}
+
 type UIPlugin interface {
 	Plugin
 
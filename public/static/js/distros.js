@@ -272,6 +272,7 @@ mciModule.controller('DistrosCtrl', function($scope, $window, $location, mciDist
         'ssh_options': $scope.activeDistro.ssh_options,
         'setup': $scope.activeDistro.setup,
         'pool_size': $scope.activeDistro.pool_size,
+        'scheduling_weight': $scope.activeDistro.scheduling_weight,
         'setup_as_sudo' : $scope.activeDistro.setup_as_sudo,
 
       }
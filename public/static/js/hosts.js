@@ -83,6 +83,7 @@ mciModule.controller('HostsCtrl', function($scope, $filter, $window, $location)
     host.status = hostDoc.status;
     host.id = hostDoc.id;
     host.host = hostDoc.host;
+    host.dns_alias = hostObj.DNSAlias;
     host.creation_time = hostDoc.creation_time;
     host.started_by = hostDoc.started_by;
     host.task = "";
@@ -233,6 +233,9 @@ mciServices.rest.factory('mciSpawnRestService', ['mciBaseRestService', function(
         config.data['key_name'] = spawnInfo.spawnKey.name;
         config.data['public_key'] = spawnInfo.spawnKey.key;
         config.data['userdata'] = spawnInfo.userData;
+        config.data['root_volume_size'] = spawnInfo.rootVolumeSize || 0;
+        config.data['setup_script'] = spawnInfo.setupScript || '';
+        config.data['request_key'] = spawnInfo.requestKey;
         baseSvc.putResource(resource, [], config, callbacks);
     };
 
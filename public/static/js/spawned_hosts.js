@@ -355,6 +355,8 @@ mciModule.controller('SpawnedHostsCtrl', ['$scope','$window', '$timeout', 'mciSp
 
       var modal = $('#spawn-modal').modal('show');
       if ($scope.modalOption === 'spawnHost') {
+        // identifies this spawn request, so that resubmitting it doesn't start a second host
+        $scope.spawnInfo.requestKey = Math.random().toString(36).slice(2) + Date.now().toString(36);
         $scope.fetchUserKeys();
         if ($scope.spawnableDistros.length == 0) {
           $scope.fetchSpawnableDistros();
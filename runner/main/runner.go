@@ -13,6 +13,8 @@ import (
 	"time"
 
 	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/cloud"
+	"github.com/evergreen-ci/evergreen/cloud/providers/ec2"
 	"github.com/evergreen-ci/evergreen/db"
 	"github.com/evergreen-ci/evergreen/notify"
 	_ "github.com/evergreen-ci/evergreen/plugin/config"
@@ -87,6 +89,9 @@ func main() {
 
 	db.SetGlobalSessionProvider(db.SessionFactoryFromConfig(settings))
 
+	// fail fast if distros start hosts in AWS accounts that aren't configured
+	grip.CatchEmergencyFatal(ec2.CheckDistroAccounts(settings))
+
 	// just run one process if an argument was passed in
 	if flag.Arg(0) != "" {
 		grip.CatchEmergencyFatal(runProcessByName(flag.Arg(0), settings))
@@ -103,6 +108,7 @@ func main() {
 	wg := &sync.WaitGroup{}
 	ch := startRunners(wg, settings)
 	go listenForSIGTERM(ch)
+	go logCloudTimings(time.Duration(runInterval)*time.Second, ch)
 
 	// wait for all the processes to exit
 	wg.Wait()
@@ -157,6 +163,21 @@ func listenForSIGTERM(ch chan bool) {
 	close(ch)
 }
 
+// logCloudTimings periodically logs how long the runners' calls to cloud providers
+// have taken, until the terminate channel is closed.
+func logCloudTimings(interval time.Duration, terminateChan chan bool) {
+	for {
+		select {
+		case <-time.NewTimer(interval).C:
+		case <-terminateChan:
+			return
+		}
+		if timings := cloud.CallTimings(); len(timings) > 0 {
+			grip.Info(message.Fields{"message": "cloud provider call timings", "timings": timings})
+		}
+	}
+}
+
 // runProcessByName runs a single process given its name and evergreen Settings.
 // Returns an error if the process does not exist.
 func runProcessByName(name string, settings *evergreen.Settings) error {
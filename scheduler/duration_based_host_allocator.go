@@ -514,7 +514,10 @@ func (self *DurationBasedHostAllocator) numNewHostsForDistro(
 }
 
 // sortDistrosByNumStaticHosts returns a sorted slice of distros where the
-// distro with the greatest number of static host is first - at index position 0
+// distro with the greatest number of static host is first - at index position 0.
+// Distros without static hosts are sorted by their scheduling weight, highest
+// first, so that the preferred distros account for tasks that can run on several
+// and have hosts spun up for them.
 func sortDistrosByNumStaticHosts(distros []distro.Distro, settings *evergreen.Settings) []distro.Distro {
 	sortableDistroObj := &sortableDistroByNumStaticHost{distros, settings}
 	sort.Sort(sortableDistroObj)
@@ -529,7 +532,7 @@ func (sd *sortableDistroByNumStaticHost) Len() int {
 func (sd *sortableDistroByNumStaticHost) Less(i, j int) bool {
 	if sd.distros[i].Provider != evergreen.HostTypeStatic &&
 		sd.distros[j].Provider != evergreen.HostTypeStatic {
-		return false
+		return sd.distros[i].SchedulingWeight > sd.distros[j].SchedulingWeight
 	}
 	if sd.distros[i].Provider == evergreen.HostTypeStatic &&
 		sd.distros[j].Provider != evergreen.HostTypeStatic {
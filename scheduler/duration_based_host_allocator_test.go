@@ -459,6 +459,24 @@ func TestSortDistrosByNumStaticHosts(t *testing.T) {
 			So(newDistros[5].Id, ShouldEqual, hosts[1])
 			So(newDistros[6].Id, ShouldEqual, hosts[0])
 		})
+		Convey("distros without static hosts should follow static ones, sorted by scheduling weight", func() {
+			distros := []distro.Distro{
+				{Id: "light", Provider: "ec2", SchedulingWeight: 1},
+				{Id: "unweighted", Provider: "ec2"},
+				{Id: "static", Provider: evergreen.HostTypeStatic,
+					ProviderSettings: &map[string]interface{}{"hosts": []interface{}{
+						map[interface{}]interface{}{"name": "host1"}}}},
+				{Id: "heavy", Provider: "ec2", SchedulingWeight: 5},
+			}
+
+			newDistros := sortDistrosByNumStaticHosts(distros, hostAllocatorTestConf)
+
+			So(len(newDistros), ShouldEqual, 4)
+			So(newDistros[0].Id, ShouldEqual, "static")
+			So(newDistros[1].Id, ShouldEqual, "heavy")
+			So(newDistros[2].Id, ShouldEqual, "light")
+			So(newDistros[3].Id, ShouldEqual, "unweighted")
+		})
 	})
 }
 
@@ -198,6 +198,13 @@ func (s *Scheduler) Schedule() error {
 			err)
 	}
 
+	// don't start hosts for distros that are being drained
+	for distroId := range newHostsNeeded {
+		if distrosByName[distroId].Draining {
+			newHostsNeeded[distroId] = 0
+		}
+	}
+
 	// spawn up the hosts
 	hostsSpawned, err := s.spawnHosts(newHostsNeeded)
 	if err != nil {
@@ -406,6 +413,16 @@ func (s *Scheduler) spawnHosts(newHostsNeeded map[string]int) (
 		}
 
 		hostsSpawnedPerDistro[distroId] = make([]host.Host, 0, numHostsToSpawn)
+		if numHostsToSpawn > 1 {
+			if newHosts, batched := s.batchSpawnHosts(distroId, numHostsToSpawn); batched {
+				if len(newHosts) != 0 {
+					hostsSpawnedPerDistro[distroId] = newHosts
+				} else {
+					delete(hostsSpawnedPerDistro, distroId)
+				}
+				continue
+			}
+		}
 		for i := 0; i < numHostsToSpawn; i++ {
 			d, err := distro.FindOne(distro.ById(distroId))
 			if err != nil {
@@ -425,6 +442,22 @@ func (s *Scheduler) spawnHosts(newHostsNeeded map[string]int) (
 				continue
 			}
 
+			room, err := cloud.DistroRoom(d, 1)
+			if err != nil {
+				grip.Errorln("Error checking room for hosts of distro:", err)
+				continue
+			}
+			if room == 0 {
+				grip.Errorf("Distro '%s' already has its max (%d) hosts up, including spawn hosts",
+					distroId, d.MaxHosts)
+				continue
+			}
+
+			if err = providers.CheckSpawnAllowed(d.Provider, s.Settings); err != nil {
+				grip.Errorf("Not spawning hosts for distro '%s': %+v", distroId, err)
+				continue
+			}
+
 			cloudManager, err := providers.GetCloudManager(d.Provider, s.Settings)
 			if err != nil {
 				grip.Errorln("Error getting cloud manager for distro:", err)
@@ -432,8 +465,9 @@ func (s *Scheduler) spawnHosts(newHostsNeeded map[string]int) (
 			}
 
 			hostOptions := cloud.HostOptions{
-				UserName: evergreen.User,
-				UserHost: false,
+				UserName:         evergreen.User,
+				UserHost:         false,
+				ShutdownBehavior: d.ShutdownBehavior,
 			}
 			newHost, err := cloudManager.SpawnInstance(d, hostOptions)
 			if err != nil {
@@ -451,3 +485,65 @@ func (s *Scheduler) spawnHosts(newHostsNeeded map[string]int) (
 	}
 	return hostsSpawnedPerDistro, nil
 }
+
+// batchSpawnHosts spawns as many of the hosts needed for the distro as its pool
+// has room for with a single call to its provider. It returns false, without
+// spawning any hosts, if the provider can't spawn hosts in batches.
+func (s *Scheduler) batchSpawnHosts(distroId string, numHostsToSpawn int) ([]host.Host, bool) {
+	d, err := distro.FindOne(distro.ById(distroId))
+	if err != nil {
+		grip.Errorf("Failed to find distro '%s': %+v", distroId, err)
+		return nil, false
+	}
+
+	cloudManager, err := providers.GetCloudManager(d.Provider, s.Settings)
+	if err != nil {
+		grip.Errorln("Error getting cloud manager for distro:", err)
+		return nil, false
+	}
+	batchSpawner, ok := cloudManager.(cloud.BatchSpawner)
+	if !ok {
+		return nil, false
+	}
+
+	if err = providers.CheckSpawnAllowed(d.Provider, s.Settings); err != nil {
+		grip.Errorf("Not spawning hosts for distro '%s': %+v", distroId, err)
+		return nil, true
+	}
+
+	allDistroHosts, err := host.Find(host.ByDistroId(distroId))
+	if err != nil {
+		grip.Errorf("Error getting hosts for distro %s: %+v", distroId, err)
+		return nil, true
+	}
+	if room := d.PoolSize - len(allDistroHosts); room < numHostsToSpawn {
+		if room <= 0 {
+			grip.Errorf("Already at max (%d) hosts for distro '%s'", d.PoolSize, distroId)
+			return nil, true
+		}
+		grip.Errorf("Only spawning %d of %d hosts for distro '%s', which has a max of %d hosts",
+			room, numHostsToSpawn, distroId, d.PoolSize)
+		numHostsToSpawn = room
+	}
+	if numHostsToSpawn, err = cloud.DistroRoom(d, numHostsToSpawn); err != nil {
+		grip.Errorln("Error checking room for hosts of distro:", err)
+		return nil, true
+	}
+	if numHostsToSpawn == 0 {
+		grip.Errorf("Distro '%s' already has its max (%d) hosts up, including spawn hosts",
+			distroId, d.MaxHosts)
+		return nil, true
+	}
+
+	hostOptions := cloud.HostOptions{
+		UserName:         evergreen.User,
+		UserHost:         false,
+		ShutdownBehavior: d.ShutdownBehavior,
+	}
+	newHosts, err := batchSpawner.BatchSpawnInstance(d, hostOptions, numHostsToSpawn)
+	if err != nil {
+		grip.Errorf("Error spawning %d instances of distro '%s': %+v", numHostsToSpawn, distroId, err)
+		return nil, true
+	}
+	return newHosts, true
+}
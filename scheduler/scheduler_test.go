@@ -187,6 +187,17 @@ func TestSpawnHosts(t *testing.T) {
 			So(distroTwoHosts[0].Distro.Id, ShouldEqual, distroIds[2])
 		})
 
+		Convey("if the provider spawns hosts in batches, the Scheduler should"+
+			" not spawn more hosts than the distro's pool has room for", func() {
+
+			d := distro.Distro{Id: distroIds[0], PoolSize: 2, Provider: mock.ProviderName}
+			So(d.Insert(), ShouldBeNil)
+
+			newHostsSpawned, err := schedulerInstance.spawnHosts(map[string]int{distroIds[0]: 3})
+			So(err, ShouldBeNil)
+			So(len(newHostsSpawned[distroIds[0]]), ShouldEqual, 2)
+		})
+
 		Reset(func() {
 			db.Clear(distro.Collection)
 			db.Clear(host.Collection)
@@ -1,15 +1,19 @@
 package service
 
 import (
+	"bufio"
 	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math/rand"
 	"net"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/codegangsta/negroni"
 	"github.com/evergreen-ci/evergreen"
@@ -44,9 +48,22 @@ const apiHostKey hostKey = 0
 
 const maxTestLogSize = 16 * 1024 * 1024 // 16 MB
 
+const maxProcessInfoSize = 16 * 1024 * 1024 // 16 MB
+
+// maxSystemInfoBatchSize is the most system info samples an agent may send in one
+// request.
+const maxSystemInfoBatchSize = 100
+
 // ErrLockTimeout is returned when the database lock takes too long to be acquired.
 var ErrLockTimeout = errors.New("Timed out acquiring global lock")
 
+const (
+	// lockRetryMinSleep and lockRetryMaxSleep bound how long handlers wait between
+	// attempts to acquire the global lock while it is held by someone else
+	lockRetryMinSleep = 100 * time.Millisecond
+	lockRetryMaxSleep = 10 * time.Second
+)
+
 // APIServer handles communication with Evergreen agents and other back-end requests.
 type APIServer struct {
 	*render.Render
@@ -54,6 +71,15 @@ type APIServer struct {
 	Settings     evergreen.Settings
 	plugins      []plugin.APIPlugin
 	clientConfig *evergreen.ClientConfig
+
+	// pluginStatuses is populated when the handler is built
+	pluginStatuses []pluginStatus
+
+	// adminNotifications sends notifications to the admins in the background
+	adminNotifications *notify.AdminQueue
+
+	// hostLimiter enforces the rate limit of each host's requests to the agent routes
+	hostLimiter *rateLimiter
 }
 
 const (
@@ -81,11 +107,34 @@ func NewAPIServer(settings *evergreen.Settings, plugins []plugin.APIPlugin) (*AP
 		Settings:     *settings,
 		plugins:      plugins,
 		clientConfig: clientConfig,
+
+		adminNotifications: notify.NewAdminQueue(settings, notify.DefaultAdminQueueSize),
+		hostLimiter:        newRateLimiter(),
 	}
 
 	return as, nil
 }
 
+// Close waits for the API server's queued notifications to be sent. It should be
+// called once the server has stopped handling requests.
+func (as *APIServer) Close() {
+	if as.adminNotifications != nil {
+		as.adminNotifications.Close()
+	}
+}
+
+// notifyAdmins sends a notification to the admins without waiting for the mail
+// server, so that request handlers aren't slowed down by it.
+func (as *APIServer) notifyAdmins(subject, message string) {
+	if as.adminNotifications == nil {
+		if err := notify.NotifyAdmins(subject, message, &as.Settings); err != nil {
+			grip.Errorln("Error sending email:", err)
+		}
+		return
+	}
+	as.adminNotifications.Notify(subject, message)
+}
+
 // MustHaveTask gets the task from an HTTP Request.
 // Panics if the task is not in request context.
 func MustHaveTask(r *http.Request) *task.Task {
@@ -195,6 +244,9 @@ func (as *APIServer) checkHost(next http.HandlerFunc) http.HandlerFunc {
 			as.LoggedError(w, r, http.StatusConflict, fmt.Errorf("Invalid host secret for host %v", h.Id))
 			return
 		}
+		if as.hostLimiter.rejectOverLimit(w, h.Id, as.Settings.Api.HostRateLimits) {
+			return
+		}
 
 		// if the task is attached to the context, check host-task relationship
 		if ctxTask := context.Get(r, apiTaskKey); ctxTask != nil {
@@ -217,21 +269,42 @@ func (as *APIServer) checkHost(next http.HandlerFunc) http.HandlerFunc {
 }
 
 func (as *APIServer) GetVersion(w http.ResponseWriter, r *http.Request) {
+	v := as.taskVersion(w, r)
+	if v == nil {
+		return
+	}
+	as.WriteVersionedJSON(w, r, http.StatusOK, v)
+}
+
+// GetVersionConfig returns the project config yaml that the task's version was
+// created from, exactly as it was stored.
+func (as *APIServer) GetVersionConfig(w http.ResponseWriter, r *http.Request) {
+	v := as.taskVersion(w, r)
+	if v == nil {
+		return
+	}
+	w.Header().Set("Content-Type", "application/yaml; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(v.Config))
+}
+
+// taskVersion finds the version of the request's task, writing an error response
+// and returning nil if it can't.
+func (as *APIServer) taskVersion(w http.ResponseWriter, r *http.Request) *version.Version {
 	t := MustHaveTask(r)
 
 	// Get the version for this task, so we can get its config data
 	v, err := version.FindOne(version.ById(t.Version))
 	if err != nil {
 		as.LoggedError(w, r, http.StatusInternalServerError, err)
-		return
+		return nil
 	}
 
 	if v == nil {
 		http.Error(w, "version not found", http.StatusNotFound)
-		return
+		return nil
 	}
-
-	as.WriteJSON(w, http.StatusOK, v)
+	return v
 }
 
 func (as *APIServer) GetProjectRef(w http.ResponseWriter, r *http.Request) {
@@ -249,45 +322,65 @@ func (as *APIServer) GetProjectRef(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	as.WriteJSON(w, http.StatusOK, p)
+	as.WriteVersionedJSON(w, r, http.StatusOK, p)
 }
 
 // AttachTestLog is the API Server hook for getting
 // the test logs and storing them in the test_logs collection.
+// Logs larger than maxTestLogSize are rejected, unless the settings'
+// test log overflow mode is to truncate them.
 func (as *APIServer) AttachTestLog(w http.ResponseWriter, r *http.Request) {
 	t := MustHaveTask(r)
-	// define a LimitedReader to prevent overly large logs from getting into memory
-	lr := &io.LimitedReader{R: r.Body, N: maxTestLogSize}
-	// manually close Body since LimitedReader is not a ReadCloser
+	// manually close Body since neither reader below closes it
 	defer r.Body.Close()
 	log := &model.TestLog{}
-	err := util.ReadJSONInto(ioutil.NopCloser(lr), log)
-	if lr.N == 0 {
-		// error if we used every available byte in the limit reader
-		as.LoggedError(w, r, http.StatusBadRequest,
-			fmt.Errorf("test log size exceeds %v bytes", maxTestLogSize))
-		return
-	}
-	if err != nil {
-		as.LoggedError(w, r, http.StatusBadRequest, err)
-		return
+	truncated := false
+	if as.Settings.Api.TestLogOverflow == evergreen.TestLogOverflowTruncate {
+		var err error
+		log, truncated, err = readTruncatedTestLog(w, r.Body, maxTestLogSize)
+		if err != nil {
+			as.LoggedError(w, r, http.StatusBadRequest, err)
+			return
+		}
+	} else {
+		// define a LimitedReader to prevent overly large logs from getting into memory
+		lr := &io.LimitedReader{R: r.Body, N: maxTestLogSize}
+		err := util.ReadJSONInto(ioutil.NopCloser(lr), log)
+		if lr.N == 0 {
+			// error if we used every available byte in the limit reader
+			as.LoggedError(w, r, http.StatusBadRequest,
+				fmt.Errorf("test log size exceeds %v bytes", maxTestLogSize))
+			return
+		}
+		if err != nil {
+			as.LoggedError(w, r, http.StatusBadRequest, err)
+			return
+		}
 	}
 
 	// enforce proper taskID and Execution
 	log.Task = t.Id
 	log.TaskExecution = t.Execution
+	if err := log.Validate(); err != nil {
+		as.LoggedError(w, r, http.StatusBadRequest, err)
+		return
+	}
 
 	if err := log.Insert(); err != nil {
 		as.LoggedError(w, r, http.StatusInternalServerError, err)
 		return
 	}
 	logReply := struct {
-		Id string `json:"_id"`
-	}{log.Id}
+		Id        string `json:"_id"`
+		Truncated bool   `json:"truncated,omitempty"`
+	}{Id: log.Id, Truncated: truncated}
 	as.WriteJSON(w, http.StatusOK, logReply)
 }
 
-// AttachResults attaches the received results to the task in the database.
+// AttachResults attaches the received results to the task in the database. By
+// default they replace the task's results; with the "append" query parameter set
+// to true, they are merged into the task's results instead, replacing earlier
+// results for tests of the same name, so that results can be sent in phases.
 func (as *APIServer) AttachResults(w http.ResponseWriter, r *http.Request) {
 	t := MustHaveTask(r)
 	results := &task.TestResults{}
@@ -296,8 +389,13 @@ func (as *APIServer) AttachResults(w http.ResponseWriter, r *http.Request) {
 		as.LoggedError(w, r, http.StatusBadRequest, err)
 		return
 	}
-	// set test result of task
-	if err := t.SetResults(results.Results); err != nil {
+	if strings.ToLower(r.URL.Query().Get("append")) == "true" {
+		err = t.AppendResults(results.Results)
+	} else {
+		// set test result of task
+		err = t.SetResults(results.Results)
+	}
+	if err != nil {
 		as.LoggedError(w, r, http.StatusInternalServerError, err)
 		return
 	}
@@ -321,7 +419,63 @@ func (as *APIServer) FetchProjectVars(w http.ResponseWriter, r *http.Request) {
 	as.WriteJSON(w, http.StatusOK, projectVars.Vars)
 }
 
-// AttachFiles updates file mappings for a task or build
+// ExpansionPrecedenceHeader is the response header listing the sources of the
+// expansions returned by FetchExpansions, from lowest to highest precedence.
+const ExpansionPrecedenceHeader = "X-Evergreen-Expansion-Precedence"
+
+// FetchExpansions is an API hook for returning every expansion a task runs with,
+// merging the deployment, task, distro, build variant, task override and project
+// expansions in the order of precedence of model.ExpansionSources. Only the
+// deployment expansions listed in the settings' TaskExpansions are included.
+func (as *APIServer) FetchExpansions(w http.ResponseWriter, r *http.Request) {
+	t := MustHaveTask(r)
+
+	h, err := findTaskHost(t)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	v, err := version.FindOne(version.ById(t.Version))
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	if v == nil {
+		http.Error(w, "version not found", http.StatusNotFound)
+		return
+	}
+
+	project := &model.Project{}
+	if err = model.LoadProjectInto([]byte(v.Config), v.Identifier, project); err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError,
+			fmt.Errorf("error reading project config: %v", err))
+		return
+	}
+
+	projectVars, err := model.FindOneProjectVars(t.Project)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	vars := map[string]string{}
+	if projectVars != nil {
+		vars = projectVars.Vars
+	}
+
+	expansions, err := model.MergeExpansions(&as.Settings, &h.Distro, v, project, t, vars)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set(ExpansionPrecedenceHeader, strings.Join(model.ExpansionSources, ","))
+	as.WriteJSON(w, http.StatusOK, expansions)
+}
+
+// AttachFiles updates file mappings for a task or build. The route requires the
+// task's secret or a token for attaching files to it, so that only the agent
+// running the task, or an uploader it has handed a token to, can attach files.
 func (as *APIServer) AttachFiles(w http.ResponseWriter, r *http.Request) {
 	t := MustHaveTask(r)
 	grip.Infoln("Attaching files to task:", t.Id)
@@ -365,6 +519,9 @@ func (as *APIServer) AppendTaskLog(w http.ResponseWriter, r *http.Request) {
 		as.LoggedError(w, r, http.StatusInternalServerError, err)
 		return
 	}
+	if err := t.UpdateLastLogTime(); err != nil {
+		grip.Warningf("Error updating last log time for task %s: %+v", t.Id, err)
+	}
 
 	as.WriteJSON(w, http.StatusOK, "Logs added")
 }
@@ -372,72 +529,179 @@ func (as *APIServer) AppendTaskLog(w http.ResponseWriter, r *http.Request) {
 // FetchTask loads the task from the database and sends it to the requester.
 func (as *APIServer) FetchTask(w http.ResponseWriter, r *http.Request) {
 	t := MustHaveTask(r)
-	as.WriteJSON(w, http.StatusOK, t)
+	as.WriteVersionedJSON(w, r, http.StatusOK, t)
 }
 
 // Heartbeat handles heartbeat pings from Evergreen agents. If the heartbeating
-// task is marked to be aborted, the abort response is sent.
+// task is marked to be aborted, the abort response is sent. A running task that
+// has gone longer than the no-output timeout without sending logs is aborted.
 func (as *APIServer) Heartbeat(w http.ResponseWriter, r *http.Request) {
 	t := MustHaveTask(r)
 
-	heartbeatResponse := apimodels.HeartbeatResponse{}
-	if t.Aborted {
-		// grip.Infofln("Sending abort signal for task %s", task.Id)
-		heartbeatResponse.Abort = true
+	timeout := as.Settings.Api.NoOutputTimeout()
+	if !t.Aborted && timeout > 0 && t.Status == evergreen.TaskStarted &&
+		t.TimeSinceOutput(time.Now()) > timeout {
+		grip.Warningf("Aborting task %s: no log output for %v", t.Id, timeout)
+		if err := model.AbortTask(t.Id, APIServerLockTitle, task.AbortReasonNoOutput); err != nil {
+			grip.Errorf("Error aborting task %s without output: %+v", t.Id, err)
+		} else {
+			t.Aborted = true
+			t.AbortReason = task.AbortReasonNoOutput
+		}
 	}
 
+	heartbeatResponse := as.abortResponse(t)
+
 	if err := t.UpdateHeartbeat(); err != nil {
 		// grip.Errorf("Error updating heartbeat for task %s : %+v", task.Id, err)
 	}
+	// the tasks reserved for the host are kept only as long as its agent is alive
+	if h := GetHost(r); h != nil && len(h.ReservedTasks) > 0 {
+		if err := task.UpdateReservedHeartbeats(h.ReservedTasks, h.Id); err != nil {
+			grip.Errorf("Error updating heartbeats of tasks reserved for host %s: %+v", h.Id, err)
+		}
+	}
 	as.WriteJSON(w, http.StatusOK, heartbeatResponse)
 }
 
+// abortResponse returns the heartbeat response for the task, which tells the
+// agent to abort it if it has been aborted.
+func (as *APIServer) abortResponse(t *task.Task) apimodels.HeartbeatResponse {
+	heartbeatResponse := apimodels.HeartbeatResponse{}
+	if t.Aborted {
+		// grip.Infofln("Sending abort signal for task %s", task.Id)
+		heartbeatResponse.Abort = true
+		heartbeatResponse.AbortReason = t.AbortReason
+		if window := as.Settings.Api.AbortDelayWindow(); window > 0 {
+			heartbeatResponse.AbortDelayMillis = rand.Int63n(int64(window / time.Millisecond))
+		}
+	}
+	return heartbeatResponse
+}
+
 // TaskSystemInfo is the handler for the system info collector, which
-// reads grip/message.SystemInfo objects from the request body.
+// reads grip/message.SystemInfo objects from the request body. The body may
+// hold either a single sample or an array of up to maxSystemInfoBatchSize
+// samples, so that agents can batch several samples into one request. Null
+// samples in an array are ignored.
 func (as *APIServer) TaskSystemInfo(w http.ResponseWriter, r *http.Request) {
 	t := MustHaveTask(r)
-	info := &message.SystemInfo{}
 	defer r.Body.Close()
 
-	if err := util.ReadJSONInto(r.Body, info); err != nil {
-		as.LoggedError(w, r, http.StatusBadRequest, err)
+	body := bufio.NewReader(r.Body)
+	infos := []*message.SystemInfo{}
+	if isJSONArray(body) {
+		if err := util.ReadJSONInto(ioutil.NopCloser(body), &infos); err != nil {
+			as.LoggedError(w, r, http.StatusBadRequest, err)
+			return
+		}
+	} else {
+		info := &message.SystemInfo{}
+		if err := util.ReadJSONInto(ioutil.NopCloser(body), info); err != nil {
+			as.LoggedError(w, r, http.StatusBadRequest, err)
+			return
+		}
+		infos = append(infos, info)
+	}
+	if len(infos) > maxSystemInfoBatchSize {
+		as.LoggedError(w, r, http.StatusBadRequest,
+			fmt.Errorf("system info batch of %v samples exceeds %v samples", len(infos), maxSystemInfoBatchSize))
 		return
 	}
 
-	event.LogTaskSystemData(t.Id, info)
+	samples := make([]*message.SystemInfo, 0, len(infos))
+	for _, info := range infos {
+		if info != nil {
+			samples = append(samples, info)
+		}
+	}
+	event.LogTaskSystemData(t.Id, samples...)
 
 	as.WriteJSON(w, http.StatusOK, struct{}{})
 }
 
+// isJSONArray reports whether the JSON document at the start of the reader is an
+// array, without consuming any of the document.
+func isJSONArray(r *bufio.Reader) bool {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return false
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		_ = r.UnreadByte()
+		return b == '['
+	}
+}
+
+// processInfoResponse reports whether some of the processes sent to TaskProcessInfo
+// were dropped because there were too many of them.
+type processInfoResponse struct {
+	Truncated bool `json:"truncated"`
+	Accepted  int  `json:"accepted"`
+	Received  int  `json:"received"`
+}
+
 // TaskProcessInfo is the handler for the process info collector, which
 // reads slices of grip/message.ProcessInfo objects from the request body.
 func (as *APIServer) TaskProcessInfo(w http.ResponseWriter, r *http.Request) {
 	t := MustHaveTask(r)
 	procs := []*message.ProcessInfo{}
+	// define a LimitedReader to prevent overly large payloads from getting into memory
+	lr := &io.LimitedReader{R: r.Body, N: maxProcessInfoSize}
+	// manually close Body since LimitedReader is not a ReadCloser
 	defer r.Body.Close()
 
-	if err := util.ReadJSONInto(r.Body, &procs); err != nil {
+	err := util.ReadJSONInto(ioutil.NopCloser(lr), &procs)
+	if lr.N == 0 {
+		// error if we used every available byte in the limit reader
+		as.LoggedError(w, r, http.StatusBadRequest,
+			fmt.Errorf("process info size exceeds %v bytes", maxProcessInfoSize))
+		return
+	}
+	if err != nil {
 		as.LoggedError(w, r, http.StatusBadRequest, err)
 		return
 	}
 
+	resp := processInfoResponse{Received: len(procs)}
+	if limit := as.Settings.Api.ProcessInfoLimit(); len(procs) > limit {
+		grip.Warningf("Task %v sent info for %v processes; only keeping the first %v",
+			t.Id, len(procs), limit)
+		procs = procs[:limit]
+		resp.Truncated = true
+	}
+	resp.Accepted = len(procs)
+
 	event.LogTaskProcessData(t.Id, procs)
-	as.WriteJSON(w, http.StatusOK, struct{}{})
+	as.WriteJSON(w, http.StatusOK, resp)
 }
 
 func home(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "Welcome to the API server's home :)\n")
 }
 
+// processStartTime approximates when the server process started.
+var processStartTime = time.Now()
+
 func (as *APIServer) serviceStatusWithAuth(w http.ResponseWriter, r *http.Request) {
 	out := struct {
-		BuildId    string              `json:"build_revision"`
-		SystemInfo *message.SystemInfo `json:"sys_info"`
-		Pid        int                 `json:"pid"`
+		BuildId          string              `json:"build_revision"`
+		SystemInfo       *message.SystemInfo `json:"sys_info"`
+		Pid              int                 `json:"pid"`
+		StartTime        time.Time           `json:"start_time"`
+		UptimeSecs       float64             `json:"uptime_secs"`
+		SettingsLoadedAt time.Time           `json:"settings_loaded_at"`
 	}{
-		BuildId:    evergreen.BuildRevision,
-		SystemInfo: message.CollectSystemInfo().(*message.SystemInfo),
-		Pid:        os.Getpid(),
+		BuildId:          evergreen.BuildRevision,
+		SystemInfo:       message.CollectSystemInfo().(*message.SystemInfo),
+		Pid:              os.Getpid(),
+		StartTime:        processStartTime,
+		UptimeSecs:       time.Since(processStartTime).Seconds(),
+		SettingsLoadedAt: as.Settings.LoadedAt(),
 	}
 
 	as.WriteJSON(w, http.StatusOK, &out)
@@ -528,15 +792,6 @@ func (as *APIServer) hostReady(w http.ResponseWriter, r *http.Request) {
 	setupSuccess := mux.Vars(r)["status"]
 	if setupSuccess == evergreen.HostStatusFailed {
 		grip.Infof("Initializing host %s failed", hostObj.Id)
-		// send notification to the Evergreen team about this provisioning failure
-		subject := fmt.Sprintf("%v Evergreen provisioning failure on %v", notify.ProvisionFailurePreface, hostObj.Distro.Id)
-
-		hostLink := fmt.Sprintf("%v/host/%v", as.Settings.Ui.Url, hostObj.Id)
-		message := fmt.Sprintf("Provisioning failed on %v host -- %v (%v). %v",
-			hostObj.Distro.Id, hostObj.Id, hostObj.Host, hostLink)
-		if err = notify.NotifyAdmins(subject, message, &as.Settings); err != nil {
-			grip.Errorln("Error sending email:", err)
-		}
 
 		// get/store setup logs
 		setupLog, err := ioutil.ReadAll(r.Body)
@@ -545,9 +800,32 @@ func (as *APIServer) hostReady(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		event.LogProvisionFailed(hostObj.Id, string(setupLog))
+		event.LogProvisionFailed(hostObj.Id, string(setupLog), 0)
 
-		err = hostObj.SetUnprovisioned()
+		// retry the setup of distros with flaky first boots, rather than failing the
+		// host on its first failure
+		if hostObj.ProvisionRetries < hostObj.Distro.ProvisionRetries {
+			if err = hostObj.RetryProvisioning(); err != nil {
+				as.LoggedError(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			grip.Infof("Retrying provisioning of host %s (retry %d of %d)", hostObj.Id,
+				hostObj.ProvisionRetries, hostObj.Distro.ProvisionRetries)
+			as.WriteJSON(w, http.StatusOK, fmt.Sprintf("Initializing host %v failed; retrying", hostObj.Id))
+			return
+		}
+
+		// send notification to the Evergreen team about this provisioning failure
+		subject := fmt.Sprintf("%v Evergreen provisioning failure on %v", notify.ProvisionFailurePreface, hostObj.Distro.Id)
+
+		hostLink := fmt.Sprintf("%v/host/%v", as.Settings.Ui.Url, hostObj.Id)
+		message := fmt.Sprintf("Provisioning failed on %v host -- %v (%v). %v",
+			hostObj.Distro.Id, hostObj.Id, hostObj.DNSAlias(&as.Settings), hostLink)
+		as.notifyAdmins(subject, message)
+
+		reason := host.ClassifyProvisionFailure(string(setupLog))
+		grip.Infof("Classified provisioning failure on host %s as '%s'", hostObj.Id, reason)
+		err = hostObj.SetUnprovisioned(reason)
 		if err != nil {
 			as.LoggedError(w, r, http.StatusInternalServerError, err)
 			return
@@ -564,9 +842,7 @@ func (as *APIServer) hostReady(w http.ResponseWriter, r *http.Request) {
 			notify.ProvisionFailurePreface, hostObj.Distro.Id)
 		message := fmt.Sprintf("Failed to get cloud manager for host %v with provider %v: %v",
 			hostObj.Id, hostObj.Provider, err)
-		if err = notify.NotifyAdmins(subject, message, &as.Settings); err != nil {
-			grip.Errorln("Error sending email:", err)
-		}
+		as.notifyAdmins(subject, message)
 		return
 	}
 
@@ -601,16 +877,36 @@ func (as *APIServer) fetchProjectRef(w http.ResponseWriter, r *http.Request) {
 	as.WriteJSON(w, http.StatusOK, projectRef)
 }
 
+// listProjects returns a page of the tracked projects, or all of them for clients
+// that don't page.
 func (as *APIServer) listProjects(w http.ResponseWriter, r *http.Request) {
-	allProjs, err := model.FindAllTrackedProjectRefs()
+	page, err := getPageParams(r, defaultPageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	allProjs, err := model.FindProjectRefs(model.TrackedProjectRefs().SecondaryOk())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	as.WriteJSON(w, http.StatusOK, allProjs)
+	if !isPagedRequest(r) {
+		page = wholeList(len(allProjs))
+	}
+	start, end := page.bounds(len(allProjs))
+	as.writePage(w, r, allProjs[start:end], len(allProjs), page)
 }
 
+// listTasks returns a page of the tasks defined in the project's config, or all of
+// them for clients that don't page. Their dependencies and commands are left out if
+// the "omit_definitions" parameter is true, for clients that only need to list the
+// tasks.
 func (as *APIServer) listTasks(w http.ResponseWriter, r *http.Request) {
+	page, err := getPageParams(r, defaultPageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	vars := mux.Vars(r)
 	id := vars["projectId"]
 	projectRef, err := model.FindOneProjectRef(id)
@@ -618,22 +914,129 @@ func (as *APIServer) listTasks(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	project, err := model.FindProject("", projectRef)
+	project, err := model.FindLastKnownGoodProject(projectRef)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// zero out the depends on and commands fields because they are
-	// unnecessary and may not get marshaled properly
-	for i := range project.Tasks {
-		project.Tasks[i].DependsOn = []model.TaskDependency{}
-		project.Tasks[i].Commands = []model.PluginCommandConf{}
+	if !isPagedRequest(r) {
+		page = wholeList(len(project.Tasks))
+	}
+	start, end := page.bounds(len(project.Tasks))
+	tasks := project.Tasks[start:end]
+	if r.FormValue("omit_definitions") == "true" {
+		for i := range tasks {
+			tasks[i].DependsOn = []model.TaskDependency{}
+			tasks[i].Commands = []model.PluginCommandConf{}
+		}
+	}
+	as.writePage(w, r, tasks, len(project.Tasks), page)
+}
+
+const (
+	defaultTaskInstancesLimit = 100
+	maxTaskInstancesLimit     = 1000
+)
+
+// taskInstancesResponse is a page of a project's tasks, for clients that don't
+// use paged responses.
+type taskInstancesResponse struct {
+	Tasks []task.Task `json:"tasks"`
+	Skip  int         `json:"skip"`
+	Limit int         `json:"limit"`
+}
+
+// listTaskInstances returns summaries of a project's tasks, newest first. The
+// optional "version" query parameter restricts the tasks to one version, and
+// "status", which may be repeated or comma-separated, to the given statuses.
+// "page" and "page_size" page through the results; clients that don't page use
+// "skip" and "limit" instead. Only superusers and the project's admins may list the
+// tasks of private projects.
+func (as *APIServer) listTaskInstances(w http.ResponseWriter, r *http.Request) {
+	projectRef, err := model.FindOneProjectRef(mux.Vars(r)["projectId"])
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	if projectRef == nil {
+		http.Error(w, "project not found", http.StatusNotFound)
+		return
+	}
+	if !as.canAccessProject(MustHaveUser(r), projectRef) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	statuses := []string{}
+	for _, param := range query["status"] {
+		for _, status := range strings.Split(param, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				statuses = append(statuses, status)
+			}
+		}
+	}
+
+	tasksQuery := task.ByProjectVersionAndStatuses(projectRef.Identifier, query.Get("version"), statuses).
+		WithFields(task.IdKey, task.DisplayNameKey, task.BuildVariantKey, task.BuildIdKey, task.VersionKey,
+			task.ProjectKey, task.RevisionKey, task.StatusKey, task.DetailsKey, task.ActivatedKey,
+			task.CreateTimeKey, task.StartTimeKey, task.FinishTimeKey, task.HostIdKey).
+		SecondaryOk()
+
+	if !isPagedRequest(r) {
+		skip, limit := 0, defaultTaskInstancesLimit
+		if s := query.Get("skip"); s != "" {
+			if skip, err = strconv.Atoi(s); err != nil || skip < 0 {
+				http.Error(w, fmt.Sprintf("invalid skip '%v'", s), http.StatusBadRequest)
+				return
+			}
+		}
+		if l := query.Get("limit"); l != "" {
+			if limit, err = strconv.Atoi(l); err != nil || limit <= 0 {
+				http.Error(w, fmt.Sprintf("invalid limit '%v'", l), http.StatusBadRequest)
+				return
+			}
+			if limit > maxTaskInstancesLimit {
+				limit = maxTaskInstancesLimit
+			}
+		}
+		tasks, err := task.Find(tasksQuery.Skip(skip).Limit(limit))
+		if err != nil {
+			as.LoggedError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		as.WriteVersionedJSON(w, r, http.StatusOK, taskInstancesResponse{Tasks: tasks, Skip: skip, Limit: limit})
+		return
+	}
 
+	page, err := getPageParams(r, defaultPageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	total, err := task.Count(tasksQuery)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
 	}
-	as.WriteJSON(w, http.StatusOK, project.Tasks)
+	tasks, err := task.Find(tasksQuery.Skip(page.skip()).Limit(page.PageSize))
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	as.writePage(w, r, tasks, total, page)
 }
+
+// listVariants returns a page of the build variants defined in the project's config,
+// or all of them for clients that don't page.
 func (as *APIServer) listVariants(w http.ResponseWriter, r *http.Request) {
+	page, err := getPageParams(r, defaultPageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	vars := mux.Vars(r)
 	id := vars["projectId"]
 	projectRef, err := model.FindOneProjectRef(id)
@@ -646,7 +1049,11 @@ func (as *APIServer) listVariants(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	as.WriteJSON(w, http.StatusOK, project.BuildVariants)
+	if !isPagedRequest(r) {
+		page = wholeList(len(project.BuildVariants))
+	}
+	start, end := page.bounds(len(project.BuildVariants))
+	as.writePage(w, r, project.BuildVariants[start:end], len(project.BuildVariants), page)
 }
 
 // validateProjectConfig returns a slice containing a list of any errors
@@ -659,19 +1066,78 @@ func (as *APIServer) validateProjectConfig(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	as.validateProjectYAML(w, r, yamlBytes, "")
+}
+
+// validateProjectConfigById runs the same checks as validateProjectConfig against
+// the current configuration of the project with the given identifier: that of its
+// most recent version, whether or not it's valid, or its local configuration if it
+// has no versions yet.
+func (as *APIServer) validateProjectConfigById(w http.ResponseWriter, r *http.Request) {
+	u := MustHaveUser(r)
+	id := mux.Vars(r)["identifier"]
+	projectRef, err := model.FindOneProjectRef(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if projectRef == nil {
+		http.Error(w, fmt.Sprintf("project %v not found", id), http.StatusNotFound)
+		return
+	}
+	if !as.canAccessProject(u, projectRef) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	lastVersion, err := version.FindOne(version.ByMostRecentForRequester(id, evergreen.RepotrackerVersionRequester))
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError,
+			fmt.Errorf("error finding most recent version of project %v: %v", id, err))
+		return
+	}
+	config := projectRef.LocalConfig
+	if lastVersion != nil {
+		config = lastVersion.Config
+	}
+	if config == "" {
+		http.Error(w, fmt.Sprintf("project %v has no configuration", id), http.StatusNotFound)
+		return
+	}
+	as.validateProjectYAML(w, r, []byte(config), id)
+}
+
+// validateProjectYAML parses a project's configuration and writes the errors found
+// in it. Configurations that can't be parsed get a 400 with the parse error.
+func (as *APIServer) validateProjectYAML(w http.ResponseWriter, r *http.Request, yamlBytes []byte, identifier string) {
 	project := &model.Project{}
-	validationErr := validator.ValidationError{}
-	if err := model.LoadProjectInto(yamlBytes, "", project); err != nil {
-		validationErr.Message = err.Error()
-		as.WriteJSON(w, http.StatusBadRequest, []validator.ValidationError{validationErr})
+	if err := model.LoadProjectInto(yamlBytes, identifier, project); err != nil {
+		as.WriteJSON(w, http.StatusBadRequest, []validator.ValidationError{{Message: err.Error()}})
 		return
 	}
+	as.writeProjectValidation(w, r, project)
+}
+
+// writeProjectValidation responds with a slice of the syntax and semantic errors
+// found in the project, with a 400 if there are any. If the request names a build
+// variant with the "variant" query parameter, only the semantics of that variant
+// and its tasks are checked.
+func (as *APIServer) writeProjectValidation(w http.ResponseWriter, r *http.Request, project *model.Project) {
 	syntaxErrs, err := validator.CheckProjectSyntax(project)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	semanticErrs := validator.CheckProjectSemantics(project)
+	var semanticErrs []validator.ValidationError
+	if variant := r.URL.Query().Get("variant"); variant != "" {
+		semanticErrs, err = validator.CheckVariantSemantics(project, variant)
+		if err != nil {
+			as.WriteJSON(w, http.StatusBadRequest, []validator.ValidationError{{Message: err.Error()}})
+			return
+		}
+	} else {
+		semanticErrs = validator.CheckProjectSemantics(project)
+	}
 	if len(syntaxErrs)+len(semanticErrs) != 0 {
 		as.WriteJSON(w, http.StatusBadRequest, append(syntaxErrs, semanticErrs...))
 		return
@@ -685,11 +1151,16 @@ func (as *APIServer) validateProjectConfig(w http.ResponseWriter, r *http.Reques
 func getGlobalLock(client, taskId, caller string) bool {
 	grip.Debugf("Attempting to acquire global lock for %s (remote addr: %s) with caller %s", taskId, client, caller)
 
-	lockAcquired, err := db.WaitTillAcquireGlobalLock(client, db.LockTimeout)
+	holder := fmt.Sprintf("%s: %s", caller, taskId)
+	lockAcquired, err := db.WaitTillAcquireGlobalLockWithBackoff(client, holder, db.LockTimeout,
+		lockRetryMinSleep, lockRetryMaxSleep)
 	if err != nil {
 		grip.Errorf("Error acquiring global lock for %s (remote addr: %s) with caller %s: %+v", taskId, client, caller, err)
 		return false
 	}
+	if err = model.RecordGlobalLockAttempt(!lockAcquired, time.Now()); err != nil {
+		grip.Errorf("Error recording global lock attempt for %s: %+v", taskId, err)
+	}
 	if !lockAcquired {
 		grip.Errorf("Timed out attempting to acquire global lock for %s (remote addr: %s) with caller %s", taskId, client, caller)
 		return false
@@ -747,8 +1218,10 @@ func (as *APIServer) Handler() (http.Handler, error) {
 	// Project lookup and validation routes
 	apiRootOld.HandleFunc("/ref/{identifier:[\\w_\\-\\@.]+}", as.fetchProjectRef)
 	apiRootOld.HandleFunc("/validate", as.validateProjectConfig).Methods("POST")
+	apiRootOld.HandleFunc("/validate/{identifier:[\\w_\\-\\@.]+}", requireUser(as.validateProjectConfigById, nil)).Methods("GET")
 	apiRootOld.HandleFunc("/projects", requireUser(as.listProjects, nil)).Methods("GET")
 	apiRootOld.HandleFunc("/tasks/{projectId}", requireUser(as.listTasks, nil)).Methods("GET")
+	apiRootOld.HandleFunc("/tasks/{projectId}/instances", requireUser(as.listTaskInstances, nil)).Methods("GET")
 	apiRootOld.HandleFunc("/variants/{projectId}", requireUser(as.listVariants, nil)).Methods("GET")
 
 	// Task Queue routes
@@ -775,8 +1248,22 @@ func (as *APIServer) Handler() (http.Handler, error) {
 	spawn := apiRootOld.PathPrefix("/spawn/").Subrouter()
 	spawn.HandleFunc("/{instance_id:[\\w_\\-\\@]+}/", requireUser(as.hostInfo, nil)).Methods("GET")
 	spawn.HandleFunc("/{instance_id:[\\w_\\-\\@]+}/", requireUser(as.modifyHost, nil)).Methods("POST")
+	spawn.HandleFunc("/{instance_id:[\\w_\\-\\@]+}/events", requireUser(as.hostEvents, nil)).Methods("GET")
+	spawn.HandleFunc("/{instance_id:[\\w_\\-\\@]+}/provision_failure", requireUser(as.hostProvisionFailure, nil)).Methods("GET")
+	spawn.HandleFunc("/{instance_id:[\\w_\\-\\@]+}/reprovision", requireUser(as.requireSuperUser(as.reprovisionHost), nil)).Methods("POST")
+	spawn.HandleFunc("/{instance_id:[\\w_\\-\\@]+}/console_output", requireUser(as.requireSuperUser(as.hostConsoleOutput), nil)).Methods("GET")
 	spawn.HandleFunc("/ready/{instance_id:[\\w_\\-\\@]+}/{status}", requireUser(as.spawnHostReady, nil)).Methods("POST")
 
+	// Instance state change notifications from providers
+	apiRootOld.HandleFunc("/instance_events/{provider}", requireUser(as.requireSuperUser(as.instanceEvent), nil)).Methods("POST")
+
+	apiRootOld.HandleFunc("/distros/{distro_id}", requireUser(as.getPublicDistro, nil)).Methods("GET")
+
+	// Draining distros before they're scaled down
+	apiRootOld.HandleFunc("/distros/{distro_id}/drain", requireUser(as.requireSuperUser(as.drainDistro), nil)).Methods("POST")
+	apiRootOld.HandleFunc("/distros/{distro_id}/drain", requireUser(as.requireSuperUser(as.undrainDistro), nil)).Methods("DELETE")
+	apiRootOld.HandleFunc("/notifications/test", requireUser(as.requireSuperUser(as.testNotification), nil)).Methods("POST")
+
 	runtimes := apiRootOld.PathPrefix("/runtimes/").Subrouter()
 	runtimes.HandleFunc("/", as.listRuntimes).Methods("GET")
 	runtimes.HandleFunc("/timeout/{seconds:\\d*}", as.lateRuntimes).Methods("GET")
@@ -785,59 +1272,79 @@ func (as *APIServer) Handler() (http.Handler, error) {
 	status := apiRootOld.PathPrefix("/status/").Subrouter()
 	status.HandleFunc("/consistent_task_assignment", as.consistentTaskAssignment).Methods("GET")
 	status.HandleFunc("/info", requireUser(as.serviceStatusWithAuth, as.serviceStatusSimple)).Methods("GET")
+	status.HandleFunc("/plugins", requireUser(as.listPluginStatusWithAuth, as.listPluginStatusSimple)).Methods("GET")
+	status.HandleFunc("/live", as.liveness).Methods("GET")
+	status.HandleFunc("/ready", as.readiness).Methods("GET")
+	status.HandleFunc("/cloud_timings", requireUser(as.requireSuperUser(as.cloudTimings), nil)).Methods("GET")
+	status.HandleFunc("/hosts", as.requireSuperUser(as.distroHostStats)).Methods("GET")
+	status.HandleFunc("/quotas", as.requireSuperUser(as.cloudQuotas)).Methods("GET")
+	status.HandleFunc("/teardowns", as.requireSuperUser(as.teardownStats)).Methods("GET")
+	status.HandleFunc("/provision_failures", as.requireSuperUser(as.provisionFailures)).Methods("GET")
+	status.HandleFunc("/global_lock", as.requireSuperUser(as.globalLock)).Methods("GET")
+	status.HandleFunc("/scheduler_health", requireUser(as.requireSuperUser(as.schedulerHealth), nil)).Methods("GET")
+
+	// Scheduler debugging
+	scheduler := apiRootOld.PathPrefix("/scheduler/").Subrouter()
+	scheduler.HandleFunc("/next_task", as.requireSuperUser(as.explainNextTask)).Methods("GET")
+
+	// Cost reporting
+	cost := apiRootOld.PathPrefix("/cost/").Subrouter()
+	cost.HandleFunc("/projects", as.requireSuperUser(as.projectCosts)).Methods("GET")
 
 	// Hosts callback
 	host := r.PathPrefix("/host/{tag:[\\w_\\-\\@]+}/").Subrouter()
 	host.HandleFunc("/ready/{status}", as.hostReady).Methods("POST")
+	host.HandleFunc("/rotate_secret", as.rotateHostSecret).Methods("POST")
 
 	// Spawnhost routes - creating new hosts, listing existing hosts, listing distros
 	spawns := apiRootOld.PathPrefix("/spawns/").Subrouter()
 	spawns.HandleFunc("/", requireUser(as.requestHost, nil)).Methods("PUT")
 	spawns.HandleFunc("/{user}/", requireUser(as.hostsInfoForUser, nil)).Methods("GET")
 	spawns.HandleFunc("/distros/list/", requireUser(as.listDistros, nil)).Methods("GET")
+	spawns.HandleFunc("/distros/spawnable/", requireUser(as.listSpawnableDistros, nil)).Methods("GET")
+	spawns.HandleFunc("/distros/{distro}/can_spawn", requireUser(as.canSpawn, nil)).Methods("GET")
 
 	// Agent routes
 	agentRouter := r.PathPrefix("/agent").Subrouter()
 	agentRouter.HandleFunc("/next_task", as.checkHost(as.NextTask)).Methods("POST")
 
-	taskRouter := r.PathPrefix("/task/{taskId}").Subrouter()
+	taskRoute := r.PathPrefix("/task/{taskId}")
+	taskRouter := taskRoute.Subrouter()
 	taskRouter.HandleFunc("/start", as.checkTask(true, as.checkHost(as.StartTask))).Methods("POST")
 	taskRouter.HandleFunc("/end", as.checkTask(true, as.checkHost(as.EndTask))).Methods("POST")
 	taskRouter.HandleFunc("/new_end", as.checkTask(true, as.checkHost(as.newEndTask))).Methods("POST")
 	taskRouter.HandleFunc("/log", as.checkTask(true, as.checkHost(as.AppendTaskLog))).Methods("POST")
 	taskRouter.HandleFunc("/heartbeat", as.checkTask(true, as.checkHost(as.Heartbeat))).Methods("POST")
+	taskRouter.HandleFunc("/abort_events", as.checkTask(true, as.checkHost(as.abortEvents))).Methods("GET")
 	taskRouter.HandleFunc("/results", as.checkTask(true, as.checkHost(as.AttachResults))).Methods("POST")
 	taskRouter.HandleFunc("/test_logs", as.checkTask(true, as.checkHost(as.AttachTestLog))).Methods("POST")
-	taskRouter.HandleFunc("/files", as.checkTask(false, as.checkHost(as.AttachFiles))).Methods("POST")
+	taskRouter.HandleFunc("/files", as.checkTask(false, as.requireSecretOrToken(attachFilesScope, as.checkHost(as.AttachFiles)))).Methods("POST")
+	taskRouter.HandleFunc("/file_token", as.checkTask(true, as.checkHost(as.fileToken))).Methods("POST")
 	taskRouter.HandleFunc("/system_info", as.checkTask(true, as.checkHost(as.TaskSystemInfo))).Methods("POST")
 	taskRouter.HandleFunc("/process_info", as.checkTask(true, as.checkHost(as.TaskProcessInfo))).Methods("POST")
 	taskRouter.HandleFunc("/distro", as.checkTask(false, as.GetDistro)).Methods("GET")
 	taskRouter.HandleFunc("/", as.checkTask(true, as.FetchTask)).Methods("GET")
 	taskRouter.HandleFunc("/version", as.checkTask(false, as.GetVersion)).Methods("GET")
+	taskRouter.HandleFunc("/version/config", as.checkTask(false, as.GetVersionConfig)).Methods("GET")
 	taskRouter.HandleFunc("/project_ref", as.checkTask(false, as.GetProjectRef)).Methods("GET")
 	taskRouter.HandleFunc("/fetch_vars", as.checkTask(true, as.FetchProjectVars)).Methods("GET")
+	taskRouter.HandleFunc("/fetch_expansions", as.checkTask(true, as.FetchExpansions)).Methods("GET")
+	taskRouter.HandleFunc("/priority", requireUser(as.checkTask(false, as.setTaskPriority), nil)).Methods("POST")
+	taskRouter.HandleFunc("/abort", requireUser(as.checkTask(false, as.abortTask), nil)).Methods("POST")
+	taskRouter.HandleFunc("/bundle", requireUser(as.checkTask(false, as.taskBundle), nil)).Methods("GET")
+	taskRouter.HandleFunc("/events", requireUser(as.checkTask(false, as.taskEvents), nil)).Methods("GET")
+	taskRouter.HandleFunc("/output_stream", requireUser(as.checkTask(false, as.taskOutputStream), nil)).Methods("GET")
+	taskRouter.HandleFunc("/rotate_secret", as.requireSuperUser(as.checkTask(false, as.rotateTaskSecret))).Methods("POST")
 
 	// Install plugin routes
-	for _, pl := range as.plugins {
-		if pl == nil {
-			continue
-		}
-		pluginSettings := as.Settings.Plugins[pl.Name()]
-		err := pl.Configure(pluginSettings)
-		if err != nil {
-			return nil, fmt.Errorf("Failed to configure plugin %s: %v", pl.Name(), err)
-		}
-		handler := pl.GetAPIHandler()
-		if handler == nil {
-			grip.Warningf("no API handlers to install for %s plugin", pl.Name())
-			continue
-		}
-		grip.Debugf("Installing API handlers for %s plugin", pl.Name())
-		util.MountHandler(taskRouter, fmt.Sprintf("/%s/", pl.Name()), as.checkTask(false, handler.ServeHTTP))
+	if err := as.installPlugins(taskRoute, taskRouter); err != nil {
+		return nil, err
 	}
 
 	n := negroni.New()
-	n.Use(NewLogger())
+	n.Use(NewLogger(as.Settings.LoggedHeaders))
+	n.Use(NewGzipMiddleware(gzipMinSize))
+	n.Use(NewAPIVersionMiddleware())
 	n.Use(negroni.HandlerFunc(UserMiddleware(as.UserManager)))
 	n.UseHandler(root)
 	return n, nil
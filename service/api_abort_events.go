@@ -0,0 +1,162 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/mongodb/grip"
+)
+
+const (
+	// abortEventsPollInterval is how often a task's abort events stream checks
+	// whether the task has been aborted. Aborts made through this API server wake
+	// the stream right away, so polling only has to catch aborts made elsewhere,
+	// such as from the UI.
+	abortEventsPollInterval = 15 * time.Second
+
+	// abortEventsKeepAlive is how often a comment is sent on an idle stream, so
+	// that proxies don't close it.
+	abortEventsKeepAlive = 30 * time.Second
+
+	// abortEventsMaxDuration is how long a stream stays open before the agent
+	// has to reconnect.
+	abortEventsMaxDuration = 30 * time.Minute
+
+	// abortEventName is the name of the server-sent event that tells an agent
+	// to abort its task.
+	abortEventName = "abort"
+)
+
+// abortNotifier wakes the abort events streams of tasks aborted by this process.
+type abortNotifier struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan struct{}]struct{}
+}
+
+// taskAborts is notified by the abort task handler.
+var taskAborts = &abortNotifier{subscribers: map[string]map[chan struct{}]struct{}{}}
+
+// subscribe returns a channel that receives a value when the task is aborted,
+// and a function that must be called to unsubscribe.
+func (n *abortNotifier) subscribe(taskId string) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.subscribers[taskId] == nil {
+		n.subscribers[taskId] = map[chan struct{}]struct{}{}
+	}
+	n.subscribers[taskId][ch] = struct{}{}
+
+	return ch, func() {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		delete(n.subscribers[taskId], ch)
+		if len(n.subscribers[taskId]) == 0 {
+			delete(n.subscribers, taskId)
+		}
+	}
+}
+
+// notify wakes every stream subscribed to the task without blocking.
+func (n *abortNotifier) notify(taskId string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for ch := range n.subscribers[taskId] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// abortEvents streams server-sent events to the agent running a task, sending
+// an "abort" event with the heartbeat response as soon as the task is aborted,
+// so that agents don't have to wait for their next heartbeat to notice. The
+// stream is closed after the abort event, once the task is no longer running,
+// or after abortEventsMaxDuration. Heartbeats still report aborts, for agents
+// that aren't subscribed. Streams don't count towards the API server's
+// connection limit, since each running task holds one open.
+func (as *APIServer) abortEvents(w http.ResponseWriter, r *http.Request) {
+	t := MustHaveTask(r)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		as.LoggedError(w, r, http.StatusInternalServerError,
+			fmt.Errorf("streaming responses are not supported"))
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	if r.ProtoMajor == 1 {
+		// the connection no longer holds a slot, so don't reuse it for
+		// other requests once the stream ends
+		w.Header().Set("Connection", "close")
+	}
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	exemptFromConnectionLimit(r)
+
+	aborted, unsubscribe := taskAborts.subscribe(t.Id)
+	defer unsubscribe()
+	poll := time.NewTicker(abortEventsPollInterval)
+	defer poll.Stop()
+	keepAlive := time.NewTicker(abortEventsKeepAlive)
+	defer keepAlive.Stop()
+	deadline := time.After(abortEventsMaxDuration)
+
+	for {
+		if t.Aborted {
+			if err := writeServerSentEvent(w, abortEventName, as.abortResponse(t)); err != nil {
+				grip.Errorf("Error sending abort event for task %s: %+v", t.Id, err)
+			}
+			flusher.Flush()
+			return
+		}
+		if t.Status != evergreen.TaskDispatched && t.Status != evergreen.TaskStarted {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-deadline:
+			return
+		case <-keepAlive.C:
+			if _, err := io.WriteString(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+			continue
+		case <-poll.C:
+		case <-aborted:
+		}
+
+		current, err := task.FindOne(task.ById(t.Id).WithFields(
+			task.IdKey, task.StatusKey, task.AbortedKey, task.AbortReasonKey))
+		if err != nil {
+			grip.Errorf("Error checking whether task %s was aborted: %+v", t.Id, err)
+			return
+		}
+		if current == nil {
+			return
+		}
+		t = current
+	}
+}
+
+// writeServerSentEvent writes an event with the JSON encoding of data to a
+// server-sent events stream.
+func writeServerSentEvent(w io.Writer, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+	return err
+}
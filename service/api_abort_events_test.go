@@ -0,0 +1,109 @@
+package service
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/testutil"
+	"github.com/gorilla/context"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAbortNotifier(t *testing.T) {
+	Convey("With an abort notifier", t, func() {
+		n := &abortNotifier{subscribers: map[string]map[chan struct{}]struct{}{}}
+		aborted, unsubscribe := n.subscribe("t1")
+
+		Convey("subscribers of the aborted task should be woken", func() {
+			n.notify("t1")
+			select {
+			case <-aborted:
+			case <-time.After(time.Second):
+				So("subscriber never woken", ShouldBeEmpty)
+			}
+		})
+
+		Convey("subscribers of other tasks should not be woken", func() {
+			n.notify("t2")
+			select {
+			case <-aborted:
+				So("subscriber woken by another task", ShouldBeEmpty)
+			default:
+			}
+		})
+
+		Convey("repeated notifications should not block", func() {
+			n.notify("t1")
+			n.notify("t1")
+			<-aborted
+		})
+
+		Convey("unsubscribing should forget the subscriber", func() {
+			unsubscribe()
+			So(n.subscribers, ShouldBeEmpty)
+			n.notify("t1")
+		})
+	})
+}
+
+func TestAbortEvents(t *testing.T) {
+	testConfig := testutil.TestConfig()
+	db.SetGlobalSessionProvider(db.SessionFactoryFromConfig(testConfig))
+
+	Convey("With an agent subscribed to a running task's abort events", t, func() {
+		testutil.HandleTestingErr(db.Clear(task.Collection), t, "error clearing tasks")
+		running := &task.Task{Id: "t1", Status: evergreen.TaskStarted}
+		So(running.Insert(), ShouldBeNil)
+
+		as := &APIServer{Settings: *testConfig}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			context.Set(r, RequestTask, running)
+			as.abortEvents(w, r)
+		}))
+		defer server.Close()
+
+		resp, err := http.Get(server.URL)
+		So(err, ShouldBeNil)
+		defer resp.Body.Close()
+		So(resp.StatusCode, ShouldEqual, http.StatusOK)
+
+		Convey("aborting the task should send an abort event without waiting to poll", func() {
+			// wait for the handler to subscribe
+			for i := 0; i < 100; i++ {
+				taskAborts.mu.Lock()
+				subscribed := len(taskAborts.subscribers[running.Id]) > 0
+				taskAborts.mu.Unlock()
+				if subscribed {
+					break
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			So((&task.Task{Id: running.Id}).SetAborted("stuck"), ShouldBeNil)
+			taskAborts.notify(running.Id)
+
+			events := make(chan string, 1)
+			go func() {
+				scanner := bufio.NewScanner(resp.Body)
+				for scanner.Scan() {
+					if strings.HasPrefix(scanner.Text(), "event: ") {
+						events <- scanner.Text()
+						return
+					}
+				}
+			}()
+			select {
+			case event := <-events:
+				So(event, ShouldEqual, "event: "+abortEventName)
+			case <-time.After(abortEventsPollInterval / 2):
+				So("abort event never sent", ShouldBeEmpty)
+			}
+		})
+	})
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/model"
+)
+
+// projectCostsResp holds the cost of each project's tasks between two times.
+type projectCostsResp struct {
+	Start    time.Time           `json:"start"`
+	End      time.Time           `json:"end"`
+	Projects []model.ProjectCost `json:"projects"`
+}
+
+// projectCosts returns the total cost of the host time used by each project's tasks
+// between the RFC3339 times given by the "start" and "end" query parameters. If end
+// is omitted, it defaults to now.
+func (as *APIServer) projectCosts(w http.ResponseWriter, r *http.Request) {
+	resp := projectCostsResp{}
+	var err error
+	if resp.Start, resp.End, err = parseTimeRange(r); err != nil {
+		as.LoggedError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	resp.Projects, err = model.ComputeProjectCosts(&as.Settings, resp.Start, resp.End)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	as.WriteJSON(w, http.StatusOK, resp)
+}
+
+// parseTimeRange reads the range of time given by the RFC3339 "start" and "end"
+// query parameters. The start is required, and the end defaults to now.
+func parseTimeRange(r *http.Request) (time.Time, time.Time, error) {
+	startParam := r.FormValue("start")
+	if startParam == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("a start time is required")
+	}
+	start, err := time.Parse(time.RFC3339, startParam)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid start time: %v", err)
+	}
+	end := time.Now()
+	if endParam := r.FormValue("end"); endParam != "" {
+		if end, err = time.Parse(time.RFC3339, endParam); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid end time: %v", err)
+		}
+	}
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("end time must be after start time")
+	}
+	return start, end, nil
+}
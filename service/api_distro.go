@@ -4,7 +4,13 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/event"
 	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/gorilla/mux"
+	"github.com/mongodb/grip"
+	"gopkg.in/mgo.v2"
 )
 
 // GetDistro loads the task's distro and sends it to the requester.
@@ -12,29 +18,143 @@ func (as *APIServer) GetDistro(w http.ResponseWriter, r *http.Request) {
 	t := MustHaveTask(r)
 
 	// Get the distro for this task
-	h, err := host.FindOne(host.ByRunningTaskId(t.Id))
+	h, err := findTaskHost(t)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	// agent can't properly unmarshal provider settings map
+	h.Distro.ProviderSettings = nil
+	as.WriteJSON(w, http.StatusOK, h.Distro)
+}
+
+// publicDistro is the configuration of a distro that anyone may read. It leaves out
+// the distro's provider settings, which may hold credentials, and its setup
+// scripts, SSH key, user data and expansions, which may hold secrets.
+type publicDistro struct {
+	Id           string `json:"id"`
+	Arch         string `json:"arch,omitempty"`
+	WorkDir      string `json:"work_dir,omitempty"`
+	Provider     string `json:"provider"`
+	InstanceType string `json:"instance_type,omitempty"`
+	SpawnAllowed bool   `json:"spawn_allowed"`
+	Draining     bool   `json:"draining"`
+}
+
+// makePublicDistro returns the parts of a distro's configuration that are safe to
+// show to anyone. The instance type is taken from the provider settings of
+// providers that have one.
+func makePublicDistro(d *distro.Distro) publicDistro {
+	out := publicDistro{
+		Id:           d.Id,
+		Arch:         d.Arch,
+		WorkDir:      d.WorkDir,
+		Provider:     d.Provider,
+		SpawnAllowed: d.SpawnAllowed,
+		Draining:     d.Draining,
+	}
+	if d.ProviderSettings != nil {
+		if instanceType, ok := (*d.ProviderSettings)["instance_type"].(string); ok {
+			out.InstanceType = instanceType
+		}
+	}
+	return out
+}
+
+// getPublicDistro sends the public configuration of the distro with the given id,
+// for clients that display distros outside of a task.
+func (as *APIServer) getPublicDistro(w http.ResponseWriter, r *http.Request) {
+	distroId := mux.Vars(r)["distro_id"]
+
+	d, err := distro.FindOne(distro.ById(distroId))
+	if err == mgo.ErrNotFound {
+		http.Error(w, fmt.Sprintf("distro '%v' not found", distroId), http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		as.LoggedError(w, r, http.StatusInternalServerError, err)
 		return
 	}
+	as.WriteJSON(w, http.StatusOK, makePublicDistro(d))
+}
+
+// findTaskHost returns the host running the task, falling back on the host
+// recorded in the task document. Returns an error if neither can be found.
+func findTaskHost(t *task.Task) (*host.Host, error) {
+	h, err := host.FindOne(host.ByRunningTaskId(t.Id))
+	if err != nil {
+		return nil, err
+	}
 
 	// Fall back to checking host field on task doc
 	if h == nil && len(t.HostId) > 0 {
 		h, err = host.FindOne(host.ById(t.HostId))
 		if err != nil {
-			as.LoggedError(w, r, http.StatusInternalServerError, err)
-			return
+			return nil, err
+		}
+		if h != nil {
+			h.SetRunningTask(t.Id, h.AgentRevision, h.TaskDispatchTime)
 		}
-		h.SetRunningTask(t.Id, h.AgentRevision, h.TaskDispatchTime)
 	}
 
 	if h == nil {
-		message := fmt.Errorf("No host found running task %v", t.Id)
-		as.LoggedError(w, r, http.StatusInternalServerError, message)
+		return nil, fmt.Errorf("No host found running task %v", t.Id)
+	}
+	return h, nil
+}
+
+// drainResponse describes whether a distro is draining and how many of its hosts
+// are still up.
+type drainResponse struct {
+	Distro   string `json:"distro"`
+	Draining bool   `json:"draining"`
+	NumHosts int    `json:"num_hosts"`
+}
+
+// drainDistro starts draining a distro: its hosts finish the tasks they have but
+// aren't given new ones, and the monitor terminates them as they go idle.
+func (as *APIServer) drainDistro(w http.ResponseWriter, r *http.Request) {
+	as.setDistroDraining(w, r, true)
+}
+
+// undrainDistro stops draining a distro, restoring its normal scheduling.
+func (as *APIServer) undrainDistro(w http.ResponseWriter, r *http.Request) {
+	as.setDistroDraining(w, r, false)
+}
+
+func (as *APIServer) setDistroDraining(w http.ResponseWriter, r *http.Request, draining bool) {
+	u := MustHaveUser(r)
+	distroId := mux.Vars(r)["distro_id"]
+
+	d, err := distro.FindOne(distro.ById(distroId))
+	if err == mgo.ErrNotFound {
+		http.Error(w, fmt.Sprintf("distro '%v' not found", distroId), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
 		return
 	}
 
-	// agent can't properly unmarshal provider settings map
-	h.Distro.ProviderSettings = nil
-	as.WriteJSON(w, http.StatusOK, h.Distro)
+	if d.Draining != draining {
+		if err = distro.SetDraining(d.Id, draining); err != nil {
+			as.LoggedError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		if draining {
+			grip.Infof("User %s started draining distro %s", u.Id, d.Id)
+			event.LogDistroDrainStarted(d.Id, u.Id)
+		} else {
+			grip.Infof("User %s stopped draining distro %s", u.Id, d.Id)
+			event.LogDistroDrainStopped(d.Id, u.Id)
+		}
+	}
+
+	hosts, err := host.Find(host.ByDistroId(d.Id))
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	as.WriteJSON(w, http.StatusOK, drainResponse{Distro: d.Id, Draining: draining, NumHosts: len(hosts)})
 }
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/evergreen-ci/evergreen/model/distro"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMakePublicDistro(t *testing.T) {
+	Convey("With a distro with secrets in its configuration", t, func() {
+		d := &distro.Distro{
+			Id:           "ubuntu",
+			Arch:         "linux_amd64",
+			WorkDir:      "/data/mci",
+			Provider:     "ec2",
+			SpawnAllowed: true,
+			ProviderSettings: &map[string]interface{}{
+				"instance_type":  "m4.large",
+				"aws_secret_key": "secret",
+			},
+			Setup:      "echo secret",
+			SSHKey:     "mci",
+			Expansions: []distro.Expansion{{Key: "token", Value: "secret"}},
+		}
+
+		Convey("only its public configuration should be kept", func() {
+			So(makePublicDistro(d), ShouldResemble, publicDistro{
+				Id:           "ubuntu",
+				Arch:         "linux_amd64",
+				WorkDir:      "/data/mci",
+				Provider:     "ec2",
+				InstanceType: "m4.large",
+				SpawnAllowed: true,
+			})
+		})
+
+		Convey("distros without an instance type should leave it empty", func() {
+			d.ProviderSettings = nil
+			So(makePublicDistro(d).InstanceType, ShouldEqual, "")
+		})
+	})
+}
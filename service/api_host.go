@@ -0,0 +1,39 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/mongodb/grip"
+)
+
+// rotateHostSecret generates a new secret for the host, persists it, and returns it to
+// the requester. The request must either come from a superuser or carry the host's
+// current secret. The old secret stops being accepted as soon as the new one is saved.
+func (as *APIServer) rotateHostSecret(w http.ResponseWriter, r *http.Request) {
+	h, err := getHostFromRequest(r)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	secret := r.Header.Get(evergreen.HostSecretHeader)
+	if !as.isSuperUser(GetUser(r)) && (secret == "" || secret != h.Secret) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err = h.RotateSecret(); err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError,
+			fmt.Errorf("Error rotating secret for host %v: %v", h.Id, err))
+		return
+	}
+	grip.Infof("Rotated secret for host %s", h.Id)
+
+	out := struct {
+		HostId string `json:"host_id"`
+		Secret string `json:"secret"`
+	}{h.Id, h.Secret}
+	as.WriteJSON(w, http.StatusOK, out)
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/mongodb/grip"
+)
+
+// errListenerClosed is returned by a limitListener that was closed while
+// waiting for a connection slot to free up.
+var errListenerClosed = errors.New("listener closed")
+
+// LimitListener returns a Listener that accepts at most n simultaneous connections
+// from the provided Listener. Once the limit is reached, new connections are queued
+// in the kernel's accept backlog until an existing connection is closed. If n is not
+// positive, the listener is returned unchanged.
+func LimitListener(l net.Listener, n int) net.Listener {
+	if n <= 0 {
+		return l
+	}
+	return &limitListener{
+		Listener: l,
+		limit:    n,
+		sem:      make(chan struct{}, n),
+		done:     make(chan struct{}),
+	}
+}
+
+type limitListener struct {
+	net.Listener
+	limit     int
+	sem       chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// acquire waits for a free connection slot, logging when the listener has to wait.
+// Returns false if the listener was closed before a slot became available.
+func (l *limitListener) acquire() bool {
+	select {
+	case l.sem <- struct{}{}:
+		return true
+	default:
+	}
+
+	grip.Warningf("Reached limit of %d concurrent connections on %s; waiting for a connection to close",
+		l.limit, l.Addr())
+	select {
+	case l.sem <- struct{}{}:
+		return true
+	case <-l.done:
+		return false
+	}
+}
+
+func (l *limitListener) release() { <-l.sem }
+
+func (l *limitListener) Accept() (net.Conn, error) {
+	if !l.acquire() {
+		return nil, errListenerClosed
+	}
+	c, err := l.Listener.Accept()
+	if err != nil {
+		l.release()
+		return nil, err
+	}
+	return &limitListenerConn{Conn: c, release: l.release}, nil
+}
+
+func (l *limitListener) Close() error {
+	err := l.Listener.Close()
+	l.closeOnce.Do(func() { close(l.done) })
+	return err
+}
+
+// limitListenerConn frees its connection slot the first time it is closed.
+type limitListenerConn struct {
+	net.Conn
+	releaseOnce sync.Once
+	release     func()
+}
+
+func (c *limitListenerConn) Close() error {
+	err := c.Conn.Close()
+	c.releaseOnce.Do(c.release)
+	return err
+}
+
+type connContextKey int
+
+const requestConnKey connContextKey = 0
+
+// ConnContext is an http.Server ConnContext hook that records the connection
+// each request arrived on, so that long-lived requests can be exempted from
+// the limit of a LimitListener.
+func ConnContext(ctx context.Context, c net.Conn) context.Context {
+	return context.WithValue(ctx, requestConnKey, c)
+}
+
+// exemptFromConnectionLimit frees the connection slot held by the request's
+// connection, so that long-lived requests such as event streams don't keep
+// other clients from connecting. It does nothing if the connection wasn't
+// accepted by a LimitListener or the server doesn't use ConnContext.
+func exemptFromConnectionLimit(r *http.Request) {
+	c, _ := r.Context().Value(requestConnKey).(net.Conn)
+	if tlsConn, ok := c.(*tls.Conn); ok {
+		c = tlsConn.NetConn()
+	}
+	if limited, ok := c.(*limitListenerConn); ok {
+		limited.releaseOnce.Do(limited.release)
+	}
+}
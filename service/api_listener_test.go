@@ -0,0 +1,101 @@
+package service
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLimitListener(t *testing.T) {
+	Convey("With a listener limited to one connection", t, func() {
+		inner, err := net.Listen("tcp", "localhost:0")
+		So(err, ShouldBeNil)
+		l := LimitListener(inner, 1)
+		defer l.Close()
+
+		accepted := make(chan net.Conn, 2)
+		go func() {
+			for {
+				c, err := l.Accept()
+				if err != nil {
+					return
+				}
+				accepted <- c
+			}
+		}()
+
+		for i := 0; i < 2; i++ {
+			c, err := net.Dial("tcp", inner.Addr().String())
+			So(err, ShouldBeNil)
+			defer c.Close()
+		}
+
+		Convey("only the first connection should be accepted until it is closed", func() {
+			first := <-accepted
+			select {
+			case <-accepted:
+				So("second connection accepted early", ShouldBeEmpty)
+			case <-time.After(100 * time.Millisecond):
+			}
+
+			So(first.Close(), ShouldBeNil)
+			select {
+			case second := <-accepted:
+				So(second.Close(), ShouldBeNil)
+			case <-time.After(time.Second):
+				So("second connection never accepted", ShouldBeEmpty)
+			}
+		})
+	})
+
+	Convey("A non-positive limit should leave the listener unchanged", t, func() {
+		inner, err := net.Listen("tcp", "localhost:0")
+		So(err, ShouldBeNil)
+		defer inner.Close()
+		So(LimitListener(inner, 0), ShouldEqual, inner)
+	})
+}
+
+func TestExemptFromConnectionLimit(t *testing.T) {
+	Convey("With a server limited to one connection", t, func() {
+		inner, err := net.Listen("tcp", "localhost:0")
+		So(err, ShouldBeNil)
+
+		streaming := make(chan struct{})
+		done := make(chan struct{})
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/stream" {
+				exemptFromConnectionLimit(r)
+				w.WriteHeader(http.StatusOK)
+				w.(http.Flusher).Flush()
+				close(streaming)
+				<-done
+			}
+		}))
+		server.Listener = LimitListener(inner, 1)
+		server.Config.ConnContext = ConnContext
+		server.Start()
+		defer server.Close()
+		defer close(done)
+
+		Convey("an exempted request should not keep other clients from connecting", func() {
+			go func() {
+				resp, err := http.Get(server.URL + "/stream")
+				if err == nil {
+					resp.Body.Close()
+				}
+			}()
+			<-streaming
+
+			client := &http.Client{Timeout: time.Second}
+			resp, err := client.Get(server.URL + "/other")
+			So(err, ShouldBeNil)
+			resp.Body.Close()
+			So(resp.StatusCode, ShouldEqual, http.StatusOK)
+		})
+	})
+}
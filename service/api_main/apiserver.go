@@ -1,6 +1,7 @@
 package main
 
 import (
+	"crypto/tls"
 	"flag"
 	"fmt"
 	"net"
@@ -64,16 +65,23 @@ func main() {
 	if err != nil {
 		grip.EmergencyFatalf("Failed to make TLS config: %+v", err)
 	}
+	if !settings.Api.DisableHTTP2 {
+		util.EnableHTTP2(tlsConfig)
+	}
 
 	nonSSL, err := service.GetListener(settings.Api.HttpListenAddr)
 	if err != nil {
 		grip.EmergencyFatalf("Failed to get HTTP listener: %+v", err)
 	}
+	nonSSL = service.LimitListener(nonSSL, settings.Api.MaxConnections)
 
-	ssl, err := service.GetTLSListener(settings.Api.HttpsListenAddr, tlsConfig)
+	// the connection limit has to apply to the raw connections, so that the TLS
+	// listener still hands the server TLS connections to negotiate HTTP/2 on
+	ssl, err := service.GetListener(settings.Api.HttpsListenAddr)
 	if err != nil {
 		grip.EmergencyFatalf("Failed to get HTTPS listener: %+v", err)
 	}
+	ssl = tls.NewListener(service.LimitListener(ssl, settings.Api.MaxConnections), tlsConfig)
 
 	// Start SSL and non-SSL servers in independent goroutines, but exit
 	// the process if either one fails
@@ -87,7 +95,7 @@ func main() {
 		grip.EmergencyFatalf("Failed to get API route handlers: %+v", err)
 	}
 
-	server := &http.Server{Handler: handler}
+	server := &http.Server{Handler: handler, ConnContext: service.ConnContext}
 
 	errChan := make(chan error, 2)
 
@@ -128,5 +136,8 @@ func main() {
 		}
 	}
 
+	// send the notifications queued by requests before exiting
+	as.Close()
+
 	os.Exit(exitCode)
 }
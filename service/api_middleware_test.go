@@ -4,6 +4,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -206,3 +207,93 @@ func TestCheckHostWrapper(t *testing.T) {
 		})
 	})
 }
+
+func TestWithRequestLimits(t *testing.T) {
+	Convey("With a handler wrapped in request limits", t, func() {
+		release := make(chan struct{})
+		defer close(release)
+		handler := limitRequest(4, 10*time.Millisecond, func(w http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			switch string(body) {
+			case "slow":
+				<-release
+			case "oops":
+				MustHaveTask(r)
+			}
+			w.WriteHeader(http.StatusCreated)
+		})
+
+		Convey("a small, fast request should be passed through", func() {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest("POST", "/", strings.NewReader("ok"))
+			So(err, ShouldBeNil)
+			handler(w, r)
+			So(w.Code, ShouldEqual, http.StatusCreated)
+		})
+		Convey("a request with an oversized body should fail", func() {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest("POST", "/", strings.NewReader("too long"))
+			So(err, ShouldBeNil)
+			handler(w, r)
+			So(w.Code, ShouldEqual, http.StatusRequestEntityTooLarge)
+		})
+		Convey("a request with an oversized body of unknown length should fail", func() {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest("POST", "/", ioutil.NopCloser(strings.NewReader("too long")))
+			So(err, ShouldBeNil)
+			So(r.ContentLength, ShouldEqual, 0)
+			handler(w, r)
+			So(w.Code, ShouldEqual, http.StatusRequestEntityTooLarge)
+		})
+		Convey("a request that runs past the timeout should fail", func() {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest("POST", "/", strings.NewReader("slow"))
+			So(err, ShouldBeNil)
+			handler(w, r)
+			So(w.Code, ShouldEqual, http.StatusServiceUnavailable)
+		})
+		Convey("a handler that panics should fail the request", func() {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest("POST", "/", strings.NewReader("oops"))
+			So(err, ShouldBeNil)
+			handler(w, r)
+			So(w.Code, ShouldEqual, http.StatusInternalServerError)
+		})
+	})
+}
+
+func TestLoggableHeaders(t *testing.T) {
+	Convey("With a request carrying credentials", t, func() {
+		header := http.Header{}
+		header.Set("User-Agent", "evergreen-agent")
+		header.Set(evergreen.TaskSecretHeader, "swordfish")
+		header.Set(evergreen.HostSecretHeader, "marlin")
+		header.Set(evergreen.TaskTokenHeader, "halibut")
+		header.Set("Authorization", "Bearer token")
+		l := NewLogger([]string{"user-agent", "x-request-id", evergreen.TaskSecretHeader,
+			evergreen.HostSecretHeader, evergreen.TaskTokenHeader, "authorization"})
+
+		Convey("only the configured headers that are set should be logged", func() {
+			logged := loggableHeaders(header, l.headers)
+			So(logged, ShouldStartWith, "User-Agent=evergreen-agent ")
+			So(logged, ShouldNotContainSubstring, "X-Request-Id")
+		})
+		Convey("the values of sensitive headers should be redacted", func() {
+			logged := loggableHeaders(header, l.headers)
+			So(logged, ShouldContainSubstring, evergreen.TaskSecretHeader+"="+redactedHeaderValue)
+			So(logged, ShouldContainSubstring, evergreen.TaskTokenHeader+"="+redactedHeaderValue)
+			So(logged, ShouldContainSubstring, "Authorization="+redactedHeaderValue)
+			So(logged, ShouldNotContainSubstring, "swordfish")
+			So(logged, ShouldNotContainSubstring, "marlin")
+			So(logged, ShouldNotContainSubstring, "halibut")
+			So(logged, ShouldNotContainSubstring, "Bearer")
+		})
+		Convey("nothing should be logged without configured headers", func() {
+			So(loggableHeaders(header, NewLogger(nil).headers), ShouldEqual, "")
+		})
+	})
+}
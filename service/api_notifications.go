@@ -0,0 +1,40 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/notify"
+	"github.com/mongodb/grip"
+)
+
+// testNotificationResp reports whether a test notification reached the admins
+// through every channel, and the outcome for each.
+type testNotificationResp struct {
+	Success  bool                   `json:"success"`
+	Channels []notify.ChannelResult `json:"channels"`
+}
+
+// testNotification sends a test notification to the admins through each
+// configured channel, so that operators can check the notification settings after
+// changing them rather than finding out when a real failure goes unnoticed.
+func (as *APIServer) testNotification(w http.ResponseWriter, r *http.Request) {
+	u := MustHaveUser(r)
+
+	subject := "Evergreen test notification"
+	message := fmt.Sprintf("This is a test notification sent by %v at %v.",
+		u.Id, time.Now().Format(time.RFC1123))
+	resp := testNotificationResp{
+		Success:  true,
+		Channels: notify.SendTestNotification(&as.Settings, subject, message),
+	}
+	for _, result := range resp.Channels {
+		if !result.Success {
+			resp.Success = false
+			grip.Warningf("Test notification through %s requested by %s failed: %s",
+				result.Channel, u.Id, result.Error)
+		}
+	}
+	as.WriteJSON(w, http.StatusOK, resp)
+}
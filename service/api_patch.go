@@ -397,7 +397,9 @@ func (as *APIServer) updatePatchModule(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
-// listPatches returns a user's "n" most recent patches.
+// listPatches returns a page of a user's patches, most recent first. For older
+// clients, "n" sets the page size if "page_size" isn't given. Clients that don't page
+// get a list of the user's "n" most recent patches, or all of them if "n" isn't set.
 func (as *APIServer) listPatches(w http.ResponseWriter, r *http.Request) {
 	dbUser := MustHaveUser(r)
 	n, err := util.GetIntValue(r, "n", 0)
@@ -405,17 +407,46 @@ func (as *APIServer) listPatches(w http.ResponseWriter, r *http.Request) {
 		as.LoggedError(w, r, http.StatusBadRequest, fmt.Errorf("cannot read value n: %v", err))
 		return
 	}
-	query := patch.ByUser(dbUser.Id).Sort([]string{"-" + patch.CreateTimeKey})
+	if !isPagedRequest(r) {
+		query := patch.ByUser(dbUser.Id).Sort([]string{"-" + patch.CreateTimeKey})
+		if n > 0 {
+			query = query.Limit(n)
+		}
+		patches, err := patch.Find(query)
+		if err != nil {
+			as.LoggedError(w, r, http.StatusInternalServerError,
+				fmt.Errorf("error finding patches for user %v: %v", dbUser.Id, err))
+			return
+		}
+		as.WriteVersionedJSON(w, r, http.StatusOK, patches)
+		return
+	}
+
+	pageSize := defaultPageSize
 	if n > 0 {
-		query = query.Limit(n)
+		pageSize = n
+	}
+	page, err := getPageParams(r, pageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	total, err := patch.Count(patch.ByUser(dbUser.Id))
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError,
+			fmt.Errorf("error counting patches for user %v: %v", dbUser.Id, err))
+		return
 	}
+	query := patch.ByUser(dbUser.Id).Sort([]string{"-" + patch.CreateTimeKey}).
+		Skip(page.skip()).Limit(page.PageSize)
 	patches, err := patch.Find(query)
 	if err != nil {
 		as.LoggedError(w, r, http.StatusInternalServerError,
 			fmt.Errorf("error finding patches for user %v: %v", dbUser.Id, err))
 		return
 	}
-	as.WriteJSON(w, http.StatusOK, patches)
+	as.writePage(w, r, patches, total, page)
 }
 
 func (as *APIServer) existingPatchRequest(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/codegangsta/negroni"
+	"github.com/evergreen-ci/evergreen/plugin"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/gorilla/mux"
+	"github.com/mongodb/grip"
+)
+
+// pluginStatus records the outcome of installing an API plugin when the
+// API server's handler was built.
+type pluginStatus struct {
+	Name          string `json:"name"`
+	Configured    bool   `json:"configured"`
+	HasHandler    bool   `json:"has_api_handler"`
+	HasMiddleware bool   `json:"has_middleware"`
+	Error         string `json:"error,omitempty"`
+}
+
+// installPlugins configures each of the API server's plugins and mounts their
+// API handlers, wrapped in any middleware the plugin provides and subject to the
+// plugin's configured request limits, under the given task router. Plugins that
+// fail to configure are logged and left uninstalled, so that one misconfigured
+// plugin doesn't keep the API server from starting. The result of each
+// installation is recorded so it can be reported by the plugin status endpoint.
+// Returns an error if two plugins share a name, or if a plugin's namespace would
+// shadow one of the core task routes.
+func (as *APIServer) installPlugins(taskRoute *mux.Route, taskRouter *mux.Router) error {
+	as.pluginStatuses = []pluginStatus{}
+	installed := map[string]plugin.APIPlugin{}
+	for _, pl := range as.plugins {
+		if pl == nil {
+			continue
+		}
+		if other, ok := installed[pl.Name()]; ok {
+			return fmt.Errorf("Plugin %T cannot be installed: plugin %T is already installed with name '%s'",
+				pl, other, pl.Name())
+		}
+		if err := checkPluginRouteConflict(taskRoute, taskRouter, pl.Name()); err != nil {
+			return err
+		}
+		installed[pl.Name()] = pl
+		status := pluginStatus{Name: pl.Name()}
+
+		pluginSettings := as.Settings.Plugins[pl.Name()]
+		err := pl.Configure(pluginSettings)
+		if err != nil {
+			grip.Errorf("Failed to configure plugin %s, not installing its API handlers: %+v", pl.Name(), err)
+			status.Error = err.Error()
+			as.pluginStatuses = append(as.pluginStatuses, status)
+			continue
+		}
+		status.Configured = true
+
+		handler := pl.GetAPIHandler()
+		if handler == nil {
+			grip.Warningf("no API handlers to install for %s plugin", pl.Name())
+			as.pluginStatuses = append(as.pluginStatuses, status)
+			continue
+		}
+		if mp, ok := pl.(plugin.APIMiddlewarePlugin); ok {
+			if middleware := mp.GetAPIMiddleware(); len(middleware) > 0 {
+				grip.Debugf("Installing %d middleware for %s plugin", len(middleware), pl.Name())
+				n := negroni.New(middleware...)
+				n.UseHandler(handler)
+				handler = n
+				status.HasMiddleware = true
+			}
+		}
+		grip.Debugf("Installing API handlers for %s plugin", pl.Name())
+		limits := as.Settings.Api.PluginLimits(pl.Name())
+		util.MountHandler(taskRouter, fmt.Sprintf("/%s/", pl.Name()),
+			withRequestLimits(limits, as.checkTask(false, handler.ServeHTTP)))
+		status.HasHandler = true
+		as.pluginStatuses = append(as.pluginStatuses, status)
+	}
+	return nil
+}
+
+// checkPluginRouteConflict returns an error if the namespace for the plugin with
+// the given name is already claimed by one of the core routes on the task router.
+func checkPluginRouteConflict(taskRoute *mux.Route, taskRouter *mux.Router, name string) error {
+	root, err := taskRoute.URL("taskId", "plugin_conflict_check")
+	if err != nil {
+		return fmt.Errorf("Error checking routes for plugin %s: %v", name, err)
+	}
+	for _, method := range []string{"GET", "POST", "PUT", "DELETE"} {
+		for _, path := range []string{"/" + name, "/" + name + "/"} {
+			req, err := http.NewRequest(method, root.String()+path, nil)
+			if err != nil {
+				return fmt.Errorf("Error checking routes for plugin %s: %v", name, err)
+			}
+			if taskRouter.Match(req, &mux.RouteMatch{}) {
+				return fmt.Errorf("Plugin %s conflicts with the core task route %s %s",
+					name, method, req.URL.Path)
+			}
+		}
+	}
+	return nil
+}
+
+// listPluginStatusWithAuth returns the name of each API plugin along with whether
+// it was configured successfully, the error it failed to configure with, and
+// whether it installed any API handlers or middleware.
+func (as *APIServer) listPluginStatusWithAuth(w http.ResponseWriter, r *http.Request) {
+	as.WriteJSON(w, http.StatusOK, as.pluginStatuses)
+}
+
+// listPluginStatusSimple is like listPluginStatusWithAuth, but leaves out the
+// configuration errors, which may reveal plugin settings, for requesters who
+// aren't logged in.
+func (as *APIServer) listPluginStatusSimple(w http.ResponseWriter, r *http.Request) {
+	statuses := make([]pluginStatus, 0, len(as.pluginStatuses))
+	for _, status := range as.pluginStatuses {
+		status.Error = ""
+		statuses = append(statuses, status)
+	}
+	as.WriteJSON(w, http.StatusOK, statuses)
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codegangsta/negroni"
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/plugin"
+	serviceutil "github.com/evergreen-ci/evergreen/service/testutil"
+	"github.com/evergreen-ci/evergreen/testutil"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// mockAPIPlugin is a minimal plugin.APIPlugin used to exercise plugin installation.
+type mockAPIPlugin struct {
+	name         string
+	configureErr error
+	handler      http.Handler
+}
+
+func (mp *mockAPIPlugin) Name() string                           { return mp.name }
+func (mp *mockAPIPlugin) Configure(map[string]interface{}) error { return mp.configureErr }
+func (mp *mockAPIPlugin) GetAPIHandler() http.Handler            { return mp.handler }
+
+func newPluginTestServer(t *testing.T, plugins []plugin.APIPlugin) *APIServer {
+	if err := os.MkdirAll(filepath.Join(evergreen.FindEvergreenHome(), evergreen.ClientDirectory), 0644); err != nil {
+		t.Fatal("could not create client directory required to start the API server:", err.Error())
+	}
+	as, err := NewAPIServer(testutil.TestConfig(), plugins)
+	if err != nil {
+		t.Fatalf("creating test API server: %v", err)
+	}
+	return as
+}
+
+func TestPluginStatus(t *testing.T) {
+	Convey("With an API server with plugins with and without handlers", t, func() {
+		as := newPluginTestServer(t, []plugin.APIPlugin{
+			&mockAPIPlugin{name: "with_handler", handler: http.NotFoundHandler()},
+			&mockAPIPlugin{name: "without_handler"},
+		})
+		handler, err := as.Handler()
+		So(err, ShouldBeNil)
+
+		Convey("the status endpoint should report each plugin", func() {
+			request, err := http.NewRequest("GET", "/api/status/plugins", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, request)
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			statuses := []pluginStatus{}
+			So(json.NewDecoder(w.Body).Decode(&statuses), ShouldBeNil)
+			So(len(statuses), ShouldEqual, 2)
+			So(statuses[0], ShouldResemble, pluginStatus{Name: "with_handler", Configured: true, HasHandler: true})
+			So(statuses[1], ShouldResemble, pluginStatus{Name: "without_handler", Configured: true})
+		})
+	})
+
+	Convey("With an API server with a plugin that fails to configure", t, func() {
+		as := newPluginTestServer(t, []plugin.APIPlugin{
+			&mockAPIPlugin{name: "broken", configureErr: fmt.Errorf("bad config")},
+		})
+
+		// logged in users are looked up in the database
+		db.SetGlobalSessionProvider(db.SessionFactoryFromConfig(testutil.TestConfig()))
+		as.UserManager = serviceutil.MockUserManager{}
+		handler, err := as.Handler()
+
+		Convey("building the handler should still succeed and record the error", func() {
+			So(err, ShouldBeNil)
+			So(len(as.pluginStatuses), ShouldEqual, 1)
+			So(as.pluginStatuses[0].Configured, ShouldBeFalse)
+			So(as.pluginStatuses[0].HasHandler, ShouldBeFalse)
+			So(as.pluginStatuses[0].Error, ShouldEqual, "bad config")
+		})
+
+		getStatuses := func(loggedIn bool) []pluginStatus {
+			request, err := http.NewRequest("GET", "/api/status/plugins", nil)
+			So(err, ShouldBeNil)
+			if loggedIn {
+				request.AddCookie(&http.Cookie{Name: evergreen.AuthTokenCookie, Value: "token"})
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, request)
+			So(w.Code, ShouldEqual, http.StatusOK)
+			statuses := []pluginStatus{}
+			So(json.NewDecoder(w.Body).Decode(&statuses), ShouldBeNil)
+			return statuses
+		}
+
+		Convey("the status endpoint should only report the error to logged in users", func() {
+			So(getStatuses(true), ShouldResemble, []pluginStatus{{Name: "broken", Error: "bad config"}})
+			So(getStatuses(false), ShouldResemble, []pluginStatus{{Name: "broken"}})
+		})
+	})
+}
+
+// mockMiddlewarePlugin is a mockAPIPlugin that also supplies middleware.
+type mockMiddlewarePlugin struct {
+	mockAPIPlugin
+	middleware []negroni.Handler
+}
+
+func (mp *mockMiddlewarePlugin) GetAPIMiddleware() []negroni.Handler { return mp.middleware }
+
+func TestPluginMiddleware(t *testing.T) {
+	db.SetGlobalSessionProvider(db.SessionFactoryFromConfig(testutil.TestConfig()))
+
+	Convey("With an API server with plugins that supply middleware", t, func() {
+		testutil.HandleTestingErr(db.Clear(task.Collection), t, "error clearing tasks")
+		So((&task.Task{Id: "t1"}).Insert(), ShouldBeNil)
+
+		guarded := &mockMiddlewarePlugin{
+			mockAPIPlugin: mockAPIPlugin{name: "guarded", handler: http.NotFoundHandler()},
+			middleware: []negroni.Handler{
+				negroni.HandlerFunc(func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+					http.Error(w, "blocked by plugin", http.StatusForbidden)
+				}),
+			},
+		}
+		tagged := &mockMiddlewarePlugin{
+			mockAPIPlugin: mockAPIPlugin{name: "tagged", handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})},
+			middleware: []negroni.Handler{
+				negroni.HandlerFunc(func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+					w.Header().Add("X-Plugin-Middleware", "first")
+					next(w, r)
+				}),
+				negroni.HandlerFunc(func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+					w.Header().Add("X-Plugin-Middleware", "second")
+					next(w, r)
+				}),
+			},
+		}
+		as := newPluginTestServer(t, []plugin.APIPlugin{guarded, tagged})
+		handler, err := as.Handler()
+		So(err, ShouldBeNil)
+
+		callPlugin := func(name string) *httptest.ResponseRecorder {
+			request, err := http.NewRequest("GET", "/api/2/task/t1/"+name+"/anything", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, request)
+			return w
+		}
+
+		Convey("the plugin status should report the middleware", func() {
+			So(len(as.pluginStatuses), ShouldEqual, 2)
+			So(as.pluginStatuses[0].HasMiddleware, ShouldBeTrue)
+			So(as.pluginStatuses[0].HasHandler, ShouldBeTrue)
+		})
+
+		Convey("middleware that blocks a request should keep it from the plugin's handler", func() {
+			w := callPlugin("guarded")
+			So(w.Code, ShouldEqual, http.StatusForbidden)
+			So(w.Body.String(), ShouldContainSubstring, "blocked by plugin")
+		})
+
+		Convey("middleware that passes a request on should run in order before the plugin's handler", func() {
+			w := callPlugin("tagged")
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Header()["X-Plugin-Middleware"], ShouldResemble, []string{"first", "second"})
+		})
+
+		Convey("middleware should only run for requests for existing tasks", func() {
+			request, err := http.NewRequest("GET", "/api/2/task/nonexistent/guarded/anything", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, request)
+			So(w.Code, ShouldEqual, http.StatusNotFound)
+		})
+	})
+}
+
+func TestPluginRouteConflicts(t *testing.T) {
+	Convey("With an API server with two plugins sharing a name", t, func() {
+		as := newPluginTestServer(t, []plugin.APIPlugin{
+			&mockAPIPlugin{name: "dup", handler: http.NotFoundHandler()},
+			&mockAPIPlugin{name: "dup", handler: http.NotFoundHandler()},
+		})
+
+		Convey("building the handler should fail naming the plugin", func() {
+			_, err := as.Handler()
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "'dup'")
+		})
+	})
+
+	Convey("With an API server with a plugin named after a core task route", t, func() {
+		as := newPluginTestServer(t, []plugin.APIPlugin{
+			&mockAPIPlugin{name: "heartbeat", handler: http.NotFoundHandler()},
+		})
+
+		Convey("building the handler should fail naming the route", func() {
+			_, err := as.Handler()
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "heartbeat")
+			So(err.Error(), ShouldContainSubstring, "core task route")
+		})
+	})
+}
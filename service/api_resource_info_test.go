@@ -6,11 +6,13 @@ import (
 	"net/http"
 	"testing"
 
+	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/db"
 	"github.com/evergreen-ci/evergreen/model/event"
 	"github.com/evergreen-ci/evergreen/model/task"
 	"github.com/evergreen-ci/evergreen/plugin"
 	"github.com/evergreen-ci/evergreen/testutil"
+	"github.com/evergreen-ci/evergreen/util"
 	"github.com/mongodb/grip"
 	"github.com/mongodb/grip/message"
 	. "github.com/smartystreets/goconvey/convey"
@@ -60,6 +62,52 @@ func TestResourceInfoEndPoints(t *testing.T) {
 			So(ok, ShouldBeTrue)
 			grip.Info(taskData.SystemInfo)
 		})
+
+		Convey("a batch of samples should all be persisted", func() {
+			batch := []*message.SystemInfo{data, data, data}
+			payload, err := json.Marshal(batch)
+			So(err, ShouldBeNil)
+
+			request, err := http.NewRequest("POST", url+taskId+"/system_info", bytes.NewBuffer(payload))
+			So(err, ShouldBeNil)
+			resp, err := http.DefaultClient.Do(request)
+			testutil.HandleTestingErr(err, t, "problem making request")
+			So(resp.StatusCode, ShouldEqual, 200)
+
+			events, err := event.Find(event.TaskLogCollection, event.TaskSystemInfoEvents(taskId, 0))
+			testutil.HandleTestingErr(err, t, "problem finding task event")
+			So(len(events), ShouldEqual, 1+len(batch))
+		})
+
+		Convey("null samples in a batch should be ignored", func() {
+			payload, err := json.Marshal([]*message.SystemInfo{data, nil})
+			So(err, ShouldBeNil)
+
+			request, err := http.NewRequest("POST", url+taskId+"/system_info", bytes.NewBuffer(payload))
+			So(err, ShouldBeNil)
+			resp, err := http.DefaultClient.Do(request)
+			testutil.HandleTestingErr(err, t, "problem making request")
+			So(resp.StatusCode, ShouldEqual, 200)
+
+			events, err := event.Find(event.TaskLogCollection, event.TaskSystemInfoEvents(taskId, 0))
+			testutil.HandleTestingErr(err, t, "problem finding task event")
+			So(len(events), ShouldEqual, 5)
+		})
+
+		Convey("batches that are too large should be rejected", func() {
+			batch := make([]*message.SystemInfo, maxSystemInfoBatchSize+1)
+			for i := range batch {
+				batch[i] = data
+			}
+			payload, err := json.Marshal(batch)
+			So(err, ShouldBeNil)
+
+			request, err := http.NewRequest("POST", url+taskId+"/system_info", bytes.NewBuffer(payload))
+			So(err, ShouldBeNil)
+			resp, err := http.DefaultClient.Do(request)
+			testutil.HandleTestingErr(err, t, "problem making request")
+			So(resp.StatusCode, ShouldEqual, http.StatusBadRequest)
+		})
 	})
 
 	Convey("For the process info endpoint", t, func() {
@@ -85,4 +133,27 @@ func TestResourceInfoEndPoints(t *testing.T) {
 			grip.Info(taskData.Processes)
 		})
 	})
+
+	Convey("For a process info report with too many processes", t, func() {
+		data := []*message.ProcessInfo{}
+		for i := 0; i <= evergreen.DefaultMaxProcessInfoEntries; i++ {
+			data = append(data, &message.ProcessInfo{Pid: int32(i)})
+		}
+		Convey("the process info endpoint should truncate the report", func() {
+			payload, err := json.Marshal(data)
+			So(err, ShouldBeNil)
+
+			request, err := http.NewRequest("POST", url+taskId+"/process_info", bytes.NewBuffer(payload))
+			So(err, ShouldBeNil)
+			resp, err := http.DefaultClient.Do(request)
+			testutil.HandleTestingErr(err, t, "problem making request")
+			So(resp.StatusCode, ShouldEqual, 200)
+
+			out := processInfoResponse{}
+			So(util.ReadJSONInto(resp.Body, &out), ShouldBeNil)
+			So(out.Truncated, ShouldBeTrue)
+			So(out.Received, ShouldEqual, len(data))
+			So(out.Accepted, ShouldEqual, evergreen.DefaultMaxProcessInfoEntries)
+		})
+	})
 }
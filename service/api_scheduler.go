@@ -0,0 +1,178 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/evergreen-ci/evergreen/model/task"
+)
+
+// nextTaskExplanation describes the task that NextTask would assign to a host
+// and why it would be chosen.
+type nextTaskExplanation struct {
+	Distro string `json:"distro"`
+	HostId string `json:"host_id,omitempty"`
+	TaskId string `json:"task_id,omitempty"`
+	Reason string `json:"reason"`
+
+	// position of the chosen task in the distro's task queue, starting at 1
+	QueuePosition int   `json:"queue_position,omitempty"`
+	Priority      int64 `json:"priority"`
+
+	// queued tasks ahead of the chosen one that would be dequeued and skipped
+	Skipped []skippedQueueItem `json:"skipped,omitempty"`
+}
+
+// skippedQueueItem is a queued task, or a task reserved for the host, that would
+// be passed over. Reserved tasks have no queue position.
+type skippedQueueItem struct {
+	TaskId        string `json:"task_id"`
+	QueuePosition int    `json:"queue_position,omitempty"`
+	Reason        string `json:"reason"`
+}
+
+// explainNextTask reports the task that a host of the given distro, or the given
+// host, would receive if it asked for work now. It follows the selection logic
+// of NextTask, including the tasks reserved for the host and the draining of its
+// distro, but never modifies task, host, or queue state.
+func (as *APIServer) explainNextTask(w http.ResponseWriter, r *http.Request) {
+	out := nextTaskExplanation{
+		Distro: r.FormValue("distro"),
+		HostId: r.FormValue("host"),
+	}
+
+	if out.HostId != "" {
+		h, err := host.FindOne(host.ById(out.HostId))
+		if err != nil {
+			as.LoggedError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		if h == nil {
+			as.LoggedError(w, r, http.StatusNotFound, fmt.Errorf("host '%v' not found", out.HostId))
+			return
+		}
+		out.Distro = h.Distro.Id
+
+		// NextTask gives a host that isn't running a task the first task reserved
+		// for it that it can still run, releasing those before it
+		if h.RunningTask == "" && len(h.ReservedTasks) > 0 {
+			reserved, err := task.Find(task.ByIds(h.ReservedTasks))
+			if err != nil {
+				as.LoggedError(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			reservedById := map[string]*task.Task{}
+			for i := range reserved {
+				reservedById[reserved[i].Id] = &reserved[i]
+			}
+			for _, id := range h.ReservedTasks {
+				t, ok := reservedById[id]
+				if !ok {
+					out.Skipped = append(out.Skipped, skippedQueueItem{
+						TaskId: id,
+						Reason: "reserved task does not exist and would be unreserved",
+					})
+					continue
+				}
+				if !isRunnableReservation(t, h) {
+					out.Skipped = append(out.Skipped, skippedQueueItem{
+						TaskId: id,
+						Reason: fmt.Sprintf("reserved task would be released: status (%v) activated (%v)",
+							t.Status, t.Activated),
+					})
+					continue
+				}
+				out.TaskId = t.Id
+				out.Priority = t.Priority
+				out.Reason = "first task reserved for the host that it can still run"
+				as.WriteJSON(w, http.StatusOK, out)
+				return
+			}
+		}
+
+		if h.RunningTask != "" {
+			t, err := task.FindOne(task.ById(h.RunningTask))
+			if err != nil {
+				as.LoggedError(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			if t == nil {
+				as.LoggedError(w, r, http.StatusInternalServerError,
+					fmt.Errorf("running task '%v' for host '%v' not found", h.RunningTask, h.Id))
+				return
+			}
+			if t.Activated {
+				out.TaskId = t.Id
+				out.Priority = t.Priority
+				out.Reason = "host is already assigned this task"
+			} else {
+				out.Reason = fmt.Sprintf("host's running task %v is inactive and would be cleared "+
+					"without assigning a new task", t.Id)
+			}
+			as.WriteJSON(w, http.StatusOK, out)
+			return
+		}
+	}
+
+	if out.Distro == "" {
+		as.LoggedError(w, r, http.StatusBadRequest, fmt.Errorf("a distro or host must be specified"))
+		return
+	}
+
+	draining, err := distroIsDraining(out.Distro)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	if draining {
+		out.Reason = "distro is draining, so its hosts are given no new tasks"
+		as.WriteJSON(w, http.StatusOK, out)
+		return
+	}
+
+	taskQueue, err := model.FindTaskQueueForDistro(out.Distro)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	if taskQueue == nil {
+		out.Reason = "distro has no task queue"
+		as.WriteJSON(w, http.StatusOK, out)
+		return
+	}
+
+	for _, idx := range taskQueue.DispatchOrder() {
+		item := taskQueue.Queue[idx]
+		t, err := task.FindOne(task.ById(item.Id))
+		if err != nil {
+			as.LoggedError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		if t == nil {
+			out.Reason = fmt.Sprintf("queued task %v at position %v does not exist; "+
+				"NextTask would return an error", item.Id, idx+1)
+			as.WriteJSON(w, http.StatusOK, out)
+			return
+		}
+		if !t.IsDispatchable() {
+			out.Skipped = append(out.Skipped, skippedQueueItem{
+				TaskId:        t.Id,
+				QueuePosition: idx + 1,
+				Reason:        fmt.Sprintf("not dispatchable: status (%v) activated (%v)", t.Status, t.Activated),
+			})
+			continue
+		}
+
+		out.TaskId = t.Id
+		out.QueuePosition = idx + 1
+		out.Priority = item.Priority
+		out.Reason = "highest priority dispatchable task in the queue, earliest in queue order among equal priorities"
+		as.WriteJSON(w, http.StatusOK, out)
+		return
+	}
+
+	out.Reason = "no dispatchable tasks in the queue"
+	as.WriteJSON(w, http.StatusOK, out)
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/testutil"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestExplainNextTask(t *testing.T) {
+	testConfig := testutil.TestConfig()
+	db.SetGlobalSessionProvider(db.SessionFactoryFromConfig(testConfig))
+	as := &APIServer{Settings: *testConfig}
+
+	explain := func(params url.Values) nextTaskExplanation {
+		request, err := http.NewRequest("GET", "/api/scheduler/next_task?"+params.Encode(), nil)
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+		as.explainNextTask(w, request)
+		So(w.Code, ShouldEqual, http.StatusOK)
+		out := nextTaskExplanation{}
+		So(json.NewDecoder(w.Body).Decode(&out), ShouldBeNil)
+		return out
+	}
+
+	Convey("With a distro with a queued task", t, func() {
+		testutil.HandleTestingErr(db.ClearCollections(distro.Collection, host.Collection,
+			task.Collection, model.TaskQueuesCollection), t, "error clearing collections")
+
+		d := &distro.Distro{Id: "d1"}
+		So(d.Insert(), ShouldBeNil)
+		queued := &task.Task{Id: "queued", Status: evergreen.TaskUndispatched, Activated: true}
+		So(queued.Insert(), ShouldBeNil)
+		So(model.UpdateTaskQueue(d.Id, []model.TaskQueueItem{{Id: queued.Id}}), ShouldBeNil)
+
+		Convey("the queued task should be explained", func() {
+			out := explain(url.Values{"distro": {d.Id}})
+			So(out.TaskId, ShouldEqual, queued.Id)
+			So(out.QueuePosition, ShouldEqual, 1)
+		})
+
+		Convey("no task should be given while the distro is draining", func() {
+			So(distro.SetDraining(d.Id, true), ShouldBeNil)
+			out := explain(url.Values{"distro": {d.Id}})
+			So(out.TaskId, ShouldEqual, "")
+			So(out.Reason, ShouldContainSubstring, "draining")
+		})
+
+		Convey("a host with tasks reserved for it should be given the first it can run", func() {
+			released := &task.Task{Id: "released", Status: evergreen.TaskUndispatched, Activated: true}
+			So(released.Insert(), ShouldBeNil)
+			reserved := &task.Task{Id: "reserved", Status: evergreen.TaskDispatched, HostId: "h1", Activated: true}
+			So(reserved.Insert(), ShouldBeNil)
+			h := &host.Host{Id: "h1", Distro: *d, ReservedTasks: []string{"missing", released.Id, reserved.Id}}
+			So(h.Insert(), ShouldBeNil)
+
+			out := explain(url.Values{"host": {h.Id}})
+			So(out.TaskId, ShouldEqual, reserved.Id)
+			So(len(out.Skipped), ShouldEqual, 2)
+			So(out.Skipped[0].TaskId, ShouldEqual, "missing")
+			So(out.Skipped[1].TaskId, ShouldEqual, released.Id)
+		})
+	})
+}
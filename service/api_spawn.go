@@ -4,9 +4,13 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/alerts"
+	"github.com/evergreen-ci/evergreen/cloud"
 	"github.com/evergreen-ci/evergreen/cloud/providers"
 	"github.com/evergreen-ci/evergreen/model/distro"
 	"github.com/evergreen-ci/evergreen/model/event"
@@ -16,6 +20,7 @@ import (
 	"github.com/evergreen-ci/evergreen/util"
 	"github.com/gorilla/mux"
 	"github.com/mongodb/grip"
+	"gopkg.in/mgo.v2"
 )
 
 type spawnRequest struct {
@@ -31,6 +36,20 @@ type spawnResponse struct {
 	HostInfo host.Host   `json:"host_info,omitempty"`
 	Distros  []string    `json:"distros,omitempty"`
 
+	// set for a single host: its provider, and whether the provider can spawn more
+	// hosts like it
+	Provider string `json:"provider,omitempty"`
+	CanSpawn *bool  `json:"can_spawn,omitempty"`
+
+	// set when listing hosts: the running cost of each host since it was created,
+	// keyed by host id, and their total. Hosts whose providers can't estimate their
+	// costs are left out.
+	Costs     map[string]float64 `json:"costs,omitempty"`
+	TotalCost *float64           `json:"total_cost,omitempty"`
+
+	// set when an image is created from a host: the provider's id for the image
+	ImageId string `json:"image_id,omitempty"`
+
 	// empty if the request succeeded
 	ErrorMessage string `json:"error_message,omitempty"`
 }
@@ -48,12 +67,108 @@ func (as *APIServer) listDistros(w http.ResponseWriter, r *http.Request) {
 	as.WriteJSON(w, http.StatusOK, spawnResponse{Distros: distroList})
 }
 
+// listSpawnableDistros returns the distros the user may spawn hosts of. Unlike
+// listDistros, it leaves out distros whose provider the settings don't allow
+// spawning with, which requests to spawn them would be rejected for.
+func (as *APIServer) listSpawnableDistros(w http.ResponseWriter, r *http.Request) {
+	distros, err := spawn.SpawnableDistros(&as.Settings)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	distroList := []string{}
+	for _, d := range distros {
+		distroList = append(distroList, d.Id)
+	}
+	as.WriteJSON(w, http.StatusOK, spawnResponse{Distros: distroList})
+}
+
+// canSpawnResponse reports whether hosts of a distro can currently be spawned.
+type canSpawnResponse struct {
+	Distro   string `json:"distro"`
+	CanSpawn bool   `json:"can_spawn"`
+
+	// empty if hosts can be spawned
+	Reason string `json:"reason,omitempty"`
+}
+
+// canSpawn checks whether the requesting user could spawn a host of the given
+// distro right now, so that clients can report problems before a host is requested.
+// If the validate_credentials parameter is set, the distro's provider is also asked
+// to check its credentials, which may be slow.
+func (as *APIServer) canSpawn(w http.ResponseWriter, r *http.Request) {
+	user := MustHaveUser(r)
+	distroId := mux.Vars(r)["distro"]
+
+	d, err := distro.FindOne(distro.ById(distroId))
+	if err == mgo.ErrNotFound {
+		http.Error(w, fmt.Sprintf("distro '%v' not found", distroId), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	out := canSpawnResponse{Distro: d.Id}
+	reason, err := as.spawnBlockedReason(d, user.Id, r.FormValue("validate_credentials") == "true")
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	out.CanSpawn = reason == ""
+	out.Reason = reason
+	as.WriteJSON(w, http.StatusOK, out)
+}
+
+// spawnBlockedReason returns why the user can't spawn a host of the distro, or an
+// empty string if nothing prevents it.
+func (as *APIServer) spawnBlockedReason(d *distro.Distro, userId string, validateCredentials bool) (string, error) {
+	if err := spawn.CheckDistro(d, &as.Settings); err != nil {
+		return err.Error(), nil
+	}
+
+	activeSpawnedHosts, err := host.Find(host.ByUserWithRunningStatus(userId))
+	if err != nil {
+		return "", fmt.Errorf("Error finding current hosts for user %v: %v", userId, err)
+	}
+	if len(activeSpawnedHosts) >= spawn.MaxPerUser {
+		return spawn.SpawnLimitErr.Error(), nil
+	}
+
+	// user hosts may be spawned with a different provider than the distro's
+	providerName := spawn.SpawnProvider(d.Provider)
+	cloudManager, err := providers.GetCloudManager(providerName, &as.Settings)
+	if err != nil {
+		return fmt.Sprintf("Provider %v for distro %v is unavailable: %v", providerName, d.Id, err), nil
+	}
+	canSpawn, err := cloudManager.CanSpawn()
+	if err != nil {
+		return fmt.Sprintf("Error checking whether provider %v can spawn hosts: %v", providerName, err), nil
+	}
+	if !canSpawn {
+		return fmt.Sprintf("Provider %v does not support spawning hosts", providerName), nil
+	}
+
+	if validateCredentials {
+		if cv, ok := cloudManager.(cloud.CredentialsValidator); ok {
+			if err = cv.ValidateCredentials(); err != nil {
+				return fmt.Sprintf("Provider %v credentials are invalid: %v", providerName, err), nil
+			}
+		}
+	}
+	return "", nil
+}
+
 func (as *APIServer) requestHost(w http.ResponseWriter, r *http.Request) {
 	user := MustHaveUser(r)
 	hostRequest := struct {
-		Distro    string `json:"distro"`
-		PublicKey string `json:"public_key"`
-		UserData  string `json:"userdata"`
+		Distro         string `json:"distro"`
+		PublicKey      string `json:"public_key"`
+		UserData       string `json:"userdata"`
+		RootVolumeSize int    `json:"root_volume_size"`
+		SetupScript    string `json:"setup_script"`
+		RequestKey     string `json:"request_key"`
 	}{}
 	err := util.ReadJSONInto(r.Body, &hostRequest)
 	if err != nil {
@@ -71,15 +186,38 @@ func (as *APIServer) requestHost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	opts := spawn.Options{
-		Distro:    hostRequest.Distro,
-		UserName:  user.Id,
-		PublicKey: hostRequest.PublicKey,
-		UserData:  hostRequest.UserData,
+		Distro:         hostRequest.Distro,
+		UserName:       user.Id,
+		PublicKey:      hostRequest.PublicKey,
+		UserData:       hostRequest.UserData,
+		RootVolumeSize: hostRequest.RootVolumeSize,
+		SetupScript:    hostRequest.SetupScript,
+		RequestKey:     hostRequest.RequestKey,
 	}
 
 	spawner := spawn.New(&as.Settings)
+
+	// a retried request gets the host started for the first one
+	claimed, requested, err := spawner.ClaimRequest(opts)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	if !claimed {
+		if requested == nil {
+			http.Error(w, fmt.Sprintf("a host is already being spawned for request %v", opts.RequestKey),
+				http.StatusConflict)
+			return
+		}
+		grip.Infof("Not spawning a second host for request %s of user %s: already started host %s",
+			opts.RequestKey, opts.UserName, requested.Id)
+		as.WriteJSON(w, http.StatusOK, spawnResponse{HostInfo: *requested})
+		return
+	}
+
 	err = spawner.Validate(opts)
 	if err != nil {
+		releaseSpawnRequest(spawner, opts)
 		errCode := http.StatusBadRequest
 		if _, ok := err.(spawn.BadOptionsErr); !ok {
 			errCode = http.StatusInternalServerError
@@ -90,6 +228,7 @@ func (as *APIServer) requestHost(w http.ResponseWriter, r *http.Request) {
 
 	err = spawner.CreateHost(opts, user)
 	if err != nil {
+		releaseSpawnRequest(spawner, opts)
 		grip.Error(err)
 		mailErr := notify.TrySendNotificationToUser(opts.UserName, "Spawning failed",
 			fmt.Sprintf("For distro '%s'.\n\nEncountered with error: %+v", hostRequest.Distro, err.Error()),
@@ -103,6 +242,14 @@ func (as *APIServer) requestHost(w http.ResponseWriter, r *http.Request) {
 	as.WriteJSON(w, http.StatusOK, "")
 }
 
+// releaseSpawnRequest releases the request key of a spawn request that failed
+// before its host was spawned, so that the client can retry it.
+func releaseSpawnRequest(spawner spawn.Spawn, opts spawn.Options) {
+	if err := spawner.ReleaseRequest(opts); err != nil {
+		grip.Errorf("Error releasing request %s of user %s: %+v", opts.RequestKey, opts.UserName, err)
+	}
+}
+
 func (as *APIServer) spawnHostReady(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	instanceId := vars["instance_id"]
@@ -137,9 +284,7 @@ func (as *APIServer) spawnHostReady(w http.ResponseWriter, r *http.Request) {
 		// send notification to the Evergreen team about this provisioning failure
 		subject := fmt.Sprintf("%v Spawn provisioning failure on %v", notify.ProvisionFailurePreface, host.Distro.Id)
 		message := fmt.Sprintf("Provisioning failed on %v host %v for user %v", host.Distro.Id, host.Host, host.StartedBy)
-		if err = notify.NotifyAdmins(subject, message, &as.Settings); err != nil {
-			grip.Errorln("issue sending email:", err)
-		}
+		as.notifyAdmins(subject, message)
 
 		// get/store setup logs
 		setupLog, err := ioutil.ReadAll(r.Body)
@@ -148,14 +293,15 @@ func (as *APIServer) spawnHostReady(w http.ResponseWriter, r *http.Request) {
 			as.LoggedError(w, r, http.StatusInternalServerError, err)
 			return
 		}
-		event.LogProvisionFailed(instanceId, string(setupLog))
+		event.LogProvisionFailed(instanceId, string(setupLog), 0)
 	}
 
+	dnsName := host.DNSAlias(&as.Settings)
 	message := fmt.Sprintf(`
 		Host with id %v spawned.
 		The host's dns name is %v.
 		To ssh in: ssh -i <your private key> %v@%v`,
-		host.Id, host.Host, host.User, host.Host)
+		host.Id, dnsName, host.User, dnsName)
 
 	if status == evergreen.HostStatusFailed {
 		message += fmt.Sprintf("\nUnfortunately, the host's setup script did not run fully - check the setup.log " +
@@ -183,7 +329,201 @@ func (as *APIServer) hostInfo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	as.WriteJSON(w, http.StatusOK, spawnResponse{HostInfo: *host})
+	canSpawn := as.providerCanSpawn(host.Provider)
+	as.WriteJSON(w, http.StatusOK, spawnResponse{
+		HostInfo: *host,
+		Provider: host.Provider,
+		CanSpawn: &canSpawn,
+	})
+}
+
+// providerCanSpawn returns true if new hosts can be spawned with the provider.
+// Providers that can't be loaded can't spawn.
+func (as *APIServer) providerCanSpawn(providerName string) bool {
+	if err := providers.CheckSpawnAllowed(providerName, &as.Settings); err != nil {
+		return false
+	}
+	cloudManager, err := providers.GetCloudManager(providerName, &as.Settings)
+	if err != nil {
+		grip.Warningf("Error loading provider %v: %v", providerName, err)
+		return false
+	}
+	canSpawn, err := cloudManager.CanSpawn()
+	if err != nil {
+		grip.Warningf("Error checking whether provider %v can spawn hosts: %v", providerName, err)
+		return false
+	}
+	return canSpawn
+}
+
+// reprovisionHost provisions a host that is up again without terminating it, for
+// hosts whose setup failed or was incomplete. The host is marked as uninitialized,
+// so that hostinit calls its provider's OnUp and runs its distro's setup script on
+// it again the next time it runs. Hosts running a task can't be reprovisioned.
+func (as *APIServer) reprovisionHost(w http.ResponseWriter, r *http.Request) {
+	u := MustHaveUser(r)
+	instanceId := mux.Vars(r)["instance_id"]
+
+	h, err := host.FindOne(host.ById(instanceId))
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	if h == nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	if err = h.SetReprovisioning(); err != nil {
+		if err == mgo.ErrNotFound {
+			http.Error(w, fmt.Sprintf("Host %v can't be reprovisioned: it must be %v and not running a task",
+				h.Id, strings.Join(host.ReprovisionableStatuses, " or ")), http.StatusBadRequest)
+			return
+		}
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	event.LogHostReprovisioning(h.Id, u.Id)
+	grip.Infof("User %s asked for host %s to be reprovisioned", u.Id, h.Id)
+	as.WriteJSON(w, http.StatusOK, spawnResponse{HostInfo: *h})
+}
+
+// hostConsoleOutput returns the console output of a host's instance, as the
+// provider reports it, to help diagnose hosts that fail to boot or become reachable.
+func (as *APIServer) hostConsoleOutput(w http.ResponseWriter, r *http.Request) {
+	instanceId := mux.Vars(r)["instance_id"]
+
+	h, err := host.FindOne(host.ById(instanceId))
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	if h == nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	cloudHost, err := providers.GetCloudHost(h, &as.Settings)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	output, err := cloudHost.GetConsoleOutput()
+	if err != nil {
+		if err == cloud.ErrConsoleOutputUnsupported {
+			http.Error(w, fmt.Sprintf("Can't get the console output of host %v: %v", h.Id, err),
+				http.StatusBadRequest)
+			return
+		}
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	as.WriteJSON(w, http.StatusOK, struct {
+		HostId string `json:"host_id"`
+		Output string `json:"output"`
+	}{HostId: h.Id, Output: output})
+}
+
+// parseHostTags parses tags given as "key=value" pairs.
+func parseHostTags(pairs []string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, fmt.Errorf("no tags given")
+	}
+	tags := map[string]string{}
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("tag '%v' must be of the form key=value", pair)
+		}
+		tags[parts[0]] = parts[1]
+	}
+	return tags, nil
+}
+
+// hostEvents returns the events logged for a host in chronological order. The
+// optional "start" and "end" query parameters, as RFC3339 times, limit the events
+// to that range. Only the user who started the host and superusers may see them.
+func (as *APIServer) hostEvents(w http.ResponseWriter, r *http.Request) {
+	instanceId := mux.Vars(r)["instance_id"]
+
+	h, err := host.FindOne(host.ById(instanceId))
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	if h == nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	user := GetUser(r)
+	if user == nil || (user.Id != h.StartedBy && !as.isSuperUser(user)) {
+		message := fmt.Sprintf("Only %v is authorized to view the events of this host", h.StartedBy)
+		http.Error(w, message, http.StatusUnauthorized)
+		return
+	}
+
+	var start, end time.Time
+	if startParam := r.FormValue("start"); startParam != "" {
+		if start, err = time.Parse(time.RFC3339, startParam); err != nil {
+			as.LoggedError(w, r, http.StatusBadRequest, fmt.Errorf("invalid start time: %v", err))
+			return
+		}
+	}
+	if endParam := r.FormValue("end"); endParam != "" {
+		if end, err = time.Parse(time.RFC3339, endParam); err != nil {
+			as.LoggedError(w, r, http.StatusBadRequest, fmt.Errorf("invalid end time: %v", err))
+			return
+		}
+	}
+
+	events, err := event.Find(event.AllLogCollection, event.HostEventsInRange(h.Id, start, end))
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	as.WriteJSON(w, http.StatusOK, events)
+}
+
+// hostProvisionFailure returns the setup log of the host's most recent provisioning
+// failure, along with the steps parsed from it. Only the user who started the host
+// and superusers may see it.
+func (as *APIServer) hostProvisionFailure(w http.ResponseWriter, r *http.Request) {
+	instanceId := mux.Vars(r)["instance_id"]
+
+	h, err := host.FindOne(host.ById(instanceId))
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	if h == nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	user := GetUser(r)
+	if user == nil || (user.Id != h.StartedBy && !as.isSuperUser(user)) {
+		message := fmt.Sprintf("Only %v is authorized to view the provisioning logs of this host", h.StartedBy)
+		http.Error(w, message, http.StatusUnauthorized)
+		return
+	}
+
+	failure, err := event.FindLastProvisionFailure(h.Id)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	if failure == nil {
+		http.Error(w, "host provisioning has not failed", http.StatusNotFound)
+		return
+	}
+
+	out := struct {
+		Logs  string                `json:"logs"`
+		Steps []event.ProvisionStep `json:"steps"`
+	}{failure.Logs, failure.ProvisionSteps}
+	as.WriteJSON(w, http.StatusOK, out)
 }
 
 // returns info on all of the hosts spawned by a user
@@ -197,7 +537,45 @@ func (as *APIServer) hostsInfoForUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	as.WriteJSON(w, http.StatusOK, spawnResponse{Hosts: hosts})
+	resp := spawnResponse{Hosts: hosts}
+	if costs, total := as.spawnHostCosts(hosts, time.Now()); len(costs) > 0 {
+		resp.Costs = costs
+		resp.TotalCost = &total
+	}
+	as.WriteJSON(w, http.StatusOK, resp)
+}
+
+// spawnHostCosts returns what each host has cost from its launch until now, keyed
+// by host id, and the total. Hosts whose providers can't calculate costs are left
+// out, as are hosts whose costs fail to be calculated, since the costs are only
+// informational.
+func (as *APIServer) spawnHostCosts(hosts []host.Host, now time.Time) (map[string]float64, float64) {
+	costs := map[string]float64{}
+	total := 0.0
+	calculators := map[string]cloud.CloudCostCalculator{}
+	for i := range hosts {
+		h := &hosts[i]
+		calc, ok := calculators[h.Provider]
+		if !ok {
+			manager, err := providers.GetCloudManager(h.Provider, &as.Settings)
+			if err != nil {
+				grip.Errorf("Error loading provider for host %s cost calculation: %+v", h.Id, err)
+			}
+			calc, _ = manager.(cloud.CloudCostCalculator)
+			calculators[h.Provider] = calc
+		}
+		if calc == nil {
+			continue
+		}
+		cost, err := calc.CostForDuration(h, h.BillingStart(), now)
+		if err != nil {
+			grip.Errorf("Error calculating cost for host %s: %+v", h.Id, err)
+			continue
+		}
+		costs[h.Id] = cost
+		total += cost
+	}
+	return costs, total
 }
 
 func (as *APIServer) modifyHost(w http.ResponseWriter, r *http.Request) {
@@ -216,8 +594,10 @@ func (as *APIServer) modifyHost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	user := GetUser(r)
-	if user == nil || user.Id != host.StartedBy {
-		message := fmt.Sprintf("Only %v is authorized to terminate this host", host.StartedBy)
+	// superusers may also retag hosts, e.g. when their owner changes
+	canModify := user != nil && (user.Id == host.StartedBy || (hostAction == "tag" && as.isSuperUser(user)))
+	if !canModify {
+		message := fmt.Sprintf("Only %v is authorized to modify this host", host.StartedBy)
 		http.Error(w, message, http.StatusUnauthorized)
 		return
 	}
@@ -240,8 +620,164 @@ func (as *APIServer) modifyHost(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		as.WriteJSON(w, http.StatusOK, spawnResponse{HostInfo: *host})
+	case "reboot":
+		if host.Status == evergreen.HostTerminated {
+			message := fmt.Sprintf("Host %v is terminated", host.Id)
+			http.Error(w, message, http.StatusBadRequest)
+			return
+		}
+
+		cloudHost, err := providers.GetCloudHost(host, &as.Settings)
+		if err != nil {
+			as.LoggedError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		if err = cloudHost.Reboot(); err != nil {
+			if err == cloud.ErrRebootUnsupported {
+				http.Error(w, fmt.Sprintf("Host %v can't be rebooted: %v", host.Id, err), http.StatusBadRequest)
+				return
+			}
+			as.LoggedError(w, r, http.StatusInternalServerError, fmt.Errorf("Failed to reboot spawn host: %v", err))
+			return
+		}
+		event.LogHostRebooted(host.Id, user.Id)
+		as.WriteJSON(w, http.StatusOK, spawnResponse{HostInfo: *host})
+	case "tag":
+		if host.Status == evergreen.HostTerminated {
+			message := fmt.Sprintf("Host %v is terminated", host.Id)
+			http.Error(w, message, http.StatusBadRequest)
+			return
+		}
+
+		tags, err := parseHostTags(r.Form["tag"])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		cloudManager, err := providers.GetCloudManager(host.Provider, &as.Settings)
+		if err != nil {
+			as.LoggedError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		tagger, ok := cloudManager.(cloud.HostTagger)
+		if !ok {
+			http.Error(w, fmt.Sprintf("Host %v's provider can't tag hosts", host.Id), http.StatusBadRequest)
+			return
+		}
+		if err = tagger.ValidateTags(tags); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid tags: %v", err), http.StatusBadRequest)
+			return
+		}
+		if err = tagger.SetTags(host, tags); err != nil {
+			as.LoggedError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		event.LogHostTagsModified(host.Id, user.Id, tags)
+		as.WriteJSON(w, http.StatusOK, spawnResponse{HostInfo: *host})
+	case "image":
+		if host.Status == evergreen.HostTerminated {
+			message := fmt.Sprintf("Host %v is terminated", host.Id)
+			http.Error(w, message, http.StatusBadRequest)
+			return
+		}
+
+		imageName := r.FormValue("image_name")
+		if imageName == "" {
+			http.Error(w, "An image name is required", http.StatusBadRequest)
+			return
+		}
+		cloudHost, err := providers.GetCloudHost(host, &as.Settings)
+		if err != nil {
+			as.LoggedError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		imageId, err := cloudHost.CreateImage(imageName)
+		if err != nil {
+			if err == cloud.ErrCreateImageUnsupported {
+				http.Error(w, fmt.Sprintf("Can't create an image of host %v: %v", host.Id, err),
+					http.StatusBadRequest)
+				return
+			}
+			as.LoggedError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		event.LogHostImageCreated(host.Id, user.Id, imageName, imageId)
+		as.WriteJSON(w, http.StatusOK, spawnResponse{HostInfo: *host, ImageId: imageId})
+	case "no_idle_termination":
+		if host.Status == evergreen.HostTerminated {
+			message := fmt.Sprintf("Host %v is terminated", host.Id)
+			http.Error(w, message, http.StatusBadRequest)
+			return
+		}
+
+		until, err := idleExemptionEnd(r.FormValue("hours"), maxIdleExemption(&as.Settings), time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err = host.SetNoIdleTerminationUntil(until); err != nil {
+			as.LoggedError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		event.LogHostIdleExemptionSet(host.Id, user.Id, until)
+		as.WriteJSON(w, http.StatusOK, spawnResponse{HostInfo: *host})
+	case "static_address":
+		if host.Status == evergreen.HostTerminated {
+			message := fmt.Sprintf("Host %v is terminated", host.Id)
+			http.Error(w, message, http.StatusBadRequest)
+			return
+		}
+
+		cloudManager, err := providers.GetCloudManager(host.Provider, &as.Settings)
+		if err != nil {
+			as.LoggedError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		associator, ok := cloudManager.(cloud.StaticAddressAssociator)
+		if !ok {
+			http.Error(w, fmt.Sprintf("Host %v's provider can't give hosts static addresses", host.Id),
+				http.StatusBadRequest)
+			return
+		}
+		address, err := associator.AssociateStaticAddress(host)
+		if err != nil {
+			as.LoggedError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		event.LogHostStaticAddressSet(host.Id, user.Id, address)
+		as.WriteJSON(w, http.StatusOK, spawnResponse{HostInfo: *host})
 	default:
 		http.Error(w, fmt.Sprintf("Unrecognized action %v", hostAction), http.StatusBadRequest)
 	}
 
 }
+
+// maxIdleExemption returns the longest a spawn host may be exempt from idle
+// termination.
+func maxIdleExemption(settings *evergreen.Settings) time.Duration {
+	if settings.Monitor.MaxIdleExemptionHours > 0 {
+		return time.Duration(settings.Monitor.MaxIdleExemptionHours) * time.Hour
+	}
+	return DefaultMaxIdleExemptionHours * time.Hour
+}
+
+// idleExemptionEnd returns when an exemption from idle termination for the given
+// number of hours, starting now, runs out. Zero hours removes the exemption, so the
+// zero time is returned for it.
+func idleExemptionEnd(hours string, max time.Duration, now time.Time) (time.Time, error) {
+	if hours == "" {
+		return time.Time{}, fmt.Errorf("The number of hours to exempt the host for is required")
+	}
+	n, err := strconv.ParseFloat(hours, 64)
+	if err != nil || n < 0 {
+		return time.Time{}, fmt.Errorf("Invalid number of hours '%v'", hours)
+	}
+	if n == 0 {
+		return time.Time{}, nil
+	}
+	duration := time.Duration(n * float64(time.Hour))
+	if duration > max {
+		return time.Time{}, fmt.Errorf("Hosts can't be exempt from idle termination for more than %v", max)
+	}
+	return now.Add(duration), nil
+}
@@ -0,0 +1,359 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/cloud"
+	"github.com/evergreen-ci/evergreen/cloud/providers/mock"
+	"github.com/evergreen-ci/evergreen/cloud/providers/static"
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/event"
+	"github.com/evergreen-ci/evergreen/model/host"
+	serviceutil "github.com/evergreen-ci/evergreen/service/testutil"
+	"github.com/evergreen-ci/evergreen/testutil"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestProviderCanSpawn(t *testing.T) {
+	Convey("With an API server", t, func() {
+		as := &APIServer{}
+
+		Convey("providers that support spawning should be able to spawn", func() {
+			So(as.providerCanSpawn(mock.ProviderName), ShouldBeTrue)
+		})
+
+		Convey("providers that don't support spawning should not", func() {
+			So(as.providerCanSpawn(static.ProviderName), ShouldBeFalse)
+		})
+
+		Convey("unknown providers should not be able to spawn", func() {
+			So(as.providerCanSpawn("nonexistent"), ShouldBeFalse)
+		})
+
+		Convey("providers the settings don't allow should not be able to spawn", func() {
+			as.Settings.Providers.Allowed = []string{static.ProviderName}
+			So(as.providerCanSpawn(mock.ProviderName), ShouldBeFalse)
+		})
+	})
+}
+
+func TestParseHostTags(t *testing.T) {
+	Convey("When parsing host tags", t, func() {
+		Convey("key=value pairs should be parsed", func() {
+			tags, err := parseHostTags([]string{"owner=jane", "cost-center=a=b", "note="})
+			So(err, ShouldBeNil)
+			So(tags, ShouldResemble, map[string]string{"owner": "jane", "cost-center": "a=b", "note": ""})
+		})
+
+		Convey("malformed pairs or no pairs should fail", func() {
+			_, err := parseHostTags([]string{"owner"})
+			So(err, ShouldNotBeNil)
+			_, err = parseHostTags([]string{"=jane"})
+			So(err, ShouldNotBeNil)
+			_, err = parseHostTags(nil)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestIdleExemptionEnd(t *testing.T) {
+	Convey("When exempting a host from idle termination", t, func() {
+		now := time.Now()
+		max := 24 * time.Hour
+
+		Convey("the exemption should run out the given number of hours from now", func() {
+			until, err := idleExemptionEnd("1.5", max, now)
+			So(err, ShouldBeNil)
+			So(until, ShouldResemble, now.Add(90*time.Minute))
+		})
+
+		Convey("zero hours should remove the exemption", func() {
+			until, err := idleExemptionEnd("0", max, now)
+			So(err, ShouldBeNil)
+			So(until.IsZero(), ShouldBeTrue)
+		})
+
+		Convey("missing, invalid or too many hours should fail", func() {
+			for _, hours := range []string{"", "soon", "-1", "25"} {
+				_, err := idleExemptionEnd(hours, max, now)
+				So(err, ShouldNotBeNil)
+			}
+		})
+	})
+}
+
+func TestSpawnHostCosts(t *testing.T) {
+	Convey("With hosts whose providers can't calculate costs", t, func() {
+		as := &APIServer{}
+		hosts := []host.Host{
+			{Id: "h1", Provider: mock.ProviderName},
+			{Id: "h2", Provider: static.ProviderName},
+		}
+
+		Convey("their costs should be left out", func() {
+			costs, total := as.spawnHostCosts(hosts, time.Now())
+			So(len(costs), ShouldEqual, 0)
+			So(total, ShouldEqual, 0)
+		})
+	})
+}
+
+func TestCanSpawn(t *testing.T) {
+	db.SetGlobalSessionProvider(db.SessionFactoryFromConfig(testutil.TestConfig()))
+
+	Convey("With an API server and a distro that allows spawning", t, func() {
+		testutil.HandleTestingErr(db.ClearCollections(distro.Collection, host.Collection), t,
+			"error clearing collections")
+		So((&distro.Distro{Id: "spawnable", Provider: mock.ProviderName, SpawnAllowed: true}).Insert(), ShouldBeNil)
+
+		as := newPluginTestServer(t, nil)
+		as.UserManager = serviceutil.MockUserManager{}
+		handler, err := as.Handler()
+		So(err, ShouldBeNil)
+
+		canSpawn := func(distroId string) *httptest.ResponseRecorder {
+			request, err := http.NewRequest("GET", "/api/spawns/distros/"+distroId+"/can_spawn", nil)
+			So(err, ShouldBeNil)
+			request.AddCookie(&http.Cookie{Name: evergreen.AuthTokenCookie, Value: "token"})
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, request)
+			return w
+		}
+
+		Convey("the distro should be reported as spawnable", func() {
+			w := canSpawn("spawnable")
+			So(w.Code, ShouldEqual, http.StatusOK)
+			out := canSpawnResponse{}
+			So(json.NewDecoder(w.Body).Decode(&out), ShouldBeNil)
+			So(out, ShouldResemble, canSpawnResponse{Distro: "spawnable", CanSpawn: true})
+		})
+
+		Convey("the distro should be blocked if the settings don't allow its provider", func() {
+			as.Settings.Providers.Allowed = []string{static.ProviderName}
+			w := canSpawn("spawnable")
+			So(w.Code, ShouldEqual, http.StatusOK)
+			out := canSpawnResponse{}
+			So(json.NewDecoder(w.Body).Decode(&out), ShouldBeNil)
+			So(out.CanSpawn, ShouldBeFalse)
+			So(out.Reason, ShouldContainSubstring, "not allowed")
+		})
+
+		Convey("an unknown distro should not be found", func() {
+			So(canSpawn("nonexistent").Code, ShouldEqual, http.StatusNotFound)
+		})
+	})
+}
+
+func TestRebootHost(t *testing.T) {
+	db.SetGlobalSessionProvider(db.SessionFactoryFromConfig(testutil.TestConfig()))
+
+	Convey("With an API server and spawn hosts", t, func() {
+		testutil.HandleTestingErr(db.ClearCollections(host.Collection, event.AllLogCollection), t,
+			"error clearing collections")
+		mock.Clear()
+		mock.MockInstances["mine"] = mock.MockInstance{IsUp: true, Status: cloud.StatusRunning}
+		hosts := []host.Host{
+			{Id: "mine", Provider: mock.ProviderName, StartedBy: serviceutil.MockUser.Id, Status: evergreen.HostRunning},
+			{Id: "theirs", Provider: mock.ProviderName, StartedBy: "someone-else", Status: evergreen.HostRunning},
+			{Id: "gone", Provider: mock.ProviderName, StartedBy: serviceutil.MockUser.Id, Status: evergreen.HostTerminated},
+			{Id: "static", Provider: static.ProviderName, StartedBy: serviceutil.MockUser.Id, Status: evergreen.HostRunning},
+		}
+		for _, h := range hosts {
+			So(h.Insert(), ShouldBeNil)
+		}
+
+		as := newPluginTestServer(t, nil)
+		as.UserManager = serviceutil.MockUserManager{}
+		handler, err := as.Handler()
+		So(err, ShouldBeNil)
+
+		reboot := func(hostId string) *httptest.ResponseRecorder {
+			request, err := http.NewRequest("POST", "/api/spawn/"+hostId+"/", strings.NewReader("action=reboot"))
+			So(err, ShouldBeNil)
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			request.AddCookie(&http.Cookie{Name: evergreen.AuthTokenCookie, Value: "token"})
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, request)
+			return w
+		}
+
+		Convey("the owner should be able to reboot their host, which should be logged", func() {
+			So(reboot("mine").Code, ShouldEqual, http.StatusOK)
+			events, err := event.Find(event.AllLogCollection, event.HostEventsInOrder("mine"))
+			So(err, ShouldBeNil)
+			So(len(events), ShouldEqual, 1)
+			So(events[0].EventType, ShouldEqual, event.EventHostRebooted)
+			So(events[0].Data.Data.(*event.HostEventData).User, ShouldEqual, serviceutil.MockUser.Id)
+		})
+
+		Convey("other users should not be able to reboot the host", func() {
+			So(reboot("theirs").Code, ShouldEqual, http.StatusUnauthorized)
+		})
+
+		Convey("terminated hosts should not be rebooted", func() {
+			So(reboot("gone").Code, ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("hosts whose provider can't reboot should be rejected", func() {
+			w := reboot("static")
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(w.Body.String(), ShouldContainSubstring, "can't be rebooted")
+		})
+
+		Convey("failed reboots should not be logged", func() {
+			mock.Clear()
+			So(reboot("mine").Code, ShouldEqual, http.StatusInternalServerError)
+			events, err := event.Find(event.AllLogCollection, event.HostEventsInOrder("mine"))
+			So(err, ShouldBeNil)
+			So(events, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestCreateHostImage(t *testing.T) {
+	db.SetGlobalSessionProvider(db.SessionFactoryFromConfig(testutil.TestConfig()))
+
+	Convey("With an API server and spawn hosts", t, func() {
+		testutil.HandleTestingErr(db.ClearCollections(host.Collection, event.AllLogCollection), t,
+			"error clearing collections")
+		mock.Clear()
+		mock.MockInstances["mine"] = mock.MockInstance{IsUp: true, Status: cloud.StatusRunning}
+		hosts := []host.Host{
+			{Id: "mine", Provider: mock.ProviderName, StartedBy: serviceutil.MockUser.Id, Status: evergreen.HostRunning},
+			{Id: "theirs", Provider: mock.ProviderName, StartedBy: "someone-else", Status: evergreen.HostRunning},
+			{Id: "gone", Provider: mock.ProviderName, StartedBy: serviceutil.MockUser.Id, Status: evergreen.HostTerminated},
+			{Id: "static", Provider: static.ProviderName, StartedBy: serviceutil.MockUser.Id, Status: evergreen.HostRunning},
+		}
+		for _, h := range hosts {
+			So(h.Insert(), ShouldBeNil)
+		}
+
+		as := newPluginTestServer(t, nil)
+		as.UserManager = serviceutil.MockUserManager{}
+		handler, err := as.Handler()
+		So(err, ShouldBeNil)
+
+		createImage := func(hostId, imageName string) *httptest.ResponseRecorder {
+			form := url.Values{"action": {"image"}, "image_name": {imageName}}
+			request, err := http.NewRequest("POST", "/api/spawn/"+hostId+"/", strings.NewReader(form.Encode()))
+			So(err, ShouldBeNil)
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			request.AddCookie(&http.Cookie{Name: evergreen.AuthTokenCookie, Value: "token"})
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, request)
+			return w
+		}
+
+		Convey("the owner should get the id of the image created from their host, which should be logged", func() {
+			w := createImage("mine", "tooling")
+			So(w.Code, ShouldEqual, http.StatusOK)
+			resp := spawnResponse{}
+			So(json.Unmarshal(w.Body.Bytes(), &resp), ShouldBeNil)
+			So(resp.ImageId, ShouldEqual, "mock_image_tooling")
+
+			events, err := event.Find(event.AllLogCollection, event.HostEventsInOrder("mine"))
+			So(err, ShouldBeNil)
+			So(len(events), ShouldEqual, 1)
+			So(events[0].EventType, ShouldEqual, event.EventHostImageCreated)
+			data := events[0].Data.Data.(*event.HostEventData)
+			So(data.User, ShouldEqual, serviceutil.MockUser.Id)
+			So(data.ImageName, ShouldEqual, "tooling")
+			So(data.ImageId, ShouldEqual, "mock_image_tooling")
+		})
+
+		Convey("an image name should be required", func() {
+			w := createImage("mine", "")
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(w.Body.String(), ShouldContainSubstring, "image name is required")
+		})
+
+		Convey("other users should not be able to create an image of the host", func() {
+			So(createImage("theirs", "tooling").Code, ShouldEqual, http.StatusUnauthorized)
+		})
+
+		Convey("terminated hosts should not be imaged", func() {
+			So(createImage("gone", "tooling").Code, ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("hosts whose provider can't create images should be rejected", func() {
+			w := createImage("static", "tooling")
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(w.Body.String(), ShouldContainSubstring, "Can't create an image")
+		})
+
+		Convey("failures to create an image should not be logged", func() {
+			mock.Clear()
+			So(createImage("mine", "tooling").Code, ShouldEqual, http.StatusInternalServerError)
+			events, err := event.Find(event.AllLogCollection, event.HostEventsInOrder("mine"))
+			So(err, ShouldBeNil)
+			So(events, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestHostConsoleOutput(t *testing.T) {
+	db.SetGlobalSessionProvider(db.SessionFactoryFromConfig(testutil.TestConfig()))
+
+	Convey("With an API server and hosts", t, func() {
+		testutil.HandleTestingErr(db.ClearCollections(host.Collection), t, "error clearing collections")
+		mock.Clear()
+		mock.MockInstances["h1"] = mock.MockInstance{IsUp: true, Status: cloud.StatusRunning}
+		hosts := []host.Host{
+			{Id: "h1", Provider: mock.ProviderName, Status: evergreen.HostRunning},
+			{Id: "static", Provider: static.ProviderName, Status: evergreen.HostRunning},
+		}
+		for _, h := range hosts {
+			So(h.Insert(), ShouldBeNil)
+		}
+
+		as := newPluginTestServer(t, nil)
+		as.UserManager = serviceutil.MockUserManager{}
+		as.Settings.SuperUsers = []string{serviceutil.MockUser.Id}
+		handler, err := as.Handler()
+		So(err, ShouldBeNil)
+
+		getOutput := func(hostId string) *httptest.ResponseRecorder {
+			request, err := http.NewRequest("GET", "/api/spawn/"+hostId+"/console_output", nil)
+			So(err, ShouldBeNil)
+			request.AddCookie(&http.Cookie{Name: evergreen.AuthTokenCookie, Value: "token"})
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, request)
+			return w
+		}
+
+		Convey("superusers should get the console output of a host's instance", func() {
+			w := getOutput("h1")
+			So(w.Code, ShouldEqual, http.StatusOK)
+			output := struct {
+				HostId string `json:"host_id"`
+				Output string `json:"output"`
+			}{}
+			So(json.Unmarshal(w.Body.Bytes(), &output), ShouldBeNil)
+			So(output.HostId, ShouldEqual, "h1")
+			So(output.Output, ShouldEqual, "mock instance h1 is running\n")
+		})
+
+		Convey("other users should not get the console output", func() {
+			as.Settings.SuperUsers = []string{"someone-else"}
+			So(getOutput("h1").Code, ShouldEqual, http.StatusUnauthorized)
+		})
+
+		Convey("hosts whose provider has no console output should be rejected", func() {
+			w := getOutput("static")
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(w.Body.String(), ShouldContainSubstring, "Can't get the console output")
+		})
+
+		Convey("unknown hosts should not be found", func() {
+			So(getOutput("nonexistent").Code, ShouldEqual, http.StatusNotFound)
+		})
+	})
+}
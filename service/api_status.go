@@ -3,11 +3,17 @@ package service
 import (
 	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
 	"time"
 
 	"github.com/evergreen-ci/evergreen/apimodels"
+	"github.com/evergreen-ci/evergreen/cloud"
+	"github.com/evergreen-ci/evergreen/cloud/providers"
+	"github.com/evergreen-ci/evergreen/db"
 	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/host"
 	"github.com/evergreen-ci/evergreen/util"
 	"github.com/gorilla/mux"
 )
@@ -17,6 +23,116 @@ const (
 	apiStatusError   = "ERROR"
 )
 
+// readinessResp holds the overall readiness status of the API server along with
+// the result of each individual dependency check, keyed by the dependency name.
+// Status is either SUCCESS or ERROR.
+type readinessResp struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// liveness responds with 200 OK as long as the process is able to serve requests
+// at all, without checking any dependencies.
+func (as *APIServer) liveness(w http.ResponseWriter, r *http.Request) {
+	as.WriteJSON(w, http.StatusOK, struct {
+		Status string `json:"status"`
+	}{apiStatusSuccess})
+}
+
+// readiness checks the dependencies the API server needs to serve traffic, responding
+// with 200 OK if all of them are available and 503 Service Unavailable otherwise.
+func (as *APIServer) readiness(w http.ResponseWriter, r *http.Request) {
+	resp := readinessResp{Status: apiStatusSuccess, Checks: map[string]string{}}
+
+	if err := db.Ping(); err != nil {
+		resp.Status = apiStatusError
+		resp.Checks["database"] = err.Error()
+	} else {
+		resp.Checks["database"] = apiStatusSuccess
+	}
+
+	for _, status := range as.pluginStatuses {
+		key := "plugin_" + status.Name
+		if !status.Configured {
+			resp.Status = apiStatusError
+			resp.Checks[key] = status.Error
+		} else {
+			resp.Checks[key] = apiStatusSuccess
+		}
+	}
+
+	code := http.StatusOK
+	if resp.Status != apiStatusSuccess {
+		code = http.StatusServiceUnavailable
+	}
+	as.WriteJSON(w, code, resp)
+}
+
+// cloudTimings returns histograms of how long this server's calls to each cloud
+// provider have taken, labeled by provider and method. The timings are kept in
+// memory by each process since it started, so they only cover the calls made by
+// the server that answers the request, not those of other app servers or the runner.
+func (as *APIServer) cloudTimings(w http.ResponseWriter, r *http.Request) {
+	as.WriteJSON(w, http.StatusOK, cloud.CallTimings())
+}
+
+// distroHostStats returns, for each distro, how many of its hosts are in each status
+// and which tasks they are running, and how many are up against its max hosts. The
+// optional "distro" parameter restricts the response to one distro.
+func (as *APIServer) distroHostStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := host.FindDistroHostStats(r.FormValue("distro"))
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	as.WriteJSON(w, http.StatusOK, stats)
+}
+
+// providerQuotas holds the quotas reported by a cloud provider, or the error
+// getting them.
+type providerQuotas struct {
+	Provider string        `json:"provider"`
+	Quotas   []cloud.Quota `json:"quotas,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// cloudQuotas returns how close each provider that distros use is to its limits,
+// for the providers that can report them.
+func (as *APIServer) cloudQuotas(w http.ResponseWriter, r *http.Request) {
+	distros, err := distro.Find(distro.All.WithFields(distro.ProviderKey))
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	providerNames := []string{}
+	for _, d := range distros {
+		if !util.SliceContains(providerNames, d.Provider) {
+			providerNames = append(providerNames, d.Provider)
+		}
+	}
+	sort.Strings(providerNames)
+
+	resp := []providerQuotas{}
+	for _, providerName := range providerNames {
+		cloudManager, err := providers.GetCloudManager(providerName, &as.Settings)
+		if err != nil {
+			resp = append(resp, providerQuotas{Provider: providerName, Error: err.Error()})
+			continue
+		}
+		reporter, ok := cloudManager.(cloud.QuotaReporter)
+		if !ok {
+			continue
+		}
+		quotas, err := reporter.GetQuotas()
+		if err != nil {
+			resp = append(resp, providerQuotas{Provider: providerName, Error: err.Error()})
+			continue
+		}
+		resp = append(resp, providerQuotas{Provider: providerName, Quotas: quotas})
+	}
+	as.WriteJSON(w, http.StatusOK, resp)
+}
+
 // taskAssignmentResp holds the status, errors and four separate lists of task and host ids
 // this is so that when addressing inconsistencies we can differentiate between the states of
 // the tasks and hosts.
@@ -178,3 +294,97 @@ func (as *APIServer) checkTaskQueueSize(w http.ResponseWriter, r *http.Request)
 
 	as.WriteJSON(w, http.StatusOK, growthResponse)
 }
+
+// teardownStatsResp holds the teardown statistics of each distro between two times.
+type teardownStatsResp struct {
+	Start   time.Time                   `json:"start"`
+	End     time.Time                   `json:"end"`
+	Distros []model.DistroTeardownStats `json:"distros"`
+}
+
+// teardownStats reports, for each distro, how often its hosts' teardown scripts
+// succeeded and how long they took, between the RFC3339 times given by the "start"
+// and "end" query parameters. If end is omitted, it defaults to now.
+func (as *APIServer) teardownStats(w http.ResponseWriter, r *http.Request) {
+	resp := teardownStatsResp{}
+	var err error
+	if resp.Start, resp.End, err = parseTimeRange(r); err != nil {
+		as.LoggedError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	resp.Distros, err = model.ComputeTeardownStats(resp.Start, resp.End)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	as.WriteJSON(w, http.StatusOK, resp)
+}
+
+// provisionFailuresResp holds the provisioning failures of each distro between two
+// times.
+type provisionFailuresResp struct {
+	Start   time.Time                       `json:"start"`
+	End     time.Time                       `json:"end"`
+	Distros []model.DistroProvisionFailures `json:"distros"`
+}
+
+// provisionFailures reports, for each distro whose hosts failed to provision
+// between the RFC3339 times given by the "start" and "end" query parameters, how
+// many failures there were and a sample of the most recent one. If end is
+// omitted, it defaults to now.
+func (as *APIServer) provisionFailures(w http.ResponseWriter, r *http.Request) {
+	resp := provisionFailuresResp{}
+	var err error
+	if resp.Start, resp.End, err = parseTimeRange(r); err != nil {
+		as.LoggedError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	resp.Distros, err = model.ComputeProvisionFailures(resp.Start, resp.End)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	as.WriteJSON(w, http.StatusOK, resp)
+}
+
+// globalLockResp describes who holds the global lock and for how long.
+type globalLockResp struct {
+	Locked   bool      `json:"locked"`
+	LockedBy string    `json:"locked_by,omitempty"`
+	Holder   string    `json:"holder,omitempty"`
+	LockedAt time.Time `json:"locked_at,omitempty"`
+	HeldSecs float64   `json:"held_secs,omitempty"`
+	// true if the lock has been held longer than db.LockTimeout, and so will be
+	// given to the next contender for it
+	Expired bool `json:"expired,omitempty"`
+}
+
+// makeGlobalLockResp describes the global lock as of now.
+func makeGlobalLockResp(lock *db.Lock, now time.Time) globalLockResp {
+	if lock == nil || !lock.Locked {
+		return globalLockResp{}
+	}
+	held := now.Sub(lock.LockedAt)
+	return globalLockResp{
+		Locked:   true,
+		LockedBy: lock.LockedBy,
+		Holder:   lock.Holder,
+		LockedAt: lock.LockedAt,
+		HeldSecs: held.Seconds(),
+		Expired:  held >= db.LockTimeout,
+	}
+}
+
+// globalLock reports the current holder of the global lock, as recorded when it
+// was acquired, and how long it has been held, to help tell a long operation from
+// a stuck holder.
+func (as *APIServer) globalLock(w http.ResponseWriter, r *http.Request) {
+	lock, err := db.FindGlobalLock()
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	as.WriteJSON(w, http.StatusOK, makeGlobalLockResp(lock, time.Now()))
+}
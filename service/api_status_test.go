@@ -7,12 +7,14 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/db"
 	"github.com/evergreen-ci/evergreen/model/host"
 	"github.com/evergreen-ci/evergreen/model/task"
 	"github.com/evergreen-ci/evergreen/plugin"
+	serviceutil "github.com/evergreen-ci/evergreen/service/testutil"
 	"github.com/evergreen-ci/evergreen/testutil"
 	"github.com/evergreen-ci/evergreen/util"
 	. "github.com/smartystreets/goconvey/convey"
@@ -160,3 +162,102 @@ func TestServiceStatusEndPoints(t *testing.T) {
 		})
 	})
 }
+
+func TestServiceLivenessAndReadiness(t *testing.T) {
+	if err := os.MkdirAll(filepath.Join(evergreen.FindEvergreenHome(), evergreen.ClientDirectory), 0644); err != nil {
+		t.Fatal("could not create client directory required to start the API server:", err.Error())
+	}
+	as, err := NewAPIServer(testutil.TestConfig(), nil)
+	testutil.HandleTestingErr(err, t, "Couldn't create apiserver: %v", err)
+	handler, err := as.Handler()
+	testutil.HandleTestingErr(err, t, "Couldn't create API handler: %v", err)
+
+	Convey("With the liveness and readiness endpoints", t, func() {
+		Convey("liveness should always report success", func() {
+			request, err := http.NewRequest("GET", "/api/status/live", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, request)
+			So(w.Code, ShouldEqual, http.StatusOK)
+		})
+		Convey("readiness should report success when the database is reachable", func() {
+			request, err := http.NewRequest("GET", "/api/status/ready", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, request)
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			out := readinessResp{}
+			So(json.NewDecoder(w.Body).Decode(&out), ShouldBeNil)
+			So(out.Status, ShouldEqual, apiStatusSuccess)
+			So(out.Checks["database"], ShouldEqual, apiStatusSuccess)
+		})
+	})
+}
+
+func TestMakeGlobalLockResp(t *testing.T) {
+	Convey("When describing the global lock", t, func() {
+		now := time.Now()
+
+		Convey("an unheld lock should be reported as unlocked", func() {
+			So(makeGlobalLockResp(nil, now), ShouldResemble, globalLockResp{})
+			So(makeGlobalLockResp(&db.Lock{LockedBy: "client"}, now), ShouldResemble, globalLockResp{})
+		})
+		Convey("a held lock should report its holder and how long it's been held", func() {
+			resp := makeGlobalLockResp(&db.Lock{
+				Locked:   true,
+				LockedBy: "10.0.0.1:5000",
+				Holder:   "start task: t1",
+				LockedAt: now.Add(-time.Minute),
+			}, now)
+			So(resp.Locked, ShouldBeTrue)
+			So(resp.LockedBy, ShouldEqual, "10.0.0.1:5000")
+			So(resp.Holder, ShouldEqual, "start task: t1")
+			So(resp.HeldSecs, ShouldEqual, 60)
+			So(resp.Expired, ShouldBeFalse)
+		})
+		Convey("a lock held past the lock timeout should be reported as expired", func() {
+			resp := makeGlobalLockResp(&db.Lock{
+				Locked:   true,
+				LockedAt: now.Add(-db.LockTimeout - time.Second),
+			}, now)
+			So(resp.Expired, ShouldBeTrue)
+		})
+	})
+}
+
+func TestCloudTimingsAuth(t *testing.T) {
+	db.SetGlobalSessionProvider(db.SessionFactoryFromConfig(testutil.TestConfig()))
+
+	Convey("With an API server", t, func() {
+		as := newPluginTestServer(t, nil)
+		as.UserManager = serviceutil.MockUserManager{}
+		handler, err := as.Handler()
+		So(err, ShouldBeNil)
+
+		getTimings := func(loggedIn bool) *httptest.ResponseRecorder {
+			request, err := http.NewRequest("GET", "/api/status/cloud_timings", nil)
+			So(err, ShouldBeNil)
+			if loggedIn {
+				request.AddCookie(&http.Cookie{Name: evergreen.AuthTokenCookie, Value: "token"})
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, request)
+			return w
+		}
+
+		Convey("anonymous requests should be rejected", func() {
+			So(getTimings(false).Code, ShouldEqual, http.StatusUnauthorized)
+		})
+
+		Convey("users that aren't superusers should be rejected", func() {
+			as.Settings.SuperUsers = []string{"someone-else"}
+			So(getTimings(true).Code, ShouldEqual, http.StatusUnauthorized)
+		})
+
+		Convey("superusers should get the timings", func() {
+			as.Settings.SuperUsers = []string{serviceutil.MockUser.Id}
+			So(getTimings(true).Code, ShouldEqual, http.StatusOK)
+		})
+	})
+}
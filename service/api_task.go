@@ -12,11 +12,14 @@ import (
 	"github.com/evergreen-ci/evergreen/cloud"
 	"github.com/evergreen-ci/evergreen/cloud/providers"
 	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/event"
 	"github.com/evergreen-ci/evergreen/model/host"
 	"github.com/evergreen-ci/evergreen/model/task"
 	"github.com/evergreen-ci/evergreen/taskrunner"
 	"github.com/evergreen-ci/evergreen/util"
 	"github.com/mongodb/grip"
+	"gopkg.in/mgo.v2"
 )
 
 // StartTask is the handler function that retrieves the task from the request
@@ -223,7 +226,7 @@ func (as *APIServer) newEndTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// clear the running task on the host now that the task has finished
-	if err := currentHost.ClearRunningTask(t.Id, time.Now()); err != nil {
+	if err := currentHost.ClearRunningTask(t.Id, time.Now(), event.RunningTaskFinished); err != nil {
 		message := fmt.Errorf("error clearing running task %s for host %s : %v", t.Id, currentHost.Id, err)
 		grip.Errorf(message.Error())
 		as.LoggedError(w, r, http.StatusInternalServerError, message)
@@ -336,8 +339,12 @@ func (as *APIServer) taskFinished(w http.ResponseWriter, t *task.Task, finishTim
 		return
 	}
 
-	// c. fetch the task's distro queue to dispatch the next pending task
-	nextTask, err := getNextDistroTask(t, host)
+	// c. run the next task reserved for the host or, if there isn't one, fetch the
+	// task's distro queue to dispatch the next pending task
+	nextTask, err := nextReservedTask(host)
+	if err == nil && nextTask == nil {
+		nextTask, err = getNextDistroTask(t, host)
+	}
 	if err != nil {
 		markHostRunningTaskFinished(host, t, "")
 		grip.Error(err)
@@ -416,7 +423,7 @@ func (as *APIServer) updateTaskCost(t *task.Task, h *host.Host, finishTime time.
 func markHostRunningTaskFinished(h *host.Host, t *task.Task, newTaskId string) {
 	// clear the running task instead
 	if newTaskId == "" {
-		err := h.ClearRunningTask(t.Id, time.Now())
+		err := h.ClearRunningTask(t.Id, time.Now(), event.RunningTaskFinished)
 		if err != nil {
 			grip.Errorf("error clearing task %s on host %s : %+v", t.Id, h.Id, err)
 			return
@@ -480,13 +487,221 @@ func assignNextAvailableTask(taskQueue *model.TaskQueue, currentHost *host.Host)
 
 }
 
+// reserveNextAvailableTasks dispatches up to n more tasks from the queue to the host
+// and reserves them for it to run after its running task.
+func reserveNextAvailableTasks(taskQueue *model.TaskQueue, h *host.Host, n int) ([]task.Task, error) {
+	reserved := []task.Task{}
+	for len(reserved) < n && !taskQueue.IsEmpty() {
+		nextTask, err := task.FindOne(task.ById(taskQueue.NextTask().Id))
+		if err != nil {
+			return reserved, err
+		}
+		if nextTask == nil {
+			return reserved, fmt.Errorf("nil task on the queue")
+		}
+		if err = taskQueue.DequeueTask(nextTask.Id); err != nil {
+			return reserved, fmt.Errorf("error pulling task with id %v from "+
+				"queue for distro %v: %v", nextTask.Id,
+				nextTask.DistroId, err)
+		}
+		if !nextTask.IsDispatchable() {
+			continue
+		}
+		if err = model.MarkTaskDispatched(nextTask, h.Id, h.Distro.Id); err != nil {
+			return reserved, err
+		}
+		if err = h.ReserveTasks([]string{nextTask.Id}); err != nil {
+			if releaseErr := model.MarkTaskUndispatched(nextTask); releaseErr != nil {
+				grip.Error(releaseErr)
+			}
+			return reserved, err
+		}
+		reserved = append(reserved, *nextTask)
+	}
+	return reserved, nil
+}
+
+// nextReservedTask unreserves and returns the first task reserved for the host that
+// is still dispatched to it and activated. Reserved tasks before it that can't be
+// run are released.
+func nextReservedTask(h *host.Host) (*task.Task, error) {
+	for len(h.ReservedTasks) > 0 {
+		taskId := h.ReservedTasks[0]
+		t, err := task.FindOne(task.ById(taskId))
+		if err != nil {
+			return nil, fmt.Errorf("error finding task %v reserved for host %v: %v", taskId, h.Id, err)
+		}
+		if t == nil {
+			if err = h.UnreserveTask(taskId); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if !isRunnableReservation(t, h) {
+			grip.Infof("Releasing task %s reserved for host %s: status (%s) activated (%t)",
+				t.Id, h.Id, t.Status, t.Activated)
+			if err = model.ReleaseReservedTask(t, h); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err = h.UnreserveTask(t.Id); err != nil {
+			return nil, err
+		}
+		if err = t.UpdateHeartbeat(); err != nil {
+			grip.Errorf("Error updating heartbeat for task %s: %+v", t.Id, err)
+		}
+		return t, nil
+	}
+	return nil, nil
+}
+
+// releaseLostReservation handles a task taken from the host's reservations to run
+// next when another request set the host's running task first. The host is
+// reloaded so that the request continues with the running task that was set, and
+// unless that's the task itself, the task is released so it can be dispatched
+// again instead of staying dispatched to a host that won't run it.
+func releaseLostReservation(t *task.Task, h *host.Host) error {
+	current, err := host.FindOne(host.ById(h.Id))
+	if err != nil {
+		return fmt.Errorf("error finding host %v: %v", h.Id, err)
+	}
+	if current == nil {
+		return fmt.Errorf("host %v not found", h.Id)
+	}
+	*h = *current
+	if h.RunningTask == t.Id {
+		return nil
+	}
+	grip.Infof("Releasing task %s reserved for host %s, which was given task %s first",
+		t.Id, h.Id, h.RunningTask)
+	return model.ReleaseReservedTask(t, h)
+}
+
+// isRunnableReservation returns whether a task reserved for the host can still be
+// run by it, which requires that it's still dispatched to the host and activated.
+func isRunnableReservation(t *task.Task, h *host.Host) bool {
+	return t.Status == evergreen.TaskDispatched && t.HostId == h.Id && t.Activated
+}
+
+// distroIsDraining returns whether the distro is being drained, in which case its
+// hosts are given no new tasks so they go idle and are terminated. Distros that no
+// longer exist aren't draining.
+func distroIsDraining(distroId string) (bool, error) {
+	d, err := distro.FindOne(distro.ById(distroId).WithFields(distro.DrainingKey))
+	if err == mgo.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return d.Draining, nil
+}
+
+// prefetchedTasks returns the tasks reserved for the host, in order.
+func prefetchedTasks(h *host.Host) ([]apimodels.PrefetchedTask, error) {
+	if len(h.ReservedTasks) == 0 {
+		return nil, nil
+	}
+	tasks, err := task.Find(task.ByIds(h.ReservedTasks))
+	if err != nil {
+		return nil, err
+	}
+	secrets := map[string]string{}
+	for _, t := range tasks {
+		secrets[t.Id] = t.Secret
+	}
+	prefetched := []apimodels.PrefetchedTask{}
+	for _, id := range h.ReservedTasks {
+		if secret, ok := secrets[id]; ok {
+			prefetched = append(prefetched, apimodels.PrefetchedTask{TaskId: id, TaskSecret: secret})
+		}
+	}
+	return prefetched, nil
+}
+
+// taskPrefetch returns how many tasks, including the next one, the agent asked to
+// be given, limited to the configured maximum.
+func (as *APIServer) taskPrefetch(r *http.Request) (int, error) {
+	input := apimodels.NextTaskRequest{}
+	if r.ContentLength != 0 {
+		if err := util.ReadJSONInto(r.Body, &input); err != nil {
+			return 0, err
+		}
+	}
+	prefetch := input.Prefetch
+	if prefetch > as.Settings.Api.MaxTaskPrefetch {
+		prefetch = as.Settings.Api.MaxTaskPrefetch
+	}
+	if prefetch < 1 {
+		prefetch = 1
+	}
+	return prefetch, nil
+}
+
+// taskPollInterval suggests how long an agent should wait before asking again for a
+// task when none was available from the queue. While the queue has tasks it's min,
+// and if it has never been seen with any it's max; otherwise it's a tenth of the
+// time since the queue last had tasks, bounded by min and max, so that agents of
+// idle distros back off while those of recently busy distros pick up new work
+// quickly.
+func taskPollInterval(queue *model.TaskQueue, now time.Time, min, max time.Duration) time.Duration {
+	if !queue.IsEmpty() {
+		return min
+	}
+	if util.IsZeroTime(queue.LastNonEmpty) {
+		return max
+	}
+	interval := now.Sub(queue.LastNonEmpty) / 10
+	if interval < min {
+		return min
+	}
+	if interval > max {
+		return max
+	}
+	return interval
+}
+
 // NextTask retrieves the next task's id given the host name and host secret by retrieving the task queue
-// and popping the next task off the task queue.
+// and popping the next task off the task queue. Agents may ask for several tasks at
+// once; the tasks after the next one are reserved for the host and returned with it.
 func (as *APIServer) NextTask(w http.ResponseWriter, r *http.Request) {
 	h := MustHaveHost(r)
 	response := apimodels.NextTaskResponse{
 		ShouldExit: false,
 	}
+	prefetch, err := as.taskPrefetch(r)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	// if there is a task reserved for the host but none assigned, as when the agent
+	// restarted after a task ended, run the reserved task next
+	if h.RunningTask == "" && len(h.ReservedTasks) > 0 {
+		reservedTask, err := nextReservedTask(h)
+		if err != nil {
+			grip.Error(err)
+			as.WriteJSON(w, http.StatusInternalServerError, err)
+			return
+		}
+		if reservedTask != nil {
+			ok, err := h.UpdateRunningTask(h.LastTaskCompleted, reservedTask.Id, time.Now())
+			if err != nil {
+				grip.Error(err)
+				as.WriteJSON(w, http.StatusInternalServerError, err)
+				return
+			}
+			if ok {
+				h.RunningTask = reservedTask.Id
+			} else if err = releaseLostReservation(reservedTask, h); err != nil {
+				grip.Error(err)
+				as.WriteJSON(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+	}
+
 	// if there is already a task assigned to the host send back that task
 	if h.RunningTask != "" {
 		t, err := task.FindOne(task.ById(h.RunningTask))
@@ -507,16 +722,23 @@ func (as *APIServer) NextTask(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 		}
-		// if the task is activated return that task
+		// if the task is activated return that task, along with those reserved for
+		// the host to run after it
 		if t.Activated {
 			response.TaskId = t.Id
 			response.TaskSecret = t.Secret
+			if prefetch > 1 {
+				response.Prefetched, err = prefetchedTasks(h)
+				if err != nil {
+					grip.Errorf("Error finding tasks reserved for host %s: %+v", h.Id, err)
+				}
+			}
 			as.WriteJSON(w, http.StatusOK, response)
 			return
 		}
 		// the task is not activated so the host's running task should be unset
 		// so it can retrieve a new task.
-		if err := h.ClearRunningTask(h.LastTaskCompleted, time.Now()); err != nil {
+		if err := h.ClearRunningTask(h.LastTaskCompleted, time.Now(), event.RunningTaskDeactivated); err != nil {
 			grip.Error(err)
 			as.WriteJSON(w, http.StatusInternalServerError, err)
 			return
@@ -528,6 +750,21 @@ func (as *APIServer) NextTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	draining, err := distroIsDraining(h.Distro.Id)
+	if err != nil {
+		grip.Error(err)
+		as.WriteJSON(w, http.StatusInternalServerError,
+			fmt.Errorf("error finding distro %s of host %s: %v", h.Distro.Id, h.Id, err))
+		return
+	}
+	if draining {
+		grip.Infof("not assigning a task to host %s of draining distro %s", h.Id, h.Distro.Id)
+		_, max := as.Settings.Api.TaskPollIntervals()
+		response.PollIntervalSecs = int(max / time.Second)
+		as.WriteJSON(w, http.StatusOK, response)
+		return
+	}
+
 	// retrieve the next task off the task queue and attempt to assign it to the host.
 	// If there is already a host that has the task, it will error
 	taskQueue, err := model.FindTaskQueueForDistro(h.Distro.Id)
@@ -555,6 +792,9 @@ func (as *APIServer) NextTask(w http.ResponseWriter, r *http.Request) {
 	if nextTask == nil {
 		// if the task is empty, still send it with an status ok and check it on the other side
 		grip.Infof("no task to assign host %v", h.Id)
+		min, max := as.Settings.Api.TaskPollIntervals()
+		interval := taskPollInterval(taskQueue, time.Now(), min, max)
+		response.PollIntervalSecs = int(interval / time.Second)
 		as.WriteJSON(w, http.StatusOK, response)
 		return
 	}
@@ -568,5 +808,155 @@ func (as *APIServer) NextTask(w http.ResponseWriter, r *http.Request) {
 	response.TaskId = nextTask.Id
 	response.TaskSecret = nextTask.Secret
 	grip.Infof("assigned task %s to host %s", nextTask.Id, h.Id)
+
+	if prefetch > 1 {
+		reserved, err := reserveNextAvailableTasks(taskQueue, h, prefetch-1)
+		if err != nil {
+			// the agent is still given the tasks that were reserved
+			grip.Errorf("Error reserving tasks for host %s: %+v", h.Id, err)
+		}
+		for _, t := range reserved {
+			response.Prefetched = append(response.Prefetched,
+				apimodels.PrefetchedTask{TaskId: t.Id, TaskSecret: t.Secret})
+		}
+		if len(reserved) > 0 {
+			grip.Infof("reserved %d tasks for host %s", len(reserved), h.Id)
+		}
+	}
 	as.WriteJSON(w, http.StatusOK, response)
 }
+
+// setTaskPriority sets the priority of a task and the tasks it depends on. Only
+// superusers and the admins of the task's project may set priorities, and project
+// admins may only set one from zero up to the limit configured for them or for the
+// project.
+func (as *APIServer) setTaskPriority(w http.ResponseWriter, r *http.Request) {
+	t := MustHaveTask(r)
+	u := MustHaveUser(r)
+
+	input := struct {
+		Priority int64 `json:"priority"`
+	}{}
+	if err := util.ReadJSONInto(r.Body, &input); err != nil {
+		as.LoggedError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	projectRef, err := model.FindOneProjectRef(t.Project)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	superUser := as.isSuperUser(u)
+	if !superUser && (projectRef == nil || !isAdmin(u, projectRef)) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if !superUser {
+		limit := as.Settings.Api.TaskPriorityLimit(u.Id, t.Project)
+		if err = validateTaskPriority(input.Priority, limit); err != nil {
+			as.LoggedError(w, r, http.StatusForbidden, err)
+			return
+		}
+	}
+
+	if err = model.SetTaskPriority(t, input.Priority, u.Id); err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	grip.Infof("User %s set priority of task %s to %d", u.Id, t.Id, input.Priority)
+
+	out := struct {
+		TaskId   string `json:"task_id"`
+		Priority int64  `json:"priority"`
+	}{t.Id, t.Priority}
+	as.WriteJSON(w, http.StatusOK, out)
+}
+
+// validateTaskPriority returns an error if a priority isn't one a project admin may
+// set: negative priorities, which deactivate tasks, and priorities above the limit
+// are reserved for superusers.
+func validateTaskPriority(priority, limit int64) error {
+	if priority < 0 {
+		return fmt.Errorf("Insufficient access to set priority %v, can only set a priority of 0 or more",
+			priority)
+	}
+	if priority > limit {
+		return fmt.Errorf("Insufficient access to set priority %v, can only set priority less than or equal to %v",
+			priority, limit)
+	}
+	return nil
+}
+
+// abortTask marks a running task to be aborted by its agent, which learns of the
+// abort at its next heartbeat. The request body may give a reason for the abort.
+func (as *APIServer) abortTask(w http.ResponseWriter, r *http.Request) {
+	t := MustHaveTask(r)
+	u := MustHaveUser(r)
+
+	input := struct {
+		Reason string `json:"reason"`
+	}{}
+	if r.ContentLength != 0 {
+		if err := util.ReadJSONInto(r.Body, &input); err != nil {
+			as.LoggedError(w, r, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	projectRef, err := model.FindOneProjectRef(t.Project)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	if projectRef == nil || !as.canAccessProject(u, projectRef) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !task.IsAbortable(*t) {
+		as.LoggedError(w, r, http.StatusConflict,
+			fmt.Errorf("Task %v can't be aborted while it is '%v'", t.Id, t.Status))
+		return
+	}
+
+	if err = model.AbortTask(t.Id, u.Id, input.Reason); err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError,
+			fmt.Errorf("Error aborting task %v: %v", t.Id, err))
+		return
+	}
+	grip.Infof("User %s aborted task %s", u.Id, t.Id)
+	taskAborts.notify(t.Id)
+
+	out := struct {
+		TaskId string `json:"task_id"`
+		Reason string `json:"reason,omitempty"`
+	}{t.Id, input.Reason}
+	as.WriteJSON(w, http.StatusOK, out)
+}
+
+// rotateTaskSecret generates a new secret for a running task and returns it to the
+// requester. Subsequent agent requests for the task must use the new secret.
+func (as *APIServer) rotateTaskSecret(w http.ResponseWriter, r *http.Request) {
+	t := MustHaveTask(r)
+	u := MustHaveUser(r)
+
+	if !util.SliceContains(evergreen.AbortableStatuses, t.Status) {
+		as.LoggedError(w, r, http.StatusConflict,
+			fmt.Errorf("Task %v is not running (status '%v')", t.Id, t.Status))
+		return
+	}
+
+	if err := t.RotateSecret(u.Id); err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError,
+			fmt.Errorf("Error rotating secret for task %v: %v", t.Id, err))
+		return
+	}
+	grip.Infof("User %s rotated secret for task %s", u.Id, t.Id)
+
+	out := struct {
+		TaskId string `json:"task_id"`
+		Secret string `json:"secret"`
+	}{t.Id, t.Secret}
+	as.WriteJSON(w, http.StatusOK, out)
+}
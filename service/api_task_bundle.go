@@ -0,0 +1,144 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/artifact"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/goamz/goamz/aws"
+	"github.com/goamz/goamz/s3"
+)
+
+const (
+	// s3BaseURL is the prefix of links to artifacts uploaded to S3, which are the
+	// only ones whose URLs can be signed.
+	s3BaseURL = "https://s3.amazonaws.com/"
+
+	// signedURLExpiration is how long signed artifact URLs are valid for.
+	signedURLExpiration = time.Hour
+)
+
+// taskBundle is a snapshot of a completed task: its document, the files it
+// attached, and references to its logs.
+type taskBundle struct {
+	Task      task.Task            `json:"task"`
+	Artifacts []taskBundleArtifact `json:"artifacts"`
+	Logs      []taskBundleLog      `json:"logs"`
+}
+
+type taskBundleArtifact struct {
+	artifact.File
+	// SignedURL is set to a link that doesn't require credentials, if one was
+	// requested and the file is in S3
+	SignedURL string `json:"signed_url,omitempty"`
+}
+
+type taskBundleLog struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// taskLogTypes are the names of the kinds of log messages a task records.
+var taskLogTypes = []struct{ name, prefix string }{
+	{"task", model.TaskLogPrefix},
+	{"agent", model.AgentLogPrefix},
+	{"system", model.SystemLogPrefix},
+}
+
+// taskBundle returns a task's document, artifacts, and log URLs in one response.
+// If the "signed" query parameter is true, each artifact in one of the S3 buckets
+// configured for signing also gets a signed URL that is valid for an hour.
+func (as *APIServer) taskBundle(w http.ResponseWriter, r *http.Request) {
+	t := MustHaveTask(r)
+	u := MustHaveUser(r)
+
+	projectRef, err := model.FindOneProjectRef(t.Project)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	if projectRef == nil || !as.canAccessProject(u, projectRef) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	signing := as.Settings.Api.ArtifactSigning
+	sign := r.FormValue("signed") == "true"
+	if sign && (signing.Key == "" || signing.Secret == "" || len(signing.Buckets) == 0) {
+		http.Error(w, "artifact signing is not configured", http.StatusNotImplemented)
+		return
+	}
+
+	entries, err := artifact.FindAll(artifact.ByTaskId(t.Id))
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError,
+			fmt.Errorf("Error finding artifacts for task %v: %v", t.Id, err))
+		return
+	}
+
+	bundle := taskBundle{
+		Task:      *t,
+		Artifacts: []taskBundleArtifact{},
+		Logs:      []taskBundleLog{},
+	}
+	bundle.Task.Secret = ""
+
+	expires := time.Now().Add(signedURLExpiration)
+	for _, entry := range entries {
+		for _, file := range entry.Files {
+			if file.Visibility == artifact.None {
+				continue
+			}
+			bundleArtifact := taskBundleArtifact{File: file}
+			if sign {
+				bundleArtifact.SignedURL = signS3URL(signing, file.Link, expires)
+			}
+			bundle.Artifacts = append(bundle.Artifacts, bundleArtifact)
+		}
+	}
+
+	uiURL := strings.TrimSuffix(as.Settings.Ui.Url, "/")
+	for _, logType := range taskLogTypes {
+		bundle.Logs = append(bundle.Logs, taskBundleLog{
+			Name: logType.name,
+			URL: fmt.Sprintf("%v/task_log_raw/%v/%v?type=%v",
+				uiURL, t.Id, t.Execution, logType.prefix),
+		})
+	}
+	for i := range t.TestResults {
+		testURL := task.GetTestUrl(&t.TestResults[i])
+		if testURL == "" {
+			continue
+		}
+		if strings.HasPrefix(testURL, "/") {
+			testURL = uiURL + testURL
+		}
+		bundle.Logs = append(bundle.Logs, taskBundleLog{Name: t.TestResults[i].TestFile, URL: testURL})
+	}
+
+	as.WriteJSON(w, http.StatusOK, bundle)
+}
+
+// signS3URL returns a URL for the S3 object at link that is valid without
+// credentials until expires, or an empty string if the link isn't to an object in
+// one of the buckets configured for signing.
+func signS3URL(signing evergreen.ArtifactSigningConfig, link string, expires time.Time) string {
+	if !strings.HasPrefix(link, s3BaseURL) {
+		return ""
+	}
+	parts := strings.SplitN(strings.TrimPrefix(link, s3BaseURL), "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return ""
+	}
+	if !util.SliceContains(signing.Buckets, parts[0]) {
+		return ""
+	}
+	auth := aws.Auth{AccessKey: signing.Key, SecretKey: signing.Secret}
+	return s3.New(auth, aws.USEast).Bucket(parts[0]).SignedURL(parts[1], expires)
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSignS3URL(t *testing.T) {
+	Convey("With artifact signing configured", t, func() {
+		signing := evergreen.ArtifactSigningConfig{Key: "access", Secret: "secret", Buckets: []string{"bucket"}}
+		expires := time.Now().Add(time.Hour)
+
+		Convey("links to S3 objects should be signed", func() {
+			signed := signS3URL(signing, s3BaseURL+"bucket/dir/file.tgz", expires)
+			So(strings.HasPrefix(signed, s3BaseURL+"bucket/dir/file.tgz?"), ShouldBeTrue)
+			So(signed, ShouldContainSubstring, "AWSAccessKeyId=access")
+			So(signed, ShouldContainSubstring, "Signature=")
+		})
+
+		Convey("other links should not be signed", func() {
+			So(signS3URL(signing, "http://fileserver/coverage.html", expires), ShouldEqual, "")
+			So(signS3URL(signing, s3BaseURL+"bucket", expires), ShouldEqual, "")
+		})
+
+		Convey("links to other buckets should not be signed", func() {
+			So(signS3URL(signing, s3BaseURL+"other-bucket/dir/file.tgz", expires), ShouldEqual, "")
+		})
+	})
+}
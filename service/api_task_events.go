@@ -0,0 +1,32 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/event"
+)
+
+// taskEvents returns every event logged for a task, such as its status changes and
+// the system and process info its agent reported, oldest first.
+func (as *APIServer) taskEvents(w http.ResponseWriter, r *http.Request) {
+	t := MustHaveTask(r)
+	u := MustHaveUser(r)
+
+	projectRef, err := model.FindOneProjectRef(t.Project)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	if projectRef == nil || !as.canAccessProject(u, projectRef) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	events, err := event.FindTaskTimeline(t.Id)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	as.WriteJSON(w, http.StatusOK, events)
+}
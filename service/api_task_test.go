@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/apimodels"
@@ -15,9 +16,12 @@ import (
 	"github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/model/build"
 	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/event"
 	"github.com/evergreen-ci/evergreen/model/host"
 	"github.com/evergreen-ci/evergreen/model/task"
 	modelUtil "github.com/evergreen-ci/evergreen/model/testutil"
+	"github.com/evergreen-ci/evergreen/model/version"
+	serviceutil "github.com/evergreen-ci/evergreen/service/testutil"
 	"github.com/evergreen-ci/evergreen/testutil"
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -383,6 +387,39 @@ func TestValidateTaskEndDetails(t *testing.T) {
 	})
 }
 
+func TestValidateTaskPriority(t *testing.T) {
+	Convey("With a priority limit of 50", t, func() {
+		So(validateTaskPriority(0, 50), ShouldBeNil)
+		So(validateTaskPriority(50, 50), ShouldBeNil)
+		So(validateTaskPriority(51, 50), ShouldNotBeNil)
+		So(validateTaskPriority(-1, 50), ShouldNotBeNil)
+	})
+}
+
+func TestTaskPollInterval(t *testing.T) {
+	Convey("With poll intervals between 5 seconds and a minute", t, func() {
+		min, max := 5*time.Second, time.Minute
+		now := time.Now()
+
+		Convey("agents should poll often while the queue has tasks", func() {
+			queue := &model.TaskQueue{Queue: []model.TaskQueueItem{{Id: "t1"}},
+				LastNonEmpty: now.Add(-time.Hour)}
+			So(taskPollInterval(queue, now, min, max), ShouldEqual, min)
+		})
+		Convey("agents should back off fully if the queue has never had tasks", func() {
+			So(taskPollInterval(&model.TaskQueue{}, now, min, max), ShouldEqual, max)
+		})
+		Convey("agents should back off the longer the queue has been empty", func() {
+			queue := &model.TaskQueue{LastNonEmpty: now.Add(-10 * time.Second)}
+			So(taskPollInterval(queue, now, min, max), ShouldEqual, min)
+			queue.LastNonEmpty = now.Add(-5 * time.Minute)
+			So(taskPollInterval(queue, now, min, max), ShouldEqual, 30*time.Second)
+			queue.LastNonEmpty = now.Add(-time.Hour)
+			So(taskPollInterval(queue, now, min, max), ShouldEqual, max)
+		})
+	})
+}
+
 func TestCheckHostHealth(t *testing.T) {
 	Convey("With a host that has different statuses", t, func() {
 		h := &host.Host{
@@ -434,3 +471,157 @@ func TestMarkHostRunningTaskFinished(t *testing.T) {
 
 	})
 }
+
+func TestRotateTaskSecret(t *testing.T) {
+	db.SetGlobalSessionProvider(db.SessionFactoryFromConfig(testutil.TestConfig()))
+
+	Convey("With an API server and a running task", t, func() {
+		testutil.HandleTestingErr(db.ClearCollections(task.Collection, event.AllLogCollection), t,
+			"error clearing collections")
+		So((&task.Task{Id: "running", Secret: "old", Status: evergreen.TaskStarted}).Insert(), ShouldBeNil)
+		So((&task.Task{Id: "finished", Secret: "old", Status: evergreen.TaskSucceeded}).Insert(), ShouldBeNil)
+
+		as := newPluginTestServer(t, nil)
+		as.UserManager = serviceutil.MockUserManager{}
+		as.Settings.SuperUsers = []string{serviceutil.MockUser.Id}
+		handler, err := as.Handler()
+		So(err, ShouldBeNil)
+
+		rotate := func(taskId string) *httptest.ResponseRecorder {
+			request, err := http.NewRequest("POST", "/api/2/task/"+taskId+"/rotate_secret", nil)
+			So(err, ShouldBeNil)
+			request.AddCookie(&http.Cookie{Name: evergreen.AuthTokenCookie, Value: "token"})
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, request)
+			return w
+		}
+
+		Convey("a superuser should get a new secret, which should be stored", func() {
+			w := rotate("running")
+			So(w.Code, ShouldEqual, http.StatusOK)
+			out := struct {
+				TaskId string `json:"task_id"`
+				Secret string `json:"secret"`
+			}{}
+			So(json.NewDecoder(w.Body).Decode(&out), ShouldBeNil)
+			So(out.TaskId, ShouldEqual, "running")
+			So(out.Secret, ShouldNotEqual, "old")
+
+			dbTask, err := task.FindOne(task.ById("running"))
+			So(err, ShouldBeNil)
+			So(dbTask.Secret, ShouldEqual, out.Secret)
+
+			Convey("and agent requests with the old secret should be rejected", func() {
+				request, err := http.NewRequest("POST", "/api/2/task/running/heartbeat", nil)
+				So(err, ShouldBeNil)
+				request.Header.Add(evergreen.TaskSecretHeader, "old")
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, request)
+				So(w.Code, ShouldEqual, http.StatusConflict)
+			})
+		})
+
+		Convey("the secret of a task that isn't running should not be rotated", func() {
+			So(rotate("finished").Code, ShouldEqual, http.StatusConflict)
+			dbTask, err := task.FindOne(task.ById("finished"))
+			So(err, ShouldBeNil)
+			So(dbTask.Secret, ShouldEqual, "old")
+		})
+
+		Convey("users that aren't superusers should be rejected", func() {
+			as.Settings.SuperUsers = []string{"someone-else"}
+			So(rotate("running").Code, ShouldEqual, http.StatusUnauthorized)
+			dbTask, err := task.FindOne(task.ById("running"))
+			So(err, ShouldBeNil)
+			So(dbTask.Secret, ShouldEqual, "old")
+		})
+	})
+}
+
+func TestGetVersionConfig(t *testing.T) {
+	db.SetGlobalSessionProvider(db.SessionFactoryFromConfig(testutil.TestConfig()))
+
+	Convey("With an API server and tasks", t, func() {
+		testutil.HandleTestingErr(db.ClearCollections(task.Collection, version.Collection), t,
+			"error clearing collections")
+		config := "buildvariants:\n- name: ubuntu  # the only variant\n"
+		So((&version.Version{Id: "v1", Config: config}).Insert(), ShouldBeNil)
+		So((&task.Task{Id: "t1", Version: "v1"}).Insert(), ShouldBeNil)
+		So((&task.Task{Id: "orphan", Version: "v2"}).Insert(), ShouldBeNil)
+
+		as := newPluginTestServer(t, nil)
+		handler, err := as.Handler()
+		So(err, ShouldBeNil)
+
+		get := func(path string) *httptest.ResponseRecorder {
+			request, err := http.NewRequest("GET", path, nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, request)
+			return w
+		}
+
+		Convey("the version's config should be returned as stored, as yaml", func() {
+			w := get("/api/2/task/t1/version/config")
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Header().Get("Content-Type"), ShouldStartWith, "application/yaml")
+			So(w.Body.String(), ShouldEqual, config)
+		})
+
+		Convey("the version itself should still be returned as json", func() {
+			w := get("/api/2/task/t1/version")
+			So(w.Code, ShouldEqual, http.StatusOK)
+			v := version.Version{}
+			So(json.Unmarshal(w.Body.Bytes(), &v), ShouldBeNil)
+			So(v.Id, ShouldEqual, "v1")
+			So(v.Config, ShouldEqual, config)
+		})
+
+		Convey("tasks whose version doesn't exist should not have a config", func() {
+			So(get("/api/2/task/orphan/version/config").Code, ShouldEqual, http.StatusNotFound)
+		})
+
+		Convey("tasks that don't exist should not have a config", func() {
+			So(get("/api/2/task/nonexistent/version/config").Code, ShouldEqual, http.StatusNotFound)
+		})
+	})
+}
+
+func TestReleaseLostReservation(t *testing.T) {
+	db.SetGlobalSessionProvider(db.SessionFactoryFromConfig(testutil.TestConfig()))
+
+	Convey("With a task taken from a host's reservations to run next", t, func() {
+		testutil.HandleTestingErr(db.ClearCollections(host.Collection, task.Collection, build.Collection), t,
+			"error clearing collections")
+		So((&build.Build{Id: "b1", Tasks: []build.TaskCache{{Id: "reserved"}}}).Insert(), ShouldBeNil)
+		reserved := &task.Task{
+			Id:        "reserved",
+			BuildId:   "b1",
+			Status:    evergreen.TaskDispatched,
+			HostId:    "h1",
+			Activated: true,
+		}
+		So(reserved.Insert(), ShouldBeNil)
+		// the host as the request loaded it, before another request set its running task
+		h := &host.Host{Id: "h1", Status: evergreen.HostRunning}
+
+		Convey("it should be released if the host was given another task first", func() {
+			So((&host.Host{Id: "h1", Status: evergreen.HostRunning, RunningTask: "other"}).Insert(), ShouldBeNil)
+			So(releaseLostReservation(reserved, h), ShouldBeNil)
+			So(h.RunningTask, ShouldEqual, "other")
+			dbTask, err := task.FindOne(task.ById("reserved"))
+			So(err, ShouldBeNil)
+			So(dbTask.Status, ShouldEqual, evergreen.TaskUndispatched)
+		})
+
+		Convey("it should stay dispatched if another request is running it on the host", func() {
+			So((&host.Host{Id: "h1", Status: evergreen.HostRunning, RunningTask: "reserved"}).Insert(), ShouldBeNil)
+			So(releaseLostReservation(reserved, h), ShouldBeNil)
+			So(h.RunningTask, ShouldEqual, "reserved")
+			dbTask, err := task.FindOne(task.ById("reserved"))
+			So(err, ShouldBeNil)
+			So(dbTask.Status, ShouldEqual, evergreen.TaskDispatched)
+			So(dbTask.HostId, ShouldEqual, "h1")
+		})
+	})
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/evergreen-ci/evergreen/model"
+)
+
+// truncatedTestLogMarker is the line appended to test logs that were truncated to
+// fit within the size limit.
+const truncatedTestLogMarker = "[evergreen: test log exceeded %v bytes and was truncated]"
+
+// readTruncatedTestLog decodes a test log from a request body of at most limit
+// bytes, and returns whether it was truncated. If the body is larger, decoding
+// stops at the limit: the lines read in full by then are kept, followed by a line
+// noting the truncation, and the rest of the body, including any fields after the
+// lines, is never read. The body must not exceed the limit before the lines start.
+func readTruncatedTestLog(w http.ResponseWriter, body io.ReadCloser, limit int64) (*model.TestLog, bool, error) {
+	dec := json.NewDecoder(http.MaxBytesReader(w, body, limit))
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, false, err
+	}
+
+	// the fields other than the lines are small, so decode them as usual once
+	// they've all been read
+	fields := map[string]json.RawMessage{}
+	lines := []string{}
+	truncated := false
+	for !truncated && dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, false, err
+		}
+		key, ok := token.(string)
+		if !ok {
+			return nil, false, fmt.Errorf("expected a field name, got %v", token)
+		}
+		if key != "lines" {
+			raw := json.RawMessage{}
+			if err = dec.Decode(&raw); err != nil {
+				return nil, false, err
+			}
+			fields[key] = raw
+			continue
+		}
+
+		if err = expectDelim(dec, '['); err != nil {
+			return nil, false, err
+		}
+		for dec.More() {
+			line := ""
+			if err = dec.Decode(&line); err != nil {
+				if isBodyTooLarge(err) {
+					truncated = true
+					break
+				}
+				return nil, false, err
+			}
+			lines = append(lines, line)
+		}
+		if truncated {
+			break
+		}
+		if err = expectDelim(dec, ']'); err != nil {
+			return nil, false, err
+		}
+	}
+	if !truncated {
+		if err := expectDelim(dec, '}'); err != nil {
+			return nil, false, err
+		}
+	}
+
+	encoded, err := json.Marshal(fields)
+	if err != nil {
+		return nil, false, err
+	}
+	log := &model.TestLog{}
+	if err = json.Unmarshal(encoded, log); err != nil {
+		return nil, false, err
+	}
+	if truncated {
+		lines = append(lines, fmt.Sprintf(truncatedTestLogMarker, limit))
+	}
+	log.Lines = lines
+	return log, truncated, nil
+}
+
+// isBodyTooLarge returns whether err came from reading past the limit of an
+// http.MaxBytesReader.
+func isBodyTooLarge(err error) bool {
+	var tooLarge *http.MaxBytesError
+	return errors.As(err, &tooLarge)
+}
+
+// expectDelim reads the next token from dec, returning an error if it isn't delim.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected '%v', got %v", delim, token)
+	}
+	return nil
+}
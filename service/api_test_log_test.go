@@ -0,0 +1,73 @@
+package service
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen/model"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestReadTruncatedTestLog(t *testing.T) {
+	Convey("When reading a test log with a size limit", t, func() {
+		body := `{"_id": "", "name": "log", "task": "t1", "execution": 2, "lines": ["aaaa", "bbbb", "cccc"]}`
+		read := func(body string, limit int64) (*model.TestLog, bool, error) {
+			return readTruncatedTestLog(httptest.NewRecorder(),
+				ioutil.NopCloser(strings.NewReader(body)), limit)
+		}
+
+		Convey("a log within the limit should be read whole", func() {
+			log, truncated, err := read(body, int64(len(body)))
+			So(err, ShouldBeNil)
+			So(truncated, ShouldBeFalse)
+			So(log.Name, ShouldEqual, "log")
+			So(log.Task, ShouldEqual, "t1")
+			So(log.TaskExecution, ShouldEqual, 2)
+			So(log.Lines, ShouldResemble, []string{"aaaa", "bbbb", "cccc"})
+		})
+		Convey("a log over the limit should keep the lines read in full and a marker", func() {
+			log, truncated, err := read(body, int64(strings.Index(body, "cccc")))
+			So(err, ShouldBeNil)
+			So(truncated, ShouldBeTrue)
+			So(log.Name, ShouldEqual, "log")
+			So(len(log.Lines), ShouldEqual, 3)
+			So(log.Lines[:2], ShouldResemble, []string{"aaaa", "bbbb"})
+			So(log.Lines[2], ShouldContainSubstring, "truncated")
+		})
+		Convey("decoding should stop at the limit even within a single line", func() {
+			huge := `{"name": "log", "lines": ["` + strings.Repeat("a", 1024*1024) + `"]}`
+			log, truncated, err := read(huge, 100)
+			So(err, ShouldBeNil)
+			So(truncated, ShouldBeTrue)
+			So(log.Name, ShouldEqual, "log")
+			So(len(log.Lines), ShouldEqual, 1)
+			So(log.Lines[0], ShouldContainSubstring, "truncated")
+		})
+		Convey("fields after the lines should only be lost if the log is truncated", func() {
+			body := `{"lines": ["aaaa", "bbbb"], "name": "log"}`
+			log, truncated, err := read(body, int64(len(body)))
+			So(err, ShouldBeNil)
+			So(truncated, ShouldBeFalse)
+			So(log.Name, ShouldEqual, "log")
+
+			log, truncated, err = read(body, 15)
+			So(err, ShouldBeNil)
+			So(truncated, ShouldBeTrue)
+			So(log.Name, ShouldEqual, "")
+		})
+		Convey("logs over the limit before their lines should be rejected", func() {
+			_, _, err := read(body, 10)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("malformed logs should be rejected", func() {
+			_, _, err := read(`["aaaa"]`, 100)
+			So(err, ShouldNotBeNil)
+			_, _, err = read(`{"lines": [1, 2]}`, 100)
+			So(err, ShouldNotBeNil)
+			_, _, err = read(`{"lines": ["aaaa"`, 100)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen/testutil"
+	"github.com/evergreen-ci/evergreen/util"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTLSListenerHTTP2(t *testing.T) {
+	Convey("With a TLS listener that has HTTP/2 enabled", t, func() {
+		settings := testutil.TestConfig()
+		tlsConfig, err := util.MakeTlsConfig(settings.Api.HttpsCert, settings.Api.HttpsKey)
+		So(err, ShouldBeNil)
+		util.EnableHTTP2(tlsConfig)
+		So(tlsConfig.NextProtos[0], ShouldEqual, "h2")
+
+		l, err := GetTLSListener("localhost:0", tlsConfig)
+		So(err, ShouldBeNil)
+		defer l.Close()
+
+		go Serve(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			fmt.Fprintf(w, "%d", len(body))
+		}))
+
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			ForceAttemptHTTP2: true,
+		}}
+
+		Convey("a large upload should be received intact over an h2 stream", func() {
+			payload := bytes.Repeat([]byte("log line\n"), 2*1024*1024)
+			resp, err := client.Post(fmt.Sprintf("https://%s/", l.Addr()), "text/plain", bytes.NewReader(payload))
+			So(err, ShouldBeNil)
+			defer resp.Body.Close()
+
+			So(resp.ProtoMajor, ShouldEqual, 2)
+			body, err := ioutil.ReadAll(resp.Body)
+			So(err, ShouldBeNil)
+			So(string(body), ShouldEqual, fmt.Sprintf("%d", len(payload)))
+		})
+	})
+}
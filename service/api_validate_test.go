@@ -0,0 +1,89 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/version"
+	"github.com/evergreen-ci/evergreen/plugin"
+	"github.com/evergreen-ci/evergreen/testutil"
+	"github.com/evergreen-ci/evergreen/validator"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestValidateProjectConfigById(t *testing.T) {
+	testConfig := testutil.TestConfig()
+	testApiServer, err := CreateTestServer(testConfig, nil, plugin.APIPlugins, true)
+	testutil.HandleTestingErr(err, t, "failed to create new API server")
+	defer testApiServer.Close()
+
+	const url = "http://localhost:8181/api/validate/"
+
+	validate := func(id string) (int, []validator.ValidationError) {
+		request, err := http.NewRequest("GET", url+id, nil)
+		So(err, ShouldBeNil)
+		request.AddCookie(&http.Cookie{Name: evergreen.AuthTokenCookie, Value: "token"})
+		resp, err := http.DefaultClient.Do(request)
+		testutil.HandleTestingErr(err, t, "problem making request")
+		defer resp.Body.Close()
+		errs := []validator.ValidationError{}
+		if resp.StatusCode != http.StatusNotFound {
+			So(json.NewDecoder(resp.Body).Decode(&errs), ShouldBeNil)
+		}
+		return resp.StatusCode, errs
+	}
+
+	Convey("With a project whose versions have different configurations", t, func() {
+		testutil.HandleTestingErr(db.ClearCollections(model.ProjectRefCollection, version.Collection,
+			distro.Collection), t, "error clearing collections")
+
+		So((&distro.Distro{Id: "ubuntu"}).Insert(), ShouldBeNil)
+
+		projectRef := &model.ProjectRef{Identifier: "project", Enabled: true}
+		So(projectRef.Insert(), ShouldBeNil)
+
+		good := &version.Version{
+			Id:                  "good",
+			Identifier:          "project",
+			Requester:           evergreen.RepotrackerVersionRequester,
+			RevisionOrderNumber: 1,
+			Config: "tasks:\n- name: compile\n  commands:\n  - command: shell.exec\n" +
+				"    params:\n      script: echo hi\n" +
+				"buildvariants:\n- name: ubuntu\n  run_on: [ubuntu]\n  tasks:\n  - name: compile\n",
+		}
+		So(good.Insert(), ShouldBeNil)
+
+		Convey("the most recent configuration should be validated", func() {
+			code, errs := validate("project")
+			So(code, ShouldEqual, http.StatusOK)
+			So(len(errs), ShouldEqual, 0)
+		})
+
+		Convey("a most recent configuration that can't be parsed should get a 400,"+
+			" even though an older one is valid", func() {
+			broken := &version.Version{
+				Id:                  "broken",
+				Identifier:          "project",
+				Requester:           evergreen.RepotrackerVersionRequester,
+				RevisionOrderNumber: 2,
+				Config:              "buildvariants: [",
+				Errors:              []string{"yaml error"},
+			}
+			So(broken.Insert(), ShouldBeNil)
+
+			code, errs := validate("project")
+			So(code, ShouldEqual, http.StatusBadRequest)
+			So(len(errs), ShouldEqual, 1)
+		})
+
+		Convey("unknown projects should get a 404", func() {
+			code, _ := validate("unknown")
+			So(code, ShouldEqual, http.StatusNotFound)
+		})
+	})
+}
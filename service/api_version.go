@@ -0,0 +1,108 @@
+package service
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/context"
+)
+
+const (
+	// APIVersionHeader is the response header reporting which schema version the
+	// response body is in.
+	APIVersionHeader = "X-Evergreen-API-Version"
+
+	// DefaultAPIVersion is the schema version used when a client doesn't ask for one.
+	DefaultAPIVersion = 1
+
+	// apiVersionMediaTypePrefix is the prefix of vendor media types that request a
+	// schema version, e.g. "application/vnd.evergreen.v1+json".
+	apiVersionMediaTypePrefix = "application/vnd.evergreen.v"
+)
+
+type apiVersionKey int
+
+const RequestAPIVersion apiVersionKey = 0
+
+// apiSchemas converts response documents into the shape of each supported schema
+// version. Version 1 is the documents' own shape; a later version needs only to
+// convert the document types whose shape it changes, passing others through.
+// Version 2 has list endpoints respond with pages of their items; see writePage.
+var apiSchemas = map[int]func(doc interface{}) interface{}{
+	1: func(doc interface{}) interface{} { return doc },
+	2: func(doc interface{}) interface{} { return doc },
+}
+
+// APIVersionMiddleware is a negroni middleware that determines which schema version
+// a client wants responses in from its Accept header, records it on the request for
+// handlers to use, and reports it in the response's version header. Clients that
+// ask for an unsupported version get 406 Not Acceptable.
+type APIVersionMiddleware struct{}
+
+// NewAPIVersionMiddleware returns an APIVersionMiddleware.
+func NewAPIVersionMiddleware() *APIVersionMiddleware {
+	return &APIVersionMiddleware{}
+}
+
+func (m *APIVersionMiddleware) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	version, err := requestedAPIVersion(r.Header.Get("Accept"))
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusNotAcceptable)
+		return
+	}
+	if _, ok := apiSchemas[version]; !ok {
+		http.Error(rw, fmt.Sprintf("API version %v is not supported", version), http.StatusNotAcceptable)
+		return
+	}
+	context.Set(r, RequestAPIVersion, version)
+	rw.Header().Set(APIVersionHeader, strconv.Itoa(version))
+	next(rw, r)
+}
+
+// requestedAPIVersion returns the schema version requested by an Accept header,
+// either through a vendor media type ("application/vnd.evergreen.v2+json") or a
+// version parameter ("application/json; version=2"). Returns the default version
+// if the header doesn't request one.
+func requestedAPIVersion(accept string) (int, error) {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+		versionString := params["version"]
+		if strings.HasPrefix(mediaType, apiVersionMediaTypePrefix) {
+			versionString = strings.TrimSuffix(
+				strings.TrimPrefix(mediaType, apiVersionMediaTypePrefix), "+json")
+		}
+		if versionString == "" {
+			continue
+		}
+		version, err := strconv.Atoi(versionString)
+		if err != nil || version <= 0 {
+			return 0, fmt.Errorf("invalid API version '%v'", versionString)
+		}
+		return version, nil
+	}
+	return DefaultAPIVersion, nil
+}
+
+// GetAPIVersion returns the schema version negotiated for the request, or the
+// default version if none was.
+func GetAPIVersion(r *http.Request) int {
+	if rv := context.Get(r, RequestAPIVersion); rv != nil {
+		return rv.(int)
+	}
+	return DefaultAPIVersion
+}
+
+// WriteVersionedJSON writes data as JSON in the schema version negotiated for the request.
+func (as *APIServer) WriteVersionedJSON(w http.ResponseWriter, r *http.Request, status int, data interface{}) {
+	schema, ok := apiSchemas[GetAPIVersion(r)]
+	if !ok {
+		schema = apiSchemas[DefaultAPIVersion]
+	}
+	as.WriteJSON(w, status, schema(data))
+}
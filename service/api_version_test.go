@@ -0,0 +1,75 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/codegangsta/negroni"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRequestedAPIVersion(t *testing.T) {
+	Convey("The requested API version should be read from the Accept header", t, func() {
+		for accept, expected := range map[string]int{
+			"":                                      DefaultAPIVersion,
+			"application/json":                      DefaultAPIVersion,
+			"*/*":                                   DefaultAPIVersion,
+			"application/vnd.evergreen.v1+json":     1,
+			"application/vnd.evergreen.v3+json":     3,
+			"application/json; version=2":           2,
+			"text/html, application/json;version=4": 4,
+		} {
+			version, err := requestedAPIVersion(accept)
+			So(err, ShouldBeNil)
+			So(version, ShouldEqual, expected)
+		}
+	})
+
+	Convey("Malformed versions should be rejected", t, func() {
+		for _, accept := range []string{
+			"application/vnd.evergreen.vX+json",
+			"application/json; version=0",
+		} {
+			_, err := requestedAPIVersion(accept)
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
+
+func TestAPIVersionMiddleware(t *testing.T) {
+	Convey("With a handler wrapped in the API version middleware", t, func() {
+		n := negroni.New(NewAPIVersionMiddleware())
+		n.UseHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(strconv.Itoa(GetAPIVersion(r))))
+		}))
+		serve := func(accept string) *httptest.ResponseRecorder {
+			request, err := http.NewRequest("GET", "/", nil)
+			So(err, ShouldBeNil)
+			request.Header.Set("Accept", accept)
+			w := httptest.NewRecorder()
+			n.ServeHTTP(w, request)
+			return w
+		}
+
+		Convey("requests without a version should get the default version", func() {
+			w := serve("application/json")
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Header().Get(APIVersionHeader), ShouldEqual, "1")
+			So(w.Body.String(), ShouldEqual, "1")
+		})
+
+		Convey("requests for a supported version should get that version", func() {
+			w := serve("application/vnd.evergreen.v1+json")
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Header().Get(APIVersionHeader), ShouldEqual, "1")
+		})
+
+		Convey("requests for an unsupported version should be refused", func() {
+			w := serve("application/vnd.evergreen.v99+json")
+			So(w.Code, ShouldEqual, http.StatusNotAcceptable)
+			So(w.Header().Get(APIVersionHeader), ShouldBeBlank)
+		})
+	})
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mongodb/grip"
+)
+
+// gzipMinSize is the smallest response body, in bytes, that is worth compressing.
+const gzipMinSize = 1024
+
+// GzipMiddleware is a negroni middleware that gzip-compresses response bodies for
+// clients that accept gzip encoding. Bodies smaller than the minimum size are sent
+// uncompressed, as are responses to protocol upgrade (e.g. WebSocket) requests.
+type GzipMiddleware struct {
+	minSize int
+}
+
+// NewGzipMiddleware returns a GzipMiddleware that only compresses response bodies of
+// at least minSize bytes.
+func NewGzipMiddleware(minSize int) *GzipMiddleware {
+	return &GzipMiddleware{minSize: minSize}
+}
+
+func (gm *GzipMiddleware) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if r.Header.Get("Upgrade") != "" || r.Method == "HEAD" {
+		next(rw, r)
+		return
+	}
+	rw.Header().Add("Vary", "Accept-Encoding")
+	if !acceptsGzip(r) {
+		next(rw, r)
+		return
+	}
+
+	gw := &gzipResponseWriter{ResponseWriter: rw, minSize: gm.minSize}
+	next(gw, r)
+	gw.finish()
+}
+
+// acceptsGzip returns true if the request's Accept-Encoding header permits gzip.
+func acceptsGzip(r *http.Request) bool {
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		parts := strings.Split(enc, ";")
+		name := strings.TrimSpace(parts[0])
+		if name != "gzip" && name != "*" {
+			continue
+		}
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+			if q, err := strconv.ParseFloat(param[len("q="):], 64); err == nil && q == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// writeGzipped compresses a whole response body and writes it with the headers of a
+// gzip-encoded response, including its compressed length. The caller sets any
+// other headers, such as the content type, beforehand.
+func writeGzipped(w http.ResponseWriter, body []byte) error {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	if _, err := gz.Write(body); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Set("Content-Length", strconv.Itoa(compressed.Len()))
+	w.WriteHeader(http.StatusOK)
+	_, err := w.Write(compressed.Bytes())
+	return err
+}
+
+// gzipResponseWriter buffers the start of a response until it knows whether the
+// body is large enough to compress, then either compresses or passes through
+// everything written to it.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	minSize int
+
+	code    int
+	buf     []byte
+	decided bool
+	gz      *gzip.Writer
+}
+
+func (gw *gzipResponseWriter) WriteHeader(code int) {
+	if gw.code == 0 {
+		gw.code = code
+	}
+}
+
+func (gw *gzipResponseWriter) Write(p []byte) (int, error) {
+	if gw.decided {
+		if gw.gz != nil {
+			return gw.gz.Write(p)
+		}
+		return gw.ResponseWriter.Write(p)
+	}
+
+	gw.buf = append(gw.buf, p...)
+	if len(gw.buf) >= gw.minSize {
+		if err := gw.decide(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush sends any buffered data to the client. If the body hasn't reached the
+// minimum size by the time the handler flushes, it is sent uncompressed.
+func (gw *gzipResponseWriter) Flush() {
+	if !gw.decided {
+		if err := gw.decide(); err != nil {
+			grip.Warningf("Error flushing response: %+v", err)
+			return
+		}
+	}
+	if gw.gz != nil {
+		if err := gw.gz.Flush(); err != nil {
+			grip.Warningf("Error flushing compressed response: %+v", err)
+		}
+	}
+	if f, ok := gw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// decide writes the response headers, choosing whether to compress the body
+// based on how much of it has been buffered, and then writes out the buffer.
+func (gw *gzipResponseWriter) decide() error {
+	gw.decided = true
+	header := gw.Header()
+	compress := len(gw.buf) >= gw.minSize &&
+		header.Get("Content-Encoding") == "" &&
+		gw.code != http.StatusNoContent && gw.code != http.StatusNotModified
+	if compress {
+		if header.Get("Content-Type") == "" {
+			header.Set("Content-Type", http.DetectContentType(gw.buf))
+		}
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		gw.gz = gzip.NewWriter(gw.ResponseWriter)
+	}
+	if gw.code != 0 {
+		gw.ResponseWriter.WriteHeader(gw.code)
+	}
+
+	buf := gw.buf
+	gw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if gw.gz != nil {
+		_, err = gw.gz.Write(buf)
+	} else {
+		_, err = gw.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// finish writes out anything still buffered once the handler has returned.
+func (gw *gzipResponseWriter) finish() {
+	if !gw.decided {
+		if gw.code == 0 && len(gw.buf) == 0 {
+			// nothing was written, so leave the response untouched
+			return
+		}
+		if err := gw.decide(); err != nil {
+			grip.Warningf("Error writing response: %+v", err)
+			return
+		}
+	}
+	if gw.gz != nil {
+		if err := gw.gz.Close(); err != nil {
+			grip.Warningf("Error closing compressed response: %+v", err)
+		}
+	}
+}
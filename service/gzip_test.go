@@ -0,0 +1,103 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/codegangsta/negroni"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGzipMiddleware(t *testing.T) {
+	Convey("With a handler wrapped in the gzip middleware", t, func() {
+		large := strings.Repeat("evergreen ", 500)
+		small := "evergreen"
+		n := negroni.New(NewGzipMiddleware(gzipMinSize))
+		n.UseHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			if r.URL.Path == "/large" {
+				w.Write([]byte(large))
+			} else {
+				w.Write([]byte(small))
+			}
+		}))
+		serve := func(path, acceptEncoding string) *httptest.ResponseRecorder {
+			request, err := http.NewRequest("GET", path, nil)
+			So(err, ShouldBeNil)
+			if acceptEncoding != "" {
+				request.Header.Set("Accept-Encoding", acceptEncoding)
+			}
+			w := httptest.NewRecorder()
+			n.ServeHTTP(w, request)
+			return w
+		}
+
+		Convey("large responses should be compressed for clients that accept gzip", func() {
+			w := serve("/large", "deflate, gzip")
+			So(w.Code, ShouldEqual, http.StatusCreated)
+			So(w.Header().Get("Content-Encoding"), ShouldEqual, "gzip")
+			So(w.Header().Get("Vary"), ShouldEqual, "Accept-Encoding")
+			reader, err := gzip.NewReader(w.Body)
+			So(err, ShouldBeNil)
+			body, err := ioutil.ReadAll(reader)
+			So(err, ShouldBeNil)
+			So(string(body), ShouldEqual, large)
+		})
+
+		Convey("small responses should not be compressed", func() {
+			w := serve("/small", "gzip")
+			So(w.Code, ShouldEqual, http.StatusCreated)
+			So(w.Header().Get("Content-Encoding"), ShouldEqual, "")
+			So(w.Body.String(), ShouldEqual, small)
+		})
+
+		Convey("responses should not be compressed for clients that don't accept gzip", func() {
+			w := serve("/large", "")
+			So(w.Header().Get("Content-Encoding"), ShouldEqual, "")
+			So(w.Body.String(), ShouldEqual, large)
+
+			w = serve("/large", "gzip;q=0, identity")
+			So(w.Header().Get("Content-Encoding"), ShouldEqual, "")
+			So(w.Body.String(), ShouldEqual, large)
+		})
+
+		Convey("upgrade requests should be passed through untouched", func() {
+			request, err := http.NewRequest("GET", "/large", nil)
+			So(err, ShouldBeNil)
+			request.Header.Set("Accept-Encoding", "gzip")
+			request.Header.Set("Upgrade", "websocket")
+			w := httptest.NewRecorder()
+			n.ServeHTTP(w, request)
+			So(w.Header().Get("Content-Encoding"), ShouldEqual, "")
+			So(bytes.Equal(w.Body.Bytes(), []byte(large)), ShouldBeTrue)
+		})
+	})
+}
+
+func TestWriteGzipped(t *testing.T) {
+	Convey("Writing a compressed body", t, func() {
+		body := strings.Repeat("evergreen ", 500)
+		w := httptest.NewRecorder()
+		So(writeGzipped(w, []byte(body)), ShouldBeNil)
+
+		Convey("should set the encoding and the compressed length", func() {
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Header().Get("Content-Encoding"), ShouldEqual, "gzip")
+			So(w.Header().Get("Content-Length"), ShouldEqual, strconv.Itoa(w.Body.Len()))
+			So(w.Body.Len(), ShouldBeLessThan, len(body))
+		})
+		Convey("should decompress to the original body", func() {
+			reader, err := gzip.NewReader(w.Body)
+			So(err, ShouldBeNil)
+			decompressed, err := ioutil.ReadAll(reader)
+			So(err, ShouldBeNil)
+			So(string(decompressed), ShouldEqual, body)
+		})
+	})
+}
@@ -73,10 +73,11 @@ func (uis *UIServer) hostPage(w http.ResponseWriter, r *http.Request) {
 		Flashes     []interface{}
 		Events      []event.Event
 		Host        *host.Host
+		DNSAlias    string
 		RunningTask *task.Task
 		User        *user.DBUser
 		ProjectData projectContext
-	}{flashes, events, h, runningTask, GetUser(r), projCtx},
+	}{flashes, events, h, h.DNSAlias(&uis.Settings), runningTask, GetUser(r), projCtx},
 		"base", "host.html", "base_angular.html", "menu.html")
 }
 
@@ -84,7 +85,7 @@ func (uis *UIServer) hostsPage(w http.ResponseWriter, r *http.Request) {
 	projCtx := MustHaveProjectContext(r)
 
 	includeSpawnedHosts, _ := strconv.ParseBool(r.FormValue(IncludeSpawnedHosts))
-	hosts, err := getHostsData(includeSpawnedHosts)
+	hosts, err := getHostsData(includeSpawnedHosts, &uis.Settings)
 	if err != nil {
 		uis.LoggedError(w, r, http.StatusInternalServerError, err)
 		return
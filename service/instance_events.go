@@ -0,0 +1,69 @@
+package service
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/evergreen-ci/evergreen/cloud"
+	"github.com/evergreen-ci/evergreen/cloud/providers"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/gorilla/mux"
+	"github.com/mongodb/grip"
+)
+
+// instanceEvent records the new status of the host whose instance a provider's
+// state change notification is about, so that hostinit can provision the host
+// without polling the provider. Only providers that hostinit is configured to
+// treat as event-driven are accepted.
+func (as *APIServer) instanceEvent(w http.ResponseWriter, r *http.Request) {
+	provider := mux.Vars(r)["provider"]
+	if !as.Settings.HostInit.IsEventDriven(provider) {
+		http.Error(w, fmt.Sprintf("provider '%v' is not configured to send instance events", provider),
+			http.StatusBadRequest)
+		return
+	}
+
+	cloudManager, err := providers.GetCloudManager(provider, &as.Settings)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	parser, ok := cloudManager.(cloud.InstanceEventParser)
+	if !ok {
+		http.Error(w, fmt.Sprintf("provider '%v' does not support instance events", provider),
+			http.StatusBadRequest)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	instanceId, status, err := parser.ParseInstanceEvent(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h, err := host.FindOne(host.ByInstanceId(instanceId))
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	if h == nil {
+		http.Error(w, fmt.Sprintf("no host has instance '%v'", instanceId), http.StatusNotFound)
+		return
+	}
+	if err = h.SetNotifiedStatus(status.String()); err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	grip.Debugf("Instance of host %s is now %v", h.Id, status)
+
+	as.WriteJSON(w, http.StatusOK, struct {
+		HostId string `json:"host_id"`
+		Status string `json:"status"`
+	}{HostId: h.Id, Status: status.String()})
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/cloud"
+	"github.com/evergreen-ci/evergreen/cloud/providers/mock"
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/evergreen-ci/evergreen/testutil"
+	"github.com/gorilla/mux"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestInstanceEvent(t *testing.T) {
+	testConfig := testutil.TestConfig()
+	db.SetGlobalSessionProvider(db.SessionFactoryFromConfig(testConfig))
+
+	Convey("With a host of an event-driven provider", t, func() {
+		testutil.HandleTestingErr(db.Clear(host.Collection), t, "error clearing hosts")
+		h := &host.Host{Id: "sir-1", InstanceId: "i-1", Provider: mock.ProviderName,
+			Status: evergreen.HostUninitialized}
+		So(h.Insert(), ShouldBeNil)
+
+		as := &APIServer{Settings: *testConfig}
+		as.Settings.HostInit.EventDrivenProviders = []string{mock.ProviderName}
+		router := mux.NewRouter()
+		router.HandleFunc("/instance_events/{provider}", as.instanceEvent).Methods("POST")
+
+		notify := func(provider string, event interface{}) *httptest.ResponseRecorder {
+			body, err := json.Marshal(event)
+			So(err, ShouldBeNil)
+			request, err := http.NewRequest("POST", "/instance_events/"+provider, bytes.NewReader(body))
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, request)
+			return w
+		}
+
+		Convey("a notification about its instance should record the instance's status", func() {
+			w := notify(mock.ProviderName, mock.MockInstanceEvent{InstanceId: "i-1", Status: cloud.StatusRunning.String()})
+			So(w.Code, ShouldEqual, http.StatusOK)
+			found, err := host.FindOne(host.ById(h.Id))
+			So(err, ShouldBeNil)
+			So(found.NotifiedStatus, ShouldEqual, cloud.StatusRunning.String())
+		})
+
+		Convey("a notification about an unknown instance should not be found", func() {
+			w := notify(mock.ProviderName, mock.MockInstanceEvent{InstanceId: "i-2", Status: cloud.StatusRunning.String()})
+			So(w.Code, ShouldEqual, http.StatusNotFound)
+		})
+
+		Convey("a malformed notification should be rejected", func() {
+			w := notify(mock.ProviderName, mock.MockInstanceEvent{Status: cloud.StatusRunning.String()})
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("notifications from providers that aren't event-driven should be rejected", func() {
+			as.Settings.HostInit.EventDrivenProviders = nil
+			w := notify(mock.ProviderName, mock.MockInstanceEvent{InstanceId: "i-1", Status: cloud.StatusRunning.String()})
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			found, err := host.FindOne(host.ById(h.Id))
+			So(err, ShouldBeNil)
+			So(found.NotifiedStatus, ShouldEqual, "")
+		})
+	})
+}
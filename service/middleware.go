@@ -1,9 +1,14 @@
 package service
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"runtime/debug"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/codegangsta/negroni"
@@ -183,6 +188,35 @@ func (uis *UIServer) requireSuperUser(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// requireSuperUser takes a request handler and returns a wrapped version which verifies that
+// the requester is authenticated as a superuser. Since API clients can't follow a login
+// redirect, requests that fail the check receive a simple "unauthorized" error instead.
+func (as *APIServer) requireSuperUser(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !as.isSuperUser(GetUser(r)) {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// isSuperUser verifies that a given user has super user permissions
+// according to the API server's settings.
+func (as *APIServer) isSuperUser(u *user.DBUser) bool {
+	if u == nil {
+		return false
+	}
+	return auth.IsSuperUser(as.Settings.SuperUsers, u)
+}
+
+// canAccessProject verifies that a user may act on a project's tasks through the
+// API. Any user may access public projects, but only superusers and the project's
+// admins may access private ones.
+func (as *APIServer) canAccessProject(u *user.DBUser, project *model.ProjectRef) bool {
+	return !project.Private || as.isSuperUser(u) || isAdmin(u, project)
+}
+
 // canEditPatch verifies that a user has permission to edit the given patch.
 // A user has permission if they are a superuser, or if they are the author of the patch.
 func (uis *UIServer) canEditPatch(currentUser *user.DBUser, currentPatch *patch.Patch) bool {
@@ -506,14 +540,32 @@ func UserMiddleware(um auth.UserManager) func(rw http.ResponseWriter, r *http.Re
 	}
 }
 
+// SensitiveHeaders are request headers that carry credentials. Their values are
+// never written to the access logs, even if the headers are configured to be logged.
+var SensitiveHeaders = []string{
+	evergreen.TaskSecretHeader,
+	evergreen.HostSecretHeader,
+	evergreen.TaskTokenHeader,
+	"Authorization",
+	"Api-Key",
+	"Cookie",
+}
+
+// redactedHeaderValue replaces the values of sensitive headers in the access logs.
+const redactedHeaderValue = "[redacted]"
+
 // Logger is a middleware handler that logs the request as it goes in and the response as it goes out.
 type Logger struct {
 	// ids is a channel producing unique, autoincrementing request ids that are included in logs.
 	ids chan int
+
+	// headers are the canonical names of the request headers that are logged
+	headers []string
 }
 
-// NewLogger returns a new Logger instance
-func NewLogger() *Logger {
+// NewLogger returns a new Logger instance that logs the given request headers
+// along with each request, redacting the values of SensitiveHeaders.
+func NewLogger(loggedHeaders []string) *Logger {
 	ids := make(chan int, 100)
 	go func() {
 		reqId := 0
@@ -523,17 +575,210 @@ func NewLogger() *Logger {
 		}
 	}()
 
-	return &Logger{ids}
+	headers := make([]string, 0, len(loggedHeaders))
+	for _, name := range loggedHeaders {
+		headers = append(headers, http.CanonicalHeaderKey(name))
+	}
+	return &Logger{ids: ids, headers: headers}
 }
 
 func (l *Logger) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
 	start := time.Now()
 	reqId := <-l.ids
 
-	grip.Infof("Started (%v) %s %s %s", reqId, r.Method, r.URL.Path, r.RemoteAddr)
+	if headers := loggableHeaders(r.Header, l.headers); headers != "" {
+		grip.Infof("Started (%v) %s %s %s [%s]", reqId, r.Method, r.URL.Path, r.RemoteAddr, headers)
+	} else {
+		grip.Infof("Started (%v) %s %s %s", reqId, r.Method, r.URL.Path, r.RemoteAddr)
+	}
 
 	next(rw, r)
 
 	res := rw.(negroni.ResponseWriter)
 	grip.Infof("Completed (%v) %v %s in %v", reqId, res.Status(), http.StatusText(res.Status()), time.Since(start))
 }
+
+// loggableHeaders formats the values of the given headers that are set on a
+// request for logging, redacting those of SensitiveHeaders.
+func loggableHeaders(header http.Header, names []string) string {
+	logged := []string{}
+	for _, name := range names {
+		values, ok := header[name]
+		if !ok {
+			continue
+		}
+		value := strings.Join(values, ",")
+		if util.SliceContains(SensitiveHeaders, name) {
+			value = redactedHeaderValue
+		}
+		logged = append(logged, fmt.Sprintf("%s=%s", name, value))
+	}
+	return strings.Join(logged, " ")
+}
+
+// withRequestLimits wraps a handler so that its request body can be no larger than
+// limits.MaxBodyBytes, and so that a response is sent to the client if the handler
+// runs for longer than limits.TimeoutSeconds. A handler that times out keeps running
+// to completion in the background, but anything it writes afterwards is discarded.
+func withRequestLimits(limits evergreen.RequestLimits, next http.HandlerFunc) http.HandlerFunc {
+	return limitRequest(limits.MaxBodyBytes, time.Duration(limits.TimeoutSeconds)*time.Second, next)
+}
+
+// limitRequest implements withRequestLimits. Requests whose bodies are larger than
+// maxBodyBytes get 413 Request Entity Too Large, whatever the handler responded.
+// Since a handler that times out is no longer covered by the server's own panic
+// recovery, panics in handlers are recovered and answered with a 500.
+func limitRequest(maxBodyBytes int64, timeout time.Duration, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if maxBodyBytes > 0 && r.ContentLength > maxBodyBytes {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		var body *limitedBody
+		if maxBodyBytes > 0 && r.Body != nil {
+			body = &limitedBody{ReadCloser: http.MaxBytesReader(w, r.Body, maxBodyBytes), limit: maxBodyBytes}
+			r.Body = body
+		}
+		if body == nil && timeout <= 0 {
+			next(w, r)
+			return
+		}
+
+		// the handler runs against the original request, rather than a copy,
+		// so that values stored in the request context remain accessible
+		tw := &timeoutWriter{w: w, header: make(http.Header)}
+		done := make(chan struct{})
+		run := func() {
+			defer close(done)
+			defer func() {
+				if p := recover(); p != nil {
+					grip.Errorf("Panic handling request %s %s: %v\n%s", r.Method, r.URL.Path, p, debug.Stack())
+					tw.fail()
+				}
+			}()
+			next(tw, r)
+		}
+		if timeout <= 0 {
+			run()
+		} else {
+			go run()
+		}
+
+		var timedOut <-chan time.Time
+		if timeout > 0 {
+			timedOut = time.After(timeout)
+		}
+		select {
+		case <-done:
+			if body != nil && body.exceeded() {
+				tw.timeout()
+				http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			tw.flush()
+		case <-timedOut:
+			tw.timeout()
+			grip.Warningf("Request %s %s timed out after %v", r.Method, r.URL.Path, timeout)
+			http.Error(w, "request timed out", http.StatusServiceUnavailable)
+		}
+	}
+}
+
+// limitedBody is a request body limited to a number of bytes, which records
+// whether the request tried to send more.
+type limitedBody struct {
+	io.ReadCloser
+	limit int64
+
+	mu   sync.Mutex
+	read int64
+	over bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.read += int64(n)
+	// the reader returned by http.MaxBytesReader fails once the limit is passed
+	if err != nil && err != io.EOF && b.read >= b.limit {
+		b.over = true
+	}
+	return n, err
+}
+
+func (b *limitedBody) exceeded() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.over
+}
+
+// timeoutWriter buffers a handler's response so that it can be discarded if the
+// handler exceeds its time limit.
+type timeoutWriter struct {
+	w      http.ResponseWriter
+	header http.Header
+	body   bytes.Buffer
+	code   int
+
+	mu       sync.Mutex
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header { return tw.header }
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.code == 0 {
+		tw.code = http.StatusOK
+	}
+	return tw.body.Write(p)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.code != 0 {
+		return
+	}
+	tw.code = code
+}
+
+// timeout marks the writer as timed out, so that any further writes fail.
+func (tw *timeoutWriter) timeout() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.timedOut = true
+}
+
+// fail replaces the buffered response with an internal server error.
+func (tw *timeoutWriter) fail() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.header = make(http.Header)
+	tw.header.Set("Content-Type", "text/plain; charset=utf-8")
+	tw.body.Reset()
+	tw.body.WriteString("internal server error\n")
+	tw.code = http.StatusInternalServerError
+}
+
+// flush copies the buffered response to the underlying writer.
+func (tw *timeoutWriter) flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	dst := tw.w.Header()
+	for k, v := range tw.header {
+		dst[k] = v
+	}
+	if tw.code == 0 {
+		tw.code = http.StatusOK
+	}
+	tw.w.WriteHeader(tw.code)
+	if _, err := tw.w.Write(tw.body.Bytes()); err != nil {
+		grip.Warningf("Error writing buffered response: %+v", err)
+	}
+}
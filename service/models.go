@@ -80,6 +80,8 @@ type uiPatch struct {
 type uiHost struct {
 	Host        host.Host
 	RunningTask *task.Task
+	// the name the host is shown by, as set by the settings' HostDNSFormat
+	DNSAlias string
 }
 
 type uiBuild struct {
@@ -308,7 +310,7 @@ func getVersionHistory(versionId string, N int) ([]version.Version, error) {
 	return versions, nil
 }
 
-func getHostsData(includeSpawnedHosts bool) (*hostsData, error) {
+func getHostsData(includeSpawnedHosts bool, settings *evergreen.Settings) (*hostsData, error) {
 	data := &hostsData{}
 
 	// get all of the hosts
@@ -332,6 +334,7 @@ func getHostsData(includeSpawnedHosts bool) (*hostsData, error) {
 		host := uiHost{
 			Host:        dbHost,
 			RunningTask: nil,
+			DNSAlias:    dbHost.DNSAlias(settings),
 		}
 
 		uiHosts[idx] = host
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+
+	// maxPage is the highest page that may be requested, which keeps the number of
+	// results before a page from overflowing.
+	maxPage = math.MaxInt32 / maxPageSize
+
+	// pagedAPIVersion is the first API schema version in which list endpoints
+	// respond with a page of their items in a pagedResponse. Earlier versions
+	// respond with a bare list of every item.
+	pagedAPIVersion = 2
+)
+
+// pageParams is the page of results a request to a list endpoint asks for. Pages
+// are numbered from 1.
+type pageParams struct {
+	Page     int
+	PageSize int
+}
+
+// getPageParams reads the "page" and "page_size" query parameters, defaulting to
+// the first page of defaultSize results. Page sizes are capped at maxPageSize.
+func getPageParams(r *http.Request, defaultSize int) (pageParams, error) {
+	p := pageParams{Page: 1, PageSize: defaultSize}
+	query := r.URL.Query()
+	if page := query.Get("page"); page != "" {
+		n, err := strconv.Atoi(page)
+		if err != nil || n <= 0 {
+			return p, fmt.Errorf("invalid page '%v'", page)
+		}
+		if n > maxPage {
+			return p, fmt.Errorf("page %v is past the last possible page, %v", n, maxPage)
+		}
+		p.Page = n
+	}
+	if size := query.Get("page_size"); size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil || n <= 0 {
+			return p, fmt.Errorf("invalid page_size '%v'", size)
+		}
+		p.PageSize = n
+	}
+	if p.PageSize > maxPageSize {
+		p.PageSize = maxPageSize
+	}
+	return p, nil
+}
+
+// isPagedRequest returns true if the request's API schema version has list
+// endpoints respond with pages of their items.
+func isPagedRequest(r *http.Request) bool {
+	return GetAPIVersion(r) >= pagedAPIVersion
+}
+
+// wholeList returns a page holding all of a list's total items, for clients that
+// don't page.
+func wholeList(total int) pageParams {
+	return pageParams{Page: 1, PageSize: total}
+}
+
+// skip returns how many results come before the page.
+func (p pageParams) skip() int {
+	return (p.Page - 1) * p.PageSize
+}
+
+// bounds returns the indexes of the start and end of the page within a list of
+// total results.
+func (p pageParams) bounds(total int) (int, int) {
+	start := p.skip()
+	if start < 0 || start > total {
+		start = total
+	}
+	end := start + p.PageSize
+	if end > total {
+		end = total
+	}
+	return start, end
+}
+
+// hasMore returns true if there are results after the page.
+func (p pageParams) hasMore(total int) bool {
+	return p.skip()+p.PageSize < total
+}
+
+// pagedResponse is the envelope the list endpoints respond with: a page of items,
+// along with the total number of items and whether there are more pages.
+type pagedResponse struct {
+	Items    interface{} `json:"items"`
+	Total    int         `json:"total"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"page_size"`
+	HasMore  bool        `json:"has_more"`
+}
+
+// pageLinks returns a Link header with the URLs of the pages before and after the
+// requested one, if there are any.
+func pageLinks(u *url.URL, p pageParams, total int) string {
+	link := func(page int, rel string) string {
+		linkURL := *u
+		query := linkURL.Query()
+		query.Set("page", strconv.Itoa(page))
+		query.Set("page_size", strconv.Itoa(p.PageSize))
+		linkURL.RawQuery = query.Encode()
+		return fmt.Sprintf("<%v>; rel=\"%v\"", linkURL.String(), rel)
+	}
+	links := []string{}
+	if p.hasMore(total) {
+		links = append(links, link(p.Page+1, "next"))
+	}
+	if p.Page > 1 {
+		links = append(links, link(p.Page-1, "prev"))
+	}
+	return strings.Join(links, ", ")
+}
+
+// writePage responds with a page of a list endpoint's items, of total items
+// altogether, along with a Link header for the adjacent pages. Clients of API
+// versions before pagedAPIVersion get the bare list of items instead.
+func (as *APIServer) writePage(w http.ResponseWriter, r *http.Request, items interface{}, total int, p pageParams) {
+	if !isPagedRequest(r) {
+		as.WriteVersionedJSON(w, r, http.StatusOK, items)
+		return
+	}
+	if links := pageLinks(r.URL, p, total); links != "" {
+		w.Header().Set("Link", links)
+	}
+	as.WriteVersionedJSON(w, r, http.StatusOK, pagedResponse{
+		Items:    items,
+		Total:    total,
+		Page:     p.Page,
+		PageSize: p.PageSize,
+		HasMore:  p.hasMore(total),
+	})
+}
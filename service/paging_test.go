@@ -0,0 +1,111 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/codegangsta/negroni"
+	"github.com/evergreen-ci/render"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPageParams(t *testing.T) {
+	Convey("When reading the page a request asks for", t, func() {
+		Convey("the first page of the default size should be the default", func() {
+			r, _ := http.NewRequest("GET", "/api/projects", nil)
+			p, err := getPageParams(r, 10)
+			So(err, ShouldBeNil)
+			So(p, ShouldResemble, pageParams{Page: 1, PageSize: 10})
+		})
+
+		Convey("the page and page size should be read and the size capped", func() {
+			r, _ := http.NewRequest("GET", "/api/projects?page=3&page_size=100000", nil)
+			p, err := getPageParams(r, 10)
+			So(err, ShouldBeNil)
+			So(p, ShouldResemble, pageParams{Page: 3, PageSize: maxPageSize})
+		})
+
+		Convey("invalid pages and page sizes should be errors", func() {
+			for _, query := range []string{"page=0", "page=x", "page_size=-1", "page=9223372036854775807&page_size=1000"} {
+				r, _ := http.NewRequest("GET", "/api/projects?"+query, nil)
+				_, err := getPageParams(r, 10)
+				So(err, ShouldNotBeNil)
+			}
+		})
+	})
+
+	Convey("With 25 items in pages of 10", t, func() {
+		Convey("the middle page should have links to both sides", func() {
+			p := pageParams{Page: 2, PageSize: 10}
+			start, end := p.bounds(25)
+			So(start, ShouldEqual, 10)
+			So(end, ShouldEqual, 20)
+			So(p.hasMore(25), ShouldBeTrue)
+
+			u, _ := url.Parse("/api/tasks/project?omit_definitions=true&page=2")
+			links := pageLinks(u, p, 25)
+			So(links, ShouldContainSubstring, `page=3&page_size=10>; rel="next"`)
+			So(links, ShouldContainSubstring, `page=1&page_size=10>; rel="prev"`)
+			So(links, ShouldContainSubstring, "omit_definitions=true")
+		})
+
+		Convey("the last page should be partial and only link back", func() {
+			p := pageParams{Page: 3, PageSize: 10}
+			start, end := p.bounds(25)
+			So(end-start, ShouldEqual, 5)
+			So(p.hasMore(25), ShouldBeFalse)
+			u, _ := url.Parse("/api/projects")
+			So(strings.Contains(pageLinks(u, p, 25), "next"), ShouldBeFalse)
+		})
+
+		Convey("a page past the end should be empty", func() {
+			start, end := pageParams{Page: 5, PageSize: 10}.bounds(25)
+			So(start, ShouldEqual, end)
+			start, end = pageParams{Page: maxPage, PageSize: maxPageSize}.bounds(25)
+			So(start, ShouldEqual, 25)
+			So(end, ShouldEqual, 25)
+		})
+	})
+}
+
+func TestWritePage(t *testing.T) {
+	Convey("With a page of items", t, func() {
+		as := &APIServer{Render: render.New(render.Options{})}
+		items := []string{"a", "b"}
+		p := pageParams{Page: 1, PageSize: 2}
+		serve := func(accept string) *httptest.ResponseRecorder {
+			n := negroni.New(NewAPIVersionMiddleware())
+			n.UseHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				as.writePage(w, r, items, 3, p)
+			}))
+			r, _ := http.NewRequest("GET", "/api/projects", nil)
+			r.Header.Set("Accept", accept)
+			w := httptest.NewRecorder()
+			n.ServeHTTP(w, r)
+			return w
+		}
+
+		Convey("clients that don't page should get the bare list", func() {
+			w := serve("application/json")
+			So(w.Code, ShouldEqual, http.StatusOK)
+			list := []string{}
+			So(json.Unmarshal(w.Body.Bytes(), &list), ShouldBeNil)
+			So(list, ShouldResemble, items)
+			So(w.Header().Get("Link"), ShouldBeBlank)
+		})
+
+		Convey("clients of the paged version should get the envelope and links", func() {
+			w := serve("application/vnd.evergreen.v2+json")
+			So(w.Code, ShouldEqual, http.StatusOK)
+			page := map[string]interface{}{}
+			So(json.Unmarshal(w.Body.Bytes(), &page), ShouldBeNil)
+			So(page["total"], ShouldEqual, 3)
+			So(page["has_more"], ShouldBeTrue)
+			So(w.Header().Get("Link"), ShouldContainSubstring, `rel="next"`)
+		})
+	})
+}
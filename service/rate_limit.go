@@ -0,0 +1,80 @@
+package service
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+)
+
+// rateLimitPruneInterval is how often a rateLimiter forgets the windows of keys
+// that have stopped making requests.
+const rateLimitPruneInterval = 10 * time.Minute
+
+// rateLimiter counts the requests made under each key, such as a project or a
+// host, in fixed windows, so that clients exceeding their rate limit can be
+// turned away until their window ends. A nil rateLimiter allows every request.
+type rateLimiter struct {
+	mu        sync.Mutex
+	windows   map[string]rateWindow
+	lastPrune time.Time
+}
+
+type rateWindow struct {
+	end   time.Time
+	count int
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{windows: map[string]rateWindow{}, lastPrune: time.Now()}
+}
+
+// allow records a request made under key at now, and reports whether it is within
+// the limit. If it isn't, allow also returns how long remains until the key's
+// window ends.
+func (l *rateLimiter) allow(key string, limit evergreen.RateLimit, now time.Time) (bool, time.Duration) {
+	if l == nil || limit.MaxRequests <= 0 || limit.PeriodSeconds <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastPrune) > rateLimitPruneInterval {
+		for k, w := range l.windows {
+			if !now.Before(w.end) {
+				delete(l.windows, k)
+			}
+		}
+		l.lastPrune = now
+	}
+
+	w, ok := l.windows[key]
+	if !ok || !now.Before(w.end) {
+		w = rateWindow{end: now.Add(time.Duration(limit.PeriodSeconds) * time.Second)}
+	}
+	if w.count >= limit.MaxRequests {
+		return false, w.end.Sub(now)
+	}
+	w.count++
+	l.windows[key] = w
+	return true, 0
+}
+
+// rejectOverLimit records a request made under key and, if it exceeds the limit,
+// responds with a 429 and a Retry-After header and returns true.
+func (l *rateLimiter) rejectOverLimit(w http.ResponseWriter, key string, limit evergreen.RateLimit) bool {
+	ok, retryAfter := l.allow(key, limit, time.Now())
+	if ok {
+		return false
+	}
+	secs := int((retryAfter + time.Second - 1) / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(secs))
+	http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+	return true
+}
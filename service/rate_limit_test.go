@@ -0,0 +1,53 @@
+package service
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRateLimiter(t *testing.T) {
+	Convey("With a limit of two requests a minute", t, func() {
+		l := newRateLimiter()
+		limit := evergreen.RateLimit{MaxRequests: 2, PeriodSeconds: 60}
+		now := time.Now()
+
+		Convey("requests beyond the limit should be rejected until the window ends", func() {
+			ok, _ := l.allow("p1", limit, now)
+			So(ok, ShouldBeTrue)
+			ok, _ = l.allow("p1", limit, now.Add(time.Second))
+			So(ok, ShouldBeTrue)
+			ok, retryAfter := l.allow("p1", limit, now.Add(10*time.Second))
+			So(ok, ShouldBeFalse)
+			So(retryAfter, ShouldEqual, 50*time.Second)
+
+			ok, _ = l.allow("p1", limit, now.Add(time.Minute))
+			So(ok, ShouldBeTrue)
+		})
+		Convey("each key should be limited separately", func() {
+			l.allow("p1", limit, now)
+			l.allow("p1", limit, now)
+			ok, _ := l.allow("p2", limit, now)
+			So(ok, ShouldBeTrue)
+		})
+		Convey("a zero limit or a nil limiter should allow every request", func() {
+			for i := 0; i < 5; i++ {
+				ok, _ := l.allow("p1", evergreen.RateLimit{}, now)
+				So(ok, ShouldBeTrue)
+				ok, _ = (*rateLimiter)(nil).allow("p1", limit, now)
+				So(ok, ShouldBeTrue)
+			}
+		})
+		Convey("rejected requests should get a 429 with a Retry-After header", func() {
+			limit.MaxRequests = 1
+			So(l.rejectOverLimit(httptest.NewRecorder(), "p1", limit), ShouldBeFalse)
+			w := httptest.NewRecorder()
+			So(l.rejectOverLimit(w, "p1", limit), ShouldBeTrue)
+			So(w.Code, ShouldEqual, 429)
+			So(w.Header().Get("Retry-After"), ShouldEqual, "60")
+		})
+	})
+}
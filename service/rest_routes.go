@@ -23,6 +23,9 @@ type restAPIService interface {
 
 type restAPI struct {
 	restAPIService
+
+	// projectLimiter enforces the rate limits of the projects requests are about
+	projectLimiter *rateLimiter
 }
 
 // loadCtx is a pre-request wrapper function that populates a model.Context from request vars,
@@ -51,6 +54,14 @@ func (ra *restAPI) loadCtx(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
+		if ctx.ProjectRef != nil {
+			settings := ra.GetSettings()
+			identifier := ctx.ProjectRef.Identifier
+			if ra.projectLimiter.rejectOverLimit(w, identifier, settings.Api.ProjectRateLimit(identifier)) {
+				return
+			}
+		}
+
 		context.Set(r, RestContext, &ctx)
 		next(w, r)
 	}
@@ -80,7 +91,7 @@ func AttachRESTHandler(root *mux.Router, service restAPIService) http.Handler {
 	rtr := root.PathPrefix("/rest/v1/").Subrouter().StrictSlash(true)
 
 	// REST routes
-	rest := restAPI{service}
+	rest := restAPI{restAPIService: service, projectLimiter: newRateLimiter()}
 
 	//restRouter := root.PathPrefix("/rest/v1/").Subrouter().StrictSlash(true)
 	rtr.HandleFunc("/projects", rest.loadCtx(rest.getProjectIds)).Name("project_list").Methods("GET")
@@ -91,6 +102,7 @@ func AttachRESTHandler(root *mux.Router, service restAPIService) http.Handler {
 	rtr.HandleFunc("/projects/{project_id}/last_green", rest.loadCtx(rest.lastGreen)).Name("last_green_version").Methods("GET")
 	rtr.HandleFunc("/patches/{patch_id}", rest.loadCtx(rest.getPatch)).Name("patch_info").Methods("GET")
 	rtr.HandleFunc("/patches/{patch_id}/config", rest.loadCtx(rest.getPatchConfig)).Name("patch_config").Methods("GET")
+	rtr.HandleFunc("/versions", rest.loadCtx(rest.getVersionsInfo)).Name("versions_info").Methods("GET")
 	rtr.HandleFunc("/versions/{version_id}", rest.loadCtx(rest.getVersionInfo)).Name("version_info").Methods("GET")
 	rtr.HandleFunc("/versions/{version_id}", requireUser(rest.loadCtx(rest.modifyVersionInfo), nil)).Name("").Methods("PATCH")
 	rtr.HandleFunc("/versions/{version_id}/status", rest.loadCtx(rest.getVersionStatus)).Name("version_status").Methods("GET")
@@ -99,6 +111,7 @@ func AttachRESTHandler(root *mux.Router, service restAPIService) http.Handler {
 	rtr.HandleFunc("/builds/{build_id}/status", rest.loadCtx(rest.getBuildStatus)).Name("build_status").Methods("GET")
 	rtr.HandleFunc("/tasks/{task_id}", rest.loadCtx(rest.getTaskInfo)).Name("task_info").Methods("GET")
 	rtr.HandleFunc("/tasks/{task_id}/status", rest.loadCtx(rest.getTaskStatus)).Name("task_status").Methods("GET")
+	rtr.HandleFunc("/tasks/{task_id}/test_logs", rest.loadCtx(rest.getTaskTestLogs)).Name("task_test_logs").Methods("GET")
 	rtr.HandleFunc("/tasks/{task_name}/history", rest.loadCtx(rest.getTaskHistory)).Name("task_history").Methods("GET")
 	rtr.HandleFunc("/scheduler/host_utilization", rest.loadCtx(rest.getHostUtilizationStats)).Name("host_utilization").Methods("GET")
 	rtr.HandleFunc("/scheduler/distro/{distro_id}/stats", rest.loadCtx(rest.getAverageSchedulerStats)).Name("avg_stats").Methods("GET")
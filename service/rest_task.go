@@ -3,6 +3,8 @@ package service
 import (
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/evergreen-ci/evergreen/model"
@@ -216,3 +218,67 @@ func (restapi restAPI) getTaskStatus(w http.ResponseWriter, r *http.Request) {
 	return
 
 }
+
+// restTestLog describes a test log without its lines, which are at its URL.
+type restTestLog struct {
+	Id       string            `json:"id"`
+	Name     string            `json:"name"`
+	URL      string            `json:"url"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// testLogMetadataParamPrefix prefixes the query parameters that filter test logs by
+// their metadata, e.g. "metadata.framework=jstest".
+const testLogMetadataParamPrefix = "metadata."
+
+// getTaskTestLogs returns a JSON response listing the test logs of the specified
+// task, for its latest execution unless the "execution" query parameter gives
+// another, filtered to those whose metadata matches every metadata parameter.
+func (restapi restAPI) getTaskTestLogs(w http.ResponseWriter, r *http.Request) {
+	projCtx := MustHaveRESTContext(r)
+	task := projCtx.Task
+	if task == nil {
+		restapi.WriteJSON(w, http.StatusNotFound, responseError{Message: "error finding task"})
+		return
+	}
+
+	execution := task.Execution
+	if param := r.FormValue("execution"); param != "" {
+		var err error
+		if execution, err = strconv.Atoi(param); err != nil {
+			restapi.WriteJSON(w, http.StatusBadRequest,
+				responseError{Message: "execution must be an integer"})
+			return
+		}
+	}
+	metadata := map[string]string{}
+	for param, values := range r.URL.Query() {
+		if !strings.HasPrefix(param, testLogMetadataParamPrefix) || len(values) == 0 {
+			continue
+		}
+		key := strings.TrimPrefix(param, testLogMetadataParamPrefix)
+		if err := model.ValidateTestLogMetadataKey(key); err != nil {
+			restapi.WriteJSON(w, http.StatusBadRequest, responseError{Message: err.Error()})
+			return
+		}
+		metadata[key] = values[0]
+	}
+
+	logs, err := model.FindTestLogsByMetadata(task.Id, execution, metadata)
+	if err != nil {
+		msg := fmt.Sprintf("Error finding test logs for task '%v': %v", task.Id, err)
+		grip.Error(msg)
+		restapi.WriteJSON(w, http.StatusInternalServerError, responseError{Message: msg})
+		return
+	}
+	result := make([]restTestLog, 0, len(logs))
+	for _, log := range logs {
+		result = append(result, restTestLog{
+			Id:       log.Id,
+			Name:     log.Name,
+			URL:      log.URL(),
+			Metadata: log.Metadata,
+		})
+	}
+	restapi.WriteJSON(w, http.StatusOK, result)
+}
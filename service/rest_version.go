@@ -19,6 +19,9 @@ import (
 
 const NumRecentVersions = 10
 
+// maxBatchVersions is the most versions that may be fetched with one request.
+const maxBatchVersions = 100
+
 type recentVersionsContent struct {
 	Project  string            `json:"project"`
 	Versions []versionLessInfo `json:"versions"`
@@ -210,6 +213,89 @@ func (restapi restAPI) getVersionInfo(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
+type versionsInfoContent struct {
+	Versions []restVersion `json:"versions"`
+	// the requested ids of versions that don't exist or aren't visible
+	NotFound []string `json:"not_found,omitempty"`
+}
+
+// getVersionsInfo returns a JSON response with the versions whose ids are given by
+// the "ids" query parameter, which may be repeated or comma-separated, in the order
+// requested and without their configs. Versions that don't exist, or belong to
+// private projects when no user is logged in, are listed as not found.
+func (restapi restAPI) getVersionsInfo(w http.ResponseWriter, r *http.Request) {
+	ids := []string{}
+	seen := map[string]bool{}
+	for _, param := range r.URL.Query()["ids"] {
+		for _, id := range strings.Split(param, ",") {
+			if id = strings.TrimSpace(id); id != "" && !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		restapi.WriteJSON(w, http.StatusBadRequest, responseError{Message: "no version ids given"})
+		return
+	}
+	if len(ids) > maxBatchVersions {
+		restapi.WriteJSON(w, http.StatusBadRequest, responseError{
+			Message: fmt.Sprintf("at most %v versions may be fetched at once", maxBatchVersions)})
+		return
+	}
+
+	versions, err := version.Find(version.ByIds(ids).WithoutFields(version.ConfigKey))
+	if err != nil {
+		msg := fmt.Sprintf("Error finding versions: %v", err)
+		grip.Error(msg)
+		restapi.WriteJSON(w, http.StatusInternalServerError, responseError{Message: msg})
+		return
+	}
+
+	// check each project once for whether its versions are visible and within its
+	// rate limit
+	settings := restapi.GetSettings()
+	loggedIn := GetUser(r) != nil
+	visible := map[string]bool{}
+	byId := map[string]*version.Version{}
+	for i := range versions {
+		v := &versions[i]
+		if _, ok := visible[v.Identifier]; !ok {
+			ref, err := model.FindOneProjectRef(v.Identifier)
+			if err != nil {
+				msg := fmt.Sprintf("Error finding project '%v': %v", v.Identifier, err)
+				grip.Error(msg)
+				restapi.WriteJSON(w, http.StatusInternalServerError, responseError{Message: msg})
+				return
+			}
+			visible[v.Identifier] = ref == nil || !ref.Private || loggedIn
+			if restapi.projectLimiter.rejectOverLimit(w, v.Identifier,
+				settings.Api.ProjectRateLimit(v.Identifier)) {
+				return
+			}
+		}
+		if visible[v.Identifier] {
+			byId[v.Id] = v
+		}
+	}
+
+	result := versionsInfoContent{Versions: []restVersion{}}
+	for _, id := range ids {
+		v, ok := byId[id]
+		if !ok {
+			result.NotFound = append(result.NotFound, id)
+			continue
+		}
+		destVersion := restVersion{}
+		copyVersion(v, &destVersion)
+		for _, buildStatus := range v.BuildVariants {
+			destVersion.BuildVariants = append(destVersion.BuildVariants, buildStatus.BuildVariant)
+		}
+		result.Versions = append(result.Versions, destVersion)
+	}
+	restapi.WriteJSON(w, http.StatusOK, result)
+}
+
 // Returns a JSON response with the marshaled output of the version
 // specified in the request.
 func (restapi restAPI) getVersionConfig(w http.ResponseWriter, r *http.Request) {
@@ -340,6 +340,68 @@ func TestGetVersionInfo(t *testing.T) {
 	})
 }
 
+func TestGetVersionsInfo(t *testing.T) {
+	uis := UIServer{
+		RootURL:     versionTestConfig.Ui.Url,
+		Settings:    *versionTestConfig,
+		UserManager: serviceutil.MockUserManager{},
+	}
+	home := evergreen.FindEvergreenHome()
+
+	uis.Render = render.New(render.Options{
+		Directory:    filepath.Join(home, WebRootPath, Templates),
+		DisableCache: true,
+	})
+	uis.InitPlugins()
+
+	router, err := uis.NewRouter()
+	testutil.HandleTestingErr(err, t, "Failed to create ui server router")
+
+	Convey("When fetching several versions at once", t, func() {
+		testutil.HandleTestingErr(db.Clear(version.Collection), t,
+			"Error clearing '%v' collection", version.Collection)
+
+		for _, id := range []string{"v1", "v2"} {
+			v := &version.Version{
+				Id:            id,
+				Identifier:    "project_test",
+				BuildVariants: []version.BuildStatus{{BuildVariant: "bv", Activated: true}},
+				Config:        "tasks: []",
+			}
+			So(v.Insert(), ShouldBeNil)
+		}
+
+		url, err := router.Get("versions_info").URL()
+		So(err, ShouldBeNil)
+		request, err := http.NewRequest("GET", url.String()+"?ids=v2,missing&ids=v1", nil)
+		So(err, ShouldBeNil)
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, request)
+		So(response.Code, ShouldEqual, http.StatusOK)
+
+		Convey("the versions should be returned in order, with missing ids reported", func() {
+			out := versionsInfoContent{}
+			So(json.Unmarshal(response.Body.Bytes(), &out), ShouldBeNil)
+			So(len(out.Versions), ShouldEqual, 2)
+			So(out.Versions[0].Id, ShouldEqual, "v2")
+			So(out.Versions[0].BuildVariants, ShouldResemble, []string{"bv"})
+			So(out.Versions[0].Config, ShouldEqual, "")
+			So(out.Versions[1].Id, ShouldEqual, "v1")
+			So(out.NotFound, ShouldResemble, []string{"missing"})
+		})
+	})
+
+	Convey("When fetching versions without ids", t, func() {
+		url, err := router.Get("versions_info").URL()
+		So(err, ShouldBeNil)
+		request, err := http.NewRequest("GET", url.String(), nil)
+		So(err, ShouldBeNil)
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, request)
+		So(response.Code, ShouldEqual, http.StatusBadRequest)
+	})
+}
+
 func TestGetVersionInfoViaRevision(t *testing.T) {
 
 	userManager, err := auth.LoadUserManager(versionTestConfig.AuthConfig)
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/event"
+	"github.com/evergreen-ci/evergreen/util"
+)
+
+// schedulerHealthWindow is how far back the rates making up the scheduler health
+// score are measured.
+const schedulerHealthWindow = time.Hour
+
+const (
+	healthGreen  = "green"
+	healthYellow = "yellow"
+	healthRed    = "red"
+
+	// the lowest scores, out of 100, that are considered green and yellow
+	healthGreenScore  = 80
+	healthYellowScore = 50
+)
+
+// the values of each factor at which it scores 100 and 0; scores fall linearly
+// between them
+var (
+	backlogAgeHealthy         = 15 * time.Minute
+	backlogAgeUnhealthy       = 2 * time.Hour
+	provisionFailureHealthy   = 0.05
+	provisionFailureUnhealthy = 0.5
+	lockTimeoutHealthy        = 0.0
+	lockTimeoutUnhealthy      = 0.1
+)
+
+// healthFactor is one of the measurements making up the scheduler health score.
+type healthFactor struct {
+	Name   string  `json:"name"`
+	Value  float64 `json:"value"`
+	Score  float64 `json:"score"`
+	Status string  `json:"status"`
+}
+
+// schedulerHealthResp combines the scheduler health factors into a single score
+// out of 100, and a status of green, yellow or red.
+type schedulerHealthResp struct {
+	Score      float64        `json:"score"`
+	Status     string         `json:"status"`
+	WindowSecs float64        `json:"window_secs"`
+	Factors    []healthFactor `json:"factors"`
+}
+
+// scoreFactor scores a value out of 100, falling linearly from 100 at healthy to 0
+// at unhealthy, where higher values are worse.
+func scoreFactor(value, healthy, unhealthy float64) float64 {
+	switch {
+	case value <= healthy:
+		return 100
+	case value >= unhealthy:
+		return 0
+	}
+	return 100 * (unhealthy - value) / (unhealthy - healthy)
+}
+
+// healthStatus returns the status of a health score.
+func healthStatus(score float64) string {
+	switch {
+	case score >= healthGreenScore:
+		return healthGreen
+	case score >= healthYellowScore:
+		return healthYellow
+	}
+	return healthRed
+}
+
+// makeHealthFactor scores a factor's value between its healthy and unhealthy values.
+func makeHealthFactor(name string, value, healthy, unhealthy float64) healthFactor {
+	score := scoreFactor(value, healthy, unhealthy)
+	return healthFactor{Name: name, Value: value, Score: score, Status: healthStatus(score)}
+}
+
+// makeSchedulerHealth scores the scheduler's health from the age of the oldest
+// queued task, and the rates at which host provisioning failed and the global
+// lock timed out. The score is the mean of the factors' scores.
+func makeSchedulerHealth(backlogAge time.Duration, provisionFailureRate, lockTimeoutRate float64) schedulerHealthResp {
+	factors := []healthFactor{
+		makeHealthFactor("queue_backlog_age_secs", backlogAge.Seconds(),
+			backlogAgeHealthy.Seconds(), backlogAgeUnhealthy.Seconds()),
+		makeHealthFactor("host_provision_failure_rate", provisionFailureRate,
+			provisionFailureHealthy, provisionFailureUnhealthy),
+		makeHealthFactor("global_lock_timeout_rate", lockTimeoutRate,
+			lockTimeoutHealthy, lockTimeoutUnhealthy),
+	}
+	total := 0.0
+	for _, factor := range factors {
+		total += factor.Score
+	}
+	score := total / float64(len(factors))
+	return schedulerHealthResp{
+		Score:      score,
+		Status:     healthStatus(score),
+		WindowSecs: schedulerHealthWindow.Seconds(),
+		Factors:    factors,
+	}
+}
+
+// queueBacklogAge returns how long the task that has waited longest in any
+// distro's queue has been scheduled for.
+func queueBacklogAge(now time.Time) (time.Duration, error) {
+	oldest, err := model.FindOldestQueuedTaskScheduledTime()
+	if err != nil {
+		return 0, err
+	}
+	if util.IsZeroTime(oldest) {
+		return 0, nil
+	}
+	return now.Sub(oldest), nil
+}
+
+// provisionFailureRate returns the fraction of the hosts that finished provisioning
+// between start and end that failed to.
+func provisionFailureRate(start, end time.Time) (float64, error) {
+	failed, err := db.CountQ(event.AllLogCollection,
+		event.HostEventsOfTypeInRange(event.EventHostProvisionFailed, start, end))
+	if err != nil {
+		return 0, err
+	}
+	succeeded, err := db.CountQ(event.AllLogCollection,
+		event.HostEventsOfTypeInRange(event.EventHostProvisioned, start, end))
+	if err != nil {
+		return 0, err
+	}
+	if failed+succeeded == 0 {
+		return 0, nil
+	}
+	return float64(failed) / float64(failed+succeeded), nil
+}
+
+// schedulerHealth reports a single score for the health of scheduling, combining
+// how long tasks have waited in queues, how often hosts have failed to provision
+// and how often the API servers have timed out acquiring the global lock.
+func (as *APIServer) schedulerHealth(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	backlogAge, err := queueBacklogAge(now)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	failureRate, err := provisionFailureRate(now.Add(-schedulerHealthWindow), now)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	lockTimeoutRate, err := model.GlobalLockTimeoutRate(now.Add(-schedulerHealthWindow), now)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	as.WriteJSON(w, http.StatusOK, makeSchedulerHealth(backlogAge, failureRate, lockTimeoutRate))
+}
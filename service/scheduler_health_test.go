@@ -0,0 +1,31 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSchedulerHealth(t *testing.T) {
+	Convey("When scoring the scheduler's health", t, func() {
+		Convey("factors should score linearly between their healthy and unhealthy values", func() {
+			So(scoreFactor(0.01, 0.05, 0.5), ShouldEqual, 100)
+			So(scoreFactor(0.275, 0.05, 0.5), ShouldAlmostEqual, 50)
+			So(scoreFactor(0.9, 0.05, 0.5), ShouldEqual, 0)
+		})
+		Convey("a healthy scheduler should be green", func() {
+			health := makeSchedulerHealth(time.Minute, 0, 0)
+			So(health.Score, ShouldEqual, 100)
+			So(health.Status, ShouldEqual, healthGreen)
+			So(len(health.Factors), ShouldEqual, 3)
+		})
+		Convey("one failing factor should make it yellow, and two red", func() {
+			health := makeSchedulerHealth(3*time.Hour, 0, 0)
+			So(health.Status, ShouldEqual, healthYellow)
+			So(health.Factors[0].Status, ShouldEqual, healthRed)
+			health = makeSchedulerHealth(3*time.Hour, 0.6, 0)
+			So(health.Status, ShouldEqual, healthRed)
+		})
+	})
+}
@@ -25,6 +25,10 @@ const (
 	HostExpirationExtension    = "extendHostExpiration"
 	HostTerminate              = "terminate"
 	MaxExpirationDurationHours = 24 * 7 // 7 days
+
+	// DefaultMaxIdleExemptionHours is the longest a spawn host may be exempt from
+	// idle termination, if the settings don't say.
+	DefaultMaxIdleExemptionHours = 24 * 7 // 7 days
 )
 
 func (uis *UIServer) spawnPage(w http.ResponseWriter, r *http.Request) {
@@ -81,7 +85,7 @@ func (uis *UIServer) getUserPublicKeys(w http.ResponseWriter, r *http.Request) {
 
 func (uis *UIServer) listSpawnableDistros(w http.ResponseWriter, r *http.Request) {
 	// load in the distros
-	distros, err := distro.Find(distro.All)
+	distros, err := spawn.SpawnableDistros(&uis.Settings)
 	if err != nil {
 		uis.LoggedError(w, r, http.StatusInternalServerError, fmt.Errorf("Error loading distros: %v", err))
 		return
@@ -90,12 +94,10 @@ func (uis *UIServer) listSpawnableDistros(w http.ResponseWriter, r *http.Request
 	distroList := []map[string]interface{}{}
 
 	for _, d := range distros {
-		if d.SpawnAllowed {
-			distroList = append(distroList, map[string]interface{}{
-				"name":             d.Id,
-				"userDataFile":     d.UserData.File,
-				"userDataValidate": d.UserData.Validate})
-		}
+		distroList = append(distroList, map[string]interface{}{
+			"name":             d.Id,
+			"userDataFile":     d.UserData.File,
+			"userDataValidate": d.UserData.Validate})
 	}
 	uis.WriteJSON(w, http.StatusOK, distroList)
 }
@@ -104,12 +106,15 @@ func (uis *UIServer) requestNewHost(w http.ResponseWriter, r *http.Request) {
 	authedUser := MustHaveUser(r)
 
 	putParams := struct {
-		Task      string `json:"task_id"`
-		Distro    string `json:"distro"`
-		KeyName   string `json:"key_name"`
-		PublicKey string `json:"public_key"`
-		SaveKey   bool   `json:"save_key"`
-		UserData  string `json:"userdata"`
+		Task           string `json:"task_id"`
+		Distro         string `json:"distro"`
+		KeyName        string `json:"key_name"`
+		PublicKey      string `json:"public_key"`
+		SaveKey        bool   `json:"save_key"`
+		UserData       string `json:"userdata"`
+		RootVolumeSize int    `json:"root_volume_size"`
+		SetupScript    string `json:"setup_script"`
+		RequestKey     string `json:"request_key"`
 	}{}
 
 	if err := util.ReadJSONInto(r.Body, &putParams); err != nil {
@@ -118,16 +123,36 @@ func (uis *UIServer) requestNewHost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	opts := spawn.Options{
-		TaskId:    putParams.Task,
-		Distro:    putParams.Distro,
-		UserName:  authedUser.Username(),
-		PublicKey: putParams.PublicKey,
-		UserData:  putParams.UserData,
+		TaskId:         putParams.Task,
+		Distro:         putParams.Distro,
+		UserName:       authedUser.Username(),
+		PublicKey:      putParams.PublicKey,
+		UserData:       putParams.UserData,
+		RootVolumeSize: putParams.RootVolumeSize,
+		SetupScript:    putParams.SetupScript,
+		RequestKey:     putParams.RequestKey,
 	}
 
 	spawner := spawn.New(&uis.Settings)
 
+	// a retried request gets the host started for the first one
+	claimed, requested, err := spawner.ClaimRequest(opts)
+	if err != nil {
+		uis.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	if !claimed {
+		if requested == nil {
+			http.Error(w, fmt.Sprintf("a host is already being spawned for request %v", opts.RequestKey),
+				http.StatusConflict)
+			return
+		}
+		uis.WriteJSON(w, http.StatusOK, "Host successfully spawned")
+		return
+	}
+
 	if err := spawner.Validate(opts); err != nil {
+		releaseSpawnRequest(spawner, opts)
 		errCode := http.StatusBadRequest
 		if _, ok := err.(spawn.BadOptionsErr); !ok {
 			errCode = http.StatusInternalServerError
@@ -140,19 +165,22 @@ func (uis *UIServer) requestNewHost(w http.ResponseWriter, r *http.Request) {
 	if putParams.SaveKey {
 		dbuser, err := user.FindOne(user.ById(authedUser.Username()))
 		if err != nil {
+			releaseSpawnRequest(spawner, opts)
 			uis.LoggedError(w, r, http.StatusInternalServerError, fmt.Errorf("Error fetching user: %v", err))
 			return
 		}
 		err = model.AddUserPublicKey(dbuser.Id, putParams.KeyName, putParams.PublicKey)
 		if err != nil {
+			releaseSpawnRequest(spawner, opts)
 			uis.LoggedError(w, r, http.StatusInternalServerError, fmt.Errorf("Error saving public key: %v", err))
 			return
 		}
 		PushFlash(uis.CookieStore, r, w, NewSuccessFlash("Public key successfully saved."))
 	}
 
-	err := spawner.CreateHost(opts, authedUser)
+	err = spawner.CreateHost(opts, authedUser)
 	if err != nil {
+		releaseSpawnRequest(spawner, opts)
 		grip.Errorln("error spawning host:", err)
 		mailErr := notify.TrySendNotificationToUser(authedUser.Username(), fmt.Sprintf("Spawning failed"),
 			err.Error(), notify.ConstructMailer(uis.Settings.Notify))
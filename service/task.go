@@ -1,11 +1,13 @@
 package service
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/evergreen-ci/evergreen"
@@ -594,7 +596,7 @@ func (uis *UIServer) taskModify(w http.ResponseWriter, r *http.Request) {
 		uis.WriteJSON(w, http.StatusOK, projCtx.Task)
 		return
 	case "abort":
-		if err := model.AbortTask(projCtx.Task.Id, authName); err != nil {
+		if err := model.AbortTask(projCtx.Task.Id, authName, ""); err != nil {
 			http.Error(w, fmt.Sprintf("Error aborting task %v: %v", projCtx.Task.Id, err), http.StatusInternalServerError)
 			return
 		}
@@ -634,7 +636,7 @@ func (uis *UIServer) taskModify(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 		}
-		if err = projCtx.Task.SetPriority(priority); err != nil {
+		if err = model.SetTaskPriority(projCtx.Task, priority, authName); err != nil {
 			http.Error(w, fmt.Sprintf("Error setting task priority %v: %v", projCtx.Task.Id, err), http.StatusInternalServerError)
 			return
 		}
@@ -683,6 +685,34 @@ func (uis *UIServer) testLog(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// clients that want the log's metadata along with its lines can ask for JSON
+	if strings.HasPrefix(r.Header.Get("Accept"), "application/json") {
+		uis.WriteJSON(w, http.StatusOK, testLog)
+		return
+	}
+
+	// raw logs are served as plain text, honoring Range headers so that clients can
+	// fetch large logs a piece at a time. Large logs are compressed for clients that
+	// accept gzip and fetch the whole log, since ranges would apply to the
+	// compressed bytes.
+	if (r.FormValue("raw") == "1") || (r.Header.Get("Content-type") == "text/plain") {
+		var body bytes.Buffer
+		for _, line := range testLog.Lines {
+			body.WriteString(line)
+			body.WriteString("\n")
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Add("Vary", "Accept-Encoding")
+		if r.Header.Get("Range") == "" && acceptsGzip(r) && body.Len() >= gzipMinSize {
+			if err := writeGzipped(w, body.Bytes()); err != nil {
+				grip.Warningf("Error writing compressed test log %s: %+v", testLog.Id, err)
+			}
+			return
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(body.Bytes()))
+		return
+	}
+
 	displayLogs := make(chan model.LogMessage)
 	go func() {
 		for _, line := range testLog.Lines {
@@ -696,15 +726,8 @@ func (uis *UIServer) testLog(w http.ResponseWriter, r *http.Request) {
 		close(displayLogs)
 	}()
 
-	template := "task_log.html"
-
-	if (r.FormValue("raw") == "1") || (r.Header.Get("Content-type") == "text/plain") {
-		template = "task_log_raw.html"
-		w.Header().Set("Content-Type", "text/plain")
-	}
-
 	uis.WriteHTML(w, http.StatusOK, struct {
 		Data chan model.LogMessage
 		User *user.DBUser
-	}{displayLogs, GetUser(r)}, "base", template)
+	}{displayLogs, GetUser(r)}, "base", "task_log.html")
 }
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/mongodb/grip"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	// taskOutputPollInterval is how often a task's output stream checks for new
+	// log lines and test results.
+	taskOutputPollInterval = 10 * time.Second
+
+	// taskOutputKeepAlive is how often a comment is sent on an idle stream, so
+	// that proxies don't close it.
+	taskOutputKeepAlive = 30 * time.Second
+
+	// taskOutputMaxDuration is how long a stream stays open before the client
+	// has to reconnect.
+	taskOutputMaxDuration = 30 * time.Minute
+
+	// the names of the server-sent events in a task's output stream
+	taskOutputLogEvent    = "log"
+	taskOutputResultEvent = "result"
+	taskOutputEndEvent    = "end"
+)
+
+// taskOutputEvent is a log line or test result in a task's output stream.
+type taskOutputEvent struct {
+	name string
+	at   time.Time
+	data interface{}
+}
+
+// taskOutputEnd is the data of the event closing a task's output stream.
+type taskOutputEnd struct {
+	TaskId string `json:"task_id"`
+	Status string `json:"status"`
+}
+
+// testResultTime returns when a test result finished, or started if it has no end
+// time.
+func testResultTime(result task.TestResult) time.Time {
+	secs := result.EndTime
+	if secs == 0 {
+		secs = result.StartTime
+	}
+	return time.Unix(0, int64(secs*float64(time.Second)))
+}
+
+// testResultKey identifies a test result, so that each is only streamed once.
+func testResultKey(result task.TestResult) string {
+	return fmt.Sprintf("%v|%v|%v|%v", result.TestFile, result.Status, result.StartTime, result.EndTime)
+}
+
+// mergeTaskOutput orders log messages and test results into a single list of
+// events by the time they were logged or finished. Ties keep logs before results,
+// and each list's own order.
+func mergeTaskOutput(logs []model.LogMessage, results []task.TestResult) []taskOutputEvent {
+	events := make([]taskOutputEvent, 0, len(logs)+len(results))
+	for _, msg := range logs {
+		events = append(events, taskOutputEvent{name: taskOutputLogEvent, at: msg.Timestamp, data: msg})
+	}
+	for _, result := range results {
+		events = append(events, taskOutputEvent{name: taskOutputResultEvent, at: testResultTime(result), data: result})
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].at.Before(events[j].at)
+	})
+	return events
+}
+
+// taskOutputDone returns whether a task with the given status has finished, so
+// that no more output will be added to its stream. Tasks that haven't been
+// dispatched yet aren't done, since they can still run.
+func taskOutputDone(status string) bool {
+	return util.SliceContains(evergreen.CompletedStatuses, status)
+}
+
+// taskOutputCursor remembers which of a task's log lines and test results have
+// already been streamed.
+type taskOutputCursor struct {
+	taskId    string
+	execution int
+
+	// the start of the latest log chunk seen, and how many messages of each chunk
+	// since then have been sent
+	chunkStart time.Time
+	sentLogs   map[bson.ObjectId]int
+
+	sentResults map[string]bool
+}
+
+func newTaskOutputCursor(t *task.Task) *taskOutputCursor {
+	return &taskOutputCursor{
+		taskId:      t.Id,
+		execution:   t.Execution,
+		sentLogs:    map[bson.ObjectId]int{},
+		sentResults: map[string]bool{},
+	}
+}
+
+// next returns the log messages and test results of the task that haven't been
+// sent yet, merged in order, and marks them sent.
+func (c *taskOutputCursor) next(results []task.TestResult) ([]taskOutputEvent, error) {
+	chunks, err := model.FindTaskLogsSinceTime(c.taskId, c.execution, c.chunkStart)
+	if err != nil {
+		return nil, err
+	}
+	newLogs := []model.LogMessage{}
+	// chunks older than the latest can no longer be returned, so only the
+	// counts of the chunks just found need to be kept
+	sentLogs := map[bson.ObjectId]int{}
+	for _, chunk := range chunks {
+		if sent := c.sentLogs[chunk.Id]; sent < len(chunk.Messages) {
+			newLogs = append(newLogs, chunk.Messages[sent:]...)
+		}
+		sentLogs[chunk.Id] = len(chunk.Messages)
+		if chunk.Timestamp.After(c.chunkStart) {
+			c.chunkStart = chunk.Timestamp
+		}
+	}
+	c.sentLogs = sentLogs
+
+	newResults := []task.TestResult{}
+	for _, result := range results {
+		key := testResultKey(result)
+		if c.sentResults[key] {
+			continue
+		}
+		c.sentResults[key] = true
+		newResults = append(newResults, result)
+	}
+
+	return mergeTaskOutput(newLogs, newResults), nil
+}
+
+// taskOutputStream streams server-sent events with a task's output as it runs:
+// a "log" event for each new log line and a "result" event for each new test
+// result, ordered by time. Output already produced is sent first, and streams of
+// tasks that haven't started yet wait for them to run. Once the task finishes, the
+// remaining output is sent, followed by an "end" event with the task's status, and
+// the stream is closed. Streams are also closed after taskOutputMaxDuration.
+func (as *APIServer) taskOutputStream(w http.ResponseWriter, r *http.Request) {
+	t := MustHaveTask(r)
+	u := MustHaveUser(r)
+
+	projectRef, err := model.FindOneProjectRef(t.Project)
+	if err != nil {
+		as.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	if projectRef == nil || !as.canAccessProject(u, projectRef) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		as.LoggedError(w, r, http.StatusInternalServerError,
+			fmt.Errorf("streaming responses are not supported"))
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	poll := time.NewTicker(taskOutputPollInterval)
+	defer poll.Stop()
+	keepAlive := time.NewTicker(taskOutputKeepAlive)
+	defer keepAlive.Stop()
+	deadline := time.After(taskOutputMaxDuration)
+
+	cursor := newTaskOutputCursor(t)
+	for {
+		events, err := cursor.next(t.TestResults)
+		if err != nil {
+			grip.Errorf("Error finding the output of task %s: %+v", t.Id, err)
+			return
+		}
+		for _, e := range events {
+			if err = writeServerSentEvent(w, e.name, e.data); err != nil {
+				return
+			}
+		}
+		if len(events) > 0 {
+			flusher.Flush()
+		}
+
+		if taskOutputDone(t.Status) {
+			if err = writeServerSentEvent(w, taskOutputEndEvent, taskOutputEnd{TaskId: t.Id, Status: t.Status}); err != nil {
+				grip.Errorf("Error sending end event for task %s: %+v", t.Id, err)
+			}
+			flusher.Flush()
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-deadline:
+			return
+		case <-keepAlive.C:
+			if _, err = io.WriteString(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-poll.C:
+			current, err := task.FindOne(task.ById(t.Id).WithFields(
+				task.IdKey, task.StatusKey, task.TestResultsKey))
+			if err != nil {
+				grip.Errorf("Error checking the output of task %s: %+v", t.Id, err)
+				return
+			}
+			if current == nil {
+				return
+			}
+			t.Status = current.Status
+			t.TestResults = current.TestResults
+		}
+	}
+}
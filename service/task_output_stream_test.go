@@ -0,0 +1,54 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/task"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMergeTaskOutput(t *testing.T) {
+	Convey("With log messages and test results from a task", t, func() {
+		start := time.Unix(1500000000, 0)
+		logs := []model.LogMessage{
+			{Message: "first", Timestamp: start},
+			{Message: "second", Timestamp: start.Add(2 * time.Second)},
+			{Message: "third", Timestamp: start.Add(3 * time.Second)},
+		}
+		results := []task.TestResult{
+			{TestFile: "a", StartTime: 1500000000, EndTime: 1500000001},
+			{TestFile: "b", StartTime: 1500000002},
+		}
+
+		Convey("they are ordered by the time they were logged or finished", func() {
+			events := mergeTaskOutput(logs, results)
+			So(len(events), ShouldEqual, 5)
+			names := []string{}
+			for _, e := range events {
+				names = append(names, e.name)
+			}
+			So(names, ShouldResemble, []string{"log", "result", "log", "result", "log"})
+			So(events[1].data.(task.TestResult).TestFile, ShouldEqual, "a")
+			So(events[2].data.(model.LogMessage).Message, ShouldEqual, "second")
+			So(events[3].data.(task.TestResult).TestFile, ShouldEqual, "b")
+		})
+
+		Convey("nothing is returned for no output", func() {
+			So(len(mergeTaskOutput(nil, nil)), ShouldEqual, 0)
+		})
+	})
+}
+
+func TestTaskOutputDone(t *testing.T) {
+	Convey("A task's output stream should only end once the task finishes", t, func() {
+		So(taskOutputDone(evergreen.TaskSucceeded), ShouldBeTrue)
+		So(taskOutputDone(evergreen.TaskFailed), ShouldBeTrue)
+		So(taskOutputDone(evergreen.TaskUndispatched), ShouldBeFalse)
+		So(taskOutputDone(evergreen.TaskInactive), ShouldBeFalse)
+		So(taskOutputDone(evergreen.TaskDispatched), ShouldBeFalse)
+		So(taskOutputDone(evergreen.TaskStarted), ShouldBeFalse)
+	})
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/testutil"
+	"github.com/evergreen-ci/render"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRawTestLogRanges(t *testing.T) {
+	db.SetGlobalSessionProvider(db.SessionFactoryFromConfig(testutil.TestConfig()))
+
+	uis := UIServer{
+		RootURL:  taskTestConfig.Ui.Url,
+		Settings: *taskTestConfig,
+	}
+	uis.Render = render.New(render.Options{
+		Directory:    filepath.Join(evergreen.FindEvergreenHome(), WebRootPath, Templates),
+		DisableCache: true,
+	})
+	uis.InitPlugins()
+	router, err := uis.NewRouter()
+	testutil.HandleTestingErr(err, t, "Failed to create ui server router")
+
+	Convey("With a test log", t, func() {
+		testutil.HandleTestingErr(db.Clear(model.TestLogCollection), t,
+			"Error clearing '%v' collection", model.TestLogCollection)
+		testLog := &model.TestLog{
+			Id:    "log1",
+			Name:  "test",
+			Task:  "t1",
+			Lines: []string{"aaaa", "bbbb", "cccc"},
+		}
+		So(testLog.Insert(), ShouldBeNil)
+
+		getRaw := func(byteRange string) *httptest.ResponseRecorder {
+			request, err := http.NewRequest("GET", "/test_log/log1?raw=1", nil)
+			So(err, ShouldBeNil)
+			if byteRange != "" {
+				request.Header.Set("Range", byteRange)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, request)
+			return w
+		}
+
+		Convey("the raw log should be served whole without a range", func() {
+			w := getRaw("")
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Header().Get("Accept-Ranges"), ShouldEqual, "bytes")
+			So(w.Body.String(), ShouldEqual, "aaaa\nbbbb\ncccc\n")
+		})
+
+		Convey("a byte range should be served as partial content", func() {
+			w := getRaw("bytes=5-9")
+			So(w.Code, ShouldEqual, http.StatusPartialContent)
+			So(w.Header().Get("Content-Range"), ShouldEqual, "bytes 5-9/15")
+			So(w.Body.String(), ShouldEqual, "bbbb\n")
+		})
+
+		Convey("a suffix range should serve the tail of the log", func() {
+			w := getRaw("bytes=-5")
+			So(w.Code, ShouldEqual, http.StatusPartialContent)
+			So(w.Header().Get("Content-Range"), ShouldEqual, "bytes 10-14/15")
+			So(w.Body.String(), ShouldEqual, "cccc\n")
+		})
+
+		Convey("a range past the end of the log should not be satisfiable", func() {
+			w := getRaw("bytes=100-200")
+			So(w.Code, ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+			So(w.Header().Get("Content-Range"), ShouldEqual, "bytes */15")
+		})
+	})
+}
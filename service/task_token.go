@@ -0,0 +1,152 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/mongodb/grip"
+)
+
+const (
+	// attachFilesScope is the scope of task tokens that can attach files to a task.
+	attachFilesScope = "attach_files"
+
+	// the default and longest lifetimes of a task token
+	taskTokenDefaultTTL = time.Hour
+	taskTokenMaxTTL     = 12 * time.Hour
+)
+
+var errInvalidTaskToken = errors.New("invalid task token")
+
+// makeTaskToken returns a token allowing whoever holds it to make the requests of
+// the scope for one execution of the task until expires, without the task's
+// secret. The token is signed with the secret, so rotating the secret revokes
+// the task's tokens.
+func makeTaskToken(t *task.Task, scope string, expires time.Time) string {
+	payload := fmt.Sprintf("%v:%v:%v:%v", scope, t.Id, t.Execution, expires.Unix())
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(signTaskToken(t.Secret, payload))
+}
+
+func signTaskToken(secret, payload string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// verifyTaskToken returns an error unless the token was made for the scope and the
+// task's current execution, with its current secret, and hasn't expired by now.
+func verifyTaskToken(t *task.Task, scope, token string, now time.Time) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return errInvalidTaskToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return errInvalidTaskToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return errInvalidTaskToken
+	}
+	if !hmac.Equal(signature, signTaskToken(t.Secret, string(payload))) {
+		return errInvalidTaskToken
+	}
+
+	// task ids may contain colons, so the fields around the id are split off
+	fields := strings.Split(string(payload), ":")
+	if len(fields) < 4 {
+		return errInvalidTaskToken
+	}
+	n := len(fields)
+	if fields[0] != scope {
+		return fmt.Errorf("task token is not valid for %v", scope)
+	}
+	if strings.Join(fields[1:n-2], ":") != t.Id || fields[n-2] != strconv.Itoa(t.Execution) {
+		return fmt.Errorf("task token is not valid for execution %v of task %v", t.Execution, t.Id)
+	}
+	expires, err := strconv.ParseInt(fields[n-1], 10, 64)
+	if err != nil {
+		return errInvalidTaskToken
+	}
+	if !now.Before(time.Unix(expires, 0)) {
+		return errors.New("task token has expired")
+	}
+	return nil
+}
+
+// requireSecretOrToken lets a request for the task in the context through if it
+// has either the task's secret, or a task token for the scope in the Task-Token
+// header. It must be wrapped by checkTask without checking the secret.
+func (as *APIServer) requireSecretOrToken(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		t := MustHaveTask(r)
+
+		if secret := r.Header.Get(evergreen.TaskSecretHeader); secret != "" {
+			if secret != t.Secret {
+				grip.Errorf("Wrong secret sent for task %s", t.Id)
+				http.Error(w, "wrong secret!", http.StatusConflict)
+				return
+			}
+			next(w, r)
+			return
+		}
+
+		token := r.Header.Get(evergreen.TaskTokenHeader)
+		if token == "" {
+			http.Error(w, "task secret or token required", http.StatusUnauthorized)
+			return
+		}
+		if err := verifyTaskToken(t, scope, token, time.Now()); err != nil {
+			grip.Warningf("Rejected token for task %s: %v", t.Id, err)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// taskTokenResp is a task token, and when it expires.
+type taskTokenResp struct {
+	TaskId  string    `json:"task_id"`
+	Scope   string    `json:"scope"`
+	Token   string    `json:"token"`
+	Expires time.Time `json:"expires"`
+}
+
+// fileToken mints a token that can attach files to the task, which the agent can
+// hand to a sidecar uploader instead of the task's secret. The token lasts for
+// ttl_secs, if given, up to taskTokenMaxTTL.
+func (as *APIServer) fileToken(w http.ResponseWriter, r *http.Request) {
+	t := MustHaveTask(r)
+
+	ttl := taskTokenDefaultTTL
+	if ttlSecs := r.FormValue("ttl_secs"); ttlSecs != "" {
+		secs, err := strconv.Atoi(ttlSecs)
+		if err != nil || secs <= 0 {
+			http.Error(w, fmt.Sprintf("invalid ttl_secs '%v'", ttlSecs), http.StatusBadRequest)
+			return
+		}
+		ttl = time.Duration(secs) * time.Second
+		if ttl > taskTokenMaxTTL {
+			ttl = taskTokenMaxTTL
+		}
+	}
+
+	expires := time.Now().Add(ttl)
+	as.WriteJSON(w, http.StatusOK, taskTokenResp{
+		TaskId:  t.Id,
+		Scope:   attachFilesScope,
+		Token:   makeTaskToken(t, attachFilesScope, expires),
+		Expires: expires,
+	})
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/model/task"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTaskTokens(t *testing.T) {
+	Convey("With a token to attach files to a task", t, func() {
+		tsk := &task.Task{Id: "project:variant:task", Execution: 1, Secret: "secret"}
+		now := time.Now()
+		token := makeTaskToken(tsk, attachFilesScope, now.Add(time.Hour))
+
+		Convey("the token should be valid for the scope until it expires", func() {
+			So(verifyTaskToken(tsk, attachFilesScope, token, now), ShouldBeNil)
+			So(verifyTaskToken(tsk, attachFilesScope, token, now.Add(2*time.Hour)), ShouldNotBeNil)
+		})
+
+		Convey("the token should not be valid for another scope", func() {
+			So(verifyTaskToken(tsk, "other", token, now), ShouldNotBeNil)
+		})
+
+		Convey("the token should not be valid for another task or execution", func() {
+			other := &task.Task{Id: "other", Execution: 1, Secret: "secret"}
+			So(verifyTaskToken(other, attachFilesScope, token, now), ShouldNotBeNil)
+			restarted := &task.Task{Id: tsk.Id, Execution: 2, Secret: "secret"}
+			So(verifyTaskToken(restarted, attachFilesScope, token, now), ShouldNotBeNil)
+		})
+
+		Convey("rotating the task's secret should revoke the token", func() {
+			tsk.Secret = "rotated"
+			So(verifyTaskToken(tsk, attachFilesScope, token, now), ShouldNotBeNil)
+		})
+
+		Convey("a tampered or malformed token should not be valid", func() {
+			So(verifyTaskToken(tsk, attachFilesScope, token+"x", now), ShouldNotBeNil)
+			So(verifyTaskToken(tsk, attachFilesScope, "garbage", now), ShouldNotBeNil)
+			So(verifyTaskToken(tsk, attachFilesScope, "", now), ShouldNotBeNil)
+		})
+	})
+}
@@ -102,7 +102,7 @@ func main() {
 
 	n := negroni.New()
 	n.Use(negroni.NewStatic(http.Dir(webHome)))
-	n.Use(service.NewLogger())
+	n.Use(service.NewLogger(settings.LoggedHeaders))
 	n.Use(negroni.HandlerFunc(service.UserMiddleware(uis.UserManager)))
 	n.UseHandler(router)
 	graceful.Run(settings.Ui.HttpListenAddr, requestTimeout, n)
@@ -27,6 +27,15 @@ const (
 
 var SpawnLimitErr = errors.New("User is already running the max allowed # of spawn hosts")
 
+const (
+	// RequestKeyWindow is how long after a host is requested that a request with the
+	// same key returns it instead of starting another host.
+	RequestKeyWindow = 10 * time.Minute
+
+	// maxRequestKeyLength is the longest request key clients may send.
+	maxRequestKeyLength = 128
+)
+
 // BadOptionsErr represents an in valid set of spawn options.
 type BadOptionsErr struct {
 	message string
@@ -48,6 +57,17 @@ type Options struct {
 	PublicKey string
 	UserData  string
 	TaskId    string
+
+	// RootVolumeSize is the size, in GB, of the host's root volume, if not the
+	// distro's size
+	RootVolumeSize int
+
+	// SetupScript is run on the host as the distro's user after it is provisioned
+	SetupScript string
+
+	// RequestKey identifies the request, so that a retried request returns the host
+	// started for the first one instead of starting another
+	RequestKey string
 }
 
 // New returns an initialized Spawn controller.
@@ -55,17 +75,45 @@ func New(settings *evergreen.Settings) Spawn {
 	return Spawn{settings}
 }
 
+// CheckDistro returns a BadOptionsErr if users may not spawn hosts of the distro,
+// either because the distro doesn't allow it or because the settings don't allow
+// spawning hosts with its provider.
+func CheckDistro(d *distro.Distro, settings *evergreen.Settings) error {
+	if !d.SpawnAllowed {
+		return BadOptionsErr{fmt.Sprintf("Spawning not allowed for distro %v", d.Id)}
+	}
+	if err := providers.CheckSpawnAllowed(SpawnProvider(d.Provider), settings); err != nil {
+		return BadOptionsErr{err.Error()}
+	}
+	return nil
+}
+
+// SpawnableDistros returns the distros that users may spawn hosts of.
+func SpawnableDistros(settings *evergreen.Settings) ([]distro.Distro, error) {
+	distros, err := distro.Find(distro.BySpawnAllowed())
+	if err != nil {
+		return nil, fmt.Errorf("Error finding spawnable distros: %v", err)
+	}
+	spawnable := []distro.Distro{}
+	for i := range distros {
+		if CheckDistro(&distros[i], settings) == nil {
+			spawnable = append(spawnable, distros[i])
+		}
+	}
+	return spawnable, nil
+}
+
 // Validate returns an instance of BadOptionsErr if the SpawnOptions object contains invalid
 // data, SpawnLimitErr if the user is already at the spawned host limit, or some other untyped
 // instance of Error if something fails during validation.
 func (sm Spawn) Validate(so Options) error {
 	d, err := distro.FindOne(distro.ById(so.Distro))
 	if err != nil {
-		return BadOptionsErr{fmt.Sprintf("Invalid dist %v", so.Distro)}
+		return BadOptionsErr{fmt.Sprintf("Invalid distro %v", so.Distro)}
 	}
 
-	if !d.SpawnAllowed {
-		return BadOptionsErr{fmt.Sprintf("Spawning not allowed for dist %v", so.Distro)}
+	if err = CheckDistro(d, sm.settings); err != nil {
+		return err
 	}
 
 	// if the user already has too many active spawned hosts, deny the request
@@ -78,6 +126,14 @@ func (sm Spawn) Validate(so Options) error {
 		return SpawnLimitErr
 	}
 
+	room, err := cloud.DistroRoom(d, 1)
+	if err != nil {
+		return err
+	}
+	if room == 0 {
+		return BadOptionsErr{fmt.Sprintf("distro %v already has its maximum of %v hosts", d.Id, d.MaxHosts)}
+	}
+
 	// validate public key
 	rsa := "ssh-rsa"
 	dss := "ssh-dss"
@@ -122,9 +178,106 @@ func (sm Spawn) Validate(so Options) error {
 			return BadOptionsErr{fmt.Sprintf("invalid %v: %v", d.UserData.Validate, err)}
 		}
 	}
+
+	if so.RootVolumeSize != 0 && !canSetRootVolumeSize(d) {
+		return BadOptionsErr{fmt.Sprintf("the root volume size of distro %v's hosts can't be set", so.Distro)}
+	}
+
+	if len(so.RequestKey) > maxRequestKeyLength {
+		return BadOptionsErr{fmt.Sprintf("request key must be at most %v characters", maxRequestKeyLength)}
+	}
+
+	if err = sm.hostOptions(so, d, so.UserName).Validate(); err != nil {
+		return BadOptionsErr{err.Error()}
+	}
 	return nil
 }
 
+// hostOptions returns the options that a host of the distro spawned for the given
+// owner is started with.
+func (sm Spawn) hostOptions(so Options, d *distro.Distro, ownerId string) cloud.HostOptions {
+	expiration := DefaultExpiration
+	return cloud.HostOptions{
+		ProvisionOptions: &host.ProvisionOptions{
+			LoadCLI:     true,
+			TaskId:      so.TaskId,
+			OwnerId:     ownerId,
+			SetupScript: so.SetupScript,
+		},
+		UserName:           so.UserName,
+		ExpirationDuration: &expiration,
+		UserData:           so.UserData,
+		UserHost:           true,
+		RootVolumeSize:     so.RootVolumeSize,
+		ShutdownBehavior:   d.ShutdownBehavior,
+		RequestKey:         so.RequestKey,
+	}
+}
+
+// ClaimRequest claims the options' request key, so that only the first of the
+// requests sent with the same key within RequestKeyWindow spawns a host. If another
+// request already claimed the key, it returns false along with the host started for
+// that request, which is nil if the host hasn't been recorded yet. Options without a
+// key are always claimed.
+func (sm Spawn) ClaimRequest(so Options) (bool, *host.Host, error) {
+	if so.RequestKey == "" {
+		return true, nil, nil
+	}
+	claimed, err := host.ClaimRequestKey(so.UserName, so.Distro, so.RequestKey, RequestKeyWindow)
+	if err != nil {
+		return false, nil, fmt.Errorf("Error claiming request %v: %v", so.RequestKey, err)
+	}
+	if claimed {
+		return true, nil, nil
+	}
+	h, err := sm.FindRequested(so)
+	return false, h, err
+}
+
+// ReleaseRequest releases the claim on the options' request key, so that a retry
+// of a request that failed before its host was spawned can spawn one.
+func (sm Spawn) ReleaseRequest(so Options) error {
+	if so.RequestKey == "" {
+		return nil
+	}
+	return host.ReleaseRequestKey(so.UserName, so.Distro, so.RequestKey)
+}
+
+// FindRequested returns the host already started for the options' request, if
+// the same user requested a host of the same distro with the same key within the
+// last RequestKeyWindow, or nil if there is no such host or the options have no key.
+func (sm Spawn) FindRequested(so Options) (*host.Host, error) {
+	if so.RequestKey == "" {
+		return nil, nil
+	}
+	h, err := host.FindOne(host.ByUserRequestSince(so.UserName, so.Distro, so.RequestKey,
+		time.Now().Add(-RequestKeyWindow)))
+	if err != nil {
+		return nil, fmt.Errorf("Error finding hosts for request %v: %v", so.RequestKey, err)
+	}
+	return h, nil
+}
+
+// canSetRootVolumeSize returns whether the root volume size of the distro's hosts
+// can be set, which EC2 supports for both on-demand and spot instances.
+func canSetRootVolumeSize(d *distro.Distro) bool {
+	switch d.Provider {
+	case ec2.OnDemandProviderName, ec2.SpotProviderName:
+		return true
+	}
+	return false
+}
+
+// SpawnProvider returns the provider that user hosts of a distro with the given
+// provider are spawned with.
+func SpawnProvider(providerName string) string {
+	// fake out replacing spot instances with on-demand equivalents
+	if providerName == ec2.SpotProviderName {
+		return ec2.OnDemandProviderName
+	}
+	return providerName
+}
+
 // CreateHost spawns a host with the given options.
 func (sm Spawn) CreateHost(so Options, owner *user.DBUser) error {
 
@@ -156,9 +309,9 @@ func (sm Spawn) CreateHost(so Options, owner *user.DBUser) error {
 		return fmt.Errorf("expansions error: %v", err)
 	}
 
-	// fake out replacing spot instances with on-demand equivalents
-	if d.Provider == ec2.SpotProviderName {
-		d.Provider = ec2.OnDemandProviderName
+	d.Provider = SpawnProvider(d.Provider)
+	if err = providers.CheckSpawnAllowed(d.Provider, sm.settings); err != nil {
+		return BadOptionsErr{err.Error()}
 	}
 
 	// get the appropriate cloud manager
@@ -168,18 +321,9 @@ func (sm Spawn) CreateHost(so Options, owner *user.DBUser) error {
 	}
 
 	// spawn the host
-	provisionOptions := &host.ProvisionOptions{
-		LoadCLI: true,
-		TaskId:  so.TaskId,
-		OwnerId: owner.Id,
-	}
-	expiration := DefaultExpiration
-	hostOptions := cloud.HostOptions{
-		ProvisionOptions:   provisionOptions,
-		UserName:           so.UserName,
-		ExpirationDuration: &expiration,
-		UserData:           so.UserData,
-		UserHost:           true,
+	hostOptions := sm.hostOptions(so, d, owner.Id)
+	if err = hostOptions.Validate(); err != nil {
+		return BadOptionsErr{err.Error()}
 	}
 
 	_, err = cloudManager.SpawnInstance(d, hostOptions)
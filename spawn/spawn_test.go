@@ -0,0 +1,102 @@
+package spawn
+
+import (
+	"testing"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/cloud/providers/ec2"
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/evergreen-ci/evergreen/testutil"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCheckDistro(t *testing.T) {
+	Convey("With a distro that allows spawning", t, func() {
+		settings := &evergreen.Settings{}
+		d := &distro.Distro{Id: "d1", Provider: ec2.SpotProviderName, SpawnAllowed: true}
+
+		Convey("it should be spawnable if its provider is allowed", func() {
+			So(CheckDistro(d, settings), ShouldBeNil)
+			// spot distros are spawned as on-demand hosts
+			settings.Providers.Allowed = []string{ec2.OnDemandProviderName}
+			So(CheckDistro(d, settings), ShouldBeNil)
+		})
+		Convey("it should not be spawnable if its provider isn't allowed", func() {
+			settings.Providers.Allowed = []string{ec2.SpotProviderName}
+			err := CheckDistro(d, settings)
+			So(err, ShouldHaveSameTypeAs, BadOptionsErr{})
+		})
+		Convey("it should not be spawnable once spawning is disallowed", func() {
+			d.SpawnAllowed = false
+			err := CheckDistro(d, settings)
+			So(err, ShouldHaveSameTypeAs, BadOptionsErr{})
+		})
+	})
+}
+
+func TestFindRequested(t *testing.T) {
+	Convey("Requests without a key should never match an earlier host", t, func() {
+		h, err := New(&evergreen.Settings{}).FindRequested(Options{Distro: "d1", UserName: "me"})
+		So(err, ShouldBeNil)
+		So(h, ShouldBeNil)
+	})
+}
+
+func TestValidateDistroRoom(t *testing.T) {
+	db.SetGlobalSessionProvider(db.SessionFactoryFromConfig(testutil.TestConfig()))
+
+	Convey("With a distro that allows one host", t, func() {
+		testutil.HandleTestingErr(db.ClearCollections(distro.Collection, host.Collection), t,
+			"error clearing collections")
+		d := distro.Distro{Id: "d1", Provider: ec2.OnDemandProviderName, SpawnAllowed: true, MaxHosts: 1}
+		So(d.Insert(), ShouldBeNil)
+		So((&host.Host{Id: "h1", Distro: d, Status: evergreen.HostRunning}).Insert(), ShouldBeNil)
+
+		Convey("requests should fail validation as bad options once it has that host", func() {
+			err := New(&evergreen.Settings{}).Validate(Options{Distro: d.Id, UserName: "me"})
+			So(err, ShouldHaveSameTypeAs, BadOptionsErr{})
+			So(err.Error(), ShouldContainSubstring, "maximum of 1 hosts")
+		})
+	})
+}
+
+func TestValidateRootVolumeSize(t *testing.T) {
+	db.SetGlobalSessionProvider(db.SessionFactoryFromConfig(testutil.TestConfig()))
+
+	Convey("With EC2 on-demand, EC2 spot and docker distros", t, func() {
+		testutil.HandleTestingErr(db.ClearCollections(distro.Collection, host.Collection), t,
+			"error clearing collections")
+		for _, d := range []distro.Distro{
+			{Id: "ondemand", Provider: ec2.OnDemandProviderName, SpawnAllowed: true},
+			{Id: "spot", Provider: ec2.SpotProviderName, SpawnAllowed: true},
+			{Id: "docker", Provider: "docker", SpawnAllowed: true},
+		} {
+			So(d.Insert(), ShouldBeNil)
+		}
+		validate := func(distroId string, size int) error {
+			return New(&evergreen.Settings{}).Validate(Options{
+				Distro:         distroId,
+				UserName:       "me",
+				PublicKey:      "ssh-rsa AAAA",
+				RootVolumeSize: size,
+			})
+		}
+
+		Convey("the root volume size of EC2 hosts should be settable", func() {
+			So(validate("ondemand", 100), ShouldBeNil)
+			So(validate("spot", 100), ShouldBeNil)
+		})
+
+		Convey("the root volume size should be rejected for other providers", func() {
+			err := validate("docker", 100)
+			So(err, ShouldHaveSameTypeAs, BadOptionsErr{})
+			So(err.Error(), ShouldContainSubstring, "distro docker's hosts")
+		})
+
+		Convey("sizes out of range should be rejected", func() {
+			So(validate("spot", 1), ShouldHaveSameTypeAs, BadOptionsErr{})
+		})
+	})
+}
@@ -171,7 +171,7 @@ func (self *TaskRunner) processDistro(distroId string) error {
 	return nil
 }
 
-// DispatchTaskForHost assigns the task at the head of the task queue to the
+// DispatchTaskForHost assigns the highest priority task in the task queue to the
 // given host, dequeues the task and then marks it as dispatched for the host
 func DispatchTaskForHost(taskQueue *model.TaskQueue, assignedHost *host.Host) (
 	nextTask *task.Task, err error) {
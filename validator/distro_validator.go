@@ -4,7 +4,9 @@ import (
 	"fmt"
 
 	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/cloud"
 	"github.com/evergreen-ci/evergreen/cloud/providers"
+	"github.com/evergreen-ci/evergreen/cloud/providers/ec2"
 	"github.com/evergreen-ci/evergreen/cloud/providers/static"
 	"github.com/evergreen-ci/evergreen/model/distro"
 	"github.com/evergreen-ci/evergreen/util"
@@ -19,6 +21,10 @@ var distroSyntaxValidators = []distroValidator{
 	ensureValidSSHOptions,
 	ensureValidExpansions,
 	ensureStaticHostsAreNotSpawnable,
+	ensureValidSchedulingWeight,
+	ensureValidMaxHosts,
+	ensureValidProvisionRetries,
+	ensureValidShutdownBehavior,
 }
 
 // CheckDistro checks if the distro configuration syntax is valid. Returns
@@ -147,6 +153,49 @@ func ensureValidExpansions(d *distro.Distro, s *evergreen.Settings) []Validation
 	return nil
 }
 
+// ensureValidSchedulingWeight checks that the distro's scheduling weight is not negative.
+func ensureValidSchedulingWeight(d *distro.Distro, s *evergreen.Settings) []ValidationError {
+	if d.SchedulingWeight < 0 {
+		return []ValidationError{{Error, "distro scheduling weight cannot be negative"}}
+	}
+	return nil
+}
+
+// ensureValidMaxHosts checks that the distro's maximum number of hosts is not
+// negative, and warns if it's less than the distro's pool size.
+func ensureValidMaxHosts(d *distro.Distro, s *evergreen.Settings) []ValidationError {
+	if d.MaxHosts < 0 {
+		return []ValidationError{{Error, "distro max hosts cannot be negative"}}
+	}
+	if d.MaxHosts > 0 && d.PoolSize > d.MaxHosts {
+		return []ValidationError{{Warning, fmt.Sprintf("distro pool size %v is more than its max hosts %v",
+			d.PoolSize, d.MaxHosts)}}
+	}
+	return nil
+}
+
+// ensureValidProvisionRetries checks that the distro's number of provisioning
+// retries is not negative.
+func ensureValidProvisionRetries(d *distro.Distro, s *evergreen.Settings) []ValidationError {
+	if d.ProvisionRetries < 0 {
+		return []ValidationError{{Error, "distro provisioning retries cannot be negative"}}
+	}
+	return nil
+}
+
+// ensureValidShutdownBehavior checks that the distro's shutdown behavior is
+// supported, and that spot distros don't stop their hosts, which spot instances
+// can't be.
+func ensureValidShutdownBehavior(d *distro.Distro, s *evergreen.Settings) []ValidationError {
+	if err := cloud.ValidateShutdownBehavior(d.ShutdownBehavior); err != nil {
+		return []ValidationError{{Error, err.Error()}}
+	}
+	if d.Provider == ec2.SpotProviderName && d.ShutdownBehavior == evergreen.HostShutdownStop {
+		return []ValidationError{{Error, "spot distros cannot stop their hosts on shutdown"}}
+	}
+	return nil
+}
+
 // ensureValidSSHOptions checks that no SSH option key is blank.
 func ensureValidSSHOptions(d *distro.Distro, s *evergreen.Settings) []ValidationError {
 	for _, o := range d.SSHOptions {
@@ -238,3 +238,70 @@ func TestEnsureValidSSHOptions(t *testing.T) {
 		})
 	})
 }
+
+func TestEnsureValidSchedulingWeight(t *testing.T) {
+	Convey("When validating a distro's scheduling weight...", t, func() {
+		Convey("if the weight is negative, an error should be returned", func() {
+			d := &distro.Distro{SchedulingWeight: -1}
+			err := ensureValidSchedulingWeight(d, conf)
+			So(len(err), ShouldEqual, 1)
+		})
+		Convey("if the weight is zero or positive, no error should be returned", func() {
+			So(ensureValidSchedulingWeight(&distro.Distro{}, conf), ShouldBeNil)
+			So(ensureValidSchedulingWeight(&distro.Distro{SchedulingWeight: 5}, conf), ShouldBeNil)
+		})
+	})
+}
+
+func TestEnsureValidMaxHosts(t *testing.T) {
+	Convey("When validating a distro's max hosts...", t, func() {
+		Convey("if it is negative, an error should be returned", func() {
+			errs := ensureValidMaxHosts(&distro.Distro{MaxHosts: -1}, conf)
+			So(len(errs), ShouldEqual, 1)
+			So(errs[0].Level, ShouldEqual, Error)
+		})
+		Convey("if it is less than the pool size, a warning should be returned", func() {
+			errs := ensureValidMaxHosts(&distro.Distro{MaxHosts: 2, PoolSize: 5}, conf)
+			So(len(errs), ShouldEqual, 1)
+			So(errs[0].Level, ShouldEqual, Warning)
+		})
+		Convey("if it is zero or at least the pool size, nothing should be returned", func() {
+			So(ensureValidMaxHosts(&distro.Distro{PoolSize: 5}, conf), ShouldBeNil)
+			So(ensureValidMaxHosts(&distro.Distro{MaxHosts: 5, PoolSize: 5}, conf), ShouldBeNil)
+		})
+	})
+}
+
+func TestEnsureValidProvisionRetries(t *testing.T) {
+	Convey("When validating a distro's provisioning retries...", t, func() {
+		Convey("if it is negative, an error should be returned", func() {
+			errs := ensureValidProvisionRetries(&distro.Distro{ProvisionRetries: -1}, conf)
+			So(len(errs), ShouldEqual, 1)
+			So(errs[0].Level, ShouldEqual, Error)
+		})
+		Convey("if it is zero or positive, nothing should be returned", func() {
+			So(ensureValidProvisionRetries(&distro.Distro{}, conf), ShouldBeNil)
+			So(ensureValidProvisionRetries(&distro.Distro{ProvisionRetries: 2}, conf), ShouldBeNil)
+		})
+	})
+}
+
+func TestEnsureValidShutdownBehavior(t *testing.T) {
+	Convey("When validating a distro's shutdown behavior...", t, func() {
+		Convey("if it is unsupported, an error should be returned", func() {
+			errs := ensureValidShutdownBehavior(&distro.Distro{ShutdownBehavior: "hibernate"}, conf)
+			So(len(errs), ShouldEqual, 1)
+			So(errs[0].Level, ShouldEqual, Error)
+		})
+		Convey("if a spot distro stops its hosts, an error should be returned", func() {
+			errs := ensureValidShutdownBehavior(&distro.Distro{Provider: "ec2-spot", ShutdownBehavior: "stop"}, conf)
+			So(len(errs), ShouldEqual, 1)
+			So(errs[0].Level, ShouldEqual, Error)
+		})
+		Convey("if it is blank or supported, nothing should be returned", func() {
+			So(ensureValidShutdownBehavior(&distro.Distro{}, conf), ShouldBeNil)
+			So(ensureValidShutdownBehavior(&distro.Distro{Provider: "ec2", ShutdownBehavior: "stop"}, conf), ShouldBeNil)
+			So(ensureValidShutdownBehavior(&distro.Distro{Provider: "ec2-spot", ShutdownBehavior: "terminate"}, conf), ShouldBeNil)
+		})
+	})
+}
@@ -14,6 +14,8 @@ import (
 
 type projectValidator func(*model.Project) []ValidationError
 
+type variantValidator func(*model.Project, *model.BuildVariant) []ValidationError
+
 type ValidationErrorLevel int64
 
 const (
@@ -60,6 +62,12 @@ var projectSemanticValidators = []projectValidator{
 	checkTaskCommands,
 }
 
+// Functions used to validate the semantics of a single build variant, which
+// check the parts of the project the variant uses.
+var variantSemanticValidators = []variantValidator{
+	checkVariantTaskCommands,
+}
+
 func (vr ValidationError) Error() string {
 	return vr.Message
 }
@@ -91,6 +99,24 @@ func CheckProjectSemantics(project *model.Project) []ValidationError {
 	return validationErrs
 }
 
+// CheckVariantSemantics runs only the semantic checks of the named build variant
+// and the tasks it runs, for quicker feedback than CheckProjectSemantics gives on
+// large projects. An error is returned if the project has no such variant.
+func CheckVariantSemantics(project *model.Project, variant string) ([]ValidationError, error) {
+	bv := project.FindBuildVariant(variant)
+	if bv == nil {
+		return nil, fmt.Errorf("buildvariant '%v' not found in project '%v'",
+			variant, project.Identifier)
+	}
+
+	validationErrs := []ValidationError{}
+	for _, variantSemanticValidator := range variantSemanticValidators {
+		validationErrs = append(validationErrs,
+			variantSemanticValidator(project, bv)...)
+	}
+	return validationErrs, nil
+}
+
 // verify that the project configuration syntax is valid
 func CheckProjectSyntax(project *model.Project) ([]ValidationError, error) {
 
@@ -440,19 +466,33 @@ func checkTaskCommands(project *model.Project) []ValidationError {
 	errs := []ValidationError{}
 	for _, task := range project.Tasks {
 		if len(task.Commands) == 0 {
-			errs = append(errs,
-				ValidationError{
-					Message: fmt.Sprintf("task '%v' in project '%v' does not "+
-						"contain any commands",
-						task.Name, project.Identifier),
-					Level: Warning,
-				},
-			)
+			errs = append(errs, noTaskCommandsError(project, task.Name))
+		}
+	}
+	return errs
+}
+
+// checkVariantTaskCommands is checkTaskCommands for the tasks the build variant runs.
+func checkVariantTaskCommands(project *model.Project, bv *model.BuildVariant) []ValidationError {
+	errs := []ValidationError{}
+	for _, bvt := range bv.Tasks {
+		task := project.FindProjectTask(bvt.Name)
+		if task != nil && len(task.Commands) == 0 {
+			errs = append(errs, noTaskCommandsError(project, task.Name))
 		}
 	}
 	return errs
 }
 
+func noTaskCommandsError(project *model.Project, taskName string) ValidationError {
+	return ValidationError{
+		Message: fmt.Sprintf("task '%v' in project '%v' does not "+
+			"contain any commands",
+			taskName, project.Identifier),
+		Level: Warning,
+	}
+}
+
 // Ensures there aren't any duplicate task names specified for any buildvariant
 // in this project
 func validateBVTaskNames(project *model.Project) []ValidationError {
@@ -1440,3 +1440,32 @@ func TestEnsureHasNecessaryBVFields(t *testing.T) {
 		})
 	})
 }
+
+func TestCheckVariantSemantics(t *testing.T) {
+	Convey("With a project whose variants run tasks without commands", t, func() {
+		project := &model.Project{
+			Identifier: "project_test",
+			BuildVariants: []model.BuildVariant{
+				{Name: "bv1", Tasks: []model.BuildVariantTask{{Name: "compile"}}},
+				{Name: "bv2", Tasks: []model.BuildVariantTask{{Name: "lint"}}},
+			},
+			Tasks: []model.ProjectTask{
+				{Name: "compile"},
+				{Name: "lint"},
+				{Name: "unused"},
+			},
+		}
+
+		Convey("checking a variant should only report on its tasks", func() {
+			errs, err := CheckVariantSemantics(project, "bv1")
+			So(err, ShouldBeNil)
+			So(len(errs), ShouldEqual, 1)
+			So(errs[0].Message, ShouldContainSubstring, "'compile'")
+			So(len(CheckProjectSemantics(project)), ShouldEqual, 3)
+		})
+		Convey("checking an unknown variant should error", func() {
+			_, err := CheckVariantSemantics(project, "bv3")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}